@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/database"
 	"github.com/PeguB/atad-project/internal/handlers"
 	"github.com/PeguB/atad-project/internal/repository"
@@ -21,10 +23,29 @@ const (
 	addTransactionScreen
 	budgetScreen
 	incomeReportScreen
+	reconcileScreen
+	envelopeScreen
+	goalsScreen
+	profileScreen
+	unlockScreen
 )
 
+// errLocked is returned by connect when the passphrase of an encrypted
+// database has to be entered first
+var errLocked = errors.New("database is encrypted; enter the passphrase")
+
+// vaultUnlock holds the passphrase typed into the unlock screen. It is shared
+// by pointer because bubbletea passes the model around by value.
+type vaultUnlock struct {
+	passphrase string
+}
+
 type model struct {
 	db                     *database.Database
+	dbPath                 string
+	cfg                    *config.Config
+	cli                    *handlers.CLIHandler
+	profile                string // Active profile; "" when --db picked the database
 	repo                   *repository.TransactionRepository
 	budgetRepo             *repository.BudgetRepository
 	categoryService        *service.CategoryService
@@ -33,27 +54,60 @@ type model struct {
 	addTransactionScreen   *tui.AddTransactionScreen
 	budgetScreen           *tui.BudgetScreen
 	incomeReportScreen     *tui.IncomeReportScreen
+	reconcileScreen        *tui.ReconcileScreen
+	envelopeScreen         *tui.EnvelopeScreen
+	goalsScreen            *tui.GoalsScreen
+	profileScreen          *tui.ProfileScreen
+	unlockScreen           *tui.UnlockScreen
+	unlock                 *vaultUnlock
+	closeErr               error // Error sealing an encrypted database on exit
 	choices                []string
 	cursor                 int
 	selected               map[int]struct{}
 	status                 string
 }
 
-func initialModel() model {
-	return model{
-		currentScreen:   menuScreen,
-		categoryService: service.NewCategoryService(),
-		choices:         []string{"Test Database Connection", "View Transactions", "Add Transaction", "Manage Budgets", "Income Report", "Exit"},
-		selected:        make(map[int]struct{}),
-		status:          "Ready",
+func initialModel(dbPath string, cli *handlers.CLIHandler, unlock *vaultUnlock) model {
+	m := model{
+		dbPath:        dbPath,
+		cfg:           cli.Config,
+		cli:           cli,
+		profile:       cli.ProfileName(),
+		currentScreen: menuScreen,
+		choices:       []string{"Test Database Connection", "View Transactions", "Add Transaction", "Manage Budgets", "Income Report", "Reconcile Account", "Envelopes", "Savings Goals", "Switch Profile", "Exit"},
+		selected:      make(map[int]struct{}),
+		status:        "Ready",
+		unlock:        unlock,
 	}
+	if database.IsVault(dbPath) && unlock.passphrase == "" {
+		m.unlockScreen = tui.NewUnlockScreen(dbPath)
+		m.currentScreen = unlockScreen
+	}
+	return m
+}
+
+// pollInterval is how often the TUI checks for changes made by other processes
+const pollInterval = 2 * time.Second
+
+// pollMsg asks the model to check the database for external changes
+type pollMsg struct{}
+
+func poll() tea.Cmd {
+	return tea.Tick(pollInterval, func(time.Time) tea.Msg {
+		return pollMsg{}
+	})
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return poll()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(pollMsg); ok {
+		m.checkExternalChanges()
+		return m, poll()
+	}
+
 	// Handle screen switching
 	if m.currentScreen == viewTransactionsScreen {
 		switch msg := msg.(type) {
@@ -119,14 +173,106 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	if m.currentScreen == reconcileScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if msg.String() == "esc" {
+				m.reconcileScreen.Reset()
+				m.currentScreen = menuScreen
+				m.status = "Returned to menu"
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.reconcileScreen, cmd = m.reconcileScreen.Update(msg)
+		return m, cmd
+	}
+
+	if m.currentScreen == envelopeScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if msg.String() == "esc" {
+				m.envelopeScreen.Reset()
+				m.currentScreen = menuScreen
+				m.status = "Returned to menu"
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.envelopeScreen, cmd = m.envelopeScreen.Update(msg)
+		return m, cmd
+	}
+
+	if m.currentScreen == goalsScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if msg.String() == "esc" {
+				m.goalsScreen.Reset()
+				m.currentScreen = menuScreen
+				m.status = "Returned to menu"
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.goalsScreen, cmd = m.goalsScreen.Update(msg)
+		return m, cmd
+	}
+
+	if m.currentScreen == profileScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if msg.String() == "esc" {
+				m.profileScreen.Reset()
+				m.currentScreen = menuScreen
+				m.status = "Returned to menu"
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.profileScreen, cmd = m.profileScreen.Update(msg)
+		if name := m.profileScreen.Chosen(); name != "" {
+			m.switchProfile(name)
+			m.currentScreen = menuScreen
+		}
+		return m, cmd
+	}
+
+	if m.currentScreen == unlockScreen {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			if msg.String() == "esc" {
+				m.unlockScreen.Reset()
+				m.currentScreen = menuScreen
+				m.status = "🔒 Database is locked"
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.unlockScreen, cmd = m.unlockScreen.Update(msg)
+		if passphrase, ok := m.unlockScreen.Passphrase(); ok {
+			m.unlock.passphrase = passphrase
+			if err := m.connect(); err != nil {
+				m.unlock.passphrase = ""
+				m.unlockScreen.Fail(err.Error())
+				return m, cmd
+			}
+			m.currentScreen = menuScreen
+			m.status = "🔓 Database unlocked"
+		}
+		return m, cmd
+	}
+
 	// Main menu handling
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
-			if m.db != nil {
-				m.db.Close()
-			}
+			m.closeDatabase()
 			return m, tea.Quit
 
 		case "up", "k":
@@ -142,78 +288,82 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter", " ":
 			switch m.cursor {
 			case 0: // Test Database Connection
-				db, err := database.NewDatabase()
-				if err != nil {
+				if err := m.connect(); err != nil {
 					m.status = fmt.Sprintf("❌ Failed to connect: %v", err)
+				} else if err := m.db.DB.Ping(); err != nil {
+					m.status = fmt.Sprintf("❌ Connection failed: %v", err)
 				} else {
-					m.db = db
-					m.repo = repository.NewTransactionRepository(db.DB)
-					m.budgetRepo = repository.NewBudgetRepository(db.DB)
-					if err := db.DB.Ping(); err != nil {
-						m.status = fmt.Sprintf("❌ Connection failed: %v", err)
-					} else {
-						m.status = "✅ Database connection successful!"
-					}
+					m.status = "✅ Database connection successful!"
 				}
 			case 1: // View Transactions
-				if m.db == nil {
-					db, err := database.NewDatabase()
-					if err != nil {
-						m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
-						return m, nil
-					}
-					m.db = db
-					m.repo = repository.NewTransactionRepository(db.DB)
-					m.budgetRepo = repository.NewBudgetRepository(db.DB)
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
 				}
-				m.viewTransactionsScreen = tui.NewViewTransactionsScreen(m.repo, m.budgetRepo)
+				m.viewTransactionsScreen = tui.NewViewTransactionsScreen(m.repo, m.budgetRepo, m.cfg)
 				m.viewTransactionsScreen.Init()
 				m.currentScreen = viewTransactionsScreen
 			case 2: // Add Transaction
-				if m.db == nil {
-					db, err := database.NewDatabase()
-					if err != nil {
-						m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
-						return m, nil
-					}
-					m.db = db
-					m.repo = repository.NewTransactionRepository(db.DB)
-					m.budgetRepo = repository.NewBudgetRepository(db.DB)
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
 				}
-				m.addTransactionScreen = tui.NewAddTransactionScreen(m.repo, m.budgetRepo, m.categoryService)
+				m.addTransactionScreen = tui.NewAddTransactionScreen(m.repo, m.budgetRepo, m.categoryService, m.newAlertService(), m.cfg)
 				m.currentScreen = addTransactionScreen
 			case 3: // Manage Budgets
-				if m.db == nil {
-					db, err := database.NewDatabase()
-					if err != nil {
-						m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
-						return m, nil
-					}
-					m.db = db
-					m.repo = repository.NewTransactionRepository(db.DB)
-					m.budgetRepo = repository.NewBudgetRepository(db.DB)
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
 				}
-				m.budgetScreen = tui.NewBudgetScreen(m.budgetRepo)
+				m.budgetScreen = tui.NewBudgetScreen(m.repo, m.budgetRepo, m.cfg)
 				m.budgetScreen.Init()
 				m.currentScreen = budgetScreen
 			case 4: // Income Report
-				if m.db == nil {
-					db, err := database.NewDatabase()
-					if err != nil {
-						m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
-						return m, nil
-					}
-					m.db = db
-					m.repo = repository.NewTransactionRepository(db.DB)
-					m.budgetRepo = repository.NewBudgetRepository(db.DB)
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
 				}
-				m.incomeReportScreen = tui.NewIncomeReportScreen(m.repo)
+				m.incomeReportScreen = tui.NewIncomeReportScreen(m.repo, m.budgetRepo, m.cfg)
 				m.incomeReportScreen.Init()
 				m.currentScreen = incomeReportScreen
-			case 5: // Exit
-				if m.db != nil {
-					m.db.Close()
+			case 5: // Reconcile Account
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
+				}
+				reconcileSvc := service.NewReconcileService(m.repo, repository.NewReconciliationRepository(m.db.DB))
+				m.reconcileScreen = tui.NewReconcileScreen(reconcileSvc, m.cfg)
+				m.currentScreen = reconcileScreen
+			case 6: // Envelopes
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
+				}
+				envelopeSvc := service.NewEnvelopeService(m.repo, m.budgetRepo, repository.NewEnvelopeRepository(m.db.DB))
+				m.envelopeScreen = tui.NewEnvelopeScreen(envelopeSvc, m.cfg)
+				if err := m.envelopeScreen.Init(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to load envelopes: %v", err)
+					return m, nil
 				}
+				m.currentScreen = envelopeScreen
+			case 7: // Savings Goals
+				if err := m.connect(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to connect to database: %v", err)
+					return m, nil
+				}
+				goalSvc := service.NewGoalService(repository.NewGoalRepository(m.db.DB))
+				m.goalsScreen = tui.NewGoalsScreen(goalSvc, m.cfg)
+				if err := m.goalsScreen.Init(); err != nil {
+					m.status = fmt.Sprintf("❌ Failed to load goals: %v", err)
+					return m, nil
+				}
+				m.currentScreen = goalsScreen
+			case 8: // Switch Profile
+				m.profileScreen = tui.NewProfileScreen(m.profile)
+				m.profileScreen.Init()
+				m.currentScreen = profileScreen
+			case 9: // Exit
+				m.closeDatabase()
 				return m, tea.Quit
 			}
 		}
@@ -222,6 +372,109 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// connect opens the database unless it is already open. An encrypted database
+// without a known passphrase shows the unlock screen and returns errLocked.
+func (m *model) connect() error {
+	if m.db != nil {
+		return nil
+	}
+	if database.IsVault(m.dbPath) && m.unlock.passphrase == "" {
+		m.unlockScreen = tui.NewUnlockScreen(m.dbPath)
+		m.currentScreen = unlockScreen
+		return errLocked
+	}
+	db, err := database.NewDatabaseAt(m.dbPath)
+	if err != nil {
+		return err
+	}
+	categoryService, err := service.NewCategoryService(repository.NewCategoryRuleRepository(db.DB))
+	if err != nil {
+		db.Close()
+		return err
+	}
+	m.db = db
+	m.categoryService = categoryService
+	m.repo = repository.NewTransactionRepository(db.DB)
+	m.budgetRepo = repository.NewBudgetRepository(db.DB)
+	return nil
+}
+
+// newAlertService applies the alert settings of the config file. The add
+// screen shows alerts itself, so they are not printed to the terminal.
+func (m *model) newAlertService() *service.AlertService {
+	rules := service.AlertRules{Thresholds: m.cfg.AlertThresholds, LargeTransaction: m.cfg.LargeTransaction}
+	svc := service.NewAlertService(m.budgetRepo, service.NewBudgetService(m.budgetRepo),
+		repository.NewAlertRepository(m.db.DB), rules, m.cfg.FormatMoney)
+	if m.cfg.AlertCommand != "" {
+		svc.AddSink(&service.CommandSink{Command: m.cfg.AlertCommand})
+	}
+	if m.cfg.AlertWebhook != "" {
+		svc.AddSink(&service.WebhookSink{URL: m.cfg.AlertWebhook})
+	}
+	return svc
+}
+
+// checkExternalChanges refreshes the transaction list when the database was
+// written to, e.g. by a cron import running alongside the TUI
+func (m *model) checkExternalChanges() {
+	if m.db == nil {
+		return
+	}
+	changed, err := m.db.Changed()
+	if err != nil || !changed {
+		return
+	}
+	// Our own writes bump data_version too, since they use other pooled
+	// connections; reloading after them is harmless
+	if m.currentScreen == viewTransactionsScreen {
+		m.viewTransactionsScreen.Refresh()
+	}
+	if m.currentScreen == envelopeScreen {
+		m.envelopeScreen.Refresh()
+	}
+	if m.currentScreen == goalsScreen {
+		m.goalsScreen.Refresh()
+	}
+}
+
+// closeDatabase closes the database, which re-encrypts an encrypted one
+func (m *model) closeDatabase() {
+	if m.db != nil {
+		if err := m.db.Close(); err != nil && m.closeErr == nil {
+			m.closeErr = err
+		}
+		m.db = nil
+	}
+}
+
+// switchProfile closes the open database and points the TUI at another profile
+func (m *model) switchProfile(name string) {
+	if err := m.cli.UseProfile(name); err != nil {
+		m.status = fmt.Sprintf("❌ %v", err)
+		return
+	}
+	dbPath, err := m.cli.DatabasePath()
+	if err != nil {
+		m.status = fmt.Sprintf("❌ %v", err)
+		return
+	}
+	m.closeDatabase()
+	if m.closeErr != nil {
+		m.status = fmt.Sprintf("❌ %v", m.closeErr)
+		return
+	}
+	m.dbPath = dbPath
+	m.profile = name
+	m.status = fmt.Sprintf("✅ Switched to profile '%s'", name)
+
+	// Another profile may use another passphrase
+	m.unlock.passphrase = os.Getenv(handlers.PassphraseEnv)
+	if database.IsVault(dbPath) && m.unlock.passphrase == "" {
+		m.unlockScreen = tui.NewUnlockScreen(dbPath)
+		m.currentScreen = unlockScreen
+	}
+}
+
 func (m model) View() string {
 	if m.currentScreen == viewTransactionsScreen {
 		statusMsg := ""
@@ -255,7 +508,44 @@ func (m model) View() string {
 		return m.incomeReportScreen.View() + statusMsg
 	}
 
-	s := "🏦 ATAD - Personal Finance Tracker\n\n"
+	if m.currentScreen == reconcileScreen {
+		statusMsg := ""
+		if m.status != "" && m.status != "Ready" {
+			statusMsg = fmt.Sprintf("\nStatus: %s\n", m.status)
+		}
+		return m.reconcileScreen.View() + statusMsg
+	}
+
+	if m.currentScreen == envelopeScreen {
+		statusMsg := ""
+		if m.status != "" && m.status != "Ready" {
+			statusMsg = fmt.Sprintf("\nStatus: %s\n", m.status)
+		}
+		return m.envelopeScreen.View() + statusMsg
+	}
+
+	if m.currentScreen == goalsScreen {
+		statusMsg := ""
+		if m.status != "" && m.status != "Ready" {
+			statusMsg = fmt.Sprintf("\nStatus: %s\n", m.status)
+		}
+		return m.goalsScreen.View() + statusMsg
+	}
+
+	if m.currentScreen == profileScreen {
+		return m.profileScreen.View()
+	}
+
+	if m.currentScreen == unlockScreen {
+		return m.unlockScreen.View()
+	}
+
+	s := "🏦 ATAD - Personal Finance Tracker\n"
+	if m.profile != "" {
+		s += fmt.Sprintf("📒 Profile: %s\n\n", m.profile)
+	} else {
+		s += fmt.Sprintf("📒 Database: %s\n\n", m.dbPath)
+	}
 
 	for i, choice := range m.choices {
 		cursor := " "
@@ -273,91 +563,35 @@ func (m model) View() string {
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		// No subcommand provided, launch interactive TUI
-		runInteractiveTUI()
-		return
-	}
+	app := handlers.NewApp(os.Stdin, os.Stdout, os.Stderr)
+	// No subcommand provided, launch interactive TUI
+	app.Interactive = runInteractiveTUI
+	os.Exit(app.Run(os.Args[1:]))
+}
 
-	// Parse subcommand
-	subcommand := os.Args[1]
-
-	// Create CLI handler
-	handler := handlers.NewCLIHandler()
-
-	// Initialize database for all commands
-	if err := handler.InitDatabase(); err != nil {
-		fmt.Printf("Error: Failed to initialize database: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Route to appropriate command
-	var cmd handlers.CommandHandler
-
-	switch subcommand {
-	case "add":
-		cmd = &handlers.AddCommand{Handler: handler}
-	case "list":
-		cmd = &handlers.ListCommand{Handler: handler}
-	case "report":
-		cmd = &handlers.ReportCommand{Handler: handler}
-	case "budget":
-		cmd = &handlers.BudgetCommand{Handler: handler}
-	case "search":
-		cmd = &handlers.SearchCommand{Handler: handler}
-	case "import":
-		cmd = &handlers.ImportCommand{Handler: handler}
-	case "help", "-h", "--help":
-		printHelp()
-		return
-	case "version", "-v", "--version":
-		fmt.Println("ATAD Personal Finance Tracker v1.0.0")
-		return
-	default:
-		fmt.Printf("Unknown command: %s\n\n", subcommand)
-		printHelp()
-		os.Exit(1)
+func runInteractiveTUI(h *handlers.CLIHandler) error {
+	dbPath, err := h.DatabasePath()
+	if err != nil {
+		return err
 	}
 
-	// Execute command
-	cmd.Handle()
-}
-
-func runInteractiveTUI() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
-		log.Fatal(err)
-		os.Exit(1)
+	// The passphrase comes from ATAD_PASSPHRASE or the unlock screen; the
+	// terminal belongs to the TUI, so there is no prompt
+	unlock := &vaultUnlock{passphrase: os.Getenv(handlers.PassphraseEnv)}
+	database.Passphrase = func(string) (string, error) {
+		if unlock.passphrase == "" {
+			return "", errLocked
+		}
+		return unlock.passphrase, nil
 	}
-}
 
-func printHelp() {
-	help := `ATAD - Personal Finance Tracker
-
-Usage:
-  atad [command] [flags]
-
-Available Commands:
-  add         Add a new transaction
-  list        List transactions
-  report      Generate reports (income/expense)
-  budget      Manage budgets
-  search      Search transactions
-  import      Import transactions from CSV/OFX files
-  help        Show this help message
-  version     Show version information
-
-Run 'atad [command] -h' for more information about a command.
-
-When run without a command, ATAD starts in interactive TUI mode.
-
-Examples:
-  atad                                    # Start interactive mode
-  atad add -type expense -amount 50       # Add an expense
-  atad list -type income                  # List all income
-  atad report income -period month        # Monthly income report
-  atad budget set Groceries 500           # Set budget for category
-  atad search "coffee"                    # Search transactions
-`
-	fmt.Println(help)
+	p := tea.NewProgram(initialModel(dbPath, h, unlock))
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	if m, ok := final.(model); ok {
+		return m.closeErr
+	}
+	return nil
 }
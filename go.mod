@@ -7,6 +7,8 @@ require (
 	github.com/charmbracelet/bubbletea v1.2.4
 	github.com/charmbracelet/lipgloss v1.0.0
 	github.com/mattn/go-sqlite3 v1.14.24
+	github.com/muesli/termenv v0.15.2
+	golang.org/x/sys v0.27.0
 	golang.org/x/text v0.32.0
 )
 
@@ -23,8 +25,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/muesli/ansi v0.0.0-20230316100256-276c6243b2f6 // indirect
 	github.com/muesli/cancelreader v0.2.2 // indirect
-	github.com/muesli/termenv v0.15.2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.27.0 // indirect
 )
@@ -0,0 +1,604 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Config holds user preferences read from ~/.atad/config.toml. The file uses a
+// small subset of TOML: [section] headers and key = value pairs, e.g.
+//
+//	[display]
+//	currency = "EUR"
+//	symbol = "€"
+//	symbol_position = "after"
+//
+//	[dates]
+//	input_format = "YYYY-MM-DD"
+//
+//	[import.profiles.bank]
+//	account = "Checking"
+//	auto_categorize = true
+//
+// Settings are addressed by their dotted key (display.currency) in 'atad config'.
+type Config struct {
+	Currency             string    // display.currency: ISO code written to exports
+	Symbol               string    // display.symbol
+	SymbolPosition       string    // display.symbol_position: before or after
+	Theme                string    // display.theme: default or mono
+	InputDateFormat      string    // dates.input_format, e.g. DD/MM/YYYY
+	OutputDateFormat     string    // dates.output_format
+	AlertThresholds      []float64 // alerts.thresholds: percents of a budget that raise alerts by default
+	LargeTransaction     float64   // alerts.large_transaction: expense amount that raises an alert, 0 for off
+	AlertTerminal        bool      // alerts.terminal: print alerts in the terminal
+	AlertCommand         string    // alerts.command: shell command run for every alert
+	AlertWebhook         string    // alerts.webhook: URL every alert is posted to as JSON
+	DefaultReportPeriod  string    // reports.default_period: all, month or year
+	PageSize             int       // tui.page_size: transactions per page
+	DatabasePath         string    // database.path: used when neither --db nor DB_PATH is set
+	ActiveProfile        string    // profile.active: ledger profile used when --profile is not given
+	BackupDir            string    // backup.dir: where snapshots are kept (default: ~/.atad/backups)
+	BackupKeep           int       // backup.keep: snapshots kept per profile
+	AutoSnapshot         bool      // backup.auto_snapshot: snapshot before destructive operations
+	DefaultImportProfile string    // import.default_profile
+
+	ImportProfiles map[string]*ImportProfile
+}
+
+// ImportProfile bundles import options under a name (import.profiles.<name>.*)
+type ImportProfile struct {
+	Account        string
+	Mapping        string
+	AutoCategorize bool
+	SkipDuplicates bool
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Currency:            "USD",
+		Symbol:              "$",
+		SymbolPosition:      "before",
+		Theme:               "default",
+		InputDateFormat:     "DD/MM/YYYY",
+		OutputDateFormat:    "DD/MM/YYYY",
+		AlertThresholds:     []float64{80, 100},
+		AlertTerminal:       true,
+		DefaultReportPeriod: "month",
+		PageSize:            15,
+		BackupKeep:          10,
+		AutoSnapshot:        true,
+		ImportProfiles:      make(map[string]*ImportProfile),
+	}
+}
+
+// DefaultPath returns the config file location: $ATAD_CONFIG or ~/.atad/config.toml
+func DefaultPath() string {
+	if path := os.Getenv("ATAD_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "config.toml"
+	}
+	return filepath.Join(home, ".atad", "config.toml")
+}
+
+// setting describes one scalar configuration key
+type setting struct {
+	key  string
+	help string
+	get  func(c *Config) string
+	set  func(c *Config, value string) error
+}
+
+// legacyWarningKey is the warning threshold setting that alerts.thresholds
+// replaced; config files that still set it are read as the lowest threshold
+const legacyWarningKey = "budget.warning_threshold"
+
+var settings = []setting{
+	{"display.currency", "Currency code used in exports", func(c *Config) string { return c.Currency }, func(c *Config, v string) error {
+		if v == "" {
+			return fmt.Errorf("currency cannot be empty")
+		}
+		c.Currency = strings.ToUpper(v)
+		return nil
+	}},
+	{"display.symbol", "Currency symbol shown next to amounts", func(c *Config) string { return c.Symbol }, func(c *Config, v string) error {
+		c.Symbol = v
+		return nil
+	}},
+	{"display.symbol_position", "Symbol placement: before or after", func(c *Config) string { return c.SymbolPosition }, func(c *Config, v string) error {
+		if v != "before" && v != "after" {
+			return fmt.Errorf("symbol_position must be 'before' or 'after'")
+		}
+		c.SymbolPosition = v
+		return nil
+	}},
+	{"display.theme", "Color theme: default or mono", func(c *Config) string { return c.Theme }, func(c *Config, v string) error {
+		if v != "default" && v != "mono" {
+			return fmt.Errorf("theme must be 'default' or 'mono'")
+		}
+		c.Theme = v
+		return nil
+	}},
+	{"dates.input_format", "Date format accepted on input (DD, MM, YYYY)", func(c *Config) string { return c.InputDateFormat }, func(c *Config, v string) error {
+		if _, err := Layout(v); err != nil {
+			return err
+		}
+		c.InputDateFormat = v
+		return nil
+	}},
+	{"dates.output_format", "Date format used for display (DD, MM, YYYY)", func(c *Config) string { return c.OutputDateFormat }, func(c *Config, v string) error {
+		if _, err := Layout(v); err != nil {
+			return err
+		}
+		c.OutputDateFormat = v
+		return nil
+	}},
+	{"alerts.thresholds", "Percents of a budget that raise alerts, e.g. 50,80,100; the lowest below 100 also marks budgets as a warning", func(c *Config) string { return models.FormatThresholds(c.AlertThresholds) }, func(c *Config, v string) error {
+		thresholds, err := models.ParseThresholds(v)
+		if err != nil {
+			return err
+		}
+		c.AlertThresholds = thresholds
+		return nil
+	}},
+	{"alerts.large_transaction", "Expense amount that raises an alert (0: off)", func(c *Config) string { return strconv.FormatFloat(c.LargeTransaction, 'f', -1, 64) }, func(c *Config, v string) error {
+		amount, err := strconv.ParseFloat(v, 64)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("large_transaction must be a non-negative number")
+		}
+		c.LargeTransaction = amount
+		return nil
+	}},
+	{"alerts.terminal", "Print alerts in the terminal", func(c *Config) string { return strconv.FormatBool(c.AlertTerminal) }, func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("terminal must be true or false")
+		}
+		c.AlertTerminal = b
+		return nil
+	}},
+	{"alerts.command", "Shell command run for every alert (alert JSON on stdin)", func(c *Config) string { return c.AlertCommand }, func(c *Config, v string) error {
+		c.AlertCommand = v
+		return nil
+	}},
+	{"alerts.webhook", "URL every alert is posted to as JSON", func(c *Config) string { return c.AlertWebhook }, func(c *Config, v string) error {
+		if v != "" && !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+			return fmt.Errorf("webhook must be an http:// or https:// URL")
+		}
+		c.AlertWebhook = v
+		return nil
+	}},
+	{"reports.default_period", "Default report period: all, month or year", func(c *Config) string { return c.DefaultReportPeriod }, func(c *Config, v string) error {
+		if v != "all" && v != "month" && v != "year" {
+			return fmt.Errorf("default_period must be 'all', 'month' or 'year'")
+		}
+		c.DefaultReportPeriod = v
+		return nil
+	}},
+	{"tui.page_size", "Transactions per page in the TUI", func(c *Config) string { return strconv.Itoa(c.PageSize) }, func(c *Config, v string) error {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 {
+			return fmt.Errorf("page_size must be a positive integer")
+		}
+		c.PageSize = size
+		return nil
+	}},
+	{"database.path", "Database file used when --db and DB_PATH are not set", func(c *Config) string { return c.DatabasePath }, func(c *Config, v string) error {
+		c.DatabasePath = v
+		return nil
+	}},
+	{"profile.active", "Ledger profile used when --profile is not given", func(c *Config) string { return c.ActiveProfile }, func(c *Config, v string) error {
+		c.ActiveProfile = v
+		return nil
+	}},
+	{"backup.dir", "Directory for snapshots (empty: backups next to the database)", func(c *Config) string { return c.BackupDir }, func(c *Config, v string) error {
+		c.BackupDir = v
+		return nil
+	}},
+	{"backup.keep", "Snapshots kept per profile", func(c *Config) string { return strconv.Itoa(c.BackupKeep) }, func(c *Config, v string) error {
+		keep, err := strconv.Atoi(v)
+		if err != nil || keep < 1 {
+			return fmt.Errorf("keep must be a positive integer")
+		}
+		c.BackupKeep = keep
+		return nil
+	}},
+	{"backup.auto_snapshot", "Snapshot the database before imports and restores", func(c *Config) string { return strconv.FormatBool(c.AutoSnapshot) }, func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("auto_snapshot must be true or false")
+		}
+		c.AutoSnapshot = b
+		return nil
+	}},
+	{"import.default_profile", "Import profile used when 'import' gets no -import-profile", func(c *Config) string { return c.DefaultImportProfile }, func(c *Config, v string) error {
+		c.DefaultImportProfile = v
+		return nil
+	}},
+}
+
+// profileFields are the keys of an import profile
+var profileFields = []string{"account", "mapping", "auto_categorize", "skip_duplicates"}
+
+// Keys returns every configuration key that currently has a value, in file order
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	for _, name := range c.ProfileNames() {
+		for _, field := range profileFields {
+			keys = append(keys, "import.profiles."+name+"."+field)
+		}
+	}
+	return keys
+}
+
+// Help returns the description of a key
+func Help(key string) string {
+	for _, s := range settings {
+		if s.key == key {
+			return s.help
+		}
+	}
+	if strings.HasPrefix(key, "import.profiles.") {
+		return "Import profile option"
+	}
+	return ""
+}
+
+// Get returns the value of a key as text
+func (c *Config) Get(key string) (string, error) {
+	for _, s := range settings {
+		if s.key == key {
+			return s.get(c), nil
+		}
+	}
+	if name, field, ok := profileKey(key); ok {
+		profile, exists := c.ImportProfiles[name]
+		if !exists {
+			return "", fmt.Errorf("no import profile named '%s'", name)
+		}
+		switch field {
+		case "account":
+			return profile.Account, nil
+		case "mapping":
+			return profile.Mapping, nil
+		case "auto_categorize":
+			return strconv.FormatBool(profile.AutoCategorize), nil
+		case "skip_duplicates":
+			return strconv.FormatBool(profile.SkipDuplicates), nil
+		}
+	}
+	return "", fmt.Errorf("unknown config key '%s'", key)
+}
+
+// Set validates and stores the value of a key. Setting a key of an unknown
+// import profile creates the profile.
+func (c *Config) Set(key, value string) error {
+	for _, s := range settings {
+		if s.key == key {
+			if err := s.set(c, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+
+	name, field, ok := profileKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key '%s'", key)
+	}
+	profile := c.ImportProfiles[name]
+	if profile == nil {
+		profile = &ImportProfile{}
+	}
+	switch field {
+	case "account":
+		profile.Account = value
+	case "mapping":
+		profile.Mapping = value
+	case "auto_categorize", "skip_duplicates":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: must be true or false", key)
+		}
+		if field == "auto_categorize" {
+			profile.AutoCategorize = b
+		} else {
+			profile.SkipDuplicates = b
+		}
+	}
+	c.ImportProfiles[name] = profile
+	return nil
+}
+
+// profileKey splits import.profiles.<name>.<field>
+func profileKey(key string) (name, field string, ok bool) {
+	rest, found := strings.CutPrefix(key, "import.profiles.")
+	if !found {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 {
+		return "", "", false
+	}
+	name, field = rest[:i], rest[i+1:]
+	if strings.Contains(name, ".") {
+		return "", "", false
+	}
+	for _, f := range profileFields {
+		if f == field {
+			return name, field, true
+		}
+	}
+	return "", "", false
+}
+
+// ProfileNames returns the import profile names in alphabetical order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.ImportProfiles))
+	for name := range c.ImportProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImportProfile returns the named profile, or the default profile if name is empty.
+// It returns nil when no profile applies.
+func (c *Config) ImportProfile(name string) (*ImportProfile, error) {
+	if name == "" {
+		name = c.DefaultImportProfile
+		if name == "" {
+			return nil, nil
+		}
+	}
+	profile, ok := c.ImportProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no import profile named '%s'", name)
+	}
+	return profile, nil
+}
+
+// Load reads a config file on top of the defaults. A missing file is not an error.
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	legacyWarning, thresholdsSet := 0.0, false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s line %d: unterminated section header", path, lineNum)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected 'key = value'", path, lineNum)
+		}
+		key = strings.TrimSpace(key)
+		if section != "" {
+			key = section + "." + key
+		}
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, lineNum, err)
+		}
+		if key == legacyWarningKey {
+			if legacyWarning, err = strconv.ParseFloat(value, 64); err != nil || legacyWarning <= 0 || legacyWarning >= 100 {
+				return nil, fmt.Errorf("%s line %d: %s must be a number between 0 and 100", path, lineNum, key)
+			}
+			continue
+		}
+		if key == "alerts.thresholds" {
+			thresholdsSet = true
+		}
+		if err := c.Set(key, value); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// The old warning setting becomes the lowest alert threshold, unless the
+	// file sets the thresholds itself
+	if legacyWarning > 0 && !thresholdsSet {
+		thresholds := []float64{legacyWarning}
+		for _, threshold := range c.AlertThresholds {
+			if threshold >= 100 {
+				thresholds = append(thresholds, threshold)
+			}
+		}
+		c.AlertThresholds = thresholds
+	}
+
+	return c, nil
+}
+
+// Save writes the configuration, creating the directory if needed
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# atad configuration. Edit by hand or with 'atad config set <key> <value>'.\n")
+	section := ""
+	for _, s := range settings {
+		sec, key := splitKey(s.key)
+		if sec != section {
+			fmt.Fprintf(&b, "\n[%s]\n", sec)
+			section = sec
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, formatValue(s.get(c)))
+	}
+	for _, name := range c.ProfileNames() {
+		profile := c.ImportProfiles[name]
+		fmt.Fprintf(&b, "\n[import.profiles.%s]\n", name)
+		fmt.Fprintf(&b, "account = %s\n", formatValue(profile.Account))
+		fmt.Fprintf(&b, "mapping = %s\n", formatValue(profile.Mapping))
+		fmt.Fprintf(&b, "auto_categorize = %t\n", profile.AutoCategorize)
+		fmt.Fprintf(&b, "skip_duplicates = %t\n", profile.SkipDuplicates)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+func splitKey(key string) (section, name string) {
+	i := strings.LastIndex(key, ".")
+	return key[:i], key[i+1:]
+}
+
+// parseValue accepts quoted strings, numbers and booleans
+func parseValue(raw string) (string, error) {
+	if strings.HasPrefix(raw, `"`) {
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", raw)
+		}
+		return value, nil
+	}
+	if strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2 {
+		return raw[1 : len(raw)-1], nil
+	}
+	return raw, nil
+}
+
+// formatValue quotes everything except numbers and booleans
+func formatValue(value string) string {
+	if value == "true" || value == "false" {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// stripComment removes a '#' comment outside quotes
+func stripComment(line string) string {
+	inQuotes := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case '#':
+			if !inQuotes {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// Layout converts a date pattern such as DD/MM/YYYY or YYYY-MM-DD to a Go time layout
+func Layout(pattern string) (string, error) {
+	layout := strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(pattern)
+	if !strings.Contains(layout, "2006") || !strings.Contains(layout, "01") || !strings.Contains(layout, "02") {
+		return "", fmt.Errorf("date format '%s' must contain DD, MM and YYYY", pattern)
+	}
+	if strings.ContainsAny(strings.NewReplacer("2006", "", "01", "", "02", "").Replace(layout), "0123456789YMD") {
+		return "", fmt.Errorf("date format '%s' may only contain DD, MM, YYYY and separators", pattern)
+	}
+	return layout, nil
+}
+
+// InputLayout is the Go layout for dates typed by the user
+func (c *Config) InputLayout() string {
+	layout, err := Layout(c.InputDateFormat)
+	if err != nil {
+		return "02/01/2006"
+	}
+	return layout
+}
+
+// OutputLayout is the Go layout for displayed dates
+func (c *Config) OutputLayout() string {
+	layout, err := Layout(c.OutputDateFormat)
+	if err != nil {
+		return "02/01/2006"
+	}
+	return layout
+}
+
+// ParseDate parses a date typed in the input format
+func (c *Config) ParseDate(value string) (time.Time, error) {
+	return time.Parse(c.InputLayout(), value)
+}
+
+// FormatDate formats a date for display
+func (c *Config) FormatDate(t time.Time) string {
+	return t.Format(c.OutputLayout())
+}
+
+// FormatInputDate formats a date in the input format, for prefilled fields
+func (c *Config) FormatInputDate(t time.Time) string {
+	return t.Format(c.InputLayout())
+}
+
+// FormatMoney formats an amount with the currency symbol, e.g. $12.50 or 12.50 €
+func (c *Config) FormatMoney(amount float64) string {
+	number := strconv.FormatFloat(amount, 'f', 2, 64)
+	if c.SymbolPosition == "after" {
+		if c.Symbol == "" {
+			return number
+		}
+		return number + " " + c.Symbol
+	}
+	if amount < 0 {
+		return "-" + c.Symbol + number[1:]
+	}
+	return c.Symbol + number
+}
+
+// WarningThreshold returns the percent of a budget used from which it is
+// shown as a warning: the lowest of alerts.thresholds below 100, or 0 when
+// there is none
+func (c *Config) WarningThreshold() float64 {
+	if len(c.AlertThresholds) == 0 || c.AlertThresholds[0] >= 100 {
+		return 0
+	}
+	return c.AlertThresholds[0]
+}
+
+// IsWarning reports whether percentUsed reaches the budget warning threshold
+func (c *Config) IsWarning(percentUsed float64) bool {
+	threshold := c.WarningThreshold()
+	return threshold > 0 && percentUsed >= threshold
+}
@@ -0,0 +1,362 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/vault"
+)
+
+// SchemaVersion is stored in PRAGMA user_version. Restores refuse backups
+// written by a newer schema; older ones are migrated when opened.
+//
+//	1: initial versioned schema
+//	2: recurring budget periods
+//	3: budget rollover
+//	4: envelope assignments and settings
+//	5: budget alert thresholds and the alert log
+//	6: income budgets
+//	7: budget templates
+//	8: stored categorization rules
+//	9: savings goals and contributions
+//	10: loans
+//	11: bills and bills marked paid
+//	12: account opening balances for reconciliation
+const SchemaVersion = 12
+
+// Snapshot file names carry the time they were taken so they sort by age.
+// The milliseconds keep snapshots taken within the same second apart; parsing
+// with snapshotTimeLayout accepts names with or without them.
+const (
+	snapshotTimeLayout  = "20060102-150405"
+	snapshotTimeWritten = snapshotTimeLayout + ".000"
+)
+
+// BackupInfo describes a backup file
+type BackupInfo struct {
+	Path          string
+	Compressed    bool
+	Encrypted     bool
+	SchemaVersion int
+	Transactions  int
+}
+
+// BackupOptions controls how a backup is written
+type BackupOptions struct {
+	Compress   bool
+	Passphrase string // Encrypt the backup with this passphrase
+}
+
+// RestoreOptions controls how a backup is restored
+type RestoreOptions struct {
+	Passphrase      string // Passphrase of an encrypted backup
+	VaultPassphrase string // Encrypt the restored database with this passphrase
+}
+
+// Snapshot is an automatic backup kept in the backup directory
+type Snapshot struct {
+	Path    string
+	Profile string
+	Reason  string
+	Taken   time.Time
+	Size    int64
+}
+
+// SchemaVersion reads the schema version of the open database
+func (d *Database) SchemaVersion() (int, error) {
+	var version int
+	err := d.DB.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// Backup writes a consistent copy of the open database to path with
+// VACUUM INTO. Other connections may keep writing while the copy is taken.
+// Backups of an encrypted database are always encrypted, with the vault key
+// unless opts.Passphrase is set.
+func (d *Database) Backup(path string, opts BackupOptions) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	data, err := d.snapshotBytes()
+	if err != nil {
+		return err
+	}
+
+	if opts.Compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("error compressing backup: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("error compressing backup: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	switch {
+	case opts.Passphrase != "":
+		data, err = vault.Encrypt(data, opts.Passphrase)
+	case d.vault != nil:
+		data, err = d.vault.key.Seal(data)
+	}
+	if err != nil {
+		return fmt.Errorf("error encrypting backup: %w", err)
+	}
+
+	if err := vault.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+	return nil
+}
+
+// snapshotBytes returns a consistent, compacted copy of the database file
+func (d *Database) snapshotBytes() ([]byte, error) {
+	// VACUUM INTO needs a path that does not exist yet
+	tmp, err := os.CreateTemp(workingDir(), "atad-copy-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("error creating database copy: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	os.Remove(tmpPath)
+	defer os.Remove(tmpPath)
+
+	if _, err := d.DB.Exec("VACUUM INTO ?", tmpPath); err != nil {
+		return nil, fmt.Errorf("error copying database: %w", err)
+	}
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading database copy: %w", err)
+	}
+	return data, nil
+}
+
+// IsEncryptedBackup reports whether a backup needs a passphrase
+func IsEncryptedBackup(path string) bool {
+	return vault.IsEncryptedFile(path)
+}
+
+// InspectBackup checks that a backup is a readable atad database and returns
+// its schema version and size. passphrase is needed for encrypted backups.
+func InspectBackup(path, passphrase string) (*BackupInfo, error) {
+	plain, info, cleanup, err := openBackup(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	db, err := OpenReadOnly(plain)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid database: %w", path, err)
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.DB.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		return nil, fmt.Errorf("%s is not a valid database: %w", path, err)
+	}
+	if check != "ok" {
+		return nil, fmt.Errorf("%s is corrupt: %s", path, check)
+	}
+
+	if info.SchemaVersion, err = db.SchemaVersion(); err != nil {
+		return nil, err
+	}
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&info.Transactions); err != nil {
+		return nil, fmt.Errorf("%s is not an atad database: %w", path, err)
+	}
+	return info, nil
+}
+
+// Restore replaces the database file at dbPath with a backup. The database
+// must not be open. Backups from a newer schema version are rejected.
+func Restore(backupPath, dbPath string, opts RestoreOptions) error {
+	info, err := InspectBackup(backupPath, opts.Passphrase)
+	if err != nil {
+		return err
+	}
+	if info.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("backup has schema version %d but this version of atad supports up to %d", info.SchemaVersion, SchemaVersion)
+	}
+
+	plain, _, cleanup, err := openBackup(backupPath, opts.Passphrase)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(plain)
+	if err != nil {
+		return fmt.Errorf("error reading backup: %w", err)
+	}
+	if opts.VaultPassphrase != "" {
+		if data, err = vault.Encrypt(data, opts.VaultPassphrase); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("error creating database directory: %w", err)
+	}
+	// Leftover journal files belong to the replaced database
+	if err := removeJournalFiles(dbPath); err != nil {
+		return err
+	}
+	if err := vault.WriteFile(dbPath, data, 0600); err != nil {
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+	return nil
+}
+
+// TakeSnapshot writes a compressed backup of the database to dir, named after
+// the profile, time and reason, and deletes all but the newest keep snapshots
+// of that profile. A non-empty passphrase encrypts the snapshot.
+func TakeSnapshot(d *Database, dir, profile, reason string, keep int, passphrase string) (string, error) {
+	var path string
+	for taken := time.Now(); ; taken = taken.Add(time.Millisecond) {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%s-%s.db.gz", profile, taken.Format(snapshotTimeWritten), reason))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+	}
+	if err := d.Backup(path, BackupOptions{Compress: true, Passphrase: passphrase}); err != nil {
+		return "", err
+	}
+	if err := PruneSnapshots(dir, profile, keep); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// ListSnapshots returns the snapshots of a profile in dir, newest first. An
+// empty profile lists the snapshots of every profile.
+func ListSnapshots(dir, profile string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		snapshot := parseSnapshotName(entry.Name())
+		if snapshot == nil || entry.IsDir() || (profile != "" && snapshot.Profile != profile) {
+			continue
+		}
+		snapshot.Path = filepath.Join(dir, entry.Name())
+		if fi, err := entry.Info(); err == nil {
+			snapshot.Size = fi.Size()
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Taken.After(snapshots[j].Taken)
+	})
+	return snapshots, nil
+}
+
+// PruneSnapshots deletes all but the newest keep snapshots of a profile
+func PruneSnapshots(dir, profile string, keep int) error {
+	if keep < 1 {
+		return nil
+	}
+	snapshots, err := ListSnapshots(dir, profile)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(snapshots); i++ {
+		if err := os.Remove(snapshots[i].Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error deleting old snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseSnapshotName parses <profile>-<yyyymmdd>-<hhmmss.mmm>-<reason>.db.gz
+func parseSnapshotName(name string) *Snapshot {
+	base, ok := strings.CutSuffix(name, ".db.gz")
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(base, "-")
+	if len(parts) < 4 {
+		return nil
+	}
+	// Profile names may contain '-', reasons may not
+	n := len(parts)
+	taken, err := time.ParseInLocation(snapshotTimeLayout, parts[n-3]+"-"+parts[n-2], time.Local)
+	if err != nil {
+		return nil
+	}
+	return &Snapshot{
+		Profile: strings.Join(parts[:n-3], "-"),
+		Reason:  parts[n-1],
+		Taken:   taken,
+	}
+}
+
+// openBackup returns the path of a plain SQLite copy of a backup. Encrypted
+// and compressed backups are decoded to a private temporary file that cleanup
+// removes.
+func openBackup(path, passphrase string) (plain string, info *BackupInfo, cleanup func(), err error) {
+	cleanup = func() {}
+	info = &BackupInfo{Path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, cleanup, fmt.Errorf("error opening backup: %w", err)
+	}
+
+	if vault.IsEncrypted(data) {
+		info.Encrypted = true
+		if data, err = vault.Decrypt(data, passphrase); err != nil {
+			return "", nil, cleanup, err
+		}
+	}
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		info.Compressed = true
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, cleanup, fmt.Errorf("error decompressing backup: %w", err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return "", nil, cleanup, fmt.Errorf("error decompressing backup: %w", err)
+		}
+	}
+	if !info.Encrypted && !info.Compressed {
+		return path, info, cleanup, nil
+	}
+
+	tmp, err := os.CreateTemp(workingDir(), "atad-backup-*.db")
+	if err != nil {
+		return "", nil, cleanup, fmt.Errorf("error decoding backup: %w", err)
+	}
+	cleanup = func() { removeDatabaseFiles(tmp.Name()) }
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, func() {}, fmt.Errorf("error decoding backup: %w", err)
+	}
+	return tmp.Name(), info, cleanup, nil
+}
+
+// BackupDir returns the default directory for snapshots, next to the default database
+func BackupDir() string {
+	return filepath.Join(filepath.Dir(DefaultPath()), "backups")
+}
@@ -1,22 +1,63 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/PeguB/atad-project/internal/models"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Connection settings for sharing a database between the TUI and CLI runs
+// such as a cron import: WAL lets readers continue while one process writes,
+// and the busy timeout makes a writer wait for the lock instead of failing
+// with "database is locked".
+const (
+	busyTimeout  = 5 * time.Second
+	maxOpenConns = 4
+	maxIdleConns = 2
+)
+
 type Database struct {
 	DB *sql.DB
+
+	vault *vaultFile // Set when the database file is encrypted
+	watch *sql.Conn  // Connection polled by Changed
+	seen  int64      // data_version last seen by Changed
 }
 
-// NewDatabase creates a new SQLite database connection
-func NewDatabase() (*Database, error) {
-	// Get database path from environment variable or use default
-	dbPath := getEnv("DB_PATH", getDefaultDBPath())
+// dsn builds the connection string for a database file. Writes start
+// immediate transactions so that lock waits happen up front, where the busy
+// timeout applies, rather than when a reader upgrades to a writer.
+func dsn(path string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=%d&_foreign_keys=on&_txlock=immediate",
+		path, busyTimeout.Milliseconds())
+}
+
+// NewDatabase opens the database of a profile, creating it if needed. An empty
+// name opens the default profile.
+func NewDatabase(profile string) (*Database, error) {
+	if profile != "" && profile != DefaultProfile {
+		if err := ValidateProfileName(profile); err != nil {
+			return nil, err
+		}
+	}
+	return NewDatabaseAt(ProfilePath(profile))
+}
+
+// NewDatabaseAt opens the database at dbPath. An empty path falls back to the
+// DB_PATH environment variable and then to ~/.atad/atad.db. An encrypted
+// database is decrypted to a working copy with the passphrase from Passphrase
+// and encrypted again by Close.
+func NewDatabaseAt(dbPath string) (*Database, error) {
+	if dbPath == "" {
+		dbPath = DefaultPath()
+	}
 
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
@@ -24,26 +65,62 @@ func NewDatabase() (*Database, error) {
 		return nil, fmt.Errorf("error creating database directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	openPath := dbPath
+	var v *vaultFile
+	if IsVault(dbPath) {
+		var err error
+		if v, err = openVault(dbPath); err != nil {
+			return nil, err
+		}
+		openPath = v.workPath
+	}
+
+	db, err := sql.Open("sqlite3", dsn(openPath))
 	if err != nil {
+		if v != nil {
+			v.discard()
+		}
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+
+	database := &Database{DB: db, vault: v}
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		database.abandon()
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	database := &Database{DB: db}
-
 	// Initialize schema
 	if err := database.initSchema(); err != nil {
+		database.abandon()
 		return nil, fmt.Errorf("error initializing schema: %w", err)
 	}
 
 	return database, nil
 }
 
+// OpenReadOnly opens an existing database for reading without creating or
+// migrating it
+func OpenReadOnly(dbPath string) (*Database, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_busy_timeout=%d", dbPath, busyTimeout.Milliseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	return &Database{DB: db}, nil
+}
+
 // initSchema creates the database tables
 func (d *Database) initSchema() error {
 	schema := `
@@ -64,24 +141,433 @@ func (d *Database) initSchema() error {
 	CREATE TABLE IF NOT EXISTS budgets (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		category TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT 'expense' CHECK(type IN ('expense', 'income')),
 		amount REAL NOT NULL,
-		period TEXT NOT NULL CHECK(period IN ('custom')),
+		period TEXT NOT NULL CHECK(period IN ('custom', 'weekly', 'monthly', 'quarterly', 'yearly')),
 		start_date DATETIME NOT NULL,
-		end_date DATETIME NOT NULL,
-		UNIQUE(category, start_date, end_date)
+		end_date DATETIME,
+		UNIQUE(category, type, start_date, end_date)
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_budgets_category ON budgets(category);
 	CREATE INDEX IF NOT EXISTS idx_budgets_category_period ON budgets(category, period);
+
+	CREATE TABLE IF NOT EXISTS reconciliations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		account TEXT NOT NULL,
+		statement_date DATETIME NOT NULL,
+		statement_balance REAL NOT NULL,
+		reconciled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_reconciliations_account ON reconciliations(account);
+
+	CREATE TABLE IF NOT EXISTS opening_balances (
+		account TEXT PRIMARY KEY,
+		balance REAL NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS envelope_assignments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date DATETIME NOT NULL,
+		category TEXT NOT NULL,
+		amount REAL NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_envelope_assignments_category ON envelope_assignments(category);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		category TEXT NOT NULL DEFAULT '',
+		budget_id INTEGER,
+		period_start DATETIME,
+		threshold REAL NOT NULL DEFAULT 0,
+		transaction_id INTEGER,
+		amount REAL NOT NULL DEFAULT 0,
+		message TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_alerts_budget_period ON alerts(budget_id, period_start);
+
+	CREATE TABLE IF NOT EXISTS category_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		category TEXT NOT NULL,
+		pattern TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		priority INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS budget_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS budget_template_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL REFERENCES budget_templates(id),
+		category TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT 'expense' CHECK(type IN ('expense', 'income')),
+		amount REAL NOT NULL,
+		period TEXT NOT NULL,
+		days INTEGER NOT NULL DEFAULT 0,
+		alert_thresholds TEXT NOT NULL DEFAULT '',
+		UNIQUE(template_id, category, type)
+	);
+
+	CREATE TABLE IF NOT EXISTS goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		target REAL NOT NULL,
+		target_date DATETIME,
+		account TEXT NOT NULL DEFAULT '',
+		category TEXT NOT NULL DEFAULT '',
+		start_date DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS goal_contributions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		goal_id INTEGER NOT NULL REFERENCES goals(id),
+		date DATETIME NOT NULL,
+		amount REAL NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_goal_contributions_goal ON goal_contributions(goal_id);
+
+	CREATE TABLE IF NOT EXISTS loans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		principal REAL NOT NULL,
+		rate REAL NOT NULL,
+		term_months INTEGER NOT NULL DEFAULT 0,
+		payment REAL NOT NULL,
+		category TEXT NOT NULL UNIQUE,
+		start_date DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bills (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		amount REAL NOT NULL,
+		period TEXT NOT NULL,
+		due_date DATETIME NOT NULL,
+		pattern TEXT NOT NULL,
+		tolerance REAL NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bill_payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bill_id INTEGER NOT NULL REFERENCES bills(id),
+		due_date DATETIME NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(bill_id, due_date)
+	);
 	`
 
-	_, err := d.DB.Exec(schema)
+	if _, err := d.DB.Exec(schema); err != nil {
+		return err
+	}
+
+	return d.migrate()
+}
+
+// migrate brings tables created by older versions up to date with the current schema
+func (d *Database) migrate() error {
+	// Reconciliation support: every transaction belongs to an account and
+	// carries a cleared status ('uncleared', 'cleared' or 'reconciled')
+	if err := d.addColumnIfMissing("transactions", "account", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.addColumnIfMissing("transactions", "status", "TEXT NOT NULL DEFAULT 'uncleared'"); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_transactions_account_status ON transactions(account, status)`); err != nil {
+		return err
+	}
+
+	// Recurring budgets: older tables only allowed 'custom' periods and
+	// required an end date
+	if err := d.migrateRecurringBudgets(); err != nil {
+		return err
+	}
+
+	// Budget rollover: what a recurring budget carries into the next period
+	if err := d.addColumnIfMissing("budgets", "rollover", "TEXT NOT NULL DEFAULT 'none'"); err != nil {
+		return err
+	}
+	if err := d.addColumnIfMissing("budgets", "rollover_cap", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Budget alerts: percentages of a budget that raise an alert; empty means
+	// the alerts.thresholds setting
+	if err := d.addColumnIfMissing("budgets", "alert_thresholds", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Income targets: budgets on the income side of a category, which may
+	// share dates with the category's expense budget
+	if err := d.addColumnIfMissing("budgets", "type", "TEXT NOT NULL DEFAULT 'expense'"); err != nil {
+		return err
+	}
+	if err := d.migrateBudgetTypes(); err != nil {
+		return err
+	}
+
+	// Categorization rules: stored since they can be synced from a finances
+	// file, starting from the built-in rules
+	if err := d.seedCategoryRules(); err != nil {
+		return err
+	}
+
+	// Record the schema version so restores can reject backups from newer versions
+	_, err := d.DB.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion))
 	return err
 }
 
-// Close closes the database connection
+// migrateRecurringBudgets rebuilds a budgets table created before recurring
+// periods existed, since SQLite cannot change a CHECK constraint in place
+func (d *Database) migrateRecurringBudgets() error {
+	var schema string
+	err := d.DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'budgets'`).Scan(&schema)
+	if err != nil {
+		return fmt.Errorf("error reading budgets schema: %w", err)
+	}
+	if !strings.Contains(schema, "CHECK(period IN ('custom'))") {
+		return nil
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE budgets_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			category TEXT NOT NULL,
+			amount REAL NOT NULL,
+			period TEXT NOT NULL CHECK(period IN ('custom', 'weekly', 'monthly', 'quarterly', 'yearly')),
+			start_date DATETIME NOT NULL,
+			end_date DATETIME,
+			UNIQUE(category, start_date, end_date)
+		)`,
+		`INSERT INTO budgets_new (id, category, amount, period, start_date, end_date)
+			SELECT id, category, amount, period, start_date, end_date FROM budgets`,
+		`DROP TABLE budgets`,
+		`ALTER TABLE budgets_new RENAME TO budgets`,
+		`CREATE INDEX IF NOT EXISTS idx_budgets_category ON budgets(category)`,
+		`CREATE INDEX IF NOT EXISTS idx_budgets_category_period ON budgets(category, period)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("error migrating budgets: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateBudgetTypes rebuilds a budgets table whose unique key predates budget
+// types, so an income target can cover the same dates as an expense budget
+func (d *Database) migrateBudgetTypes() error {
+	var schema string
+	err := d.DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'budgets'`).Scan(&schema)
+	if err != nil {
+		return fmt.Errorf("error reading budgets schema: %w", err)
+	}
+	if !strings.Contains(schema, "UNIQUE(category, start_date, end_date)") {
+		return nil
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE budgets_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			category TEXT NOT NULL,
+			type TEXT NOT NULL DEFAULT 'expense' CHECK(type IN ('expense', 'income')),
+			amount REAL NOT NULL,
+			period TEXT NOT NULL CHECK(period IN ('custom', 'weekly', 'monthly', 'quarterly', 'yearly')),
+			start_date DATETIME NOT NULL,
+			end_date DATETIME,
+			rollover TEXT NOT NULL DEFAULT 'none',
+			rollover_cap REAL NOT NULL DEFAULT 0,
+			alert_thresholds TEXT NOT NULL DEFAULT '',
+			UNIQUE(category, type, start_date, end_date)
+		)`,
+		`INSERT INTO budgets_new (id, category, type, amount, period, start_date, end_date, rollover, rollover_cap, alert_thresholds)
+			SELECT id, category, type, amount, period, start_date, end_date, rollover, rollover_cap, alert_thresholds FROM budgets`,
+		`DROP TABLE budgets`,
+		`ALTER TABLE budgets_new RENAME TO budgets`,
+		`CREATE INDEX IF NOT EXISTS idx_budgets_category ON budgets(category)`,
+		`CREATE INDEX IF NOT EXISTS idx_budgets_category_period ON budgets(category, period)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("error migrating budgets: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// rulesSeededKey is the settings key marking that the built-in categorization
+// rules were stored, so rules deleted later are not brought back
+const rulesSeededKey = "rules.seeded"
+
+// seedCategoryRules stores the built-in categorization rules the first time
+// a database is opened by a version with stored rules
+func (d *Database) seedCategoryRules() error {
+	var seeded int
+	err := d.DB.QueryRow(`SELECT COUNT(*) FROM settings WHERE key = ?`, rulesSeededKey).Scan(&seeded)
+	if err != nil {
+		return fmt.Errorf("error reading settings: %w", err)
+	}
+	if seeded > 0 {
+		return nil
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rule := range models.DefaultCategoryRules() {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO category_rules (category, pattern, description, priority) VALUES (?, ?, ?, ?)`,
+			rule.Category, rule.Pattern, rule.Description, rule.Priority)
+		if err != nil {
+			return fmt.Errorf("error storing categorization rules: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES (?, 'true')`, rulesSeededKey); err != nil {
+		return fmt.Errorf("error storing categorization rules: %w", err)
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists
+func (d *Database) addColumnIfMissing(table, column, definition string) error {
+	rows, err := d.DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("error reading columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("error scanning columns of %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = d.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("error adding column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// Close closes the database connection. An encrypted database is sealed
+// again and its working copy removed.
 func (d *Database) Close() error {
-	return d.DB.Close()
+	if d.watch != nil {
+		d.watch.Close()
+		d.watch = nil
+	}
+	err := d.DB.Close()
+	if d.vault != nil {
+		if sealErr := d.vault.seal(); err == nil {
+			err = sealErr
+		}
+		d.vault = nil
+	}
+	return err
+}
+
+// Changed reports whether another connection, usually another atad process,
+// has committed to the database since the previous call. The first call only
+// records the current state. SQLite bumps PRAGMA data_version for commits made
+// by other connections, so it is read on a connection kept for the purpose.
+func (d *Database) Changed() (bool, error) {
+	if d.watch == nil {
+		conn, err := d.DB.Conn(context.Background())
+		if err != nil {
+			return false, fmt.Errorf("error opening watch connection: %w", err)
+		}
+		if err := conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&d.seen); err != nil {
+			conn.Close()
+			return false, fmt.Errorf("error reading data version: %w", err)
+		}
+		d.watch = conn
+		return false, nil
+	}
+
+	var version int64
+	if err := d.watch.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version); err != nil {
+		return false, fmt.Errorf("error reading data version: %w", err)
+	}
+	changed := version != d.seen
+	d.seen = version
+	return changed, nil
+}
+
+// Encrypted reports whether the database is an encrypted vault
+func (d *Database) Encrypted() bool {
+	return d.vault != nil
+}
+
+// abandon closes the connection without writing a working copy back into the vault
+func (d *Database) abandon() {
+	if d.watch != nil {
+		d.watch.Close()
+		d.watch = nil
+	}
+	d.DB.Close()
+	if d.vault != nil {
+		d.vault.discard()
+		d.vault = nil
+	}
+}
+
+// DefaultPath returns the database path used when none is given explicitly
+func DefaultPath() string {
+	return getEnv("DB_PATH", getDefaultDBPath())
 }
 
 // getDefaultDBPath returns the default database path
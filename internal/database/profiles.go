@@ -0,0 +1,105 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile stored at the default database path
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProfileName checks that a profile name can be used as a file name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name '%s': use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfileDir returns the directory holding the databases of named profiles,
+// next to the default database
+func ProfileDir() string {
+	return filepath.Join(filepath.Dir(DefaultPath()), "profiles")
+}
+
+// ProfilePath returns the database file of a profile. The default profile
+// uses DefaultPath.
+func ProfilePath(name string) string {
+	if name == "" || name == DefaultProfile {
+		return DefaultPath()
+	}
+	return filepath.Join(ProfileDir(), name+".db")
+}
+
+// ProfileExists reports whether a profile has a database. The default profile always exists.
+func ProfileExists(name string) bool {
+	if name == "" || name == DefaultProfile {
+		return true
+	}
+	_, err := os.Stat(ProfilePath(name))
+	return err == nil
+}
+
+// ListProfiles returns the default profile followed by the named profiles in
+// alphabetical order
+func ListProfiles() ([]string, error) {
+	profiles := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(ProfileDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, fmt.Errorf("error reading profile directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".db")
+		if !ok || entry.IsDir() || ValidateProfileName(name) != nil || name == DefaultProfile {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
+}
+
+// CreateProfile creates the database of a new profile
+func CreateProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if ProfileExists(name) {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+	db, err := NewDatabase(name)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// DeleteProfile removes the database of a named profile. The default profile
+// cannot be deleted.
+func DeleteProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		return fmt.Errorf("the default profile cannot be deleted")
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("no profile named '%s'", name)
+	}
+	path := ProfilePath(name)
+	for _, file := range []string{path, path + "-wal", path + "-shm", path + "-journal"} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error deleting profile '%s': %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/PeguB/atad-project/internal/vault"
+)
+
+// Passphrase supplies the passphrase of an encrypted database when it is
+// opened. Frontends set it to read ATAD_PASSPHRASE or prompt the user.
+var Passphrase func(path string) (string, error)
+
+// ErrVaultInUse is returned when an encrypted database is already open in
+// another process
+var ErrVaultInUse = errors.New("the encrypted database is open in another atad process")
+
+// vaultFile is an encrypted database that has been decrypted to a working
+// copy. Closing the database seals the working copy back into the vault.
+type vaultFile struct {
+	path     string // Encrypted database file
+	workPath string // Decrypted copy in memory-backed storage
+	key      *vault.Key
+}
+
+// IsVault reports whether the database at path is encrypted
+func IsVault(path string) bool {
+	return vault.IsEncryptedFile(path)
+}
+
+// openVault decrypts the database at path into a private working copy. A lock
+// file next to the database keeps a second process from opening it, since the
+// last one to close would overwrite the other's changes.
+func openVault(path string) (*vaultFile, error) {
+	if Passphrase == nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", path, vault.ErrNoPassphrase)
+	}
+	passphrase, err := Passphrase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockVault(path); err != nil {
+		return nil, err
+	}
+	v := &vaultFile{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		v.discard()
+		return nil, fmt.Errorf("error reading encrypted database: %w", err)
+	}
+	plaintext, key, err := vault.Open(data, passphrase)
+	if err != nil {
+		v.discard()
+		return nil, err
+	}
+	v.key = key
+
+	work, err := os.CreateTemp(workingDir(), "atad-vault-*.db")
+	if err != nil {
+		v.discard()
+		return nil, fmt.Errorf("error creating working copy: %w", err)
+	}
+	v.workPath = work.Name()
+	_, err = work.Write(plaintext)
+	if closeErr := work.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		v.discard()
+		return nil, fmt.Errorf("error writing working copy: %w", err)
+	}
+	return v, nil
+}
+
+// seal encrypts the working copy back into the vault and removes it
+func (v *vaultFile) seal() error {
+	defer v.discard()
+
+	data, err := os.ReadFile(v.workPath)
+	if err != nil {
+		return fmt.Errorf("error reading working copy: %w", err)
+	}
+	sealed, err := v.key.Seal(data)
+	if err != nil {
+		return err
+	}
+	if err := vault.WriteFile(v.path, sealed, 0600); err != nil {
+		return fmt.Errorf("error writing encrypted database: %w", err)
+	}
+	return nil
+}
+
+// discard removes the working copy and releases the lock
+func (v *vaultFile) discard() {
+	if v.workPath != "" {
+		removeDatabaseFiles(v.workPath)
+	}
+	os.Remove(v.path + ".lock")
+}
+
+func lockVault(path string) error {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w (remove %s.lock if no atad process is running)", ErrVaultInUse, path)
+		}
+		return fmt.Errorf("error locking encrypted database: %w", err)
+	}
+	fmt.Fprintf(lock, "%d\n", os.Getpid())
+	return lock.Close()
+}
+
+// workingDir picks memory-backed storage for decrypted working copies when
+// available: $XDG_RUNTIME_DIR, then /dev/shm, then the temp directory
+func workingDir() string {
+	for _, dir := range []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"} {
+		if dir == "" {
+			continue
+		}
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// EnableVault encrypts the plaintext database at path in place
+func EnableVault(path, passphrase string) error {
+	key, err := vault.NewKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	// Lock before the snapshot so no other process writes changes that the
+	// encrypted copy would lose
+	if err := lockVault(path); err != nil {
+		return err
+	}
+	defer os.Remove(path + ".lock")
+	if IsVault(path) {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+
+	db, err := NewDatabaseAt(path)
+	if err != nil {
+		return err
+	}
+	data, err := db.snapshotBytes()
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	sealed, err := key.Seal(data)
+	if err != nil {
+		return err
+	}
+	if err := vault.WriteFile(path, sealed, 0600); err != nil {
+		return fmt.Errorf("error writing encrypted database: %w", err)
+	}
+	removeJournalFiles(path)
+	return nil
+}
+
+// DisableVault decrypts the database at path in place
+func DisableVault(path, passphrase string) error {
+	if !IsVault(path) {
+		return fmt.Errorf("%s is not encrypted", path)
+	}
+	if err := lockVault(path); err != nil {
+		return err
+	}
+	defer os.Remove(path + ".lock")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading encrypted database: %w", err)
+	}
+	plaintext, err := vault.Decrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := vault.WriteFile(path, plaintext, 0600); err != nil {
+		return fmt.Errorf("error writing database: %w", err)
+	}
+	return nil
+}
+
+// ChangeVaultPassphrase re-encrypts the database at path with a new passphrase
+func ChangeVaultPassphrase(path, oldPassphrase, newPassphrase string) error {
+	if !IsVault(path) {
+		return fmt.Errorf("%s is not encrypted", path)
+	}
+	key, err := vault.NewKey(newPassphrase)
+	if err != nil {
+		return err
+	}
+	if err := lockVault(path); err != nil {
+		return err
+	}
+	defer os.Remove(path + ".lock")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading encrypted database: %w", err)
+	}
+	plaintext, err := vault.Decrypt(data, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	sealed, err := key.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	if err := vault.WriteFile(path, sealed, 0600); err != nil {
+		return fmt.Errorf("error writing encrypted database: %w", err)
+	}
+	return nil
+}
+
+// removeDatabaseFiles deletes a database file and its journal files
+func removeDatabaseFiles(path string) {
+	os.Remove(path)
+	removeJournalFiles(path)
+}
+
+// removeJournalFiles deletes the journal files SQLite keeps next to a database
+func removeJournalFiles(path string) error {
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing %s: %w", path+suffix, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnableVaultRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atad.db")
+	db, err := NewDatabaseAt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB.Exec(`INSERT INTO transactions (type, amount, category, description, date) VALUES ('expense', 12.5, 'Food', 'lunch', '2026-10-18')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnableVault(path, "secret"); err != nil {
+		t.Fatalf("EnableVault: %v", err)
+	}
+	if !IsVault(path) {
+		t.Fatal("database is not encrypted")
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+
+	Passphrase = func(string) (string, error) { return "secret", nil }
+	defer func() { Passphrase = nil }()
+	db, err = NewDatabaseAt(path)
+	if err != nil {
+		t.Fatalf("open encrypted database: %v", err)
+	}
+	var count int
+	err = db.DB.QueryRow(`SELECT COUNT(*) FROM transactions WHERE description = 'lunch'`).Scan(&count)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || count != 1 {
+		t.Errorf("transactions after encryption = %d, %v; want 1", count, err)
+	}
+}
+
+func TestEnableVaultWaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atad.db")
+	db, err := NewDatabaseAt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockVault(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnableVault(path, "secret"); !errors.Is(err, ErrVaultInUse) {
+		t.Errorf("EnableVault with the lock held: err = %v, want ErrVaultInUse", err)
+	}
+	if IsVault(path) {
+		t.Error("database was encrypted while locked")
+	}
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/ledger"
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Writer streams transactions to an output format one at a time
+type Writer interface {
+	Write(tx *models.Transaction) error
+	// Close flushes buffered output and writes any trailer; it does not close the underlying io.Writer
+	Close() error
+}
+
+// Formats lists the supported export formats
+var Formats = []string{"csv", "json", "ndjson", "beancount", "ledger"}
+
+// NewWriter creates a writer for the given format. The mapping is only used by
+// the plain-text accounting formats; nil selects ledger.DefaultMapping.
+func NewWriter(format string, w io.Writer, mapping *ledger.Mapping) (Writer, error) {
+	switch format {
+	case "beancount":
+		return ledger.NewWriter(ledger.Beancount, w, mapping), nil
+	case "ledger", "hledger":
+		return ledger.NewWriter(ledger.Ledger, w, mapping), nil
+	case "csv":
+		return newCSVWriter(w), nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format '%s'", format)
+	}
+}
+
+// CheckFormat returns the error NewWriter gives for an unsupported format, so
+// callers can reject it before creating an output file
+func CheckFormat(format string) error {
+	_, err := NewWriter(format, io.Discard, nil)
+	return err
+}
+
+// CSVHeader is the header row written by the CSV exporter. Its column names are
+// recognized by parser.CSVParser so exported files re-import losslessly.
+var CSVHeader = []string{"Date", "Description", "Amount", "Category", "Type", "Account", "Status"}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(tx *models.Transaction) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(CSVHeader); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	return c.w.Write([]string{
+		tx.Date.Format(time.RFC3339Nano),
+		tx.Description,
+		strconv.FormatFloat(tx.SignedAmount(), 'f', -1, 64),
+		tx.Category,
+		tx.Type,
+		tx.Account,
+		tx.Status,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	// An empty export still gets a header so it can be re-imported
+	if !c.wroteHeader {
+		if err := c.w.Write(CSVHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter writes a single JSON array, element by element
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(tx *models.Transaction) error {
+	prefix := ",\n  "
+	if j.count == 0 {
+		prefix = "[\n  "
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+	if _, err := j.w.Write(data); err != nil {
+		return err
+	}
+	j.count++
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	trailer := "\n]\n"
+	if j.count == 0 {
+		trailer = "[]\n"
+	}
+	_, err := io.WriteString(j.w, trailer)
+	return err
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(tx *models.Transaction) error {
+	return n.enc.Encode(tx)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
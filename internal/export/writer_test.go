@@ -0,0 +1,136 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/export"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/parser"
+)
+
+func testTransactions() []*models.Transaction {
+	date := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	return []*models.Transaction{
+		{Type: "income", Amount: 2500, Category: "Salary", Description: "ACME payroll", Date: date(1), Account: "Checking", Status: models.StatusReconciled},
+		{Type: "expense", Amount: 1234.56, Category: "Rent", Description: `Rent, March "flat 2"`, Date: date(2), Account: "Checking", Status: models.StatusCleared},
+		{Type: "expense", Amount: 0.1, Category: "Fees", Description: "Card fee", Date: date(3), Status: models.StatusUncleared},
+		{Type: "income", Amount: 19.99, Category: "Refunds", Description: "Refund; shop", Date: date(4), Account: "Card", Status: models.StatusUncleared},
+	}
+}
+
+// exportAll writes transactions in a format and returns the output
+func exportAll(t *testing.T, format string, transactions []*models.Transaction) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	buffered := bufio.NewWriter(&out)
+	writer, err := export.NewWriter(format, buffered, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range transactions {
+		if err := writer.Write(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := buffered.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func assertSameTransactions(t *testing.T, got, want []*models.Transaction) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Type != w.Type || g.Amount != w.Amount || g.Category != w.Category || g.Description != w.Description ||
+			!g.Date.Equal(w.Date) || g.Account != w.Account || g.Status != w.Status {
+			t.Errorf("transaction %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	want := testTransactions()
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(path, exportAll(t, "csv", want), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.NewCSVParser()
+	got, err := p.ParseFile(path)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if warnings := p.Warnings(); len(warnings) > 0 {
+		t.Errorf("import warnings: %v", warnings)
+	}
+	assertSameTransactions(t, got, want)
+}
+
+func TestCSVRoundTripEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(path, exportAll(t, "csv", nil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parser.NewCSVParser().ParseFile(path)
+	if err != nil {
+		t.Fatalf("import of an empty export: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %d transactions, want none", len(got))
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	want := testTransactions()
+
+	var got []*models.Transaction
+	if err := json.Unmarshal(exportAll(t, "json", want), &got); err != nil {
+		t.Fatalf("json export does not decode: %v", err)
+	}
+	assertSameTransactions(t, got, want)
+
+	var empty []*models.Transaction
+	if err := json.Unmarshal(exportAll(t, "json", nil), &empty); err != nil || len(empty) != 0 {
+		t.Errorf("empty json export = %v, %v; want an empty array", empty, err)
+	}
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	want := testTransactions()
+
+	var got []*models.Transaction
+	dec := json.NewDecoder(bytes.NewReader(exportAll(t, "ndjson", want)))
+	for dec.More() {
+		tx := &models.Transaction{}
+		if err := dec.Decode(tx); err != nil {
+			t.Fatalf("ndjson export does not decode: %v", err)
+		}
+		got = append(got, tx)
+	}
+	assertSameTransactions(t, got, want)
+}
+
+func TestCheckFormat(t *testing.T) {
+	for _, format := range export.Formats {
+		if err := export.CheckFormat(format); err != nil {
+			t.Errorf("CheckFormat(%q) = %v", format, err)
+		}
+	}
+	if err := export.CheckFormat("bogus"); err == nil {
+		t.Error("CheckFormat(\"bogus\") accepted an unknown format")
+	}
+}
@@ -0,0 +1,430 @@
+package finances
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// DateLayout is the date format of finances files, independent of the
+// configured input format so a file reads the same everywhere
+const DateLayout = "2006-01-02"
+
+// File is the desired state of the budgets and categorization rules, kept
+// in a finances file such as:
+//
+//	budgets:
+//	  - category: Groceries
+//	    amount: 500
+//	    period: monthly
+//	    start: 2026-01-01
+//	    rollover: capped
+//	    cap: 200
+//	    alerts: [50, 80, 100]
+//	  - category: Freelance
+//	    type: income
+//	    amount: 2000
+//	    period: monthly
+//	  - category: Holiday
+//	    amount: 1200
+//	    start: 2026-08-01
+//	    end: 2026-08-21
+//
+//	rules:
+//	  - category: Groceries
+//	    pattern: '(?i)(grocery|supermarket)'
+//	    description: Grocery stores
+//	    priority: 10
+//
+// The file is a small subset of YAML: the two top-level lists, entries of
+// plain or quoted scalars, flow lists ([a, b]) and '#' comments. A recurring
+// budget without a start keeps its current anchor, or starts with the current
+// period when it is new.
+type File struct {
+	Budgets []*models.Budget
+	Rules   []*models.CategoryRule
+}
+
+// entry is one '-' item of a list, with the line each field was read from
+type entry struct {
+	line   int
+	fields map[string]string
+	lines  map[string]int
+}
+
+// ReadFile reads and checks a finances file
+func ReadFile(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse reads a finances file and checks it describes a valid state: known
+// fields, valid values and no two budgets applying to the same days
+func Parse(r io.Reader) (*File, error) {
+	lists, err := parseLists(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	for _, e := range lists["budgets"] {
+		budget, err := parseBudget(e)
+		if err != nil {
+			return nil, err
+		}
+		f.Budgets = append(f.Budgets, budget)
+	}
+	for _, e := range lists["rules"] {
+		rule, err := parseRule(e)
+		if err != nil {
+			return nil, err
+		}
+		f.Rules = append(f.Rules, rule)
+	}
+
+	if err := f.check(lists); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check rejects budgets that would apply on the same days, which the
+// database refuses as well, and rules with the same pattern
+func (f *File) check(lists map[string][]*entry) error {
+	for i, budget := range f.Budgets {
+		for j := 0; j < i; j++ {
+			if budget.Overlaps(f.Budgets[j]) {
+				return fmt.Errorf("line %d: the '%s' budget overlaps the one on line %d", lists["budgets"][i].line,
+					budget.Category, lists["budgets"][j].line)
+			}
+		}
+	}
+	patterns := map[string]int{}
+	for i, rule := range f.Rules {
+		line := lists["rules"][i].line
+		if other, ok := patterns[rule.Pattern]; ok {
+			return fmt.Errorf("line %d: pattern already used by the rule on line %d", line, other)
+		}
+		patterns[rule.Pattern] = line
+	}
+	return nil
+}
+
+// parseLists splits a file into the entries of its top-level lists
+func parseLists(r io.Reader) (map[string][]*entry, error) {
+	lists := map[string][]*entry{}
+	section := ""
+	var current *entry
+	itemIndent := 0
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := stripComment(scanner.Text())
+		content := strings.TrimSpace(text)
+		if content == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", lineNum)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		isItem := content == "-" || strings.HasPrefix(content, "- ")
+
+		if indent == 0 && !isItem {
+			key, value, ok := cutKey(content)
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected 'budgets:' or 'rules:'", lineNum)
+			}
+			switch key {
+			case "budgets", "rules":
+				if value != "" && value != "[]" {
+					return nil, fmt.Errorf("line %d: %s must be a list of '- ' entries", lineNum, key)
+				}
+				if _, seen := lists[key]; seen {
+					return nil, fmt.Errorf("line %d: %s listed twice", lineNum, key)
+				}
+				lists[key] = []*entry{}
+				section, current = key, nil
+			case "version":
+				if value != "1" {
+					return nil, fmt.Errorf("line %d: unsupported version %s", lineNum, value)
+				}
+				section, current = "", nil
+			default:
+				return nil, fmt.Errorf("line %d: unknown section '%s'", lineNum, key)
+			}
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: entries belong under 'budgets:' or 'rules:'", lineNum)
+		}
+
+		if isItem {
+			current = &entry{line: lineNum, fields: map[string]string{}, lines: map[string]int{}}
+			lists[section] = append(lists[section], current)
+			itemIndent = indent
+			content = strings.TrimSpace(content[1:])
+			if content == "" {
+				continue
+			}
+		} else if current == nil || indent <= itemIndent {
+			return nil, fmt.Errorf("line %d: expected '- ' to start an entry", lineNum)
+		}
+
+		key, raw, ok := cutKey(content)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'field: value'", lineNum)
+		}
+		if _, dup := current.fields[key]; dup {
+			return nil, fmt.Errorf("line %d: %s given twice", lineNum, key)
+		}
+		value, err := parseScalar(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		current.fields[key] = value
+		current.lines[key] = lineNum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read finances file: %w", err)
+	}
+	return lists, nil
+}
+
+// cutKey splits "key: value" at the colon after a plain key
+func cutKey(content string) (key, value string, ok bool) {
+	key, value, found := strings.Cut(content, ":")
+	if !found || key == "" || strings.ContainsAny(key, " \t'\"") {
+		return "", "", false
+	}
+	if value != "" && value[0] != ' ' {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// parseScalar reads a plain, single-quoted or double-quoted value, or a flow
+// list, which is returned comma-separated
+func parseScalar(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", nil
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("unterminated quoted string %s", raw)
+		}
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), nil
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("unterminated list %s", raw)
+		}
+		var items []string
+		for _, item := range strings.Split(raw[1:len(raw)-1], ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := parseScalar(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return raw, nil
+}
+
+// stripComment removes a '#' comment that starts a line or follows a space,
+// outside quoted values. A quote only opens a value at its start, so the
+// apostrophe in "Joe's" does not.
+func stripComment(line string) string {
+	var quote, prev rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\', quote == '\'' && r == '\'' && strings.HasPrefix(line[i+1:], "'"):
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && strings.ContainsRune(":-[,", prev):
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+		if r != ' ' && r != '\t' {
+			prev = r
+		}
+	}
+	return line
+}
+
+// budgetFields are the fields a budget entry may have
+var budgetFields = []string{"category", "type", "amount", "period", "start", "end", "rollover", "cap", "alerts"}
+
+// parseBudget converts an entry to a budget, with the same rules as
+// 'atad budget set'
+func parseBudget(e *entry) (*models.Budget, error) {
+	if err := e.checkFields("budget", budgetFields); err != nil {
+		return nil, err
+	}
+	errorf := func(field, format string, args ...interface{}) error {
+		line := e.line
+		if l, ok := e.lines[field]; ok {
+			line = l
+		}
+		return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+
+	budget := &models.Budget{
+		Category: e.fields["category"],
+		Type:     e.get("type", models.BudgetExpense),
+		Period:   e.get("period", models.PeriodCustom),
+		Rollover: e.get("rollover", models.RolloverNone),
+	}
+	if budget.Category == "" {
+		return nil, errorf("category", "budget needs a category")
+	}
+	if budget.Type != models.BudgetExpense && budget.Type != models.BudgetIncome {
+		return nil, errorf("type", "invalid type '%s'. Use expense or income", budget.Type)
+	}
+	if !models.IsValidBudgetPeriod(budget.Period) {
+		return nil, errorf("period", "invalid period '%s'. Use one of: %s", budget.Period, strings.Join(models.BudgetPeriods, ", "))
+	}
+	if !models.IsValidRollover(budget.Rollover) {
+		return nil, errorf("rollover", "invalid rollover '%s'. Use one of: %s", budget.Rollover, strings.Join(models.Rollovers, ", "))
+	}
+
+	amount, ok := e.fields["amount"]
+	if !ok {
+		return nil, errorf("category", "the '%s' budget needs an amount", budget.Category)
+	}
+	var err error
+	if budget.Amount, err = strconv.ParseFloat(amount, 64); err != nil || budget.Amount < 0 {
+		return nil, errorf("amount", "invalid amount '%s'", amount)
+	}
+	if capValue, ok := e.fields["cap"]; ok {
+		if budget.RolloverCap, err = strconv.ParseFloat(capValue, 64); err != nil {
+			return nil, errorf("cap", "invalid cap '%s'", capValue)
+		}
+	}
+	if budget.Rollover == models.RolloverCapped && budget.RolloverCap <= 0 {
+		return nil, errorf("rollover", "rollover capped needs a positive cap")
+	}
+	if budget.RolloverCap != 0 && budget.Rollover != models.RolloverCapped {
+		return nil, errorf("cap", "cap only applies with rollover capped")
+	}
+	if alerts := e.get("alerts", "default"); alerts != "default" {
+		if budget.AlertThresholds, err = models.ParseThresholds(alerts); err != nil {
+			return nil, errorf("alerts", "%v", err)
+		}
+	}
+	if budget.IsIncome() && (budget.Rollover != models.RolloverNone || e.has("alerts")) {
+		return nil, errorf("type", "rollover and alerts only apply to expense budgets")
+	}
+
+	if start, ok := e.fields["start"]; ok {
+		if budget.StartDate, err = time.Parse(DateLayout, start); err != nil {
+			return nil, errorf("start", "invalid start date '%s'. Use YYYY-MM-DD", start)
+		}
+	}
+	if end, ok := e.fields["end"]; ok {
+		if budget.EndDate, err = time.Parse(DateLayout, end); err != nil {
+			return nil, errorf("end", "invalid end date '%s'. Use YYYY-MM-DD", end)
+		}
+	}
+	if budget.IsRecurring() {
+		if e.has("end") {
+			return nil, errorf("end", "end only applies to custom budgets; recurring budgets repeat from start")
+		}
+		return budget, nil
+	}
+	if budget.StartDate.IsZero() || budget.EndDate.IsZero() {
+		return nil, errorf("category", "the custom '%s' budget needs a start and an end", budget.Category)
+	}
+	if budget.Rollover != models.RolloverNone {
+		return nil, errorf("rollover", "rollover only applies to recurring budgets")
+	}
+	if budget.EndDate.Before(budget.StartDate) {
+		return nil, errorf("end", "end date is before start date")
+	}
+	return budget, nil
+}
+
+// ruleFields are the fields a rule entry may have
+var ruleFields = []string{"category", "pattern", "description", "priority"}
+
+// parseRule converts an entry to a categorization rule
+func parseRule(e *entry) (*models.CategoryRule, error) {
+	if err := e.checkFields("rule", ruleFields); err != nil {
+		return nil, err
+	}
+
+	rule := &models.CategoryRule{
+		Category:    e.fields["category"],
+		Pattern:     e.fields["pattern"],
+		Description: e.fields["description"],
+	}
+	if rule.Category == "" || rule.Pattern == "" {
+		return nil, fmt.Errorf("line %d: rule needs a category and a pattern", e.line)
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return nil, fmt.Errorf("line %d: invalid pattern: %v", e.lines["pattern"], err)
+	}
+	if priority, ok := e.fields["priority"]; ok {
+		var err error
+		if rule.Priority, err = strconv.Atoi(priority); err != nil {
+			return nil, fmt.Errorf("line %d: invalid priority '%s'", e.lines["priority"], priority)
+		}
+	}
+	return rule, nil
+}
+
+// checkFields rejects fields an entry of kind does not have
+func (e *entry) checkFields(kind string, known []string) error {
+	for field, line := range e.lines {
+		valid := false
+		for _, k := range known {
+			valid = valid || k == field
+		}
+		if !valid {
+			return fmt.Errorf("line %d: unknown %s field '%s'", line, kind, field)
+		}
+	}
+	return nil
+}
+
+func (e *entry) has(field string) bool {
+	_, ok := e.fields[field]
+	return ok
+}
+
+// get returns a field, or def when it is missing or empty
+func (e *entry) get(field, def string) string {
+	if value := e.fields[field]; value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,215 @@
+package finances
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+const example = `# Household budgets
+version: 1
+budgets:
+  - category: Groceries
+    amount: 500   # per month
+    period: monthly
+    start: 2026-01-01
+    rollover: capped
+    cap: 200
+    alerts: [50, 80, 100]
+  - category: Freelance
+    type: income
+    amount: 2000
+    period: monthly
+  -
+    category: "Holiday #2"
+    amount: 1200
+    start: 2026-08-01
+    end: 2026-08-21
+
+rules:
+  - category: Groceries
+    pattern: '(?i)(grocery|supermarket)'
+    description: Joe's # not a quote
+    priority: 10
+  - category: Dining
+    pattern: "café #1"
+  - category: Fees
+    pattern: 'it''s a fee'
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(example))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBudgets := []*models.Budget{
+		{Category: "Groceries", Type: models.BudgetExpense, Amount: 500, Period: models.PeriodMonthly,
+			StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Rollover: models.RolloverCapped, RolloverCap: 200,
+			AlertThresholds: []float64{50, 80, 100}},
+		{Category: "Freelance", Type: models.BudgetIncome, Amount: 2000, Period: models.PeriodMonthly, Rollover: models.RolloverNone},
+		{Category: "Holiday #2", Type: models.BudgetExpense, Amount: 1200, Period: models.PeriodCustom, Rollover: models.RolloverNone,
+			StartDate: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 8, 21, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(f.Budgets, wantBudgets) {
+		t.Errorf("budgets:\n got %+v\nwant %+v", f.Budgets, wantBudgets)
+	}
+
+	wantRules := []*models.CategoryRule{
+		{Category: "Groceries", Pattern: "(?i)(grocery|supermarket)", Description: "Joe's", Priority: 10},
+		{Category: "Dining", Pattern: "café #1"},
+		{Category: "Fees", Pattern: "it's a fee"},
+	}
+	if !reflect.DeepEqual(f.Rules, wantRules) {
+		t.Errorf("rules:\n got %+v\nwant %+v", f.Rules, wantRules)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "tab indent",
+			file: "budgets:\n  - category: Food\n\tamount: 5\n",
+			want: "line 3: indent with spaces, not tabs",
+		},
+		{
+			name: "tab after spaces",
+			file: "budgets:\n  - category: Food\n  \tamount: 5\n",
+			want: "line 3: indent with spaces, not tabs",
+		},
+		{
+			name: "duplicate field",
+			file: "budgets:\n  - category: Food\n    amount: 5\n    amount: 6\n",
+			want: "line 4: amount given twice",
+		},
+		{
+			name: "duplicate section",
+			file: "rules: []\nrules:\n",
+			want: "line 2: rules listed twice",
+		},
+		{
+			name: "overlapping recurring budgets",
+			file: "budgets:\n  - category: Food\n    amount: 5\n    period: monthly\n  - category: Food\n    amount: 50\n    period: weekly\n",
+			want: "line 5: the 'Food' budget overlaps the one on line 2",
+		},
+		{
+			name: "overlapping custom budgets",
+			file: "budgets:\n" +
+				"  - category: Trip\n    amount: 5\n    start: 2026-08-01\n    end: 2026-08-10\n" +
+				"  - category: Trip\n    amount: 5\n    start: 2026-08-10\n    end: 2026-08-20\n",
+			want: "line 6: the 'Trip' budget overlaps the one on line 2",
+		},
+		{
+			name: "duplicate pattern",
+			file: "rules:\n  - category: A\n    pattern: shop\n  - category: B\n    pattern: 'shop'\n",
+			want: "line 4: pattern already used by the rule on line 2",
+		},
+		{
+			name: "unterminated quote",
+			file: "rules:\n  - category: A\n    pattern: 'shop\n",
+			want: "line 3: unterminated quoted string 'shop",
+		},
+		{
+			name: "invalid pattern",
+			file: "rules:\n  - category: A\n    pattern: '(shop'\n",
+			want: "line 3: invalid pattern",
+		},
+		{
+			name: "unknown field",
+			file: "budgets:\n  - category: Food\n    amount: 5\n    colour: red\n",
+			want: "line 4: unknown budget field 'colour'",
+		},
+		{
+			name: "entry outside a section",
+			file: "- category: Food\n",
+			want: "line 1: entries belong under 'budgets:' or 'rules:'",
+		},
+		{
+			name: "custom budget without dates",
+			file: "budgets:\n  - category: Food\n    amount: 5\n",
+			want: "line 2: the custom 'Food' budget needs a start and an end",
+		},
+		{
+			name: "cap without capped rollover",
+			file: "budgets:\n  - category: Food\n    amount: 5\n    period: monthly\n    cap: 20\n",
+			want: "line 5: cap only applies with rollover capped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.file))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAllowsSeparateBudgets(t *testing.T) {
+	// The same category as expense and income, or recurring with a custom
+	// override, are different budgets
+	file := "budgets:\n" +
+		"  - category: Food\n    amount: 5\n    period: monthly\n" +
+		"  - category: Food\n    type: income\n    amount: 5\n    period: monthly\n" +
+		"  - category: Food\n    amount: 50\n    start: 2026-12-01\n    end: 2026-12-31\n"
+	f, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Budgets) != 3 {
+		t.Errorf("got %d budgets, want 3", len(f.Budgets))
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"# comment", ""},
+		{"  amount: 5 # five", "  amount: 5 "},
+		{"  pattern: a#b", "  pattern: a#b"},
+		{"  pattern: 'a #b' # c", "  pattern: 'a #b' "},
+		{`  pattern: "a \" #b" # c`, `  pattern: "a \" #b" `},
+		{"  pattern: 'it''s #1'", "  pattern: 'it''s #1'"},
+		{"  description: Joe's #1", "  description: Joe's "},
+		{"  alerts: ['50', 80] # x", "  alerts: ['50', 80] "},
+	}
+	for _, tt := range tests {
+		if got := stripComment(tt.line); got != tt.want {
+			t.Errorf("stripComment(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	want, err := Parse(strings.NewReader(example))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Budgets[1].StartDate = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	want.Rules = append(want.Rules,
+		&models.CategoryRule{Category: "Misc", Pattern: "tab\there: yes", Description: "-dash"},
+		&models.CategoryRule{Category: "true", Pattern: "123"})
+
+	var buf bytes.Buffer
+	if err := want.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("written file does not parse: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package finances
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Write writes the file in the format Parse reads, leaving out fields that
+// have their default value
+func (f *File) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# atad budgets and categorization rules.")
+	fmt.Fprintln(bw, "# Preview changes with 'atad plan -f <file>' and sync them with 'atad apply -f <file>'.")
+
+	fmt.Fprintln(bw)
+	if len(f.Budgets) == 0 {
+		fmt.Fprintln(bw, "budgets: []")
+	} else {
+		fmt.Fprintln(bw, "budgets:")
+	}
+	for _, budget := range f.Budgets {
+		writeField(bw, "  - ", "category", budget.Category)
+		if budget.IsIncome() {
+			writeField(bw, "    ", "type", budget.Type)
+		}
+		fmt.Fprintf(bw, "    amount: %s\n", FormatNumber(budget.Amount))
+		writeField(bw, "    ", "period", budget.Period)
+		fmt.Fprintf(bw, "    start: %s\n", budget.StartDate.Format(DateLayout))
+		if !budget.IsRecurring() {
+			fmt.Fprintf(bw, "    end: %s\n", budget.EndDate.Format(DateLayout))
+		}
+		if budget.RollsOver() {
+			writeField(bw, "    ", "rollover", budget.Rollover)
+		}
+		if budget.Rollover == models.RolloverCapped {
+			fmt.Fprintf(bw, "    cap: %s\n", FormatNumber(budget.RolloverCap))
+		}
+		if budget.AlertThresholds != nil {
+			fmt.Fprintf(bw, "    alerts: %s\n", FormatAlerts(budget.AlertThresholds))
+		}
+	}
+
+	fmt.Fprintln(bw)
+	if len(f.Rules) == 0 {
+		fmt.Fprintln(bw, "rules: []")
+	} else {
+		fmt.Fprintln(bw, "rules:")
+	}
+	for _, rule := range f.Rules {
+		writeField(bw, "  - ", "category", rule.Category)
+		writeField(bw, "    ", "pattern", rule.Pattern)
+		if rule.Description != "" {
+			writeField(bw, "    ", "description", rule.Description)
+		}
+		fmt.Fprintf(bw, "    priority: %d\n", rule.Priority)
+	}
+	return bw.Flush()
+}
+
+func writeField(w io.Writer, prefix, key, value string) {
+	fmt.Fprintf(w, "%s%s: %s\n", prefix, key, quote(value))
+}
+
+// FormatNumber writes an amount without trailing zeros
+func FormatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// FormatAlerts writes alert thresholds as a flow list, "none" or "default"
+func FormatAlerts(thresholds []float64) string {
+	if thresholds == nil {
+		return "default"
+	}
+	if len(thresholds) == 0 {
+		return models.ThresholdsNone
+	}
+	fields := make([]string, len(thresholds))
+	for i, threshold := range thresholds {
+		fields[i] = FormatNumber(threshold)
+	}
+	return "[" + strings.Join(fields, ", ") + "]"
+}
+
+// quote leaves a value plain when YAML reads it back as the same string, and
+// otherwise single-quotes it
+func quote(value string) string {
+	if isPlain(value) {
+		return value
+	}
+	if strings.ContainsAny(value, "\n\r\t") {
+		return strconv.Quote(value)
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// isPlain reports whether a value needs no quotes: it does not start with a
+// YAML indicator, hold a ': ' or ' #', or read as a number or boolean
+func isPlain(value string) bool {
+	if value == "" || value != strings.TrimSpace(value) || strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	if strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.HasSuffix(value, ":") ||
+		strings.ContainsAny(value, "\n\r\t") {
+		return false
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// AlertsCommand handles the 'alerts' subcommands
+type AlertsCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *AlertsCommand) handleList(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	count := fs.Int("n", 20, "Number of alerts to show")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *count < 1 {
+		return validationErrorf("-n must be at least 1")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	alerts, err := h.alertSvc.Recent(*count)
+	if err != nil {
+		return dbErrorf("failed to read alerts: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		columns := []string{"id", "created_at", "kind", "category", "budget_id", "period_start", "threshold",
+			"transaction_id", "amount", "message"}
+		records := make([]Record, 0, len(alerts))
+		for _, alert := range alerts {
+			records = append(records, Record{
+				{"id", alert.ID},
+				{"created_at", alert.CreatedAt},
+				{"kind", alert.Kind},
+				{"category", alert.Category},
+				{"budget_id", alert.BudgetID},
+				{"period_start", alert.PeriodStart},
+				{"threshold", alert.Threshold},
+				{"transaction_id", alert.TransactionID},
+				{"amount", alert.Amount},
+				{"message", alert.Message},
+			})
+		}
+		return h.WriteRecords(columns, records)
+	}
+
+	if len(alerts) == 0 {
+		h.println("No alerts yet.")
+		return nil
+	}
+	h.println("\n🔔 Alerts")
+	h.println("──────────────────────────────────────────────────────────────────────────────")
+	for _, alert := range alerts {
+		h.printf("%s %s  %s\n", h.Config.FormatDate(alert.CreatedAt), alert.CreatedAt.Format("15:04"), alert.Message)
+	}
+	return nil
+}
+
+// handleTest sends an alert through every configured sink, so hooks can be
+// checked without overspending
+func (c *AlertsCommand) handleTest(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	alert := &models.Alert{
+		Kind:    models.AlertTest,
+		Message: "Test alert from atad: budget alerts are working",
+	}
+	if err := h.alertSvc.Send(alert); err != nil {
+		return fmt.Errorf("test alert not delivered: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"id", alert.ID}, {"kind", alert.Kind}, {"message", alert.Message}, {"sinks", h.alertSvc.SinkNames()}})
+	}
+	h.infof("✅ Test alert sent to: %s\n", h.alertSvc.SinkNames())
+	return nil
+}
@@ -0,0 +1,1209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Version is printed by 'atad version'
+const Version = "1.0.0"
+
+// Command is a node in the CLI command tree. Leaf commands have a handler
+// constructor; group commands (like 'budget') only dispatch to subcommands.
+type Command struct {
+	Name        string
+	Usage       string // Arguments shown after the command path in help
+	Summary     string
+	Examples    []string
+	Hidden      bool
+	New         func(h *CLIHandler) CommandHandler
+	Subcommands []*Command
+
+	// Shell completion for positional arguments (by position) and flag values
+	// (by flag name, overriding flagCompleters)
+	Args       []Completer
+	FlagValues map[string]Completer
+
+	parent *Command
+}
+
+// Path returns the full command path, e.g. "atad budget set"
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Find returns the direct subcommand with the given name
+func (c *Command) Find(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// CommandFunc adapts a function to the CommandHandler interface
+type CommandFunc func(args []string) error
+
+func (f CommandFunc) Run(args []string) error {
+	return f(args)
+}
+
+// App is the atad command-line application
+type App struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Interactive starts the TUI when no command is given
+	Interactive func(h *CLIHandler) error
+
+	root *Command
+}
+
+// NewApp creates the application with its command tree
+func NewApp(stdin io.Reader, stdout, stderr io.Writer) *App {
+	return &App{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		root:   newCommandTree(),
+	}
+}
+
+// Root returns the top of the command tree
+func (a *App) Root() *Command {
+	return a.root
+}
+
+// Run executes the command line (without the program name) and returns the exit code
+func (a *App) Run(args []string) int {
+	h := NewCLIHandler(a.Stdin, a.Stdout, a.Stderr)
+	h.root = a.root
+
+	// Completion sees the command line exactly as typed, global flags included
+	if len(args) > 0 && args[0] == "__complete" {
+		h.noPrompt = true
+		database.Passphrase = h.databasePassphrase
+		err := (&CompleteCommand{Handler: h}).Run(args[1:])
+		h.Close()
+		return h.reportError(err)
+	}
+	database.Passphrase = h.databasePassphrase
+
+	args, err := h.parseGlobalFlags(args)
+	if err != nil {
+		return h.reportError(err)
+	}
+	if err := h.loadConfig(); err != nil {
+		return h.reportError(err)
+	}
+
+	if len(args) == 0 {
+		if a.Interactive != nil {
+			return h.reportError(a.Interactive(h))
+		}
+		h.printHelp(a.root)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help":
+		args[0] = "help"
+	case "-v", "-version", "--version":
+		args[0] = "version"
+	}
+
+	// Walk down the command tree
+	cmd := a.root
+	for len(args) > 0 {
+		sub := cmd.Find(args[0])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		args = args[1:]
+	}
+
+	h.command = cmd
+	if cmd.New == nil {
+		if len(args) > 0 && !isHelpArg(args[0]) {
+			return h.reportError(usageErrorf("unknown command: %s %s", cmd.Path(), args[0]))
+		}
+		h.printHelp(cmd)
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	err = cmd.New(h).Run(args)
+	if closeErr := h.Close(); err == nil || ExitCode(err) == ExitOK {
+		err = closeErr
+	}
+	return h.reportError(err)
+}
+
+// parseGlobalFlags removes the global flags from the command line. They are
+// taken before and between the command names and from the run of flags that
+// ends the command line, so a command's own arguments and anything after "--"
+// are passed through untouched.
+func (h *CLIHandler) parseGlobalFlags(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	cmd := h.root
+	i := 0
+	for i < len(args) {
+		if width := globalFlagWidth(args[i]); width > 0 {
+			if err := h.setGlobalFlag(args[i:]); err != nil {
+				return nil, err
+			}
+			i += width
+			continue
+		}
+		sub := cmd.Find(args[i])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		rest = append(rest, args[i])
+		i++
+	}
+
+	tail := args[i:]
+	end := len(tail)
+	for j, arg := range tail {
+		if arg == "--" {
+			end = j
+			break
+		}
+	}
+	run := trailingGlobalFlags(tail[:end])
+	rest = append(rest, tail[:run]...)
+	for j := run; j < end; j += globalFlagWidth(tail[j]) {
+		if err := h.setGlobalFlag(tail[j:end]); err != nil {
+			return nil, err
+		}
+	}
+	rest = append(rest, tail[end:]...)
+
+	if h.Output == "" {
+		h.Output = OutputTable
+	}
+	if !ValidOutputFormat(h.Output) {
+		return nil, usageErrorf("--output must be one of %s", strings.Join(OutputFormats, ", "))
+	}
+	if h.DBPath != "" && h.Profile != "" {
+		return nil, usageErrorf("--db and --profile cannot be used together")
+	}
+
+	return rest, nil
+}
+
+// globalFlagWidth returns how many words the global flag starting at arg
+// takes, or 0 when arg is not a global flag
+func globalFlagWidth(arg string) int {
+	if !isFlag(arg) {
+		return 0
+	}
+	name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	completer, ok := globalFlags[name]
+	switch {
+	case !ok:
+		return 0
+	case completer == nil || hasValue:
+		return 1
+	}
+	return 2
+}
+
+// trailingGlobalFlags returns the index of the run of global flags that ends
+// args, or len(args) when there is none. A value flag missing its value at the
+// very end still counts so that it is reported.
+func trailingGlobalFlags(args []string) int {
+	for start := 0; start < len(args); start++ {
+		i := start
+		for i < len(args) {
+			width := globalFlagWidth(args[i])
+			if width == 0 {
+				break
+			}
+			i += width
+		}
+		if i >= len(args) {
+			return start
+		}
+	}
+	return len(args)
+}
+
+// setGlobalFlag applies the global flag at args[0], reading its value from
+// args[1] when it is not given with "="
+func (h *CLIHandler) setGlobalFlag(args []string) error {
+	name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+
+	var target *string
+	switch name {
+	case "output":
+		target = &h.Output
+	case "db":
+		target = &h.DBPath
+	case "config":
+		target = &h.ConfigPath
+	case "profile":
+		target = &h.Profile
+	case "no-color":
+		h.NoColor = true
+		return nil
+	case "quiet":
+		h.Quiet = true
+		return nil
+	}
+
+	if !hasValue {
+		if len(args) < 2 {
+			return usageErrorf("flag --%s needs a value", name)
+		}
+		value = args[1]
+	}
+	*target = value
+	return nil
+}
+
+// loadConfig reads the config file (--config, $ATAD_CONFIG or ~/.atad/config.toml)
+// and applies the settings that depend on it
+func (h *CLIHandler) loadConfig() error {
+	if h.ConfigPath == "" {
+		h.ConfigPath = config.DefaultPath()
+	}
+	cfg, err := config.Load(h.ConfigPath)
+	if err != nil {
+		return validationErrorf("invalid config: %v", err)
+	}
+	h.Config = cfg
+
+	if h.Profile == "" && h.DBPath == "" {
+		h.Profile = cfg.ActiveProfile
+	}
+
+	if h.NoColor || os.Getenv("NO_COLOR") != "" || cfg.Theme == "mono" {
+		h.NoColor = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	return nil
+}
+
+// reportError prints a command error to stderr and returns its exit code
+func (h *CLIHandler) reportError(err error) int {
+	code := ExitCode(err)
+	if code == ExitOK {
+		return code
+	}
+
+	if h.Output == OutputJSON {
+		data, _ := json.Marshal(struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+		}{err.Error(), code})
+		fmt.Fprintf(h.Stderr, "%s\n", data)
+	} else {
+		fmt.Fprintf(h.Stderr, "Error: %v\n", err)
+		if code == ExitUsage && h.command != nil {
+			fmt.Fprintf(h.Stderr, "Run '%s -h' for usage.\n", h.command.Path())
+		}
+	}
+	return code
+}
+
+// newFlagSet creates a flag set for the running command that reports errors instead of exiting
+func (h *CLIHandler) newFlagSet() *flag.FlagSet {
+	name := "atad"
+	if h.command != nil {
+		name = h.command.Path()
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	h.flags = fs
+	return fs
+}
+
+// parseArgs parses flags that may be interspersed with positional arguments
+// and returns the positional arguments
+func (h *CLIHandler) parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				h.printCommandHelp(h.command, fs)
+				return nil, ErrHelp
+			}
+			return nil, usageErrorf("%v", err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help" || arg == "help"
+}
+
+// printHelp prints help for a group command or, for leaf commands, their usage line
+func (h *CLIHandler) printHelp(cmd *Command) {
+	if cmd.New != nil {
+		h.printCommandHelp(cmd, nil)
+		return
+	}
+
+	w := h.Stdout
+	if cmd.parent == nil {
+		fmt.Fprintln(w, "ATAD - Personal Finance Tracker")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Usage:")
+		fmt.Fprintln(w, "  atad [global flags] [command] [flags]")
+	} else {
+		fmt.Fprintf(w, "%s\n\n", cmd.Summary)
+		fmt.Fprintln(w, "Usage:")
+		fmt.Fprintf(w, "  %s <command> [flags]\n", cmd.Path())
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Available Commands:")
+	for _, sub := range cmd.Subcommands {
+		if !sub.Hidden {
+			fmt.Fprintf(w, "  %-11s %s\n", sub.Name, sub.Summary)
+		}
+	}
+
+	if cmd.parent == nil {
+		fmt.Fprintln(w, "  help        Show help for a command")
+		fmt.Fprintln(w, "  version     Show version information")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Global Flags:")
+		fmt.Fprintln(w, "  --output <format>   Output format: table (default), json, csv or tsv")
+		fmt.Fprintln(w, "  --db <path>         Database file (default: $DB_PATH or ~/.atad/atad.db)")
+		fmt.Fprintln(w, "  --profile <name>    Use the database of a named profile (default: profile.active)")
+		fmt.Fprintln(w, "  --config <path>     Configuration file (default: $ATAD_CONFIG or ~/.atad/config.toml)")
+		fmt.Fprintln(w, "  --no-color          Disable colored output")
+		fmt.Fprintln(w, "  --quiet             Only print results and errors")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Environment:")
+		fmt.Fprintln(w, "  ATAD_PASSPHRASE     Passphrase of an encrypted database, backup or export")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Run 'atad [command] -h' for more information about a command.")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "When run without a command, ATAD starts in interactive TUI mode.")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Exit Codes:")
+		fmt.Fprintln(w, "  0 success, 1 error, 2 usage, 3 not found, 4 validation, 5 database, 6 budget exceeded")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Examples:")
+		fmt.Fprintln(w, "  atad                                    # Start interactive mode")
+		fmt.Fprintln(w, "  atad add -type expense -desc Lunch -amount 12.50")
+		fmt.Fprintln(w, "  atad list -type income --output json    # List all income as JSON")
+		fmt.Fprintln(w, "  atad report income -period month        # Monthly income report")
+		fmt.Fprintln(w, "  atad budget set Groceries 500 01/12/2025 31/12/2025")
+		fmt.Fprintln(w, "  atad --db /tmp/test.db search coffee    # Search another database")
+		fmt.Fprintln(w, "  atad --profile business list            # List transactions of the 'business' profile")
+	} else {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Run '%s <command> -h' for more information about a command.\n", cmd.Path())
+	}
+}
+
+// printCommandHelp prints the usage of a leaf command and, if known, its flags
+func (h *CLIHandler) printCommandHelp(cmd *Command, fs *flag.FlagSet) {
+	w := h.Stdout
+	if cmd == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n\n", cmd.Summary)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintf(w, "  %s %s\n", cmd.Path(), cmd.Usage)
+
+	if fs != nil {
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "Flags:")
+			fs.SetOutput(w)
+			fs.PrintDefaults()
+			fs.SetOutput(io.Discard)
+		}
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Examples:")
+		for _, example := range cmd.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// newCommandTree builds the CLI command tree
+func newCommandTree() *Command {
+	root := &Command{
+		Name: "atad",
+		Subcommands: []*Command{
+			{
+				Name:     "add",
+				Usage:    "-type <income|expense> -desc <description> -amount <amount> [-category <category>] [-date <date>] [-account <account>]",
+				Summary:  "Add a new transaction",
+				Examples: []string{`atad add -type expense -desc "Grocery shopping" -amount 75.50 -category Groceries`},
+				FlagValues: map[string]Completer{
+					"type": completeWords("income", "expense"),
+				},
+				New: func(h *CLIHandler) CommandHandler { return &AddCommand{Handler: h} },
+			},
+			{
+				Name:     "list",
+				Usage:    "[-type <all|income|expense>] [-category <category>] [-account <account>] [-from <date>] [-to <date>] [-limit <n>]",
+				Summary:  "List transactions",
+				Examples: []string{"atad list -type income", "atad list -category Groceries -from 01/12/2025 --output json"},
+				New:      func(h *CLIHandler) CommandHandler { return &ListCommand{Handler: h} },
+			},
+			{
+				Name:    "report",
+				Usage:   "<income|expense> [-period <all|month|year>] | budget-variance [-periods <n>] [-period <weekly|monthly|quarterly|yearly>] [-type <expense|income>] | debt [-extra <amount>]",
+				Summary: "Generate reports (income/expense, budget variance, debt)",
+				Examples: []string{
+					"atad report income -period month",
+					"atad report budget-variance -periods 6",
+					"atad report budget-variance -type income -period quarterly -periods 4 --output csv",
+					"atad report debt -extra 200",
+				},
+				Args: []Completer{completeWords("income", "expense", "budget-variance", "debt")},
+				New:  func(h *CLIHandler) CommandHandler { return &ReportCommand{Handler: h} },
+			},
+			{
+				Name:    "budget",
+				Summary: "Manage budgets",
+				Subcommands: []*Command{
+					{
+						Name:    "list",
+						Summary: "List all budgets",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:    "set",
+						Usage:   "[-type <expense|income>] <category> <amount> <start_date> <end_date> | -period <weekly|monthly|quarterly|yearly> [-anchor <date>] [-rollover <none|surplus|full|capped>] [-cap <amount>] [-alerts <percents>] <category> <amount>",
+						Summary: "Set a one-off or recurring budget, or an income target with -type income",
+						Examples: []string{
+							"atad budget set Groceries 500 01/12/2025 31/12/2025",
+							"atad budget set -period monthly Groceries 500",
+							"atad budget set -period weekly -anchor 05/01/2026 Eating-out 60",
+							"atad budget set -period monthly -rollover capped -cap 200 Groceries 500",
+							"atad budget set -period monthly -alerts 50,80,100 Groceries 500",
+							"atad budget set -type income -period monthly Freelance 2000",
+						},
+						Args: []Completer{completeCategories},
+						FlagValues: map[string]Completer{
+							"period":   completeWords(models.BudgetPeriods...),
+							"rollover": completeWords(models.Rollovers...),
+							"type":     completeWords(models.BudgetExpense, models.BudgetIncome),
+							"alerts":   completeWords(models.ThresholdsNone, "default"),
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleSet)
+						},
+					},
+					{
+						Name:    "edit",
+						Usage:   "<id> [-category <category>] [-type <expense|income>] [-amount <amount>] [-period <period>] [-start <date>] [-end <date>] [-anchor <date>] [-rollover <rollover>] [-cap <amount>] [-alerts <percents>]",
+						Summary: "Change a budget by id (see 'atad budget list')",
+						Examples: []string{
+							"atad budget edit 3 -amount 450",
+							"atad budget edit 5 -start 01/12/2025 -end 24/12/2025",
+							"atad budget edit 3 -alerts none",
+						},
+						Args: []Completer{completeBudgetIDs},
+						FlagValues: map[string]Completer{
+							"category": completeCategories,
+							"period":   completeWords(models.BudgetPeriods...),
+							"rollover": completeWords(models.Rollovers...),
+							"type":     completeWords(models.BudgetExpense, models.BudgetIncome),
+							"alerts":   completeWords(models.ThresholdsNone, "default"),
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleEdit)
+						},
+					},
+					{
+						Name:     "delete",
+						Usage:    "<id>",
+						Summary:  "Delete a budget by id",
+						Examples: []string{"atad budget delete 3"},
+						Args:     []Completer{completeBudgetIDs},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleDelete)
+						},
+					},
+					{
+						Name:     "check",
+						Usage:    "<category> [-type <expense|income>]",
+						Summary:  "Check budget status (exits 6 when over budget)",
+						Examples: []string{"atad budget check Groceries"},
+						Args:     []Completer{completeBudgetCategories},
+						FlagValues: map[string]Completer{
+							"type": completeWords(models.BudgetExpense, models.BudgetIncome),
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleCheck)
+						},
+					},
+					{
+						Name:     "status",
+						Summary:  "Check every active budget and income target at once (exits 6 when any budget is over)",
+						Examples: []string{"atad budget status", "atad budget status --output json || notify-send 'Over budget'"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleStatus)
+						},
+					},
+					{
+						Name:     "summary",
+						Usage:    "[-month <MM/YYYY>]",
+						Summary:  "Compare planned and actual income and spending for a month",
+						Examples: []string{"atad budget summary", "atad budget summary -month 09/2026 --output csv"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleSummary)
+						},
+					},
+					{
+						Name:     "history",
+						Usage:    "<category> [-n <periods>] [-type <expense|income>]",
+						Summary:  "Show spending per period of a recurring budget",
+						Examples: []string{"atad budget history Groceries -n 12"},
+						Args:     []Completer{completeBudgetCategories},
+						FlagValues: map[string]Completer{
+							"type": completeWords(models.BudgetExpense, models.BudgetIncome),
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BudgetCommand{Handler: h}).handleHistory)
+						},
+					},
+					{
+						Name:    "template",
+						Summary: "Save budget sets as templates and apply them to new periods",
+						Subcommands: []*Command{
+							{
+								Name:     "save",
+								Usage:    "<name> [-date <date>] [-force]",
+								Summary:  "Save the budgets and income targets active on a date as a template",
+								Examples: []string{"atad budget template save everyday", "atad budget template save q3 -date 15/09/2026 -force"},
+								New: func(h *CLIHandler) CommandHandler {
+									return CommandFunc((&BudgetCommand{Handler: h}).handleTemplateSave)
+								},
+							},
+							{
+								Name:    "apply",
+								Usage:   "<name> <start_date> <end_date> [-scale <percent>] [-from-actual] [-skip-conflicts] [-dry-run]",
+								Summary: "Create a budget per template entry for a date range",
+								Examples: []string{
+									"atad budget template apply everyday 01/01/2027 31/03/2027 -dry-run",
+									"atad budget template apply everyday 01/01/2027 31/03/2027 -scale 5",
+									"atad budget template apply everyday 01/11/2026 30/11/2026 -from-actual -skip-conflicts",
+								},
+								Args: []Completer{completeTemplateNames},
+								New: func(h *CLIHandler) CommandHandler {
+									return CommandFunc((&BudgetCommand{Handler: h}).handleTemplateApply)
+								},
+							},
+							{
+								Name:    "list",
+								Summary: "List budget templates",
+								New: func(h *CLIHandler) CommandHandler {
+									return CommandFunc((&BudgetCommand{Handler: h}).handleTemplateList)
+								},
+							},
+							{
+								Name:     "delete",
+								Usage:    "<name>",
+								Summary:  "Delete a template; budgets created from it are kept",
+								Examples: []string{"atad budget template delete q3"},
+								Args:     []Completer{completeTemplateNames},
+								New: func(h *CLIHandler) CommandHandler {
+									return CommandFunc((&BudgetCommand{Handler: h}).handleTemplateDelete)
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "alerts",
+				Summary: "Review budget alerts and test where they are sent",
+				Subcommands: []*Command{
+					{
+						Name:     "list",
+						Usage:    "[-n <count>]",
+						Summary:  "Show the latest alerts",
+						Examples: []string{"atad alerts list", "atad alerts list -n 50 --output json"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&AlertsCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:    "test",
+						Summary: "Send a test alert to the terminal, command and webhook",
+						Examples: []string{
+							"atad alerts test",
+							"atad config set alerts.webhook https://ntfy.sh/my-budget && atad alerts test",
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&AlertsCommand{Handler: h}).handleTest)
+						},
+					},
+				},
+			},
+			{
+				Name:    "goal",
+				Summary: "Save towards goals with target amounts and dates",
+				Subcommands: []*Command{
+					{
+						Name:    "add",
+						Usage:   "<name> <target> [-by <date>] [-account <account> | -category <category>] [-start <date>] [-saved <amount>]",
+						Summary: "Add a savings goal, optionally linked to an account or category",
+						Examples: []string{
+							"atad goal add Car 8000 -by 01/06/2027",
+							"atad goal add Holiday 2500 -by 01/07/2027 -category \"Holiday fund\"",
+							"atad goal add Emergency 10000 -account Savings -saved 1500",
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&GoalCommand{Handler: h}).handleAdd)
+						},
+					},
+					{
+						Name:    "list",
+						Summary: "List goals with progress, required monthly saving and status",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&GoalCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:     "contribute",
+						Usage:    "<name> <amount> [-date <date>] [-note <text>] [-withdraw]",
+						Summary:  "Record money put towards a goal, or taken out with -withdraw",
+						Examples: []string{"atad goal contribute Car 300", "atad goal contribute Car 120 -withdraw -note \"new tyres\""},
+						Args:     []Completer{completeGoalNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&GoalCommand{Handler: h}).handleContribute)
+						},
+					},
+					{
+						Name:     "status",
+						Usage:    "[name]",
+						Summary:  "Show the progress of every goal, or of one with its contributions",
+						Examples: []string{"atad goal status", "atad goal status Car"},
+						Args:     []Completer{completeGoalNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&GoalCommand{Handler: h}).handleStatus)
+						},
+					},
+					{
+						Name:     "delete",
+						Usage:    "<name>",
+						Summary:  "Delete a goal and its contributions; linked transactions are kept",
+						Examples: []string{"atad goal delete Holiday"},
+						Args:     []Completer{completeGoalNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&GoalCommand{Handler: h}).handleDelete)
+						},
+					},
+				},
+			},
+			{
+				Name:    "loan",
+				Summary: "Track loans and credit card debt with amortization and payoff plans",
+				Subcommands: []*Command{
+					{
+						Name:    "add",
+						Usage:   "<name> <principal> -rate <percent> (-term <months> | -payment <amount>) [-category <category>] [-start <date>]",
+						Summary: "Add a loan; expenses in its category (default: its name) are its payments",
+						Examples: []string{
+							"atad loan add Mortgage 250000 -rate 4.2 -term 360",
+							"atad loan add \"Car loan\" 15000 -rate 6.5 -term 60",
+							"atad loan add Visa 3200 -rate 21.9 -payment 120 -category \"Credit card\"",
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleAdd)
+						},
+					},
+					{
+						Name:    "list",
+						Summary: "List loans with what is owed and the projected payoff date",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:     "pay",
+						Usage:    "<name> [amount] [-date <date>] [-account <account>] [-desc <text>]",
+						Summary:  "Record a payment (default: the monthly payment) and split it into interest and principal",
+						Examples: []string{"atad loan pay Mortgage", "atad loan pay Visa 500 -account Checking"},
+						Args:     []Completer{completeLoanNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handlePay)
+						},
+					},
+					{
+						Name:     "status",
+						Usage:    "<name>",
+						Summary:  "Show what is owed on a loan and how each payment was split",
+						Examples: []string{"atad loan status Mortgage"},
+						Args:     []Completer{completeLoanNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleStatus)
+						},
+					},
+					{
+						Name:     "schedule",
+						Usage:    "<name> [-extra <amount>]",
+						Summary:  "Show the amortization schedule, or what paying more each month changes",
+						Examples: []string{"atad loan schedule \"Car loan\"", "atad loan schedule Mortgage -extra 100"},
+						Args:     []Completer{completeLoanNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleSchedule)
+						},
+					},
+					{
+						Name:     "compare",
+						Usage:    "[-extra <amount>]",
+						Summary:  "Compare paying off all debts with the avalanche and snowball strategies",
+						Examples: []string{"atad loan compare", "atad loan compare -extra 250"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleCompare)
+						},
+					},
+					{
+						Name:     "delete",
+						Usage:    "<name>",
+						Summary:  "Delete a loan; its payment transactions are kept",
+						Examples: []string{"atad loan delete Visa"},
+						Args:     []Completer{completeLoanNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&LoanCommand{Handler: h}).handleDelete)
+						},
+					},
+				},
+			},
+			{
+				Name:    "bills",
+				Summary: "Track bills, their due dates and whether they are paid",
+				Subcommands: []*Command{
+					{
+						Name:    "add",
+						Usage:   "<name> <amount> -due <date> [-every <weekly|monthly|quarterly|yearly>] [-match <text>] [-tolerance <percent>]",
+						Summary: "Add a bill; expenses matching its text and amount mark its due dates paid",
+						Examples: []string{
+							"atad bills add Rent 1200 -due 01/11/2026",
+							"atad bills add Electricity 85 -due 15/11/2026 -match \"power co\" -tolerance 30",
+							"atad bills add Insurance 540 -due 01/03/2027 -every yearly",
+						},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleAdd)
+						},
+					},
+					{
+						Name:    "list",
+						Summary: "List bills with their next due date, last payment and overdue count",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:     "upcoming",
+						Usage:    "[-days <n>]",
+						Summary:  "Show the bills due soon and whether they are already paid",
+						Examples: []string{"atad bills upcoming", "atad bills upcoming -days 7"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleUpcoming)
+						},
+					},
+					{
+						Name:    "overdue",
+						Summary: "Show the due dates that passed without a payment",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleOverdue)
+						},
+					},
+					{
+						Name:     "paid",
+						Usage:    "<name> [-due <date>] [-note <text>]",
+						Summary:  "Mark a due date paid when no matching expense was recorded",
+						Examples: []string{"atad bills paid Rent", "atad bills paid Insurance -due 01/03/2027 -note \"paid in cash\""},
+						Args:     []Completer{completeBillNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handlePaid)
+						},
+					},
+					{
+						Name:     "delete",
+						Usage:    "<name>",
+						Summary:  "Delete a bill; the transactions that paid it are kept",
+						Examples: []string{"atad bills delete Rent"},
+						Args:     []Completer{completeBillNames},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleDelete)
+						},
+					},
+					{
+						Name:    "export",
+						Usage:   "[-format ics] [-o <file>] [-months <n>] [-remind <days>]",
+						Summary: "Export the due dates as an iCalendar feed for calendar apps",
+						Examples: []string{
+							"atad bills export -format ics -o ~/Calendars/bills.ics",
+							"atad bills export -months 3 -remind 0",
+						},
+						FlagValues: map[string]Completer{"format": completeWords("ics"), "o": completeFiles},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&BillsCommand{Handler: h}).handleExport)
+						},
+					},
+				},
+			},
+			{
+				Name:    "envelope",
+				Summary: "Give every unit of income a job (zero-based budgeting)",
+				Subcommands: []*Command{
+					{
+						Name:    "status",
+						Summary: "Show ready-to-assign money and what each envelope has available",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&EnvelopeCommand{Handler: h}).handleStatus)
+						},
+					},
+					{
+						Name:     "assign",
+						Usage:    "<category> <amount> [-date <date>] [-note <text>]",
+						Summary:  "Assign ready-to-assign money to an envelope",
+						Examples: []string{"atad envelope assign Groceries 400", "atad envelope assign Rent 1200 -note \"March rent\""},
+						Args:     []Completer{completeCategories},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&EnvelopeCommand{Handler: h}).handleAssign)
+						},
+					},
+					{
+						Name:     "move",
+						Usage:    "<from> <to> <amount> [-date <date>] [-note <text>] [-force]",
+						Summary:  "Move money between envelopes",
+						Examples: []string{"atad envelope move Eating-out Groceries 50"},
+						Args:     []Completer{completeCategories, completeCategories},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&EnvelopeCommand{Handler: h}).handleMove)
+						},
+					},
+					{
+						Name:     "start",
+						Usage:    "<date>",
+						Summary:  "Set the date envelopes count income and spending from",
+						Examples: []string{"atad envelope start 01/01/2026"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&EnvelopeCommand{Handler: h}).handleStart)
+						},
+					},
+				},
+			},
+			{
+				Name:     "search",
+				Usage:    "<query> [-type <all|income|expense>] [-category <category>] [-account <account>] [-from <date>] [-to <date>]",
+				Summary:  "Search transactions",
+				Examples: []string{`atad search "coffee"`},
+				New:      func(h *CLIHandler) CommandHandler { return &SearchCommand{Handler: h} },
+			},
+			{
+				Name:     "import",
+				Usage:    "<file> [-import-profile <name>] [-auto-categorize] [-skip-duplicates] [-account <name>] [-mapping <file>]",
+				Summary:  "Import transactions from CSV files or Beancount/Ledger journals",
+				Examples: []string{"atad import statement.csv -auto-categorize", "atad import statement.csv -import-profile bank", "atad import books.beancount -mapping accounts.map"},
+				Args:     []Completer{completeFiles},
+				New:      func(h *CLIHandler) CommandHandler { return &ImportCommand{Handler: h} },
+			},
+			{
+				Name:     "export",
+				Usage:    "[-format <csv|json|ndjson|beancount|ledger>] [filters] [-o <file>] [-encrypt]",
+				Summary:  "Export transactions to CSV, JSON, NDJSON, Beancount or Ledger",
+				Examples: []string{"atad export -format json -o tx.json", "atad export -format beancount -mapping accounts.map"},
+				New:      func(h *CLIHandler) CommandHandler { return &ExportCommand{Handler: h} },
+			},
+			{
+				Name:       "plan",
+				Usage:      "-f <file>",
+				Summary:    "Show how the budgets and rules differ from a finances file",
+				Examples:   []string{"atad plan -f finances.yaml"},
+				FlagValues: map[string]Completer{"f": completeFiles},
+				New:        func(h *CLIHandler) CommandHandler { return &PlanCommand{Handler: h} },
+			},
+			{
+				Name:       "apply",
+				Usage:      "-f <file> [-yes]",
+				Summary:    "Create, update and delete budgets and rules to match a finances file",
+				Examples:   []string{"atad apply -f finances.yaml", "atad dump | atad apply -f - -yes"},
+				FlagValues: map[string]Completer{"f": completeFiles},
+				New:        func(h *CLIHandler) CommandHandler { return &ApplyCommand{Handler: h} },
+			},
+			{
+				Name:     "dump",
+				Usage:    "[-o <file>]",
+				Summary:  "Write the budgets and rules as a finances file",
+				Examples: []string{"atad dump -o finances.yaml"},
+				New:      func(h *CLIHandler) CommandHandler { return &DumpCommand{Handler: h} },
+			},
+			{
+				Name:    "backup",
+				Usage:   "[-o <file>] [-compress] [-encrypt] [-list]",
+				Summary: "Back up the database or list its snapshots",
+				Examples: []string{
+					"atad backup                        # Snapshot into ~/.atad/backups",
+					"atad backup -o ~/atad-2026.db.gz   # Compressed copy",
+					"atad backup -o ~/atad.enc -encrypt # Passphrase-protected copy",
+					"atad backup -list",
+				},
+				New: func(h *CLIHandler) CommandHandler { return &BackupCommand{Handler: h} },
+			},
+			{
+				Name:     "restore",
+				Usage:    "<file> [-yes]",
+				Summary:  "Replace the database with a backup",
+				Examples: []string{"atad restore ~/.atad/backups/default-20260101-120000-manual.db.gz"},
+				Args:     []Completer{completeFiles},
+				New:      func(h *CLIHandler) CommandHandler { return &RestoreCommand{Handler: h} },
+			},
+			{
+				Name:    "vault",
+				Summary: "Encrypt the database at rest",
+				Subcommands: []*Command{
+					{
+						Name:    "status",
+						Summary: "Show whether the database is encrypted",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&VaultCommand{Handler: h}).handleStatus)
+						},
+					},
+					{
+						Name:     "enable",
+						Summary:  "Encrypt the database with a passphrase",
+						Examples: []string{"atad vault enable", "ATAD_PASSPHRASE=... atad --profile business vault enable"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&VaultCommand{Handler: h}).handleEnable)
+						},
+					},
+					{
+						Name:    "disable",
+						Summary: "Decrypt the database back to a plain SQLite file",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&VaultCommand{Handler: h}).handleDisable)
+						},
+					},
+					{
+						Name:    "passwd",
+						Summary: "Change the passphrase of the database",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&VaultCommand{Handler: h}).handlePasswd)
+						},
+					},
+					{
+						Name:     "decrypt",
+						Usage:    "<file> [-o <file>]",
+						Summary:  "Decrypt an encrypted backup or export",
+						Examples: []string{"atad vault decrypt tx.json.enc -o tx.json"},
+						Args:     []Completer{completeFiles},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&VaultCommand{Handler: h}).handleDecrypt)
+						},
+					},
+				},
+			},
+			{
+				Name:    "reconcile",
+				Usage:   "-account <name> -statement-end <date> -balance <amount> [-opening <amount>] [-clear <ids>] [-unclear <ids>] [-i] [-finish]",
+				Summary: "Reconcile an account against a bank statement",
+				Examples: []string{
+					"atad reconcile -account Checking -statement-end 31/12/2025 -balance 2450.75 -i",
+					"atad reconcile -account Checking -statement-end 31/01/2026 -balance 2610.20 -opening 1830.00",
+				},
+				New: func(h *CLIHandler) CommandHandler { return &ReconcileCommand{Handler: h} },
+			},
+			{
+				Name:    "config",
+				Summary: "Show and change settings",
+				Subcommands: []*Command{
+					{
+						Name:    "list",
+						Summary: "List all settings",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ConfigCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:     "get",
+						Usage:    "<key>",
+						Summary:  "Print a setting",
+						Examples: []string{"atad config get display.currency"},
+						Args:     []Completer{completeConfigKeys},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ConfigCommand{Handler: h}).handleGet)
+						},
+					},
+					{
+						Name:    "set",
+						Usage:   "<key> <value>",
+						Summary: "Change a setting",
+						Examples: []string{
+							"atad config set display.symbol €",
+							"atad config set display.symbol_position after",
+							"atad config set dates.input_format YYYY-MM-DD",
+							"atad config set alerts.thresholds 90,100",
+							"atad config set import.profiles.bank.account Checking",
+						},
+						Args: []Completer{completeConfigKeys},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ConfigCommand{Handler: h}).handleSet)
+						},
+					},
+					{
+						Name:    "path",
+						Summary: "Print the config file location",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ConfigCommand{Handler: h}).handlePath)
+						},
+					},
+				},
+			},
+			{
+				Name:    "profile",
+				Summary: "Manage separate ledgers (profiles)",
+				Subcommands: []*Command{
+					{
+						Name:    "list",
+						Summary: "List profiles",
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ProfileCommand{Handler: h}).handleList)
+						},
+					},
+					{
+						Name:     "create",
+						Usage:    "<name>",
+						Summary:  "Create a profile with an empty database",
+						Examples: []string{"atad profile create business"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ProfileCommand{Handler: h}).handleCreate)
+						},
+					},
+					{
+						Name:     "use",
+						Usage:    "<name>",
+						Summary:  "Make a profile the active one",
+						Examples: []string{"atad profile use business", "atad profile use default"},
+						Args:     []Completer{completeProfiles},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ProfileCommand{Handler: h}).handleUse)
+						},
+					},
+					{
+						Name:     "delete",
+						Usage:    "<name> [-force]",
+						Summary:  "Delete a profile and its database",
+						Examples: []string{"atad profile delete old-household -force"},
+						Args:     []Completer{completeProfiles},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ProfileCommand{Handler: h}).handleDelete)
+						},
+					},
+					{
+						Name:     "report",
+						Usage:    "[-period <all|month|year>]",
+						Summary:  "Show income and expenses across all profiles (read-only)",
+						Examples: []string{"atad profile report -period year"},
+						New: func(h *CLIHandler) CommandHandler {
+							return CommandFunc((&ProfileCommand{Handler: h}).handleReport)
+						},
+					},
+				},
+			},
+			{
+				Name:    "completion",
+				Usage:   "<bash|zsh|fish>",
+				Summary: "Generate a shell completion script",
+				Examples: []string{
+					"source <(atad completion bash)    # add to ~/.bashrc",
+					"source <(atad completion zsh)     # add to ~/.zshrc",
+					"atad completion fish > ~/.config/fish/completions/atad.fish",
+				},
+				Args: []Completer{completeWords("bash", "zsh", "fish")},
+				New:  func(h *CLIHandler) CommandHandler { return &CompletionCommand{Handler: h} },
+			},
+			{
+				Name:   "__complete",
+				Usage:  "<words...>",
+				Hidden: true,
+				New:    func(h *CLIHandler) CommandHandler { return &CompleteCommand{Handler: h} },
+			},
+			{
+				Name:    "version",
+				Summary: "Show version information",
+				Hidden:  true,
+				New: func(h *CLIHandler) CommandHandler {
+					return CommandFunc(func(args []string) error {
+						if _, err := h.parseArgs(h.newFlagSet(), args); err != nil {
+							return err
+						}
+						fmt.Fprintf(h.Stdout, "ATAD Personal Finance Tracker v%s\n", Version)
+						return nil
+					})
+				},
+			},
+			{
+				Name:    "help",
+				Usage:   "[command]",
+				Summary: "Show help for a command",
+				Hidden:  true,
+				Args:    []Completer{completeCommands},
+				New: func(h *CLIHandler) CommandHandler {
+					return CommandFunc(func(args []string) error {
+						cmd := h.root
+						for _, name := range args {
+							sub := cmd.Find(name)
+							if sub == nil {
+								return usageErrorf("unknown command: %s %s", cmd.Path(), name)
+							}
+							cmd = sub
+						}
+						h.printHelp(cmd)
+						return nil
+					})
+				},
+			},
+		},
+	}
+	setParents(root)
+	return root
+}
+
+func setParents(cmd *Command) {
+	for _, sub := range cmd.Subcommands {
+		sub.parent = cmd
+		setParents(sub)
+	}
+}
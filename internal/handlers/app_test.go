@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testApp runs command lines in-process against a database and config file
+// in a temporary directory
+type testApp struct {
+	t      *testing.T
+	dir    string
+	db     string
+	config string
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("DB_PATH", "")
+	t.Setenv("ATAD_CONFIG", "")
+	return &testApp{
+		t:      t,
+		dir:    dir,
+		db:     filepath.Join(dir, "atad.db"),
+		config: filepath.Join(dir, "config.toml"),
+	}
+}
+
+// run executes a command line with the test database and config and returns
+// the exit code and what was written to stdout and stderr
+func (a *testApp) run(args ...string) (int, string, string) {
+	a.t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"--db", a.db, "--config", a.config}, args...)
+	code := NewApp(strings.NewReader(""), &stdout, &stderr).Run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+// mustRun runs a command line that is expected to succeed
+func (a *testApp) mustRun(args ...string) string {
+	a.t.Helper()
+	code, stdout, stderr := a.run(args...)
+	if code != ExitOK {
+		a.t.Fatalf("%v: exit code %d, stderr %q", args, code, stderr)
+	}
+	return stdout
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup [][]string
+		args  []string
+		code  int
+	}{
+		{
+			name: "unknown command",
+			args: []string{"nosuch"},
+			code: ExitUsage,
+		},
+		{
+			name: "missing required flags",
+			args: []string{"add", "-type", "expense"},
+			code: ExitUsage,
+		},
+		{
+			name: "bad flag value",
+			args: []string{"add", "-type", "expense", "-desc", "lunch", "-amount", "abc"},
+			code: ExitUsage,
+		},
+		{
+			name: "missing budget",
+			args: []string{"budget", "delete", "999"},
+			code: ExitNotFound,
+		},
+		{
+			name: "bad date",
+			args: []string{"add", "-type", "expense", "-desc", "lunch", "-amount", "5", "-date", "99/99/2026"},
+			code: ExitValidation,
+		},
+		{
+			name: "over budget",
+			setup: [][]string{
+				{"budget", "set", "-period", "monthly", "Food", "10"},
+				{"add", "-type", "expense", "-desc", "lunch", "-amount", "25", "-category", "Food"},
+			},
+			args: []string{"budget", "status"},
+			code: ExitBudgetExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			for _, args := range tt.setup {
+				app.mustRun(args...)
+			}
+
+			code, stdout, stderr := app.run(tt.args...)
+			if code != tt.code {
+				t.Fatalf("exit code = %d, want %d (stderr %q)", code, tt.code, stderr)
+			}
+			if !strings.HasPrefix(stderr, "Error: ") {
+				t.Errorf("stderr = %q, want an error message", stderr)
+			}
+			if tt.code != ExitBudgetExceeded && stdout != "" {
+				t.Errorf("stdout = %q, want nothing", stdout)
+			}
+		})
+	}
+}
+
+func TestRunDatabaseError(t *testing.T) {
+	app := newTestApp(t)
+	if err := os.WriteFile(app.db, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := app.run("list")
+	if code != ExitDatabase {
+		t.Fatalf("exit code = %d, want %d (stderr %q)", code, ExitDatabase, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+	if !strings.Contains(stderr, "database") {
+		t.Errorf("stderr = %q, want a database error", stderr)
+	}
+}
+
+func TestRunJSONError(t *testing.T) {
+	app := newTestApp(t)
+
+	code, stdout, stderr := app.run("--output", "json", "bad\x01cmd")
+	if code != ExitUsage {
+		t.Fatalf("exit code = %d, want %d", code, ExitUsage)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+
+	var got struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(stderr), &got); err != nil {
+		t.Fatalf("stderr %q is not JSON: %v", stderr, err)
+	}
+	if got.Code != ExitUsage || !strings.Contains(got.Error, "bad\x01cmd") {
+		t.Errorf("error = %+v", got)
+	}
+}
+
+func TestRunGlobalFlagPosition(t *testing.T) {
+	app := newTestApp(t)
+	app.mustRun("add", "-type", "expense", "-desc", "lunch", "-amount", "12.5", "-category", "Food")
+
+	for _, args := range [][]string{
+		{"--output", "json", "list"},
+		{"list", "--output", "json"},
+		{"list", "--output=json"},
+	} {
+		stdout := app.mustRun(args...)
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(stdout), &records); err != nil {
+			t.Fatalf("%v: stdout %q is not JSON: %v", args, stdout, err)
+		}
+		if len(records) != 1 || records[0]["description"] != "lunch" {
+			t.Errorf("%v: records = %v", args, records)
+		}
+	}
+
+	// A global flag after the subcommand is not mistaken for one of its flags
+	if code, _, stderr := app.run("list", "--output", "yaml"); code != ExitUsage {
+		t.Errorf("bad --output after the command: exit code = %d, want %d (stderr %q)", code, ExitUsage, stderr)
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		rest   []string
+		output string
+		db     string
+	}{
+		{
+			name:   "before the command",
+			args:   []string{"--output", "json", "list", "-category", "Food"},
+			rest:   []string{"list", "-category", "Food"},
+			output: OutputJSON,
+		},
+		{
+			name:   "between command names",
+			args:   []string{"budget", "--output=csv", "summary"},
+			rest:   []string{"budget", "summary"},
+			output: OutputCSV,
+		},
+		{
+			name:   "ending the command line",
+			args:   []string{"list", "-category", "Food", "--output", "json", "--quiet"},
+			rest:   []string{"list", "-category", "Food"},
+			output: OutputJSON,
+		},
+		{
+			name:   "value of a command flag",
+			args:   []string{"add", "-desc", "--db", "-amount", "5"},
+			rest:   []string{"add", "-desc", "--db", "-amount", "5"},
+			output: OutputTable,
+		},
+		{
+			name:   "after --",
+			args:   []string{"list", "--", "--output", "json"},
+			rest:   []string{"list", "--", "--output", "json"},
+			output: OutputTable,
+		},
+		{
+			name:   "before -- and after the command arguments",
+			args:   []string{"list", "-n", "5", "--db", "x.db", "--", "--db", "y.db"},
+			rest:   []string{"list", "-n", "5", "--", "--db", "y.db"},
+			output: OutputTable,
+			db:     "x.db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCLIHandler(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+			h.root = newCommandTree()
+			rest, err := h.parseGlobalFlags(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(rest, " ") != strings.Join(tt.rest, " ") {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+			if h.Output != tt.output || h.DBPath != tt.db {
+				t.Errorf("output, db = %q, %q; want %q, %q", h.Output, h.DBPath, tt.output, tt.db)
+			}
+		})
+	}
+}
+
+func TestRunQuietKeepsErrorsOnStderr(t *testing.T) {
+	app := newTestApp(t)
+
+	code, stdout, stderr := app.run("--quiet", "budget", "delete", "999")
+	if code != ExitNotFound {
+		t.Fatalf("exit code = %d, want %d", code, ExitNotFound)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+	if !strings.Contains(stderr, "not found") {
+		t.Errorf("stderr = %q, want the error", stderr)
+	}
+}
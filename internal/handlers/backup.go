@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// BackupCommand handles the 'backup' subcommand
+type BackupCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *BackupCommand) Run(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	output := fs.String("o", "", "Backup file (default: a snapshot in the backup directory)")
+	compress := fs.Bool("compress", false, "Gzip the backup (implied by a .gz file name and for snapshots)")
+	list := fs.Bool("list", false, "List the snapshots of the active profile")
+	encrypt := fs.Bool("encrypt", false, "Encrypt the backup with a passphrase (backups of an encrypted database always are)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if *list {
+		return c.listSnapshots()
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	count, err := h.txRepo.Count()
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	opts := database.BackupOptions{Compress: *compress || strings.HasSuffix(*output, ".gz")}
+	if *encrypt {
+		if opts.Passphrase, err = h.readPassphrase(PassphraseEnv, "Backup passphrase: ", true); err != nil {
+			return err
+		}
+	}
+	encrypted := *encrypt || h.db.Encrypted()
+
+	path := *output
+	if path == "" {
+		path, err = database.TakeSnapshot(h.db, h.backupDir(), h.snapshotProfile(), "manual", h.Config.BackupKeep, opts.Passphrase)
+	} else {
+		err = h.db.Backup(path, opts)
+	}
+	if err != nil {
+		return dbErrorf("backup failed: %w", err)
+	}
+
+	size := int64(0)
+	if fi, err := os.Stat(path); err == nil {
+		size = fi.Size()
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"path", path}, {"transactions", count}, {"size", size}, {"encrypted", encrypted}})
+	}
+	lock := ""
+	if encrypted {
+		lock = ", encrypted"
+	}
+	h.printf("💾 Backup written to %s (%d transactions, %s%s)\n", path, count, formatSize(size), lock)
+	return nil
+}
+
+func (c *BackupCommand) listSnapshots() error {
+	h := c.Handler
+	snapshots, err := database.ListSnapshots(h.backupDir(), h.snapshotProfile())
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(snapshots))
+		for _, s := range snapshots {
+			records = append(records, Record{{"taken", s.Taken}, {"reason", s.Reason}, {"size", s.Size}, {"path", s.Path}})
+		}
+		return h.WriteRecords([]string{"taken", "reason", "size", "path"}, records)
+	}
+
+	if len(snapshots) == 0 {
+		h.printf("No snapshots in %s\n", h.backupDir())
+		return nil
+	}
+	h.printf("\n💾 Snapshots of '%s' (%s)\n", h.snapshotProfile(), h.backupDir())
+	h.println("─────────────────────────────────────────────────────────────")
+	for _, s := range snapshots {
+		h.printf("%-20s %-8s %9s  %s\n", h.Config.FormatDate(s.Taken)+s.Taken.Format(" 15:04"), s.Reason, formatSize(s.Size), s.Path)
+	}
+	return nil
+}
+
+// RestoreCommand handles the 'restore' subcommand
+type RestoreCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *RestoreCommand) Run(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("restore needs exactly one backup <file>")
+	}
+	file := positional[0]
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return notFoundErrorf("file '%s' not found", file)
+	}
+	var opts database.RestoreOptions
+	if database.IsEncryptedBackup(file) {
+		if opts.Passphrase, err = h.readPassphrase(PassphraseEnv, "Backup passphrase: ", false); err != nil {
+			return err
+		}
+	}
+	info, err := database.InspectBackup(file, opts.Passphrase)
+	if err != nil {
+		return validationErrorf("%v", err)
+	}
+	if info.SchemaVersion > database.SchemaVersion {
+		return validationErrorf("backup has schema version %d but this version of atad supports up to %d; upgrade atad first",
+			info.SchemaVersion, database.SchemaVersion)
+	}
+
+	dbPath, err := h.DatabasePath()
+	if err != nil {
+		return err
+	}
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	current, err := h.txRepo.Count()
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	if !*yes {
+		fmt.Fprintf(h.Stderr, "This replaces %s (%d transactions) with %s (%d transactions).\n", dbPath, current, file, info.Transactions)
+		fmt.Fprint(h.Stderr, "Continue? [y/N] ")
+		answer, _ := bufio.NewReader(h.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Fprintln(h.Stderr, "Restore cancelled")
+			return nil
+		}
+	}
+
+	if err := h.snapshot("restore"); err != nil {
+		return err
+	}
+	// An encrypted database stays encrypted with its passphrase
+	if h.db.Encrypted() {
+		opts.VaultPassphrase = h.passphrase
+	}
+	if err := h.Close(); err != nil {
+		return err
+	}
+
+	if err := database.Restore(file, dbPath, opts); err != nil {
+		return dbErrorf("restore failed: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"path", dbPath}, {"source", file}, {"transactions", info.Transactions}})
+	}
+	h.printf("✅ Restored %s from %s (%d transactions)\n", dbPath, file, info.Transactions)
+	return nil
+}
+
+// snapshot takes an automatic backup before a destructive operation, unless
+// backup.auto_snapshot is off or the database is empty
+func (h *CLIHandler) snapshot(reason string) error {
+	if !h.Config.AutoSnapshot {
+		return nil
+	}
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	return h.snapshotDatabase(h.db, h.snapshotProfile(), reason)
+}
+
+// snapshotProfileDatabase takes the automatic backup of a profile other than
+// the open one, such as a profile about to be deleted
+func (h *CLIHandler) snapshotProfileDatabase(profile, reason string) error {
+	if !h.Config.AutoSnapshot {
+		return nil
+	}
+	db, err := database.NewDatabase(profile)
+	if err != nil {
+		return dbErrorf("failed to open profile '%s': %w", profile, err)
+	}
+	err = h.snapshotDatabase(db, profile, reason)
+	if closeErr := db.Close(); err == nil && closeErr != nil {
+		err = dbErrorf("failed to close profile '%s': %w", profile, closeErr)
+	}
+	return err
+}
+
+// snapshotDatabase writes a rotating snapshot of db unless it has no transactions
+func (h *CLIHandler) snapshotDatabase(db *database.Database, profile, reason string) error {
+	count, err := repository.NewTransactionRepository(db.DB).Count()
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	path, err := database.TakeSnapshot(db, h.backupDir(), profile, reason, h.Config.BackupKeep, "")
+	if err != nil {
+		return dbErrorf("snapshot before %s failed: %w (set backup.auto_snapshot to false to skip)", reason, err)
+	}
+	h.infof("💾 Snapshot saved to %s\n", path)
+	return nil
+}
+
+// backupDir returns the directory holding snapshots
+func (h *CLIHandler) backupDir() string {
+	if h.Config.BackupDir != "" {
+		return h.Config.BackupDir
+	}
+	return database.BackupDir()
+}
+
+// snapshotProfile names the snapshots of the open database
+func (h *CLIHandler) snapshotProfile() string {
+	if name := h.ProfileName(); name != "" {
+		return name
+	}
+	return "custom"
+}
+
+// formatSize formats a file size in bytes for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreDeclinedIsNotAnError(t *testing.T) {
+	app := newTestApp(t)
+	backup := filepath.Join(app.dir, "backup.db")
+	app.mustRun("backup", "-o", backup)
+	app.mustRun("add", "-type", "expense", "-desc", "lunch", "-amount", "12.5", "-category", "Food")
+
+	// The test app answers every prompt with an empty line
+	code, _, stderr := app.run("restore", backup)
+	if code != ExitOK {
+		t.Fatalf("exit code = %d, want %d (stderr %q)", code, ExitOK, stderr)
+	}
+	if !strings.Contains(stderr, "Restore cancelled") {
+		t.Errorf("stderr = %q, want the cancellation", stderr)
+	}
+	if stdout := app.mustRun("list"); !strings.Contains(stdout, "lunch") {
+		t.Errorf("database was restored after declining: %q", stdout)
+	}
+}
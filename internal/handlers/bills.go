@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/ical"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// BillsCommand handles the 'bills' subcommands for bills and their due dates
+type BillsCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *BillsCommand) handleAdd(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	due := fs.String("due", "", "First due date (required)")
+	every := fs.String("every", models.PeriodMonthly, "How often the bill is due: weekly, monthly, quarterly or yearly")
+	match := fs.String("match", "", "Text in the description of the paying expense (default: the bill name)")
+	tolerance := fs.Float64("tolerance", 10, "Percent the paid amount may differ from the expected amount")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("bills add needs <name> <amount>")
+	}
+	if *due == "" {
+		return usageErrorf("bills add needs the first due date (-due <date>)")
+	}
+	bill := &models.Bill{Name: positional[0], Period: *every, Pattern: *match, Tolerance: *tolerance}
+	if bill.Amount, err = strconv.ParseFloat(positional[1], 64); err != nil || bill.Amount <= 0 {
+		return validationErrorf("invalid amount '%s': must be a positive number", positional[1])
+	}
+	if !models.IsValidBillPeriod(bill.Period) {
+		return validationErrorf("-every must be 'weekly', 'monthly', 'quarterly' or 'yearly'")
+	}
+	if bill.Tolerance < 0 || bill.Tolerance > 100 {
+		return validationErrorf("-tolerance must be a percentage between 0 and 100")
+	}
+	if bill.DueDate, err = h.Config.ParseDate(*due); err != nil {
+		return validationErrorf("invalid due date. Use %s format", h.Config.InputDateFormat)
+	}
+	if bill.Pattern == "" {
+		bill.Pattern = bill.Name
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	existing, err := h.billSvc.Get(bill.Name)
+	if err != nil {
+		return dbErrorf("failed to retrieve bill: %w", err)
+	}
+	if existing != nil {
+		return validationErrorf("bill '%s' already exists", bill.Name)
+	}
+	if err := h.billSvc.Add(bill); err != nil {
+		return dbErrorf("failed to add bill: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(billRecord(bill))
+	}
+	h.printf("✅ Bill '%s' added: %s %s from %s\n", bill.Name, h.money(bill.Amount), bill.Period, h.Config.FormatDate(bill.DueDate))
+	h.printf("   Paid by expenses matching \"%s\" within %s%% of the amount\n", bill.Pattern, strconv.FormatFloat(bill.Tolerance, 'f', -1, 64))
+	return nil
+}
+
+func (c *BillsCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	bills, err := h.billSvc.All()
+	if err != nil {
+		return dbErrorf("failed to load bills: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(bills))
+		for _, bill := range bills {
+			records = append(records, billRecord(bill))
+		}
+		return h.WriteRecords(billColumns, records)
+	}
+	if len(bills) == 0 {
+		h.println("No bills yet. Add one with 'atad bills add <name> <amount> -due <date>'.")
+		return nil
+	}
+
+	today := time.Now()
+	h.println("\n🧾 Bills")
+	h.println("─────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-20s %12s  %-10s %-12s %-10s %8s  %s\n", "Bill", "Amount", "Every", "Next due", "Last paid", "Overdue", "Matches")
+	h.println("─────────────────────────────────────────────────────────────────────────────────────────")
+	for _, bill := range bills {
+		// A year ahead covers the next due date of every schedule
+		dues, err := h.billSvc.Dues(bill, today.AddDate(1, 0, 0))
+		if err != nil {
+			return dbErrorf("failed to match bill payments: %w", err)
+		}
+		next, last, overdue := "-", "-", 0
+		for i := range dues {
+			due := &dues[i]
+			switch {
+			case due.DaysUntil(today) >= 0 && next == "-":
+				next = h.Config.FormatDate(due.Date)
+				if due.Paid() {
+					next += " ✓"
+				}
+			case due.Status(today) == service.BillOverdue:
+				overdue++
+			}
+			if due.Paid() && due.DaysUntil(today) <= 0 {
+				last = h.Config.FormatDate(c.paidDate(due))
+			}
+		}
+		h.printf("%-20s %12s  %-10s %-12s %-10s %8d  \"%s\" ±%s%%\n", TruncateString(bill.Name, 20), h.money(bill.Amount), bill.Period,
+			next, last, overdue, bill.Pattern, strconv.FormatFloat(bill.Tolerance, 'f', -1, 64))
+	}
+	return nil
+}
+
+func (c *BillsCommand) handleUpcoming(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	days := fs.Int("days", 30, "Show the bills due within this many days")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *days < 0 {
+		return validationErrorf("-days cannot be negative")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	today := time.Now()
+	schedule, err := h.billSvc.Schedule(today, today.AddDate(0, 0, *days))
+	if err != nil {
+		return dbErrorf("failed to load bills: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(billDueColumns, billDueRecords(schedule, today))
+	}
+	h.printf("\n📅 Bills due in the next %d days\n", *days)
+	if len(schedule) == 0 {
+		h.println("Nothing due.")
+		return nil
+	}
+	total := 0.0
+	for i := range schedule {
+		due := &schedule[i]
+		var state string
+		switch due.Status(today) {
+		case service.BillPaid:
+			state = "✅ " + c.paidLabel(due)
+		case service.BillDueToday:
+			state = "⏰ due today"
+			total += due.Bill.Amount
+		default:
+			state = fmt.Sprintf("in %d days", due.DaysUntil(today))
+			if due.DaysUntil(today) == 1 {
+				state = "tomorrow"
+			}
+			total += due.Bill.Amount
+		}
+		h.printf("%-10s  %-20s %12s  %s\n", h.Config.FormatDate(due.Date), TruncateString(due.Bill.Name, 20), h.money(due.Bill.Amount), state)
+	}
+	h.printf("\nStill to pay: %s\n", h.money(total))
+	return nil
+}
+
+func (c *BillsCommand) handleOverdue(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	today := time.Now()
+	overdue, err := h.billSvc.Overdue(today)
+	if err != nil {
+		return dbErrorf("failed to load bills: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(billDueColumns, billDueRecords(overdue, today))
+	}
+	if len(overdue) == 0 {
+		h.println("✅ No overdue bills.")
+		return nil
+	}
+	h.println("\n⚠️  Overdue bills")
+	total := 0.0
+	for i := range overdue {
+		due := &overdue[i]
+		total += due.Bill.Amount
+		h.printf("%-10s  %-20s %12s  %d days late\n", h.Config.FormatDate(due.Date), TruncateString(due.Bill.Name, 20),
+			h.money(due.Bill.Amount), -due.DaysUntil(today))
+	}
+	h.printf("\nOverdue: %s. Mark one paid with 'atad bills paid <name> -due <date>'.\n", h.money(total))
+	return nil
+}
+
+func (c *BillsCommand) handlePaid(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	dueFlag := fs.String("due", "", "Due date to mark paid (default: the oldest unpaid one)")
+	note := fs.String("note", "", "Note stored with the payment")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("bills paid needs a bill <name>")
+	}
+	var date time.Time
+	if *dueFlag != "" {
+		if date, err = h.Config.ParseDate(*dueFlag); err != nil {
+			return validationErrorf("invalid due date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	bill, err := c.getBill(positional[0])
+	if err != nil {
+		return err
+	}
+	today := time.Now()
+	through := today.AddDate(1, 0, 0)
+	if !date.IsZero() && date.After(through) {
+		through = date
+	}
+	dues, err := h.billSvc.Dues(bill, through)
+	if err != nil {
+		return dbErrorf("failed to match bill payments: %w", err)
+	}
+
+	var due *service.BillDue
+	for i := range dues {
+		if date.IsZero() && !dues[i].Paid() || !date.IsZero() && dues[i].DaysUntil(date) == 0 {
+			due = &dues[i]
+			break
+		}
+	}
+	switch {
+	case due == nil && date.IsZero():
+		return validationErrorf("bill '%s' has no unpaid due date in the next year", bill.Name)
+	case due == nil:
+		return validationErrorf("%s is not a due date of bill '%s'", h.Config.FormatDate(date), bill.Name)
+	case due.Transaction != nil:
+		return validationErrorf("%s of bill '%s' is already paid by '%s' on %s", h.Config.FormatDate(due.Date), bill.Name,
+			due.Transaction.Description, h.Config.FormatDate(due.Transaction.Date))
+	}
+	if err := h.billSvc.MarkPaid(bill, due.Date, *note); err != nil {
+		return dbErrorf("failed to mark bill paid: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"bill", bill.Name}, {"due_date", due.Date}, {"status", service.BillPaid}})
+	}
+	h.printf("✅ Marked %s of '%s' paid\n", h.Config.FormatDate(due.Date), bill.Name)
+	return nil
+}
+
+func (c *BillsCommand) handleDelete(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("bills delete needs a bill <name>")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	bill, err := c.getBill(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := h.billSvc.Delete(bill.Name); err != nil {
+		return dbErrorf("failed to delete bill: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"bill", bill.Name}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted bill '%s'; the transactions that paid it are kept\n", bill.Name)
+	return nil
+}
+
+func (c *BillsCommand) handleExport(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	format := fs.String("format", "ics", "Export format (ics)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	months := fs.Int("months", 12, "Include due dates this many months ahead")
+	remind := fs.Int("remind", 2, "Days before an unpaid due date to show a reminder (0 for none)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *format != "ics" {
+		return validationErrorf("unsupported format '%s': use 'ics'", *format)
+	}
+	if *months < 1 || *months > 60 {
+		return validationErrorf("-months must be between 1 and 60")
+	}
+	if *remind < 0 {
+		return validationErrorf("-remind cannot be negative")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	today := time.Now()
+	schedule, err := h.billSvc.Schedule(time.Time{}, today.AddDate(0, *months, 0))
+	if err != nil {
+		return dbErrorf("failed to load bills: %w", err)
+	}
+
+	// Recent due dates stay in the feed; older ones only while unpaid
+	recent := today.AddDate(0, -1, 0)
+	calendar := &ical.Calendar{Name: "atad bills"}
+	for i := range schedule {
+		due := &schedule[i]
+		if due.Paid() && due.Date.Before(recent) {
+			continue
+		}
+		calendar.Events = append(calendar.Events, c.billEvent(due, today, *remind))
+	}
+
+	out := h.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := calendar.Write(out, today); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+
+	if *output != "" {
+		h.infof("✅ Exported %d bill due dates to %s\n", len(calendar.Events), *output)
+	}
+	return nil
+}
+
+// billEvent describes a due date as a calendar event. Only unpaid due dates
+// get a reminder.
+func (c *BillsCommand) billEvent(due *service.BillDue, today time.Time, remind int) ical.Event {
+	h := c.Handler
+	bill := due.Bill
+	event := ical.Event{
+		UID:  fmt.Sprintf("bill-%d-%s@atad", bill.ID, due.Date.Format("20060102")),
+		Date: due.Date,
+	}
+	switch due.Status(today) {
+	case service.BillPaid:
+		event.Summary = fmt.Sprintf("✓ %s paid", bill.Name)
+		event.Description = fmt.Sprintf("%s of %s: %s.", h.money(bill.Amount), bill.Name, c.paidLabel(due))
+	case service.BillOverdue:
+		event.Summary = fmt.Sprintf("⚠ %s overdue (%s)", bill.Name, h.money(bill.Amount))
+		event.Description = fmt.Sprintf("%s of %s was due on %s and is not paid yet.", h.money(bill.Amount), bill.Name, h.Config.FormatDate(due.Date))
+	default:
+		event.Summary = fmt.Sprintf("%s due (%s)", bill.Name, h.money(bill.Amount))
+		event.Description = fmt.Sprintf("%s of %s is due. Expenses matching \"%s\" mark it paid.", h.money(bill.Amount), bill.Name, bill.Pattern)
+		event.Remind = remind
+	}
+	return event
+}
+
+// paidDate is when a due date was paid: the date of the matching expense, or
+// the due date itself when it was marked paid by hand
+func (c *BillsCommand) paidDate(due *service.BillDue) time.Time {
+	if due.Transaction != nil {
+		return due.Transaction.Date
+	}
+	return due.Date
+}
+
+// paidLabel describes what paid a due date, e.g. "paid 28/10/2026 ($1200.00)"
+func (c *BillsCommand) paidLabel(due *service.BillDue) string {
+	h := c.Handler
+	if due.Transaction == nil {
+		label := "marked paid"
+		if due.Manual.Note != "" {
+			label += ": " + due.Manual.Note
+		}
+		return label
+	}
+	return fmt.Sprintf("paid %s (%s)", h.Config.FormatDate(due.Transaction.Date), h.money(due.Transaction.Amount))
+}
+
+// getBill finds a bill by name, failing when there is none
+func (c *BillsCommand) getBill(name string) (*models.Bill, error) {
+	bill, err := c.Handler.billSvc.Get(name)
+	if err != nil {
+		return nil, dbErrorf("failed to retrieve bill: %w", err)
+	}
+	if bill == nil {
+		return nil, notFoundErrorf("bill '%s' not found", name)
+	}
+	return bill, nil
+}
+
+// billColumns are the machine-readable fields of a bill
+var billColumns = []string{"id", "name", "amount", "period", "due_date", "pattern", "tolerance"}
+
+func billRecord(bill *models.Bill) Record {
+	return Record{
+		{"id", bill.ID},
+		{"name", bill.Name},
+		{"amount", bill.Amount},
+		{"period", bill.Period},
+		{"due_date", bill.DueDate},
+		{"pattern", bill.Pattern},
+		{"tolerance", bill.Tolerance},
+	}
+}
+
+// billDueColumns are the machine-readable fields of a bill's due date
+var billDueColumns = []string{"bill", "due_date", "amount", "status", "days", "transaction_id", "paid_date", "paid_amount"}
+
+func billDueRecords(dues []service.BillDue, today time.Time) []Record {
+	records := make([]Record, 0, len(dues))
+	for i := range dues {
+		due := &dues[i]
+		var txID, paidDate, paidAmount interface{}
+		if tx := due.Transaction; tx != nil {
+			txID, paidDate, paidAmount = tx.ID, tx.Date, tx.Amount
+		}
+		records = append(records, Record{
+			{"bill", due.Bill.Name},
+			{"due_date", due.Date},
+			{"amount", due.Bill.Amount},
+			{"status", due.Status(today)},
+			{"days", due.DaysUntil(today)},
+			{"transaction_id", txID},
+			{"paid_date", paidDate},
+			{"paid_amount", paidAmount},
+		})
+	}
+	return records
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+	"github.com/PeguB/atad-project/internal/tui"
+)
+
+// progressWidth is the number of cells in the progress bars of budget status
+const progressWidth = 10
+
+// handleStatus evaluates every active budget and income target at once. It
+// exits with ExitBudgetExceeded when any budget is over, so cron jobs and
+// shell prompts can warn about it; income targets falling short do not.
+func (c *BudgetCommand) handleStatus(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	statuses, err := h.budgetSvc.StatusAll(now)
+	if err != nil {
+		return dbErrorf("failed to calculate budget status: %w", err)
+	}
+
+	var expenses []service.BudgetStatus
+	var over []string
+	warnings := 0
+	// Income targets are projected one by one; StatusAll lists them last
+	var incomes []*service.BudgetForecast
+	var targets []*service.BudgetStatus
+	for i := range statuses {
+		s := &statuses[i]
+		if s.Budget.IsIncome() {
+			forecast, err := h.forecastSvc.Forecast(s.Budget, now)
+			if err != nil {
+				return dbErrorf("failed to project income: %w", err)
+			}
+			incomes = append(incomes, forecast)
+			targets = append(targets, s)
+			continue
+		}
+		expenses = append(expenses, *s)
+		if s.Over() {
+			over = append(over, s.Budget.Category)
+		} else if h.Config.IsWarning(s.PercentUsed()) {
+			warnings++
+		}
+	}
+
+	if h.IsMachineOutput() {
+		columns := []string{"id", "category", "type", "period", "start_date", "end_date", "available", "spent", "remaining",
+			"percent_used", "days_left", "burn_rate", "projected", "status"}
+		records := make([]Record, 0, len(statuses))
+		for _, s := range expenses {
+			forecast, err := h.forecastSvc.Forecast(s.Budget, now)
+			if err != nil {
+				return dbErrorf("failed to forecast budget: %w", err)
+			}
+			records = append(records, Record{
+				{"id", s.Budget.ID},
+				{"category", s.Budget.Category},
+				{"type", s.Budget.Type},
+				{"period", s.Budget.Period},
+				{"start_date", s.Start},
+				{"end_date", s.End},
+				{"available", roundTo(s.Available, 2)},
+				{"spent", roundTo(s.Spent, 2)},
+				{"remaining", roundTo(s.Remaining(), 2)},
+				{"percent_used", roundTo(s.PercentUsed(), 1)},
+				{"days_left", s.DaysLeft(now)},
+				{"burn_rate", roundTo(s.BurnRate(now), 2)},
+				{"projected", roundTo(forecast.Projected, 2)},
+				{"status", h.budgetState(s.Over(), s.PercentUsed())},
+			})
+		}
+		for i, f := range incomes {
+			records = append(records, Record{
+				{"id", targets[i].Budget.ID},
+				{"category", targets[i].Budget.Category},
+				{"type", targets[i].Budget.Type},
+				{"period", targets[i].Budget.Period},
+				{"start_date", f.Start},
+				{"end_date", f.End},
+				{"available", roundTo(f.Available, 2)},
+				{"spent", roundTo(f.Spent, 2)},
+				{"remaining", roundTo(f.Remaining(), 2)},
+				{"percent_used", roundTo(f.PercentUsed(), 1)},
+				{"days_left", f.DaysLeft(now)},
+				{"burn_rate", roundTo(f.BurnRate(now), 2)},
+				{"projected", roundTo(f.Projected, 2)},
+				{"status", incomeState(f)},
+			})
+		}
+		if err := h.WriteRecords(columns, records); err != nil {
+			return err
+		}
+	} else if len(statuses) == 0 {
+		h.println("No active budgets.")
+	} else {
+		if len(expenses) > 0 {
+			h.printf("\n💰 Budget Status - %s\n", h.Config.FormatDate(now))
+			h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+			h.printf("%-18s %-23s %11s %11s  %-17s %9s %10s\n", "Category", "Period", "Spent", "Remaining", "Used", "Days left", "Burn/day")
+			h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+			for _, s := range expenses {
+				marker := "✅"
+				if s.Over() {
+					marker = "🚨"
+				} else if h.Config.IsWarning(s.PercentUsed()) {
+					marker = "⚠️"
+				}
+				h.printf("%-18s %-23s %11s %11s  %s %4.0f%% %9d %10s %s\n",
+					TruncateString(s.Budget.Category, 18), h.formatPeriod(s.Start, s.End),
+					h.money(s.Spent), h.money(s.Remaining()), progressBar(s.PercentUsed()), s.PercentUsed(),
+					s.DaysLeft(now), h.money(s.BurnRate(now)), marker)
+			}
+			h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+			if threshold := h.Config.WarningThreshold(); threshold > 0 {
+				h.printf("%d budgets: %d over, %d at or above %.0f%%\n", len(expenses), len(over), warnings, threshold)
+			} else {
+				h.printf("%d budgets: %d over\n", len(expenses), len(over))
+			}
+		}
+		if len(incomes) > 0 {
+			c.printIncomeTargets(targets, incomes, now)
+		}
+	}
+
+	if len(over) > 0 {
+		return &ExitError{Code: ExitBudgetExceeded, Err: fmt.Errorf("over budget: %s", strings.Join(over, ", "))}
+	}
+	return nil
+}
+
+// printIncomeTargets shows the progress and projection of income targets
+func (c *BudgetCommand) printIncomeTargets(targets []*service.BudgetStatus, forecasts []*service.BudgetForecast, now time.Time) {
+	h := c.Handler
+	h.printf("\n🎯 Income Targets - %s\n", h.Config.FormatDate(now))
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-18s %-23s %11s %11s  %-17s %11s %9s\n", "Category", "Period", "Received", "Shortfall", "Achieved", "Projected", "Days left")
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+	met, onTrack := 0, 0
+	for i, f := range forecasts {
+		marker := "⚠️"
+		switch incomeState(f) {
+		case "met":
+			marker = "✅"
+			met++
+		case "on_track":
+			marker = "📈"
+			onTrack++
+		}
+		h.printf("%-18s %-23s %11s %11s  %s %4.0f%% %11s %9d %s\n",
+			TruncateString(targets[i].Budget.Category, 18), h.formatPeriod(f.Start, f.End),
+			h.money(f.Spent), h.money(f.Shortfall()), progressBar(f.PercentUsed()), f.PercentUsed(),
+			h.money(f.Projected), f.DaysLeft(now), marker)
+	}
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%d income targets: %d met, %d on track, %d behind\n", len(forecasts), met, onTrack, len(forecasts)-met-onTrack)
+}
+
+// handleSummary compares the income targets and expense budgets of a month
+// with what was actually earned and spent
+func (c *BudgetCommand) handleSummary(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	month := fs.String("month", "", "Any date in the month, or the month as MM/YYYY or YYYY-MM (default: this month)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	date := time.Now()
+	if *month != "" {
+		if date, err = h.parseMonth(*month); err != nil {
+			return err
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	summary, err := h.summarySvc.Monthly(date)
+	if err != nil {
+		return dbErrorf("failed to summarize the month: %w", err)
+	}
+
+	plannedIncome, actualIncome := service.Totals(summary.Income)
+	plannedExpenses, actualExpenses := service.Totals(summary.Expenses)
+
+	if h.IsMachineOutput() {
+		columns := []string{"month", "type", "category", "planned", "actual", "variance", "budgeted"}
+		var records []Record
+		add := func(txType string, lines []service.PlanLine) {
+			for _, l := range lines {
+				records = append(records, Record{
+					{"month", summary.Start.Format("2006-01")},
+					{"type", txType},
+					{"category", l.Category},
+					{"planned", l.Planned},
+					{"actual", l.Actual},
+					{"variance", l.Variance()},
+					{"budgeted", l.Budgeted},
+				})
+			}
+		}
+		add(models.BudgetIncome, summary.Income)
+		add(models.BudgetExpense, summary.Expenses)
+		return h.WriteRecords(columns, records)
+	}
+
+	h.printf("\n📋 Planned vs Actual - %s\n", summary.Start.Format("January 2006"))
+	h.println("──────────────────────────────────────────────────────────────────")
+	h.printf("%-24s %13s %13s %13s\n", "", "Planned", "Actual", "Variance")
+	c.printPlanSection("Income", summary.Income)
+	c.printPlanSection("Expenses", summary.Expenses)
+	h.println("──────────────────────────────────────────────────────────────────")
+	h.printf("%-24s %13s %13s %13s\n", "Net", h.money(plannedIncome-plannedExpenses),
+		h.money(actualIncome-actualExpenses), h.money((actualIncome-actualExpenses)-(plannedIncome-plannedExpenses)))
+	for _, lines := range [][]service.PlanLine{summary.Income, summary.Expenses} {
+		for _, l := range lines {
+			if !l.Budgeted {
+				h.println("\n* no budget or income target")
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// printPlanSection prints the lines of one side of the summary with a total
+func (c *BudgetCommand) printPlanSection(title string, lines []service.PlanLine) {
+	h := c.Handler
+	h.printf("\n%s\n", title)
+	if len(lines) == 0 {
+		h.println("  (none)")
+		return
+	}
+	for _, l := range lines {
+		name := TruncateString(l.Category, 20)
+		if !l.Budgeted {
+			name += " *"
+		}
+		h.printf("  %-22s %13s %13s %13s\n", name, h.money(l.Planned), h.money(l.Actual), h.money(l.Variance()))
+	}
+	planned, actual := service.Totals(lines)
+	h.printf("  %-22s %13s %13s %13s\n", "Total", h.money(planned), h.money(actual), h.money(actual-planned))
+}
+
+// parseMonth reads a month as MM/YYYY, YYYY-MM or any date in it
+func (h *CLIHandler) parseMonth(value string) (time.Time, error) {
+	for _, layout := range []string{"01/2006", "2006-01"} {
+		if month, err := time.Parse(layout, value); err == nil {
+			return month, nil
+		}
+	}
+	date, err := h.Config.ParseDate(value)
+	if err != nil {
+		return time.Time{}, validationErrorf("invalid month '%s'. Use MM/YYYY, YYYY-MM or a date in %s format", value, h.Config.InputDateFormat)
+	}
+	return date, nil
+}
+
+// budgetState names the state of a budget in machine-readable output
+func (h *CLIHandler) budgetState(over bool, percentUsed float64) string {
+	if over {
+		return "over"
+	}
+	if h.Config.IsWarning(percentUsed) {
+		return "warning"
+	}
+	return "ok"
+}
+
+// incomeState names the state of an income target in machine-readable output:
+// met, on_track when the projection reaches it, or behind
+func incomeState(forecast *service.BudgetForecast) string {
+	switch {
+	case forecast.Shortfall() == 0:
+		return "met"
+	case forecast.ProjectedShortfall() == 0:
+		return "on_track"
+	}
+	return "behind"
+}
+
+// progressBar draws percent as a bar of progressWidth cells, full from 100%
+func progressBar(percent float64) string {
+	return tui.ProgressBar(percent, progressWidth)
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// handleTemplateSave saves the budgets active on a date as a named template
+func (c *BudgetCommand) handleTemplateSave(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	date := fs.String("date", "", "Save the budgets active on this date (default: today)")
+	force := fs.Bool("force", false, "Replace a template of the same name")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget template save needs a template <name>")
+	}
+	name := positional[0]
+
+	at := time.Now()
+	if *date != "" {
+		if at, err = h.Config.ParseDate(*date); err != nil {
+			return validationErrorf("invalid date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	existing, err := h.templateSvc.Get(name)
+	if err != nil {
+		return dbErrorf("failed to retrieve template: %w", err)
+	}
+	if existing != nil && !*force {
+		return validationErrorf("template '%s' already exists; use -force to replace it", name)
+	}
+	template, err := h.templateSvc.Capture(name, at)
+	if err != nil {
+		return dbErrorf("failed to read budgets: %w", err)
+	}
+	if len(template.Items) == 0 {
+		return validationErrorf("no budgets are active on %s", h.Config.FormatDate(at))
+	}
+	if err := h.templateSvc.Save(template); err != nil {
+		return dbErrorf("failed to save template: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(templateItemColumns, templateItemRecords(template))
+	}
+	h.printf("✅ Template '%s' saved with %d budgets active on %s\n", name, len(template.Items), h.Config.FormatDate(at))
+	for _, item := range template.Items {
+		h.printf("   %-20s %-8s %s%s\n", item.Category, item.Type, h.money(item.Amount), item.PeriodLabel())
+	}
+	return nil
+}
+
+// templateItemColumns are the machine-readable fields of a template's budgets
+var templateItemColumns = []string{"template", "category", "type", "amount", "period", "days", "alert_thresholds"}
+
+func templateItemRecords(template *models.BudgetTemplate) []Record {
+	records := make([]Record, 0, len(template.Items))
+	for _, item := range template.Items {
+		records = append(records, Record{
+			{"template", template.Name},
+			{"category", item.Category},
+			{"type", item.Type},
+			{"amount", item.Amount},
+			{"period", item.Period},
+			{"days", item.Days},
+			{"alert_thresholds", models.FormatThresholds(item.AlertThresholds)},
+		})
+	}
+	return records
+}
+
+// handleTemplateApply creates custom budgets for a date range from a
+// template, after showing what will be created
+func (c *BudgetCommand) handleTemplateApply(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	scale := fs.Float64("scale", 0, "Percent to add to every amount, e.g. 10 or -5")
+	fromActual := fs.Bool("from-actual", false, "Start from the spending of the previous period of the same length")
+	skipConflicts := fs.Bool("skip-conflicts", false, "Create the budgets that do not clash with existing ones")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be created")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 3 {
+		return usageErrorf("budget template apply needs <name> <start_date> <end_date>")
+	}
+	if *scale <= -100 {
+		return validationErrorf("-scale must be above -100")
+	}
+	opts := service.ApplyOptions{Scale: *scale, FromActual: *fromActual}
+	if opts.Start, err = h.Config.ParseDate(positional[1]); err != nil {
+		return validationErrorf("invalid start date. Use %s format", h.Config.InputDateFormat)
+	}
+	if opts.End, err = h.Config.ParseDate(positional[2]); err != nil {
+		return validationErrorf("invalid end date. Use %s format", h.Config.InputDateFormat)
+	}
+	if opts.End.Before(opts.Start) {
+		return validationErrorf("end date is before start date")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	template, err := h.templateSvc.Get(positional[0])
+	if err != nil {
+		return dbErrorf("failed to retrieve template: %w", err)
+	}
+	if template == nil {
+		return notFoundErrorf("template '%s' not found", positional[0])
+	}
+	plan, err := h.templateSvc.Plan(template, opts)
+	if err != nil {
+		return dbErrorf("failed to plan budgets: %w", err)
+	}
+
+	conflicts := 0
+	for _, planned := range plan {
+		if planned.Conflict != nil {
+			conflicts++
+		}
+	}
+	apply := !*dryRun && (conflicts == 0 || *skipConflicts)
+	if apply {
+		if _, err := h.templateSvc.Apply(plan); err != nil {
+			return dbErrorf("failed to create budgets: %w", err)
+		}
+	}
+
+	if h.IsMachineOutput() {
+		if err := h.WriteRecords(plannedBudgetColumns, plannedBudgetRecords(plan, apply)); err != nil {
+			return err
+		}
+	} else {
+		c.printPlan(template.Name, plan, opts)
+	}
+
+	switch {
+	case conflicts > 0 && !*skipConflicts && !*dryRun:
+		return validationErrorf("%d of %d budgets clash with budgets already set; edit or delete those, or use -skip-conflicts to create the rest",
+			conflicts, len(plan))
+	case *dryRun:
+		h.infof("Dry run: %d budgets would be created, %d skipped\n", len(plan)-conflicts, conflicts)
+	default:
+		h.infof("✅ Created %d budgets from '%s', %d skipped\n", len(plan)-conflicts, template.Name, conflicts)
+	}
+	return nil
+}
+
+// plannedBudgetColumns are the machine-readable fields of an applied template
+var plannedBudgetColumns = []string{"category", "type", "base", "source", "amount", "start_date", "end_date", "status", "conflict_id", "budget_id"}
+
+// plannedBudgetRecords describes a plan; budget ids are filled in once created
+func plannedBudgetRecords(plan []*service.PlannedBudget, created bool) []Record {
+	records := make([]Record, 0, len(plan))
+	for _, planned := range plan {
+		var conflictID, budgetID interface{}
+		if planned.Conflict != nil {
+			conflictID = planned.Conflict.ID
+		} else if created {
+			budgetID = planned.Budget.ID
+		}
+		records = append(records, Record{
+			{"category", planned.Budget.Category},
+			{"type", planned.Budget.Type},
+			{"base", planned.Base},
+			{"source", planned.Source},
+			{"amount", planned.Budget.Amount},
+			{"start_date", planned.Budget.StartDate},
+			{"end_date", planned.Budget.EndDate},
+			{"status", planned.Status()},
+			{"conflict_id", conflictID},
+			{"budget_id", budgetID},
+		})
+	}
+	return records
+}
+
+// printPlan shows the budgets a template creates with their conflicts
+func (c *BudgetCommand) printPlan(name string, plan []*service.PlannedBudget, opts service.ApplyOptions) {
+	h := c.Handler
+	title := fmt.Sprintf("\n📋 Template '%s' for %s", name, h.formatPeriod(opts.Start, opts.End))
+	if opts.Scale != 0 {
+		title += fmt.Sprintf(" (%+g%%)", opts.Scale)
+	}
+	h.println(title)
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-20s %-8s %16s %12s  %-8s %12s  %s\n", "Category", "Type", "Template", "Base", "Source", "Amount", "Status")
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────")
+	for _, planned := range plan {
+		item := planned.Item
+		status := planned.Status()
+		if planned.Conflict != nil {
+			status = fmt.Sprintf("%s (id %d)", status, planned.Conflict.ID)
+		}
+		h.printf("%-20s %-8s %16s %12s  %-8s %12s  %s\n", TruncateString(item.Category, 20), item.Type,
+			h.money(item.Amount)+item.PeriodLabel(), h.money(planned.Base), planned.Source, h.money(planned.Budget.Amount), status)
+	}
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────────")
+}
+
+// handleTemplateList lists the saved templates
+func (c *BudgetCommand) handleTemplateList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	templates, err := h.templateSvc.All()
+	if err != nil {
+		return dbErrorf("failed to retrieve templates: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		var records []Record
+		for _, template := range templates {
+			records = append(records, templateItemRecords(template)...)
+		}
+		return h.WriteRecords(templateItemColumns, records)
+	}
+
+	if len(templates) == 0 {
+		h.println("No budget templates. Save one with 'atad budget template save <name>'.")
+		return nil
+	}
+	h.println("\n📋 Budget Templates")
+	h.println("──────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-20s %7s  %-10s  %s\n", "Name", "Budgets", "Saved", "Categories")
+	h.println("──────────────────────────────────────────────────────────────────────────────")
+	for _, template := range templates {
+		categories := make([]string, 0, len(template.Items))
+		for _, item := range template.Items {
+			categories = append(categories, item.Category)
+		}
+		h.printf("%-20s %7d  %-10s  %s\n", TruncateString(template.Name, 20), len(template.Items),
+			h.Config.FormatDate(template.CreatedAt), TruncateString(strings.Join(categories, ", "), 36))
+	}
+	return nil
+}
+
+// handleTemplateDelete removes a template; budgets created from it stay
+func (c *BudgetCommand) handleTemplateDelete(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget template delete needs a template <name>")
+	}
+	name := positional[0]
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	template, err := h.templateSvc.Get(name)
+	if err != nil {
+		return dbErrorf("failed to retrieve template: %w", err)
+	}
+	if template == nil {
+		return notFoundErrorf("template '%s' not found", name)
+	}
+	if err := h.templateSvc.Delete(name); err != nil {
+		return dbErrorf("failed to delete template: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"template", name}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted template '%s'\n", name)
+	return nil
+}
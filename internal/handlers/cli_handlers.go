@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/ledger"
 	"github.com/PeguB/atad-project/internal/models"
 	"github.com/PeguB/atad-project/internal/parser"
 	"github.com/PeguB/atad-project/internal/repository"
@@ -17,9 +22,10 @@ import (
 	"golang.org/x/text/language"
 )
 
-// CommandHandler defines the interface for all command handlers
+// CommandHandler defines the interface for all command handlers. Run receives
+// the arguments after the command path and returns an error carrying the exit code.
 type CommandHandler interface {
-	Handle()
+	Run(args []string) error
 }
 
 // CLIHandler manages all CLI command operations
@@ -27,32 +33,225 @@ type CLIHandler struct {
 	db              *database.Database
 	txRepo          *repository.TransactionRepository
 	budgetRepo      *repository.BudgetRepository
+	recRepo         *repository.ReconciliationRepository
 	categoryService *service.CategoryService
+	reconcileSvc    *service.ReconcileService
+	budgetSvc       *service.BudgetService
+	forecastSvc     *service.ForecastService
+	envelopeSvc     *service.EnvelopeService
+	alertSvc        *service.AlertService
+	summarySvc      *service.SummaryService
+	templateSvc     *service.TemplateService
+	syncSvc         *service.SyncService
+	goalSvc         *service.GoalService
+	loanSvc         *service.LoanService
+	billSvc         *service.BillService
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Global flags
+	Output     string // --output format: table (default), json, csv or tsv
+	DBPath     string // --db; empty uses the database of the active profile
+	Profile    string // --profile, or profile.active from the config file
+	ConfigPath string // --config
+	NoColor    bool   // --no-color
+	Quiet      bool   // --quiet: suppress progress and informational messages
+
+	// Config holds the user preferences loaded from the config file
+	Config *config.Config
+
+	root    *Command
+	command *Command
+	flags   *flag.FlagSet // Flag set of the running command, for completion
+
+	passphrase string // Passphrase of the encrypted database, once known
+	noPrompt   bool   // Never prompt for a passphrase (shell completion)
 }
 
-// NewCLIHandler creates a new CLI handler instance
-func NewCLIHandler() *CLIHandler {
-	return &CLIHandler{}
+// NewCLIHandler creates a new CLI handler reading from stdin and writing to stdout/stderr
+func NewCLIHandler(stdin io.Reader, stdout, stderr io.Writer) *CLIHandler {
+	return &CLIHandler{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		Output: OutputTable,
+		Config: config.Default(),
+	}
 }
 
-// InitDatabase initializes the database connection and repositories
+// InitDatabase initializes the database connection and repositories. It is a
+// no-op if the database is already open.
 func (h *CLIHandler) InitDatabase() error {
-	db, err := database.NewDatabase()
+	if h.db != nil {
+		return nil
+	}
+	path, err := h.DatabasePath()
 	if err != nil {
 		return err
 	}
+	db, err := database.NewDatabaseAt(path)
+	if err != nil {
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			return err
+		}
+		return dbErrorf("failed to connect to database: %w", err)
+	}
+	categoryService, err := service.NewCategoryService(repository.NewCategoryRuleRepository(db.DB))
+	if err != nil {
+		db.Close()
+		return dbErrorf("failed to load categorization rules: %w", err)
+	}
 	h.db = db
+	h.categoryService = categoryService
 	h.txRepo = repository.NewTransactionRepository(db.DB)
 	h.budgetRepo = repository.NewBudgetRepository(db.DB)
-	h.categoryService = service.NewCategoryService()
+	h.recRepo = repository.NewReconciliationRepository(db.DB)
+	h.reconcileSvc = service.NewReconcileService(h.txRepo, h.recRepo)
+	h.budgetSvc = service.NewBudgetService(h.budgetRepo)
+	h.forecastSvc = service.NewForecastService(h.txRepo, h.budgetSvc)
+	h.envelopeSvc = service.NewEnvelopeService(h.txRepo, h.budgetRepo, repository.NewEnvelopeRepository(db.DB))
+	h.alertSvc = h.newAlertService(repository.NewAlertRepository(db.DB))
+	h.summarySvc = service.NewSummaryService(h.txRepo, h.budgetRepo)
+	h.templateSvc = service.NewTemplateService(repository.NewTemplateRepository(db.DB), h.budgetRepo, h.txRepo)
+	h.goalSvc = service.NewGoalService(repository.NewGoalRepository(db.DB))
+	h.loanSvc = service.NewLoanService(repository.NewLoanRepository(db.DB), h.txRepo)
+	h.billSvc = service.NewBillService(repository.NewBillRepository(db.DB))
+	h.syncSvc = service.NewSyncService(h.budgetRepo, repository.NewCategoryRuleRepository(db.DB), repository.NewChangesetRepository(db.DB))
 	return nil
 }
 
-// Close closes the database connection
-func (h *CLIHandler) Close() {
-	if h.db != nil {
-		h.db.Close()
+// newAlertService applies the alert settings of the config file. Terminal
+// alerts go to stderr when stdout carries machine output or is quiet.
+func (h *CLIHandler) newAlertService(alertRepo *repository.AlertRepository) *service.AlertService {
+	rules := service.AlertRules{Thresholds: h.Config.AlertThresholds, LargeTransaction: h.Config.LargeTransaction}
+	svc := service.NewAlertService(h.budgetRepo, h.budgetSvc, alertRepo, rules, h.money)
+	if h.Config.AlertTerminal {
+		w := h.Stdout
+		if h.Quiet || h.IsMachineOutput() {
+			w = h.Stderr
+		}
+		svc.AddSink(&service.TerminalSink{W: w})
+	}
+	if h.Config.AlertCommand != "" {
+		svc.AddSink(&service.CommandSink{Command: h.Config.AlertCommand})
+	}
+	if h.Config.AlertWebhook != "" {
+		svc.AddSink(&service.WebhookSink{URL: h.Config.AlertWebhook})
+	}
+	return svc
+}
+
+// DatabasePath returns the database file to open: --db if given, otherwise the
+// database of the active profile
+func (h *CLIHandler) DatabasePath() (string, error) {
+	if h.DBPath != "" {
+		return h.DBPath, nil
+	}
+	return h.profilePath(h.Profile)
+}
+
+// ProfileName returns the active profile, or "" when --db selects the database directly
+func (h *CLIHandler) ProfileName() string {
+	if h.DBPath != "" {
+		return ""
+	}
+	if h.Profile == "" {
+		return database.DefaultProfile
+	}
+	return h.Profile
+}
+
+// UseProfile switches to another profile for the rest of the session and
+// closes the open database
+func (h *CLIHandler) UseProfile(name string) error {
+	if _, err := h.profilePath(name); err != nil {
+		return err
+	}
+	if err := h.Close(); err != nil {
+		return err
+	}
+	h.DBPath = ""
+	h.Profile = name
+	return nil
+}
+
+// profilePath resolves the database of a profile. The default profile honours
+// DB_PATH and then database.path from the config file.
+func (h *CLIHandler) profilePath(name string) (string, error) {
+	if name == "" || name == database.DefaultProfile {
+		if os.Getenv("DB_PATH") == "" && h.Config.DatabasePath != "" {
+			return h.Config.DatabasePath, nil
+		}
+		return database.DefaultPath(), nil
 	}
+	if err := database.ValidateProfileName(name); err != nil {
+		return "", validationErrorf("%v", err)
+	}
+	if !database.ProfileExists(name) {
+		return "", notFoundErrorf("no profile named '%s'; create it with 'atad profile create %s'", name, name)
+	}
+	return database.ProfilePath(name), nil
+}
+
+// Close closes the database connection. For an encrypted database this
+// writes the changes back into the vault, so the error matters.
+func (h *CLIHandler) Close() error {
+	if h.db == nil {
+		return nil
+	}
+	err := h.db.Close()
+	h.db = nil
+	if err != nil {
+		return dbErrorf("failed to close database: %w", err)
+	}
+	return nil
+}
+
+// printf writes command output to stdout
+func (h *CLIHandler) printf(format string, args ...interface{}) {
+	fmt.Fprintf(h.Stdout, format, args...)
+}
+
+// println writes a line of command output to stdout
+func (h *CLIHandler) println(args ...interface{}) {
+	fmt.Fprintln(h.Stdout, args...)
+}
+
+// money formats an amount with the configured currency symbol
+func (h *CLIHandler) money(amount float64) string {
+	return h.Config.FormatMoney(amount)
+}
+
+// loadMapping reads an account mapping file; without one the default mapping
+// uses the configured currency
+func (h *CLIHandler) loadMapping(filename string) (*ledger.Mapping, error) {
+	if filename == "" {
+		mapping := ledger.DefaultMapping()
+		mapping.Currency = h.Config.Currency
+		return mapping, nil
+	}
+	mapping, err := ledger.LoadMapping(filename)
+	if err != nil {
+		return nil, validationErrorf("%v", err)
+	}
+	return mapping, nil
+}
+
+// infof writes progress and informational messages, which --quiet and
+// machine-readable output suppress
+func (h *CLIHandler) infof(format string, args ...interface{}) {
+	if h.Quiet || h.IsMachineOutput() {
+		return
+	}
+	fmt.Fprintf(h.Stdout, format, args...)
+}
+
+// warnf writes a warning to stderr
+func (h *CLIHandler) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(h.Stderr, "Warning: "+format+"\n", args...)
 }
 
 // AddCommand handles the 'add' subcommand
@@ -60,41 +259,27 @@ type AddCommand struct {
 	Handler *CLIHandler
 }
 
-func (c *AddCommand) Handle() {
-	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+func (c *AddCommand) Run(args []string) error {
+	h := c.Handler
+	addCmd := h.newFlagSet()
 	txType := addCmd.String("type", "", "Transaction type: income or expense (required)")
 	description := addCmd.String("desc", "", "Transaction description (required)")
 	amount := addCmd.Float64("amount", 0, "Transaction amount (required)")
 	category := addCmd.String("category", "", "Transaction category (optional, auto-categorized if not provided)")
-	date := addCmd.String("date", "", "Transaction date in DD/MM/YYYY format (optional, defaults to today)")
+	date := addCmd.String("date", "", "Transaction date in "+h.Config.InputDateFormat+" format (optional, defaults to today)")
+	account := addCmd.String("account", "", "Account the transaction belongs to (optional, used for reconciliation)")
 
-	addCmd.Parse(os.Args[2:])
+	if _, err := h.parseArgs(addCmd, args); err != nil {
+		return err
+	}
 
 	// Validate required fields
 	if *txType == "" || *description == "" || *amount == 0 {
-		fmt.Println("Error: -type, -desc, and -amount are required")
-		fmt.Println("\nUsage: atad add -type <income|expense> -desc <description> -amount <amount> [-category <category>] [-date <DD/MM/YYYY>]")
-		fmt.Println("\nExample: atad add -type expense -desc \"Grocery shopping\" -amount 75.50 -category Groceries")
-		os.Exit(1)
+		return usageErrorf("-type, -desc, and -amount are required")
 	}
 
 	if *txType != "income" && *txType != "expense" {
-		fmt.Println("Error: -type must be either 'income' or 'expense'")
-		os.Exit(1)
-	}
-
-	// Initialize database
-	if err := c.Handler.InitDatabase(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
-	}
-	defer c.Handler.Close()
-
-	// Auto-categorize if no category provided
-	finalCategory := *category
-	if finalCategory == "" {
-		finalCategory = c.Handler.categoryService.CategorizeTransaction(*description)
-		fmt.Printf("Auto-categorized as: %s\n", finalCategory)
+		return validationErrorf("-type must be either 'income' or 'expense'")
 	}
 
 	// Parse date
@@ -103,13 +288,23 @@ func (c *AddCommand) Handle() {
 	if *date == "" {
 		txDate = time.Now()
 	} else {
-		txDate, err = time.Parse("02/01/2006", *date)
+		txDate, err = h.Config.ParseDate(*date)
 		if err != nil {
-			fmt.Printf("Error: Invalid date format. Use DD/MM/YYYY (e.g., 15/12/2025)\n")
-			os.Exit(1)
+			return validationErrorf("invalid date format. Use %s", h.Config.InputDateFormat)
 		}
 	}
 
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	// Auto-categorize if no category provided
+	finalCategory := *category
+	if finalCategory == "" {
+		finalCategory = h.categoryService.CategorizeTransaction(*description)
+		h.infof("Auto-categorized as: %s\n", finalCategory)
+	}
+
 	// Create transaction
 	tx := &models.Transaction{
 		Date:        txDate,
@@ -117,35 +312,120 @@ func (c *AddCommand) Handle() {
 		Amount:      *amount,
 		Category:    finalCategory,
 		Type:        *txType,
+		Account:     *account,
 	}
 
-	err = c.Handler.txRepo.Create(tx)
-	if err != nil {
-		fmt.Printf("Error saving transaction: %v\n", err)
-		os.Exit(1)
+	if err := h.txRepo.Create(tx); err != nil {
+		return dbErrorf("failed to save transaction: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		if err := h.WriteRecord(transactionRecord(tx)); err != nil {
+			return err
+		}
+		h.raiseAlerts(tx)
+		return nil
 	}
 
-	fmt.Printf("✅ Transaction added successfully!\n")
+	h.printf("✅ Transaction added successfully!\n")
 	caser := cases.Title(language.English)
-	fmt.Printf("   Type: %s\n", caser.String(*txType))
-	fmt.Printf("   Description: %s\n", *description)
-	fmt.Printf("   Amount: $%.2f\n", *amount)
-	fmt.Printf("   Category: %s\n", finalCategory)
-	fmt.Printf("   Date: %s\n", txDate.Format("02/01/2006"))
-
-	// Check budget
-	if *txType == "expense" {
-		budget, _ := c.Handler.budgetRepo.GetByCategory(finalCategory)
-		if budget != nil {
-			spending, _ := c.Handler.budgetRepo.GetSpending(finalCategory, budget.StartDate, budget.EndDate)
-			percentUsed := (spending / budget.Amount) * 100
-			if spending > budget.Amount {
-				fmt.Printf("\n⚠️  Over budget! Spent: $%.2f / $%.2f (%.0f%%)\n", spending, budget.Amount, percentUsed)
-			} else if percentUsed >= 80 {
-				fmt.Printf("\n⚠️  Budget warning: $%.2f / $%.2f (%.0f%%)\n", spending, budget.Amount, percentUsed)
-			}
+	h.printf("   Type: %s\n", caser.String(*txType))
+	h.printf("   Description: %s\n", *description)
+	h.printf("   Amount: %s\n", h.money(*amount))
+	h.printf("   Category: %s\n", finalCategory)
+	h.printf("   Date: %s\n", h.Config.FormatDate(txDate))
+	if *account != "" {
+		h.printf("   Account: %s\n", *account)
+	}
+
+	alerts := h.raiseAlerts(tx)
+	budget, _ := h.budgetRepo.GetByCategoryAt(finalCategory, *txType, txDate)
+	if budget == nil {
+		return nil
+	}
+	if budget.IsIncome() {
+		h.printIncomeProgress(budget, txDate)
+	} else {
+		h.forecastBudget(budget, txDate, alerts)
+	}
+	return nil
+}
+
+// printIncomeProgress follows income with the progress of its target
+func (h *CLIHandler) printIncomeProgress(budget *models.Budget, txDate time.Time) {
+	status, err := h.budgetSvc.Status(budget, txDate)
+	if err != nil {
+		return
+	}
+	if status.Shortfall() > 0 {
+		h.printf("\n🎯 %s target: %s of %s (%.0f%%), %s to go\n", budget.Category, h.money(status.Spent),
+			h.money(status.Available), status.PercentUsed(), h.money(status.Shortfall()))
+	} else {
+		h.printf("\n✅ %s target met: %s of %s (%.0f%%)\n", budget.Category, h.money(status.Spent),
+			h.money(status.Available), status.PercentUsed())
+	}
+}
+
+// raiseAlerts runs the alert rules for a saved transaction. Failed deliveries
+// are only warnings, as the transaction is saved either way.
+func (h *CLIHandler) raiseAlerts(tx *models.Transaction) []*models.Alert {
+	alerts, err := h.alertSvc.Check(tx)
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			h.warnf("%v", err)
 		}
+	} else if err != nil {
+		h.warnf("%v", err)
+	}
+	return alerts
+}
+
+// forecastBudget follows an expense with the outlook of its budget: what can
+// still be spent per day after a budget alert, or a warning when the period
+// is on track to run over. Only the current period is forecast.
+func (h *CLIHandler) forecastBudget(budget *models.Budget, txDate time.Time, alerts []*models.Alert) {
+	now := time.Now()
+	start, _, _ := budget.PeriodAt(txDate)
+	if current, _, ok := budget.PeriodAt(now); !ok || !current.Equal(start) {
+		return
+	}
+
+	forecast, err := h.forecastSvc.Forecast(budget, now)
+	if err != nil || forecast.Over() {
+		return
+	}
+	alerted := false
+	for _, alert := range alerts {
+		alerted = alerted || alert.Kind == models.AlertBudgetThreshold
+	}
+	if forecast.ProjectedOver() {
+		h.printf("\n📈 At this pace the %s budget runs out on %s\n", budget.Category, h.Config.FormatDate(forecast.OverrunDate))
+	} else if !alerted {
+		return
 	}
+	h.printf("   Safe to spend: %s/day for %d more days\n", h.money(forecast.SafePerDay), forecast.DaysLeft(now))
+}
+
+// printForecast shows the projected spending of a budget's current period
+func (h *CLIHandler) printForecast(forecast *service.BudgetForecast) {
+	h.printf("Projected:  %s by %s\n", h.money(forecast.Projected), h.Config.FormatDate(forecast.End))
+	h.printUpcoming(forecast)
+	if forecast.ProjectedOver() && !forecast.Over() {
+		h.printf("Overrun:    expected on %s\n", h.Config.FormatDate(forecast.OverrunDate))
+	}
+	h.printf("Safe/day:   %s for %d days\n", h.money(forecast.SafePerDay), forecast.DaysLeft(forecast.Date))
+}
+
+// printUpcoming totals the recurring payments a forecast still expects
+func (h *CLIHandler) printUpcoming(forecast *service.BudgetForecast) {
+	if len(forecast.Upcoming) == 0 {
+		return
+	}
+	committed := 0.0
+	for _, e := range forecast.Upcoming {
+		committed += e.Amount
+	}
+	h.printf("            incl. %s in %s still due\n", h.money(committed), plural(len(forecast.Upcoming), "recurring payment"))
 }
 
 // ListCommand handles the 'list' subcommand
@@ -153,35 +433,32 @@ type ListCommand struct {
 	Handler *CLIHandler
 }
 
-func (c *ListCommand) Handle() {
-	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
-	txType := listCmd.String("type", "all", "Filter by type: all, income, or expense")
+func (c *ListCommand) Run(args []string) error {
+	h := c.Handler
+	listCmd := h.newFlagSet()
+	filters := addFilterFlags(listCmd, h.Config)
 	limit := listCmd.Int("limit", 20, "Number of transactions to display")
 
-	listCmd.Parse(os.Args[2:])
-
-	if err := c.Handler.InitDatabase(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
+	positional, err := h.parseArgs(listCmd, args)
+	if err != nil {
+		return err
 	}
-	defer c.Handler.Close()
-
-	var transactions []*models.Transaction
-	var err error
-	if *txType == "all" {
-		transactions, err = c.Handler.txRepo.GetAll()
-	} else {
-		transactions, err = c.Handler.txRepo.GetByType(*txType)
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
 	}
 
+	filter, err := filters.toFilter()
 	if err != nil {
-		fmt.Printf("Error retrieving transactions: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	if len(transactions) == 0 {
-		fmt.Println("No transactions found.")
-		return
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	transactions, err := h.txRepo.Find(filter)
+	if err != nil {
+		return dbErrorf("failed to retrieve transactions: %w", err)
 	}
 
 	// Limit results
@@ -190,29 +467,43 @@ func (c *ListCommand) Handle() {
 		displayCount = *limit
 	}
 
-	fmt.Printf("\n📋 Transactions (%d of %d)\n", displayCount, len(transactions))
-	fmt.Println("─────────────────────────────────────────────────────────────────────────────")
-	fmt.Printf("%-12s %-10s %-25s %-15s %10s\n", "Date", "Type", "Description", "Category", "Amount")
-	fmt.Println("─────────────────────────────────────────────────────────────────────────────")
+	if h.IsMachineOutput() {
+		return h.writeTransactions(transactions[:displayCount])
+	}
+
+	if len(transactions) == 0 {
+		h.println("No transactions found.")
+		return nil
+	}
+
+	h.printf("\n📋 Transactions (%d of %d)\n", displayCount, len(transactions))
+	h.printTransactionTable(transactions[:displayCount])
+
+	if len(transactions) > displayCount {
+		h.printf("\n... and %d more. Use -limit flag to see more.\n", len(transactions)-displayCount)
+	}
+	return nil
+}
+
+// printTransactionTable prints transactions in the table layout shared by list and search
+func (h *CLIHandler) printTransactionTable(transactions []*models.Transaction) {
+	h.println("─────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-12s %-10s %-25s %-15s %10s\n", "Date", "Type", "Description", "Category", "Amount")
+	h.println("─────────────────────────────────────────────────────────────────────────────")
 
 	caser := cases.Title(language.English)
-	for i := 0; i < displayCount; i++ {
-		tx := transactions[i]
+	for _, tx := range transactions {
 		typeIcon := "💰"
 		if tx.Type == "expense" {
 			typeIcon = "💸"
 		}
-		fmt.Printf("%-12s %-10s %-25s %-15s %10.2f\n",
-			tx.Date.Format("02/01/2006"),
+		h.printf("%-12s %-10s %-25s %-15s %10.2f\n",
+			h.Config.FormatDate(tx.Date),
 			typeIcon+" "+caser.String(tx.Type),
 			TruncateString(tx.Description, 25),
 			TruncateString(tx.Category, 15),
 			tx.Amount)
 	}
-
-	if len(transactions) > displayCount {
-		fmt.Printf("\n... and %d more. Use -limit flag to see more.\n", len(transactions)-displayCount)
-	}
 }
 
 // ReportCommand handles the 'report' subcommand
@@ -220,45 +511,39 @@ type ReportCommand struct {
 	Handler *CLIHandler
 }
 
-func (c *ReportCommand) Handle() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: atad report <income|expense> [-period <all|month|year>]")
-		os.Exit(1)
+func (c *ReportCommand) Run(args []string) error {
+	h := c.Handler
+	if len(args) > 0 && args[0] == "budget-variance" {
+		return c.handleBudgetVariance(args[1:])
 	}
+	if len(args) > 0 && args[0] == "debt" {
+		return c.handleDebt(args[1:])
+	}
+	reportCmd := h.newFlagSet()
+	period := reportCmd.String("period", h.Config.DefaultReportPeriod, "Time period: all, month, or year")
 
-	reportType := os.Args[2]
-	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
-	period := reportCmd.String("period", "month", "Time period: all, month, or year")
-
-	reportCmd.Parse(os.Args[3:])
-
-	if err := c.Handler.InitDatabase(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
+	positional, err := h.parseArgs(reportCmd, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || (positional[0] != "income" && positional[0] != "expense") {
+		return usageErrorf("report type must be 'income', 'expense', 'budget-variance' or 'debt'")
 	}
-	defer c.Handler.Close()
+	reportType := positional[0]
 
-	var startDate, endDate time.Time
 	now := time.Now()
+	startDate, endDate, err := periodRange(*period, now)
+	if err != nil {
+		return err
+	}
 
-	switch *period {
-	case "month":
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		endDate = time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
-	case "year":
-		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-		endDate = time.Date(now.Year(), 12, 31, 0, 0, 0, 0, now.Location())
-	case "all":
-		// No date filter
-	default:
-		fmt.Println("Error: -period must be 'all', 'month', or 'year'")
-		os.Exit(1)
+	if err := h.InitDatabase(); err != nil {
+		return err
 	}
 
-	transactions, err := c.Handler.txRepo.GetAll()
+	transactions, err := h.txRepo.GetAll()
 	if err != nil {
-		fmt.Printf("Error retrieving transactions: %v\n", err)
-		os.Exit(1)
+		return dbErrorf("failed to retrieve transactions: %w", err)
 	}
 
 	total := 0.0
@@ -279,23 +564,20 @@ func (c *ReportCommand) Handle() {
 		byCategory[tx.Category] += tx.Amount
 	}
 
-	caser := cases.Title(language.English)
-	periodName := caser.String(*period)
-	if *period == "month" {
-		periodName = now.Format("January 2006")
-	} else if *period == "year" {
-		periodName = strconv.Itoa(now.Year())
+	if h.IsMachineOutput() {
+		return c.writeReport(reportType, startDate, endDate, total, byCategory)
 	}
 
-	fmt.Printf("\n📊 %s Report - %s\n\n", caser.String(reportType), periodName)
-	fmt.Printf("Total %s: $%.2f\n\n", caser.String(reportType), total)
+	caser := cases.Title(language.English)
+	h.printf("\n📊 %s Report - %s\n\n", caser.String(reportType), periodName(*period, now))
+	h.printf("Total %s: %s\n\n", caser.String(reportType), h.money(total))
 
 	if len(byCategory) > 0 {
 		// Draw bar chart
-		DrawCategoryBarChart(byCategory, total)
+		DrawCategoryBarChart(h.Stdout, byCategory, total)
 
-		fmt.Println("\nBreakdown by Category:")
-		fmt.Println("─────────────────────────────────────")
+		h.println("\nBreakdown by Category:")
+		h.println("─────────────────────────────────────")
 
 		// Sort categories by amount for consistent display
 		type categoryAmount struct {
@@ -320,162 +602,811 @@ func (c *ReportCommand) Handle() {
 			// Get color for this category
 			colorStyle := GetCategoryColor(item.category)
 			colorIndicator := colorStyle.Render("█")
-			fmt.Printf("  %s %-20s $%8.2f  (%.1f%%)\n", colorIndicator, item.category, item.amount, percentage)
+			h.printf("  %s %-20s %9s  (%.1f%%)\n", colorIndicator, item.category, h.money(item.amount), percentage)
 		}
 	} else {
-		fmt.Printf("No %s transactions found for this period.\n", reportType)
+		h.printf("No %s transactions found for this period.\n", reportType)
 	}
+	return nil
 }
 
-// BudgetCommand handles the 'budget' subcommand
-type BudgetCommand struct {
-	Handler *CLIHandler
+// periodRange returns the first and last day of a report period containing now.
+// Both are zero for "all".
+func periodRange(period string, now time.Time) (startDate, endDate time.Time, err error) {
+	switch period {
+	case "month":
+		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		endDate = time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
+	case "year":
+		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		endDate = time.Date(now.Year(), 12, 31, 0, 0, 0, 0, now.Location())
+	case "all":
+		// No date filter
+	default:
+		return startDate, endDate, validationErrorf("-period must be 'all', 'month', or 'year'")
+	}
+	return startDate, endDate, nil
 }
 
-func (c *BudgetCommand) Handle() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage:")
-		fmt.Println("  atad budget list                                      # List all budgets")
-		fmt.Println("  atad budget set <category> <amount> <start> <end>     # Set a budget")
-		fmt.Println("  atad budget check <category>                          # Check budget status")
-		os.Exit(1)
+// periodName describes a report period for headings, e.g. "January 2026"
+func periodName(period string, now time.Time) string {
+	switch period {
+	case "month":
+		return now.Format("January 2006")
+	case "year":
+		return strconv.Itoa(now.Year())
 	}
+	return cases.Title(language.English).String(period)
+}
 
-	action := os.Args[2]
+// writeReport prints the report breakdown as machine-readable records, one per category
+func (c *ReportCommand) writeReport(reportType string, startDate, endDate time.Time, total float64, byCategory map[string]float64) error {
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if byCategory[categories[i]] != byCategory[categories[j]] {
+			return byCategory[categories[i]] > byCategory[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+
+	columns := []string{"type", "category", "amount", "percentage", "period_start", "period_end"}
+	records := make([]Record, 0, len(categories))
+	for _, category := range categories {
+		records = append(records, Record{
+			{"type", reportType},
+			{"category", category},
+			{"amount", byCategory[category]},
+			{"percentage", byCategory[category] / total * 100},
+			{"period_start", startDate},
+			{"period_end", endDate},
+		})
+	}
+
+	return c.Handler.WriteRecords(columns, records)
+}
 
-	if err := c.Handler.InitDatabase(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
+// BudgetCommand handles the 'budget' subcommands
+type BudgetCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *BudgetCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
 	}
-	defer c.Handler.Close()
 
-	switch action {
-	case "list":
-		c.handleList()
-	case "set":
-		c.handleSet()
-	case "check":
-		c.handleCheck()
-	default:
-		fmt.Printf("Unknown budget action: %s\n", action)
-		os.Exit(1)
+	if err := h.InitDatabase(); err != nil {
+		return err
 	}
-}
 
-func (c *BudgetCommand) handleList() {
-	budgets, err := c.Handler.budgetRepo.GetAll()
+	budgets, err := h.budgetRepo.GetAll()
 	if err != nil {
-		fmt.Printf("Error retrieving budgets: %v\n", err)
-		os.Exit(1)
+		return dbErrorf("failed to retrieve budgets: %w", err)
+	}
+
+	now := time.Now()
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(budgets))
+		for _, budget := range budgets {
+			records = append(records, budgetRecord(budget, now))
+		}
+		return h.WriteRecords(budgetColumns, records)
 	}
 
 	if len(budgets) == 0 {
-		fmt.Println("No budgets set.")
-		return
+		h.println("No budgets set.")
+		return nil
 	}
 
-	fmt.Println("\n💰 Budgets")
-	fmt.Println("─────────────────────────────────────────────────────────────")
-	fmt.Printf("%-20s %12s  %-24s\n", "Category", "Amount", "Period")
-	fmt.Println("─────────────────────────────────────────────────────────────")
+	h.println("\n💰 Budgets")
+	h.println("────────────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%4s  %-20s %-8s %12s  %-10s %-24s %s\n", "ID", "Category", "Type", "Amount", "Period", "Dates", "Rollover")
+	h.println("────────────────────────────────────────────────────────────────────────────────────────────────")
 
 	for _, budget := range budgets {
-		period := fmt.Sprintf("%s - %s",
-			budget.StartDate.Format("02/01/2006"),
-			budget.EndDate.Format("02/01/2006"))
-		fmt.Printf("%-20s $%11.2f  %-24s\n", budget.Category, budget.Amount, period)
+		rollover := ""
+		if budget.RollsOver() {
+			rollover = budget.RolloverName(h.money)
+		}
+		h.printf("%4d  %-20s %-8s %12s  %-10s %-24s %s\n", budget.ID, budget.Category, budget.Type, h.money(budget.Amount), budget.PeriodName(), h.budgetDates(budget, now), rollover)
 	}
+	return nil
 }
 
-func (c *BudgetCommand) handleSet() {
-	if len(os.Args) < 7 {
-		fmt.Println("Usage: atad budget set <category> <amount> <start_date> <end_date>")
-		fmt.Println("Example: atad budget set Groceries 500 01/12/2025 31/12/2025")
-		os.Exit(1)
+// budgetDates describes the dates a budget applies to at now: the range of a
+// custom budget or the current period of a recurring one
+func (h *CLIHandler) budgetDates(budget *models.Budget, now time.Time) string {
+	if !budget.IsRecurring() {
+		return h.formatPeriod(budget.StartDate, budget.EndDate)
+	}
+	start, end, ok := budget.PeriodAt(now)
+	if !ok {
+		return "starts " + h.Config.FormatDate(budget.StartDate)
+	}
+	return h.formatPeriod(start, end)
+}
+
+// formatPeriod formats an inclusive date range for display
+func (h *CLIHandler) formatPeriod(start, end time.Time) string {
+	return fmt.Sprintf("%s - %s", h.Config.FormatDate(start), h.Config.FormatDate(end))
+}
+
+// budgetColumns are the machine-readable fields of a budget
+var budgetColumns = []string{"id", "category", "type", "amount", "period", "start_date", "end_date", "rollover", "rollover_cap", "alert_thresholds", "current_start", "current_end"}
+
+// budgetRecord describes a budget with the period that applies at now; the
+// current period is empty when the budget does not cover now
+func budgetRecord(budget *models.Budget, now time.Time) Record {
+	currentStart, currentEnd, _ := budget.PeriodAt(now)
+	return Record{
+		{"id", budget.ID},
+		{"category", budget.Category},
+		{"type", budget.Type},
+		{"amount", budget.Amount},
+		{"period", budget.Period},
+		{"start_date", budget.StartDate},
+		{"end_date", budget.EndDate},
+		{"rollover", budget.Rollover},
+		{"rollover_cap", budget.RolloverCap},
+		{"alert_thresholds", models.FormatThresholds(budget.AlertThresholds)},
+		{"current_start", currentStart},
+		{"current_end", currentEnd},
+	}
+}
+
+func (c *BudgetCommand) handleSet(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	budgetType := fs.String("type", models.BudgetExpense, "Budget type: expense, or income for a target to earn")
+	period := fs.String("period", models.PeriodCustom, "Budget period: custom, weekly, monthly, quarterly or yearly")
+	anchor := fs.String("anchor", "", "First day of a recurring budget (default: start of the current period)")
+	rollover := fs.String("rollover", "", "What a recurring budget carries into the next period: none, surplus, full or capped")
+	rolloverCap := fs.Float64("cap", 0, "Largest amount carried over with -rollover capped")
+	alerts := fs.String("alerts", "", "Percents of the budget that raise alerts, e.g. 50,80,100; none or default")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if !models.IsValidBudgetPeriod(*period) {
+		return validationErrorf("invalid period '%s'. Use one of: %s", *period, strings.Join(models.BudgetPeriods, ", "))
+	}
+
+	if *budgetType != models.BudgetExpense && *budgetType != models.BudgetIncome {
+		return validationErrorf("invalid type '%s'. Use expense or income", *budgetType)
+	}
+	if *budgetType == models.BudgetIncome && (*rollover != "" || *alerts != "") {
+		return usageErrorf("-rollover and -alerts only apply to expense budgets")
+	}
+	if *rollover != "" && !models.IsValidRollover(*rollover) {
+		return validationErrorf("invalid rollover '%s'. Use one of: %s", *rollover, strings.Join(models.Rollovers, ", "))
+	}
+	if *rollover == models.RolloverCapped && *rolloverCap <= 0 {
+		return usageErrorf("-rollover capped needs a positive -cap")
+	}
+	if *rolloverCap != 0 && *rollover != models.RolloverCapped {
+		return usageErrorf("-cap only applies with -rollover capped")
+	}
+
+	budget := &models.Budget{Type: *budgetType, Period: *period, Rollover: *rollover, RolloverCap: *rolloverCap}
+	if budget.AlertThresholds, err = parseAlertThresholds(*alerts); err != nil {
+		return err
+	}
+	if budget.IsRecurring() {
+		if len(positional) != 2 {
+			return usageErrorf("budget set -period %s needs <category> <amount>", *period)
+		}
+		budget.StartDate = models.DefaultAnchor(*period, time.Now())
+		if *anchor != "" {
+			if budget.StartDate, err = h.Config.ParseDate(*anchor); err != nil {
+				return validationErrorf("invalid anchor date. Use %s format", h.Config.InputDateFormat)
+			}
+		}
+	} else {
+		if len(positional) != 4 {
+			return usageErrorf("budget set needs <category> <amount> <start_date> <end_date>")
+		}
+		if *anchor != "" || *rollover != "" {
+			return usageErrorf("-anchor and -rollover only apply to recurring budgets")
+		}
+		if budget.StartDate, err = h.Config.ParseDate(positional[2]); err != nil {
+			return validationErrorf("invalid start date. Use %s format", h.Config.InputDateFormat)
+		}
+		if budget.EndDate, err = h.Config.ParseDate(positional[3]); err != nil {
+			return validationErrorf("invalid end date. Use %s format", h.Config.InputDateFormat)
+		}
+		if budget.EndDate.Before(budget.StartDate) {
+			return validationErrorf("end date is before start date")
+		}
+	}
+
+	budget.Category = positional[0]
+	if budget.Amount, err = strconv.ParseFloat(positional[1], 64); err != nil {
+		return validationErrorf("invalid amount '%s'", positional[1])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	// Setting a recurring budget of the same period again updates it, keeping
+	// the anchor and rollover unless given
+	if budget.IsRecurring() {
+		existing, err := h.budgetRepo.GetByCategoryAndPeriod(budget.Category, budget.Type, budget.Period)
+		if err != nil {
+			return dbErrorf("failed to retrieve budget: %w", err)
+		}
+		if existing != nil {
+			budget.ID = existing.ID
+			if *anchor == "" {
+				budget.StartDate = existing.StartDate
+			}
+			if budget.Rollover == "" {
+				budget.Rollover, budget.RolloverCap = existing.Rollover, existing.RolloverCap
+			}
+			if *alerts == "" {
+				budget.AlertThresholds = existing.AlertThresholds
+			}
+		}
+	}
+	if err := c.checkOverlap(budget); err != nil {
+		return err
 	}
 
-	category := os.Args[3]
-	amount, err := strconv.ParseFloat(os.Args[4], 64)
+	if budget.ID != 0 {
+		err = h.budgetRepo.Update(budget)
+	} else {
+		err = h.budgetRepo.Create(budget)
+	}
 	if err != nil {
-		fmt.Println("Error: Invalid amount")
-		os.Exit(1)
+		return dbErrorf("failed to save budget: %w", err)
+	}
+
+	now := time.Now()
+	if h.IsMachineOutput() {
+		return h.WriteRecord(budgetRecord(budget, now))
+	}
+
+	if budget.IsIncome() {
+		h.printf("✅ Income target set successfully!\n")
+	} else {
+		h.printf("✅ Budget set successfully!\n")
+	}
+	h.printf("   Category: %s\n", budget.Category)
+	h.printf("   Amount: %s\n", h.money(budget.Amount))
+	if budget.IsRecurring() {
+		h.printf("   Period: %s from %s (now %s)\n", budget.PeriodName(), h.Config.FormatDate(budget.StartDate), h.budgetDates(budget, now))
+	} else {
+		h.printf("   Period: %s to %s\n", h.Config.FormatDate(budget.StartDate), h.Config.FormatDate(budget.EndDate))
 	}
+	if !budget.IsIncome() {
+		if budget.IsRecurring() {
+			h.printf("   Rollover: %s\n", budget.RolloverName(h.money))
+		}
+		h.printf("   Alerts: %s\n", h.alertThresholdsName(budget))
+	}
+	return nil
+}
 
-	startDate, err := time.Parse("02/01/2006", os.Args[5])
+// parseAlertThresholds reads the -alerts flag of a budget: percentages,
+// "none" to turn its alerts off, or "default" (and empty) for alerts.thresholds
+func parseAlertThresholds(value string) ([]float64, error) {
+	if value == "" || value == "default" {
+		return nil, nil
+	}
+	thresholds, err := models.ParseThresholds(value)
 	if err != nil {
-		fmt.Println("Error: Invalid start date. Use DD/MM/YYYY format")
-		os.Exit(1)
+		return nil, validationErrorf("%v", err)
+	}
+	return thresholds, nil
+}
+
+// alertThresholdsName describes the thresholds a budget alerts at
+func (h *CLIHandler) alertThresholdsName(budget *models.Budget) string {
+	thresholds, suffix := budget.AlertThresholds, ""
+	if thresholds == nil {
+		thresholds, suffix = h.Config.AlertThresholds, " (default)"
 	}
+	if len(thresholds) == 0 {
+		return "off" + suffix
+	}
+	fields := make([]string, len(thresholds))
+	for i, threshold := range thresholds {
+		fields[i] = strconv.FormatFloat(threshold, 'f', -1, 64) + "%"
+	}
+	return strings.Join(fields, ", ") + suffix
+}
 
-	endDate, err := time.Parse("02/01/2006", os.Args[6])
+// checkOverlap rejects a budget that would apply on the same days as another
+// budget of its category
+func (c *BudgetCommand) checkOverlap(budget *models.Budget) error {
+	h := c.Handler
+	other, err := h.budgetRepo.GetOverlapping(budget)
 	if err != nil {
-		fmt.Println("Error: Invalid end date. Use DD/MM/YYYY format")
-		os.Exit(1)
+		return dbErrorf("failed to check budgets: %w", err)
 	}
+	if other == nil {
+		return nil
+	}
+	kind := budgetKind(other)
+	if other.IsRecurring() {
+		return validationErrorf("'%s' already has a %s %s (id %d); a category can have one recurring %s. Change it with 'atad budget edit %d'",
+			other.Category, other.Period, kind, other.ID, kind, other.ID)
+	}
+	return validationErrorf("overlaps the '%s' %s %s (id %d). Change it with 'atad budget edit %d' or remove it with 'atad budget delete %d'",
+		other.Category, kind, h.formatPeriod(other.StartDate, other.EndDate), other.ID, other.ID, other.ID)
+}
 
-	budget := &models.Budget{
-		Category:  category,
-		Amount:    amount,
-		Period:    "custom",
-		StartDate: startDate,
-		EndDate:   endDate,
+// budgetKind names a budget in messages: a budget or an income target
+func budgetKind(budget *models.Budget) string {
+	if budget.IsIncome() {
+		return "income target"
 	}
+	return "budget"
+}
 
-	err = c.Handler.budgetRepo.Create(budget)
+// handleEdit changes the fields of a budget given by id
+func (c *BudgetCommand) handleEdit(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	category := fs.String("category", "", "New category")
+	budgetType := fs.String("type", "", "New type: expense or income")
+	amount := fs.Float64("amount", 0, "New amount per period")
+	period := fs.String("period", "", "New period: custom, weekly, monthly, quarterly or yearly")
+	start := fs.String("start", "", "New start date of a custom budget")
+	end := fs.String("end", "", "New end date of a custom budget")
+	anchor := fs.String("anchor", "", "New first day of a recurring budget")
+	rollover := fs.String("rollover", "", "What a recurring budget carries over: none, surplus, full or capped")
+	rolloverCap := fs.Float64("cap", 0, "Largest amount carried over with -rollover capped")
+	alerts := fs.String("alerts", "", "Percents of the budget that raise alerts, e.g. 50,80,100; none or default")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget edit needs a budget <id>")
+	}
+	id, err := parseBudgetID(positional[0])
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if len(set) == 0 {
+		return usageErrorf("nothing to change; give at least one of -category, -type, -amount, -period, -start, -end, -anchor, -rollover, -cap, -alerts")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	budget, err := h.budgetRepo.GetByID(id)
 	if err != nil {
-		fmt.Printf("Error creating budget: %v\n", err)
-		os.Exit(1)
+		return dbErrorf("failed to retrieve budget: %w", err)
+	}
+	if budget == nil {
+		return notFoundErrorf("budget %d not found", id)
 	}
 
-	fmt.Printf("✅ Budget set successfully!\n")
-	fmt.Printf("   Category: %s\n", category)
-	fmt.Printf("   Amount: $%.2f\n", amount)
-	fmt.Printf("   Period: %s to %s\n", startDate.Format("02/01/2006"), endDate.Format("02/01/2006"))
+	if set["category"] {
+		if *category == "" {
+			return validationErrorf("category cannot be empty")
+		}
+		budget.Category = *category
+	}
+	if set["amount"] {
+		if *amount <= 0 {
+			return validationErrorf("amount must be positive")
+		}
+		budget.Amount = *amount
+	}
+	if set["type"] {
+		if *budgetType != models.BudgetExpense && *budgetType != models.BudgetIncome {
+			return validationErrorf("invalid type '%s'. Use expense or income", *budgetType)
+		}
+		budget.Type = *budgetType
+	}
+	if budget.IsIncome() {
+		// Income targets neither roll over nor raise alerts
+		if set["rollover"] || set["cap"] || set["alerts"] {
+			return usageErrorf("-rollover, -cap and -alerts only apply to expense budgets")
+		}
+		budget.Rollover, budget.RolloverCap, budget.AlertThresholds = models.RolloverNone, 0, nil
+	}
+	if set["alerts"] {
+		if budget.AlertThresholds, err = parseAlertThresholds(*alerts); err != nil {
+			return err
+		}
+	}
+	if set["period"] {
+		if !models.IsValidBudgetPeriod(*period) {
+			return validationErrorf("invalid period '%s'. Use one of: %s", *period, strings.Join(models.BudgetPeriods, ", "))
+		}
+		if budget.IsRecurring() && *period == models.PeriodCustom && !set["end"] {
+			return usageErrorf("changing to a custom budget needs -end (and optionally -start)")
+		}
+		budget.Period = *period
+	}
+
+	if budget.IsRecurring() {
+		if set["start"] || set["end"] {
+			return usageErrorf("-start and -end only apply to custom budgets; use -anchor")
+		}
+		budget.EndDate = time.Time{}
+		if set["anchor"] {
+			if budget.StartDate, err = h.Config.ParseDate(*anchor); err != nil {
+				return validationErrorf("invalid anchor date. Use %s format", h.Config.InputDateFormat)
+			}
+		}
+		if set["rollover"] {
+			if !models.IsValidRollover(*rollover) {
+				return validationErrorf("invalid rollover '%s'. Use one of: %s", *rollover, strings.Join(models.Rollovers, ", "))
+			}
+			budget.Rollover = *rollover
+			if !set["cap"] {
+				budget.RolloverCap = 0
+			}
+		}
+		if set["cap"] {
+			if budget.Rollover != models.RolloverCapped {
+				return usageErrorf("-cap only applies with -rollover capped")
+			}
+			budget.RolloverCap = *rolloverCap
+		}
+		if budget.Rollover == models.RolloverCapped && budget.RolloverCap <= 0 {
+			return usageErrorf("-rollover capped needs a positive -cap")
+		}
+	} else {
+		if set["anchor"] || set["rollover"] || set["cap"] {
+			return usageErrorf("-anchor, -rollover and -cap only apply to recurring budgets")
+		}
+		budget.Rollover, budget.RolloverCap = models.RolloverNone, 0
+		if set["start"] {
+			if budget.StartDate, err = h.Config.ParseDate(*start); err != nil {
+				return validationErrorf("invalid start date. Use %s format", h.Config.InputDateFormat)
+			}
+		}
+		if set["end"] {
+			if budget.EndDate, err = h.Config.ParseDate(*end); err != nil {
+				return validationErrorf("invalid end date. Use %s format", h.Config.InputDateFormat)
+			}
+		}
+		if budget.EndDate.Before(budget.StartDate) {
+			return validationErrorf("end date is before start date")
+		}
+	}
+
+	if err := c.checkOverlap(budget); err != nil {
+		return err
+	}
+	if err := h.budgetRepo.Update(budget); err != nil {
+		return dbErrorf("failed to update budget: %w", err)
+	}
+
+	now := time.Now()
+	if h.IsMachineOutput() {
+		return h.WriteRecord(budgetRecord(budget, now))
+	}
+	h.printf("✅ %s %d updated: %s %s, %s %s\n", cases.Title(language.English).String(budgetKind(budget)), budget.ID, budget.Category, h.money(budget.Amount),
+		strings.ToLower(budget.PeriodName()), h.budgetDates(budget, now))
+	return nil
 }
 
-func (c *BudgetCommand) handleCheck() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: atad budget check <category>")
-		os.Exit(1)
+// handleDelete removes a budget given by id
+func (c *BudgetCommand) handleDelete(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget delete needs a budget <id>")
+	}
+	id, err := parseBudgetID(positional[0])
+	if err != nil {
+		return err
 	}
 
-	category := os.Args[3]
-	budget, err := c.Handler.budgetRepo.GetByCategory(category)
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	budget, err := h.budgetRepo.GetByID(id)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return dbErrorf("failed to retrieve budget: %w", err)
+	}
+	if budget == nil {
+		return notFoundErrorf("budget %d not found", id)
+	}
+	if err := h.budgetRepo.Delete(id); err != nil {
+		return dbErrorf("failed to delete budget: %w", err)
 	}
 
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"id", id}, {"category", budget.Category}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted budget %d (%s, %s)\n", id, budget.Category, strings.ToLower(budget.PeriodName()))
+	return nil
+}
+
+// parseBudgetID parses the <id> argument of budget edit and delete
+func parseBudgetID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, validationErrorf("invalid budget ID '%s'", arg)
+	}
+	return id, nil
+}
+
+// currentBudget returns the budget of a category and type that covers now.
+// Without a type the expense budget is preferred over the income target.
+func (c *BudgetCommand) currentBudget(category, budgetType string, now time.Time) (*models.Budget, error) {
+	h := c.Handler
+	types := []string{models.BudgetExpense, models.BudgetIncome}
+	switch budgetType {
+	case "":
+	case models.BudgetExpense, models.BudgetIncome:
+		types = []string{budgetType}
+	default:
+		return nil, validationErrorf("invalid type '%s'. Use expense or income", budgetType)
+	}
+
+	var budget *models.Budget
+	for _, t := range types {
+		var err error
+		if budget, err = h.budgetRepo.GetByCategoryAt(category, t, now); err != nil {
+			return nil, dbErrorf("failed to retrieve budget: %w", err)
+		}
+		if budget != nil {
+			break
+		}
+	}
 	if budget == nil {
-		fmt.Printf("No budget set for category '%s'\n", category)
-		return
+		others, err := h.budgetRepo.GetAllByCategory(category)
+		if err != nil {
+			return nil, dbErrorf("failed to retrieve budget: %w", err)
+		}
+		if len(others) > 0 {
+			return nil, notFoundErrorf("no budget for category '%s' covers %s", category, h.Config.FormatDate(now))
+		}
+		return nil, notFoundErrorf("no budget set for category '%s'", category)
+	}
+	return budget, nil
+}
+
+func (c *BudgetCommand) handleCheck(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	budgetType := fs.String("type", "", "Check the expense budget or the income target (default: expense, else income)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget check needs a <category>")
+	}
+	category := positional[0]
+
+	if err := h.InitDatabase(); err != nil {
+		return err
 	}
 
-	spending, err := c.Handler.budgetRepo.GetSpending(category, budget.StartDate, budget.EndDate)
+	budget, err := c.currentBudget(category, *budgetType, time.Now())
 	if err != nil {
-		fmt.Printf("Error calculating spending: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+
+	now := time.Now()
+	forecast, err := h.forecastSvc.Forecast(budget, now)
+	if err != nil {
+		return dbErrorf("failed to calculate spending: %w", err)
+	}
+	if budget.IsIncome() {
+		return c.checkIncome(budget, forecast)
+	}
+	status := &forecast.BudgetPeriodStatus
+
+	percentUsed := status.PercentUsed()
+	remaining := status.Remaining()
+	over := status.Over()
+
+	if h.IsMachineOutput() {
+		err := h.WriteRecord(Record{
+			{"id", budget.ID},
+			{"category", budget.Category},
+			{"type", budget.Type},
+			{"period", budget.Period},
+			{"amount", budget.Amount},
+			{"carried", roundTo(status.Carried, 2)},
+			{"available", roundTo(status.Available, 2)},
+			{"spent", roundTo(status.Spent, 2)},
+			{"remaining", roundTo(remaining, 2)},
+			{"percent_used", roundTo(percentUsed, 1)},
+			{"start_date", status.Start},
+			{"end_date", status.End},
+			{"status", h.budgetState(over, percentUsed)},
+			{"projected", roundTo(forecast.Projected, 2)},
+			{"overrun_date", forecast.OverrunDate},
+			{"safe_per_day", roundTo(forecast.SafePerDay, 2)},
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		h.printf("\n💰 Budget Status: %s\n", category)
+		h.println("─────────────────────────────────────")
+		h.printf("Budget:     %s\n", h.money(budget.Amount))
+		if budget.RollsOver() {
+			h.printf("Carried:    %s (rollover: %s)\n", h.money(status.Carried), budget.RolloverName(h.money))
+			h.printf("Available:  %s\n", h.money(status.Available))
+		}
+		h.printf("Spent:      %s (%.0f%%)\n", h.money(status.Spent), percentUsed)
+		h.printf("Remaining:  %s\n", h.money(remaining))
+		if budget.IsRecurring() {
+			h.printf("Period:     %s, %s\n", budget.PeriodName(), h.formatPeriod(status.Start, status.End))
+		} else {
+			h.printf("Period:     %s\n", h.formatPeriod(status.Start, status.End))
+		}
+
+		h.printForecast(forecast)
+
+		if over {
+			h.printf("\n⚠️  Over budget by %s!\n", h.money(-remaining))
+		} else if h.Config.IsWarning(percentUsed) {
+			h.printf("\n⚠️  Warning: %.0f%% or more of budget used\n", h.Config.WarningThreshold())
+		} else {
+			h.println("\n✅ Within budget")
+		}
 	}
 
-	percentUsed := (spending / budget.Amount) * 100
-	remaining := budget.Amount - spending
+	if over {
+		return &ExitError{Code: ExitBudgetExceeded, Err: fmt.Errorf("budget for '%s' exceeded by %s", category, h.money(-remaining))}
+	}
+	return nil
+}
 
-	fmt.Printf("\n💰 Budget Status: %s\n", category)
-	fmt.Println("─────────────────────────────────────")
-	fmt.Printf("Budget:     $%.2f\n", budget.Amount)
-	fmt.Printf("Spent:      $%.2f (%.0f%%)\n", spending, percentUsed)
-	fmt.Printf("Remaining:  $%.2f\n", remaining)
-	fmt.Printf("Period:     %s - %s\n",
-		budget.StartDate.Format("02/01/2006"),
-		budget.EndDate.Format("02/01/2006"))
+// checkIncome reports the progress of an income target. Falling short is not
+// an error, so it never exits with ExitBudgetExceeded.
+func (c *BudgetCommand) checkIncome(budget *models.Budget, forecast *service.BudgetForecast) error {
+	h := c.Handler
+	status := &forecast.BudgetPeriodStatus
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{
+			{"id", budget.ID},
+			{"category", budget.Category},
+			{"type", budget.Type},
+			{"period", budget.Period},
+			{"amount", budget.Amount},
+			{"received", roundTo(status.Spent, 2)},
+			{"shortfall", roundTo(status.Shortfall(), 2)},
+			{"percent_achieved", roundTo(status.PercentUsed(), 1)},
+			{"start_date", status.Start},
+			{"end_date", status.End},
+			{"status", incomeState(forecast)},
+			{"projected", roundTo(forecast.Projected, 2)},
+			{"projected_shortfall", roundTo(forecast.ProjectedShortfall(), 2)},
+			{"target_date", forecast.OverrunDate},
+			{"needed_per_day", roundTo(forecast.SafePerDay, 2)},
+		})
+	}
 
-	if spending > budget.Amount {
-		fmt.Printf("\n⚠️  Over budget by $%.2f!\n", spending-budget.Amount)
-	} else if percentUsed >= 80 {
-		fmt.Println("\n⚠️  Warning: 80% or more of budget used")
+	h.printf("\n🎯 Income Target: %s\n", budget.Category)
+	h.println("─────────────────────────────────────")
+	h.printf("Target:     %s\n", h.money(status.Available))
+	h.printf("Received:   %s (%.0f%%)\n", h.money(status.Spent), status.PercentUsed())
+	h.printf("Shortfall:  %s\n", h.money(status.Shortfall()))
+	if budget.IsRecurring() {
+		h.printf("Period:     %s, %s\n", budget.PeriodName(), h.formatPeriod(status.Start, status.End))
 	} else {
-		fmt.Println("\n✅ Within budget")
+		h.printf("Period:     %s\n", h.formatPeriod(status.Start, status.End))
+	}
+	h.printf("Projected:  %s by %s\n", h.money(forecast.Projected), h.Config.FormatDate(forecast.End))
+	h.printUpcoming(forecast)
+
+	switch {
+	case status.Shortfall() == 0:
+		h.println("\n✅ Target met")
+	case forecast.ProjectedShortfall() == 0 && forecast.ProjectedOver():
+		h.printf("\n📈 On track: expected to reach the target on %s\n", h.Config.FormatDate(forecast.OverrunDate))
+	case forecast.ProjectedShortfall() == 0:
+		h.println("\n📈 On track to reach the target")
+	default:
+		h.printf("\n⚠️  Expected to fall short by %s; %s/day more needed for %d days\n",
+			h.money(forecast.ProjectedShortfall()), h.money(forecast.SafePerDay), forecast.DaysLeft(forecast.Date))
+	}
+	return nil
+}
+
+// handleHistory shows the spending of a recurring budget in its current and
+// past periods
+func (c *BudgetCommand) handleHistory(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	count := fs.Int("n", 6, "Number of periods to show, including the current one")
+	budgetType := fs.String("type", "", "Show the expense budget or the income target (default: expense, else income)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("budget history needs a <category>")
+	}
+	if *count < 1 {
+		return validationErrorf("-n must be at least 1")
+	}
+	category := positional[0]
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	budget, err := c.currentBudget(category, *budgetType, time.Now())
+	if err != nil {
+		return err
 	}
+
+	periods, err := h.budgetSvc.History(budget, time.Now(), *count)
+	if err != nil {
+		return dbErrorf("failed to calculate spending: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(periods))
+		for _, p := range periods {
+			records = append(records, Record{
+				{"start_date", p.Start},
+				{"end_date", p.End},
+				{"amount", p.Amount},
+				{"carried", roundTo(p.Carried, 2)},
+				{"available", roundTo(p.Available, 2)},
+				{"spent", roundTo(p.Spent, 2)},
+				{"remaining", roundTo(p.Remaining(), 2)},
+				{"percent_used", roundTo(p.PercentUsed(), 1)},
+			})
+		}
+		return h.WriteRecords([]string{"start_date", "end_date", "amount", "carried", "available", "spent", "remaining", "percent_used"}, records)
+	}
+
+	if budget.IsIncome() {
+		h.printf("\n📅 Income History: %s (%s, %s target per period)\n", category, budget.PeriodName(), h.money(budget.Amount))
+		h.println("──────────────────────────────────────────────────────────────────────")
+		h.printf("%-25s %12s %12s %9s\n", "Period", "Received", "Shortfall", "Achieved")
+		h.println("──────────────────────────────────────────────────────────────────────")
+		for _, p := range periods {
+			marker := ""
+			if p.Shortfall() == 0 {
+				marker = " ✅"
+			}
+			h.printf("%-25s %12s %12s %8.0f%%%s\n",
+				h.formatPeriod(p.Start, p.End), h.money(p.Spent), h.money(p.Shortfall()), p.PercentUsed(), marker)
+		}
+		return nil
+	}
+
+	h.printf("\n📅 Budget History: %s (%s, %s per period, rollover: %s)\n", category, budget.PeriodName(), h.money(budget.Amount), budget.RolloverName(h.money))
+	h.println("───────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-25s %12s %12s %12s %7s\n", "Period", "Carried", "Spent", "Remaining", "Used")
+	h.println("───────────────────────────────────────────────────────────────────────────────────")
+	for _, p := range periods {
+		marker := ""
+		if p.Over() {
+			marker = " 🚨"
+		} else if h.Config.IsWarning(p.PercentUsed()) {
+			marker = " ⚠️"
+		}
+		h.printf("%-25s %12s %12s %12s %6.0f%%%s\n",
+			h.formatPeriod(p.Start, p.End), h.money(p.Carried), h.money(p.Spent), h.money(p.Remaining()), p.PercentUsed(), marker)
+	}
+	return nil
 }
 
 // SearchCommand handles the 'search' subcommand
@@ -483,119 +1414,165 @@ type SearchCommand struct {
 	Handler *CLIHandler
 }
 
-func (c *SearchCommand) Handle() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: atad search <query>")
-		fmt.Println("Example: atad search \"coffee\"")
-		os.Exit(1)
+func (c *SearchCommand) Run(args []string) error {
+	h := c.Handler
+	searchCmd := h.newFlagSet()
+	filters := addFilterFlags(searchCmd, h.Config)
+
+	positional, err := h.parseArgs(searchCmd, args)
+	if err != nil {
+		return err
 	}
+	if len(positional) != 1 {
+		return usageErrorf("search needs exactly one <query>")
+	}
+	query := positional[0]
 
-	query := strings.ToLower(os.Args[2])
+	filter, err := filters.toFilter()
+	if err != nil {
+		return err
+	}
+	filter.Query = query
 
-	if err := c.Handler.InitDatabase(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
+	if err := h.InitDatabase(); err != nil {
+		return err
 	}
-	defer c.Handler.Close()
 
-	transactions, err := c.Handler.txRepo.GetAll()
+	results, err := h.txRepo.Find(filter)
 	if err != nil {
-		fmt.Printf("Error retrieving transactions: %v\n", err)
-		os.Exit(1)
+		return dbErrorf("failed to retrieve transactions: %w", err)
 	}
 
-	var results []*models.Transaction
-	for _, tx := range transactions {
-		if strings.Contains(strings.ToLower(tx.Description), query) ||
-			strings.Contains(strings.ToLower(tx.Category), query) {
-			results = append(results, tx)
-		}
+	if h.IsMachineOutput() {
+		return h.writeTransactions(results)
 	}
 
 	if len(results) == 0 {
-		fmt.Printf("No transactions found matching '%s'\n", os.Args[2])
-		return
+		h.printf("No transactions found matching '%s'\n", query)
+		return nil
 	}
 
-	fmt.Printf("\n🔍 Search Results for '%s' (%d found)\n", os.Args[2], len(results))
-	fmt.Println("─────────────────────────────────────────────────────────────────────────────")
-	fmt.Printf("%-12s %-10s %-25s %-15s %10s\n", "Date", "Type", "Description", "Category", "Amount")
-	fmt.Println("─────────────────────────────────────────────────────────────────────────────")
-
-	caser := cases.Title(language.English)
-	for _, tx := range results {
-		typeIcon := "💰"
-		if tx.Type == "expense" {
-			typeIcon = "💸"
-		}
-		fmt.Printf("%-12s %-10s %-25s %-15s %10.2f\n",
-			tx.Date.Format("02/01/2006"),
-			typeIcon+" "+caser.String(tx.Type),
-			TruncateString(tx.Description, 25),
-			TruncateString(tx.Category, 15),
-			tx.Amount)
-	}
+	h.printf("\n🔍 Search Results for '%s' (%d found)\n", query, len(results))
+	h.printTransactionTable(results)
+	return nil
 }
+
 // ImportCommand handles the 'import' subcommand
 type ImportCommand struct {
 	Handler *CLIHandler
 }
 
-func (c *ImportCommand) Handle() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: atad import <file.csv> [--auto-categorize] [--skip-duplicates]")
-		fmt.Println("\nSupported format: CSV (comma-separated values)")
-		fmt.Println("\nOptions:")
-		fmt.Println("  --auto-categorize    Automatically categorize imported transactions")
-		fmt.Println("  --skip-duplicates    Skip transactions that appear to be duplicates")
-		fmt.Println("\nExample:")
-		fmt.Println("  atad import statement.csv --auto-categorize")
-		os.Exit(1)
-	}
+func (c *ImportCommand) Run(args []string) error {
+	h := c.Handler
+	importCmd := h.newFlagSet()
+	autoCategorize := importCmd.Bool("auto-categorize", false, "Automatically categorize imported transactions")
+	skipDuplicates := importCmd.Bool("skip-duplicates", false, "Skip transactions that appear to be duplicates")
+	account := importCmd.String("account", "", "Assign imported transactions to an account")
+	mappingFile := importCmd.String("mapping", "", "Account mapping file for journal imports")
+	profileName := importCmd.String("import-profile", "", "Import profile from the config file (default: import.default_profile)")
 
-	filename := os.Args[2]
-	autoCategorize := false
-	skipDuplicates := false
+	positional, err := h.parseArgs(importCmd, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("import needs exactly one <file>")
+	}
+	filename := positional[0]
 
-	// Parse flags
-	for i := 3; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--auto-categorize":
-			autoCategorize = true
-		case "--skip-duplicates":
-			skipDuplicates = true
+	// Options given on the command line win over the import profile
+	profile, err := h.Config.ImportProfile(*profileName)
+	if err != nil {
+		return notFoundErrorf("%v", err)
+	}
+	if profile != nil {
+		set := make(map[string]bool)
+		importCmd.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["account"] {
+			*account = profile.Account
+		}
+		if !set["mapping"] {
+			*mappingFile = profile.Mapping
+		}
+		if !set["auto-categorize"] {
+			*autoCategorize = profile.AutoCategorize
+		}
+		if !set["skip-duplicates"] {
+			*skipDuplicates = profile.SkipDuplicates
 		}
 	}
 
 	// Check if file exists
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		fmt.Printf("Error: File '%s' not found\n", filename)
-		os.Exit(1)
+		return notFoundErrorf("file '%s' not found", filename)
 	}
 
-	fmt.Printf("📥 Importing transactions from %s...\n\n", filename)
+	h.infof("📥 Importing transactions from %s...\n\n", filename)
 
-	// Parse CSV file
-	csvParser := parser.NewCSVParser()
-	transactions, err := csvParser.ParseFile(filename)
+	// Parse the file: plain-text accounting journals by extension, CSV otherwise
+	var transactions []*models.Transaction
+	var balanceCheck *parser.BalanceCheck
+	var warnings []string
+	if parser.IsJournalFile(filename) {
+		var mapping *ledger.Mapping
+		mapping, err = h.loadMapping(*mappingFile)
+		if err != nil {
+			return err
+		}
+		journalParser := parser.NewJournalParser(mapping)
+		transactions, err = journalParser.ParseFile(filename)
+		warnings = journalParser.Warnings()
+	} else {
+		csvParser := parser.NewCSVParser()
+		transactions, err = csvParser.ParseFile(filename)
+		balanceCheck = csvParser.CheckBalances(transactions)
+		warnings = csvParser.Warnings()
+	}
 	if err != nil {
-		fmt.Printf("Error parsing file: %v\n", err)
-		os.Exit(1)
+		return validationErrorf("failed to parse file: %v", err)
+	}
+	for _, warning := range warnings {
+		h.warnf("%s", warning)
 	}
 
 	if len(transactions) == 0 {
-		fmt.Println("No transactions found in file")
-		return
+		h.println("No transactions found in file")
+		return nil
 	}
 
-	fmt.Printf("Found %d transactions\n", len(transactions))
+	h.infof("Found %d transactions\n", len(transactions))
+
+	// Verify running balances carried by the statement
+	if check := balanceCheck; check != nil {
+		if check.OK() {
+			h.infof("✅ Running balances verified (%d rows), closing balance: %s\n", check.Checked, h.money(check.ClosingBalance))
+		} else {
+			h.warnf("%d running balance mismatch(es) in statement:", len(check.Mismatches))
+			for _, mismatch := range check.Mismatches {
+				fmt.Fprintf(h.Stderr, "   %s\n", mismatch)
+			}
+		}
+	}
+
+	if *account != "" {
+		for _, tx := range transactions {
+			tx.Account = *account
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	if err := h.snapshot("import"); err != nil {
+		return err
+	}
 
 	// Auto-categorize if requested
-	if autoCategorize {
-		fmt.Println("Applying automatic categorization...")
+	if *autoCategorize {
+		h.infof("Applying automatic categorization...\n")
 		for _, tx := range transactions {
 			if tx.Category == "Uncategorized" || tx.Category == "" {
-				suggestedCat := c.Handler.categoryService.CategorizeTransaction(tx.Description)
+				suggestedCat := h.categoryService.CategorizeTransaction(tx.Description)
 				if suggestedCat != "" {
 					tx.Category = suggestedCat
 				}
@@ -608,16 +1585,16 @@ func (c *ImportCommand) Handle() {
 	skipped := 0
 	errors := 0
 
-	if skipDuplicates {
-		fmt.Println("Checking for duplicates...")
+	if *skipDuplicates {
+		h.infof("Checking for duplicates...\n")
 	}
 
 	for _, tx := range transactions {
 		// Check for duplicates
-		if skipDuplicates {
-			isDuplicate, err := c.Handler.txRepo.IsDuplicate(tx)
+		if *skipDuplicates {
+			isDuplicate, err := h.txRepo.IsDuplicate(tx)
 			if err != nil {
-				fmt.Printf("Warning: Error checking duplicate: %v\n", err)
+				h.warnf("error checking duplicate: %v", err)
 			} else if isDuplicate {
 				skipped++
 				continue
@@ -625,9 +1602,8 @@ func (c *ImportCommand) Handle() {
 		}
 
 		// Save transaction
-		err := c.Handler.txRepo.Create(tx)
-		if err != nil {
-			fmt.Printf("Error importing transaction: %v\n", err)
+		if err := h.txRepo.Create(tx); err != nil {
+			fmt.Fprintf(h.Stderr, "Error importing transaction: %v\n", err)
 			errors++
 		} else {
 			imported++
@@ -635,22 +1611,27 @@ func (c *ImportCommand) Handle() {
 	}
 
 	// Summary
-	fmt.Println("\n✅ Import complete!")
-	fmt.Printf("   Imported: %d\n", imported)
+	h.println("\n✅ Import complete!")
+	h.printf("   Imported: %d\n", imported)
 	if skipped > 0 {
-		fmt.Printf("   Skipped (duplicates): %d\n", skipped)
+		h.printf("   Skipped (duplicates): %d\n", skipped)
 	}
 	if errors > 0 {
-		fmt.Printf("   Errors: %d\n", errors)
+		h.printf("   Errors: %d\n", errors)
 	}
 
-	if autoCategorize {
+	if *autoCategorize {
 		categorized := 0
 		for _, tx := range transactions {
 			if tx.Category != "Uncategorized" && tx.Category != "" {
 				categorized++
 			}
 		}
-		fmt.Printf("   Auto-categorized: %d/%d\n", categorized, imported)
+		h.printf("   Auto-categorized: %d/%d\n", categorized, imported)
 	}
+
+	if errors > 0 {
+		return &ExitError{Code: ExitDatabase, Err: fmt.Errorf("%d transactions could not be imported", errors)}
+	}
+	return nil
 }
@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/export"
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Completer returns completion candidates for a word. Candidates need not match
+// the prefix; the caller filters them.
+type Completer func(h *CLIHandler, prefix string) []string
+
+// flagCompleters complete flag values by flag name. Commands can override an
+// entry through Command.FlagValues.
+var flagCompleters = map[string]Completer{
+	"category":       completeCategories,
+	"account":        completeAccounts,
+	"type":           completeWords("all", "income", "expense"),
+	"period":         completeWords("all", "month", "year"),
+	"format":         completeWords(export.Formats...),
+	"mapping":        completeFiles,
+	"o":              completeFiles,
+	"import-profile": completeImportProfiles,
+}
+
+// globalFlags lists the global flags and whether they take a value
+var globalFlags = map[string]Completer{
+	"output":   completeWords(OutputFormats...),
+	"db":       completeFiles,
+	"config":   completeFiles,
+	"profile":  completeProfiles,
+	"no-color": nil,
+	"quiet":    nil,
+}
+
+// completionScripts are the shell scripts printed by 'atad completion'. They
+// pass the words typed so far to 'atad __complete' and offer its output.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for atad
+# Load with: source <(atad completion bash)
+_atad() {
+    local IFS=$'\n'
+    mapfile -t COMPREPLY < <(atad __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+    COMPREPLY=("${COMPREPLY[@]// /\\ }")
+}
+complete -F _atad atad
+`,
+	"zsh": `#compdef atad
+# zsh completion for atad
+# Load with: source <(atad completion zsh)
+_atad() {
+    local -a candidates dirs others
+    candidates=("${(@f)$(atad __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    dirs=(${(M)candidates:#*/})
+    others=(${candidates:#*/})
+    (( ${#dirs} )) && compadd -Q -S '' -- $dirs
+    (( ${#others} )) && compadd -- $others
+}
+compdef _atad atad
+`,
+	"fish": `# fish completion for atad
+# Load with: atad completion fish | source
+function __atad_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    atad __complete $tokens[2..-1] "$current" 2>/dev/null
+end
+complete -c atad -f -a '(__atad_complete)'
+`,
+}
+
+// CompletionCommand handles 'atad completion <shell>'
+type CompletionCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *CompletionCommand) Run(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("completion needs a shell: bash, zsh or fish")
+	}
+
+	script, ok := completionScripts[positional[0]]
+	if !ok {
+		return usageErrorf("unsupported shell '%s'; use bash, zsh or fish", positional[0])
+	}
+	_, err = io.WriteString(h.Stdout, script)
+	return err
+}
+
+// CompleteCommand handles the hidden 'atad __complete <words...>' command used by
+// the shell scripts. The last word is the one being completed (possibly empty);
+// candidates are printed one per line.
+type CompleteCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *CompleteCommand) Run(args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for _, candidate := range c.Handler.complete(args[:len(args)-1], args[len(args)-1]) {
+		fmt.Fprintln(c.Handler.Stdout, candidate)
+	}
+	return nil
+}
+
+// complete returns the candidates for current given the preceding words
+func (h *CLIHandler) complete(words []string, current string) []string {
+	// A global flag waiting for its value
+	if len(words) > 0 {
+		if completer, ok := globalFlags[flagName(words[len(words)-1])]; ok && completer != nil && isFlag(words[len(words)-1]) {
+			return filterPrefix(completer(h, current), current)
+		}
+	}
+
+	words, err := h.parseGlobalFlags(words)
+	if err != nil {
+		return nil
+	}
+	h.loadConfig()
+
+	// Walk down the command tree
+	cmd := h.root
+	for len(words) > 0 {
+		sub := cmd.Find(words[0])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		words = words[1:]
+	}
+
+	if strings.HasPrefix(current, "-") {
+		return filterPrefix(h.flagNames(cmd), current)
+	}
+
+	if cmd.New == nil {
+		if len(words) > 0 {
+			return nil
+		}
+		return filterPrefix(subcommandNames(cmd), current)
+	}
+
+	flags := h.commandFlags(cmd)
+
+	// Skip over flags and their values to find the positional index
+	position := 0
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !isFlag(word) {
+			position++
+			continue
+		}
+		if strings.Contains(word, "=") {
+			continue
+		}
+		f := lookupFlag(flags, flagName(word))
+		if f == nil || isBoolFlag(f) {
+			continue
+		}
+		if i == len(words)-1 {
+			// The current word is this flag's value
+			return filterPrefix(h.completeFlagValue(cmd, f.Name, current), current)
+		}
+		i++
+	}
+
+	if position < len(cmd.Args) && cmd.Args[position] != nil {
+		return filterPrefix(cmd.Args[position](h, current), current)
+	}
+	return nil
+}
+
+// completeFlagValue completes the value of a command flag
+func (h *CLIHandler) completeFlagValue(cmd *Command, name, prefix string) []string {
+	if completer, ok := cmd.FlagValues[name]; ok {
+		if completer == nil {
+			return nil
+		}
+		return completer(h, prefix)
+	}
+	if completer, ok := flagCompleters[name]; ok {
+		return completer(h, prefix)
+	}
+	return nil
+}
+
+// commandFlags returns the flag set a leaf command registers. The command is run
+// with -h against a discarded writer, which parses flags before touching the database.
+func (h *CLIHandler) commandFlags(cmd *Command) *flag.FlagSet {
+	if cmd.New == nil {
+		return nil
+	}
+	probe := NewCLIHandler(nil, io.Discard, io.Discard)
+	probe.root = h.root
+	probe.command = cmd
+	cmd.New(probe).Run([]string{"-h"})
+	return probe.flags
+}
+
+// flagNames lists the flags of a command plus the global flags
+func (h *CLIHandler) flagNames(cmd *Command) []string {
+	var names []string
+	if fs := h.commandFlags(cmd); fs != nil {
+		fs.VisitAll(func(f *flag.Flag) {
+			names = append(names, "-"+f.Name)
+		})
+	}
+	for name := range globalFlags {
+		names = append(names, "--"+name)
+	}
+	return names
+}
+
+func subcommandNames(cmd *Command) []string {
+	var names []string
+	for _, sub := range cmd.Subcommands {
+		if !sub.Hidden || sub.Name == "help" || sub.Name == "version" {
+			names = append(names, sub.Name)
+		}
+	}
+	return names
+}
+
+func lookupFlag(fs *flag.FlagSet, name string) *flag.Flag {
+	if fs == nil {
+		return nil
+	}
+	return fs.Lookup(name)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func isFlag(word string) bool {
+	return strings.HasPrefix(word, "-") && word != "-" && word != "--"
+}
+
+func flagName(word string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(word, "-"), "=")
+	return name
+}
+
+// filterPrefix keeps the sorted, unique candidates starting with prefix
+func filterPrefix(candidates []string, prefix string) []string {
+	seen := make(map[string]bool)
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) && !seen[candidate] {
+			seen[candidate] = true
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// completeWords completes from a fixed list of words
+func completeWords(words ...string) Completer {
+	return func(*CLIHandler, string) []string {
+		return words
+	}
+}
+
+// completeCategories offers the categories in the database and the default rules
+func completeCategories(h *CLIHandler, prefix string) []string {
+	var categories []string
+	for _, rule := range models.DefaultCategoryRules() {
+		categories = append(categories, rule.Category)
+	}
+	if h.InitDatabase() == nil {
+		if used, err := h.txRepo.GetCategories(); err == nil {
+			categories = append(categories, used...)
+		}
+	}
+	return append(categories, completeBudgetCategories(h, prefix)...)
+}
+
+// completeBudgetCategories offers the categories that have a budget
+func completeBudgetCategories(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	budgets, err := h.budgetRepo.GetAll()
+	if err != nil {
+		return nil
+	}
+	categories := make([]string, 0, len(budgets))
+	for _, budget := range budgets {
+		categories = append(categories, budget.Category)
+	}
+	return categories
+}
+
+// completeBudgetIDs offers the ids of all budgets
+func completeBudgetIDs(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	budgets, err := h.budgetRepo.GetAll()
+	if err != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(budgets))
+	for _, budget := range budgets {
+		ids = append(ids, strconv.FormatInt(budget.ID, 10))
+	}
+	return ids
+}
+
+// completeTemplateNames offers the names of the budget templates
+func completeTemplateNames(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	templates, err := h.templateSvc.All()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(templates))
+	for _, template := range templates {
+		names = append(names, template.Name)
+	}
+	return names
+}
+
+// completeGoalNames offers the names of the savings goals
+func completeGoalNames(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	goals, err := h.goalSvc.All()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(goals))
+	for _, goal := range goals {
+		names = append(names, goal.Name)
+	}
+	return names
+}
+
+// completeLoanNames offers the names of the loans
+func completeLoanNames(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	loans, err := h.loanSvc.All()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(loans))
+	for _, loan := range loans {
+		names = append(names, loan.Name)
+	}
+	return names
+}
+
+// completeBillNames offers the names of the bills
+func completeBillNames(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	bills, err := h.billSvc.All()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(bills))
+	for _, bill := range bills {
+		names = append(names, bill.Name)
+	}
+	return names
+}
+
+// completeAccounts offers the account names in use
+func completeAccounts(h *CLIHandler, prefix string) []string {
+	if h.InitDatabase() != nil {
+		return nil
+	}
+	accounts, _ := h.txRepo.GetAccounts()
+	return accounts
+}
+
+// completeFiles offers paths below the directory typed so far; directories end in '/'
+func completeFiles(h *CLIHandler, prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	if strings.HasPrefix(readDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			readDir = filepath.Join(home, readDir[2:])
+		}
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		path := dir + name
+		if entry.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// completeCommands offers command paths for 'atad help'
+func completeCommands(h *CLIHandler, prefix string) []string {
+	return subcommandNames(h.root)
+}
+
+// completeImportProfiles offers the import profiles defined in the config file
+func completeImportProfiles(h *CLIHandler, prefix string) []string {
+	return h.Config.ProfileNames()
+}
+
+// completeProfiles offers the ledger profile names
+func completeProfiles(h *CLIHandler, prefix string) []string {
+	profiles, _ := database.ListProfiles()
+	return profiles
+}
+
+// completeConfigKeys offers the configuration keys
+func completeConfigKeys(h *CLIHandler, prefix string) []string {
+	return h.Config.Keys()
+}
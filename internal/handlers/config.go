@@ -0,0 +1,102 @@
+package handlers
+
+import "strings"
+
+// ConfigCommand handles the 'config' subcommands
+type ConfigCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *ConfigCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	keys := h.Config.Keys()
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(keys))
+		for _, key := range keys {
+			value, _ := h.Config.Get(key)
+			records = append(records, Record{{"key", key}, {"value", value}})
+		}
+		return h.WriteRecords([]string{"key", "value"}, records)
+	}
+
+	h.printf("\n⚙️  Configuration (%s)\n", h.ConfigPath)
+	h.println("─────────────────────────────────────────────────────────────")
+	for _, key := range keys {
+		value, _ := h.Config.Get(key)
+		h.printf("%-36s %s\n", key, value)
+	}
+	return nil
+}
+
+func (c *ConfigCommand) handleGet(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("config get needs a <key>")
+	}
+
+	value, err := h.Config.Get(positional[0])
+	if err != nil {
+		return notFoundErrorf("%v", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"key", positional[0]}, {"value", value}})
+	}
+	h.println(value)
+	return nil
+}
+
+func (c *ConfigCommand) handleSet(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("config set needs a <key> and a <value>")
+	}
+	key, value := positional[0], positional[1]
+
+	if _, err := h.Config.Get(key); err != nil && !isProfileKey(key) {
+		return notFoundErrorf("%v", err)
+	}
+	if err := h.Config.Set(key, value); err != nil {
+		return validationErrorf("%v", err)
+	}
+	if err := h.Config.Save(h.ConfigPath); err != nil {
+		return err
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"key", key}, {"value", value}})
+	}
+	h.printf("✅ %s = %s\n", key, value)
+	return nil
+}
+
+func (c *ConfigCommand) handlePath(args []string) error {
+	h := c.Handler
+	if _, err := h.parseArgs(h.newFlagSet(), args); err != nil {
+		return err
+	}
+	h.println(h.ConfigPath)
+	return nil
+}
+
+// isProfileKey reports whether key names an import profile option, which may
+// create the profile when set
+func isProfileKey(key string) bool {
+	return strings.HasPrefix(key, "import.profiles.")
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// readyToAssign names the pool of unassigned income in machine-readable output
+const readyToAssign = "Ready to Assign"
+
+// EnvelopeCommand handles the 'envelope' subcommands for zero-based budgeting
+type EnvelopeCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *EnvelopeCommand) handleStatus(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	status, err := h.envelopeSvc.Status(time.Now())
+	if err != nil {
+		return dbErrorf("failed to load envelopes: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		// The pool comes first: its income is "assigned" to it and handing
+		// money to envelopes draws it down like spending
+		records := []Record{{
+			{"envelope", readyToAssign},
+			{"assigned", status.Income},
+			{"spent", status.Assigned},
+			{"available", status.ReadyToAssign()},
+		}}
+		for _, e := range status.Envelopes {
+			records = append(records, Record{
+				{"envelope", e.Category},
+				{"assigned", e.Assigned},
+				{"spent", e.Spent},
+				{"available", e.Available()},
+			})
+		}
+		return h.WriteRecords([]string{"envelope", "assigned", "spent", "available"}, records)
+	}
+
+	h.printf("\n✉️  Envelopes since %s\n", h.Config.FormatDate(status.Start))
+	h.println("─────────────────────────────────────────────────────────────")
+	h.printf("%-20s %12s %12s %12s\n", "Envelope", "Assigned", "Spent", "Available")
+	h.println("─────────────────────────────────────────────────────────────")
+	for _, e := range status.Envelopes {
+		marker := ""
+		if e.Available() < 0 {
+			marker = " 🚨"
+		}
+		h.printf("%-20s %12s %12s %12s%s\n", e.Category, h.money(e.Assigned), h.money(e.Spent), h.money(e.Available()), marker)
+	}
+	if len(status.Envelopes) == 0 {
+		h.println("No envelopes yet. Fund one with 'atad envelope assign <category> <amount>'.")
+	}
+	h.println("─────────────────────────────────────────────────────────────")
+	h.printf("Income:           %s\n", h.money(status.Income))
+	h.printf("Assigned:         %s\n", h.money(status.Assigned))
+	c.printReadyToAssign(status)
+	return nil
+}
+
+func (c *EnvelopeCommand) printReadyToAssign(status *service.EnvelopeStatus) {
+	h := c.Handler
+	ready := status.ReadyToAssign()
+	switch {
+	case ready > 0.005:
+		h.printf("Ready to assign:  %s\n", h.money(ready))
+	case ready < -0.005:
+		h.printf("Ready to assign:  %s ⚠️  more assigned than earned\n", h.money(ready))
+	default:
+		h.printf("Ready to assign:  %s ✅ every unit has a job\n", h.money(0))
+	}
+}
+
+func (c *EnvelopeCommand) handleAssign(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	note := fs.String("note", "", "Note stored with the assignment")
+	date := fs.String("date", "", "Date of the assignment (default: today)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("envelope assign needs <category> <amount>")
+	}
+	category := positional[0]
+	amount, err := c.parseAmount(positional[1])
+	if err != nil {
+		return err
+	}
+	when, err := c.parseDate(*date)
+	if err != nil {
+		return err
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	if err := h.envelopeSvc.Assign(category, amount, when, *note); err != nil {
+		return dbErrorf("failed to assign: %w", err)
+	}
+	return c.reportEnvelopes(category)
+}
+
+func (c *EnvelopeCommand) handleMove(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	note := fs.String("note", "", "Note stored with the move")
+	date := fs.String("date", "", "Date of the move (default: today)")
+	force := fs.Bool("force", false, "Move even if it leaves the source envelope overspent")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 3 {
+		return usageErrorf("envelope move needs <from> <to> <amount>")
+	}
+	from, to := positional[0], positional[1]
+	if from == to {
+		return validationErrorf("cannot move money within envelope '%s'", from)
+	}
+	amount, err := c.parseAmount(positional[2])
+	if err != nil {
+		return err
+	}
+	when, err := c.parseDate(*date)
+	if err != nil {
+		return err
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	if !*force {
+		status, err := h.envelopeSvc.Status(time.Now())
+		if err != nil {
+			return dbErrorf("failed to load envelopes: %w", err)
+		}
+		source := status.Envelope(from)
+		if available := source.Available(); amount > available+0.005 {
+			return validationErrorf("envelope '%s' only holds %s; use -force to move %s anyway",
+				from, h.money(available), h.money(amount))
+		}
+	}
+	if err := h.envelopeSvc.Move(from, to, amount, when, *note); err != nil {
+		return dbErrorf("failed to move: %w", err)
+	}
+	return c.reportEnvelopes(from, to)
+}
+
+func (c *EnvelopeCommand) handleStart(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("envelope start needs a <date>")
+	}
+	start, err := h.Config.ParseDate(positional[0])
+	if err != nil {
+		return validationErrorf("invalid date. Use %s format", h.Config.InputDateFormat)
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	if err := h.envelopeSvc.Start(start); err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"start", start}})
+	}
+	h.printf("✅ Envelopes count income and spending from %s\n", h.Config.FormatDate(start))
+	return nil
+}
+
+// reportEnvelopes shows the envelopes changed by an assign or move and the
+// pool, warning when either went negative
+func (c *EnvelopeCommand) reportEnvelopes(categories ...string) error {
+	h := c.Handler
+	status, err := h.envelopeSvc.Status(time.Now())
+	if err != nil {
+		return dbErrorf("failed to load envelopes: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		record := Record{}
+		for _, category := range categories {
+			e := status.Envelope(category)
+			record = append(record, Field{category, e.Available()})
+		}
+		record = append(record, Field{"ready_to_assign", status.ReadyToAssign()})
+		return h.WriteRecord(record)
+	}
+
+	for _, category := range categories {
+		e := status.Envelope(category)
+		h.printf("✉️  %-20s available %s\n", e.Category, h.money(e.Available()))
+		if e.Available() < 0 {
+			h.warnf("envelope '%s' is overspent by %s", e.Category, h.money(-e.Available()))
+		}
+	}
+	c.printReadyToAssign(status)
+	return nil
+}
+
+func (c *EnvelopeCommand) parseAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount <= 0 {
+		return 0, validationErrorf("invalid amount '%s': must be a positive number", value)
+	}
+	return amount, nil
+}
+
+func (c *EnvelopeCommand) parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	date, err := c.Handler.Config.ParseDate(value)
+	if err != nil {
+		return time.Time{}, validationErrorf("invalid date. Use %s format", c.Handler.Config.InputDateFormat)
+	}
+	return date, nil
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeMoveRejectsOverdraw(t *testing.T) {
+	app := newTestApp(t)
+	app.mustRun("add", "-type", "income", "-desc", "pay", "-amount", "500", "-category", "Salary")
+	app.mustRun("envelope", "assign", "Food", "70")
+
+	code, _, stderr := app.run("envelope", "move", "Food", "Rent", "500")
+	if code != ExitValidation {
+		t.Fatalf("exit code = %d, want %d (stderr %q)", code, ExitValidation, stderr)
+	}
+	if !strings.Contains(stderr, "-force") {
+		t.Errorf("stderr = %q, want a hint about -force", stderr)
+	}
+
+	stdout := app.mustRun("--output", "json", "envelope", "move", "Food", "Rent", "70")
+	if !strings.Contains(stdout, `"Food": 0`) {
+		t.Errorf("moving the whole envelope: stdout = %q", stdout)
+	}
+
+	_, _, stderr = app.run("envelope", "move", "-force", "Rent", "Food", "100")
+	if !strings.Contains(stderr, "'Rent' is overspent") {
+		t.Errorf("forced move: stderr = %q, want an overspent warning", stderr)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PeguB/atad-project/internal/repository"
+	"github.com/PeguB/atad-project/internal/vault"
+)
+
+// Process exit codes. Scripts may rely on these values; do not renumber them.
+const (
+	ExitOK             = 0
+	ExitFailure        = 1 // Unexpected failure
+	ExitUsage          = 2 // Bad command line: unknown command, missing argument, bad flag
+	ExitNotFound       = 3 // A referenced transaction, budget or file does not exist
+	ExitValidation     = 4 // Input was understood but rejected (bad date, amount, unbalanced reconciliation)
+	ExitDatabase       = 5 // The database could not be opened, read or written
+	ExitBudgetExceeded = 6 // A budget check found spending over the budget
+)
+
+// ErrHelp is returned by commands after printing their help on request
+var ErrHelp = errors.New("help requested")
+
+// ExitError carries the exit code a failed command should terminate with
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitUsage, Err: fmt.Errorf(format, args...)}
+}
+
+func validationErrorf(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitValidation, Err: fmt.Errorf(format, args...)}
+}
+
+func notFoundErrorf(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitNotFound, Err: fmt.Errorf(format, args...)}
+}
+
+// dbErrorf wraps a repository failure; missing records keep the not-found code
+func dbErrorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &ExitError{Code: ExitNotFound, Err: err}
+	}
+	if errors.Is(err, repository.ErrTransactionLocked) || errors.Is(err, vault.ErrWrongPassphrase) || errors.Is(err, vault.ErrNoPassphrase) {
+		return &ExitError{Code: ExitValidation, Err: err}
+	}
+	return &ExitError{Code: ExitDatabase, Err: err}
+}
+
+// ExitCode maps an error returned by a command to a process exit code
+func ExitCode(err error) int {
+	if err == nil || errors.Is(err, ErrHelp) {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return ExitNotFound
+	}
+	return ExitFailure
+}
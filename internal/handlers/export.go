@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/export"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/vault"
+)
+
+// ExportCommand handles the 'export' subcommand
+type ExportCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *ExportCommand) Run(args []string) error {
+	h := c.Handler
+	exportCmd := h.newFlagSet()
+	format := exportCmd.String("format", "csv", "Output format: "+strings.Join(export.Formats, ", "))
+	filters := addFilterFlags(exportCmd, h.Config)
+	query := exportCmd.String("query", "", "Only transactions whose description or category contains this text")
+	output := exportCmd.String("o", "", "Output file (defaults to stdout)")
+	mappingFile := exportCmd.String("mapping", "", "Account mapping file for beancount/ledger output")
+	encrypt := exportCmd.Bool("encrypt", false, "Encrypt the output file with a passphrase (requires -o)")
+
+	positional, err := h.parseArgs(exportCmd, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	filter, err := filters.toFilter()
+	if err != nil {
+		return err
+	}
+	filter.Query = *query
+
+	mapping, err := h.loadMapping(*mappingFile)
+	if err != nil {
+		return err
+	}
+	if err := export.CheckFormat(*format); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	// Encrypted exports are collected in memory and sealed once complete
+	var passphrase string
+	var sealed bytes.Buffer
+	if *encrypt {
+		if *output == "" {
+			return usageErrorf("-encrypt needs an output file (-o)")
+		}
+		if passphrase, err = h.readPassphrase(PassphraseEnv, "Export passphrase: ", true); err != nil {
+			return err
+		}
+	}
+
+	// Open the database before creating the output file, so a failure leaves
+	// an existing file untouched
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	out := h.Stdout
+	var file *os.File
+	if *encrypt {
+		out = &sealed
+	} else if *output != "" {
+		if file, err = os.Create(*output); err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		out = file
+	}
+
+	buffered := bufio.NewWriter(out)
+	writer, err := export.NewWriter(*format, buffered, mapping)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	count := 0
+	err = h.txRepo.Iterate(filter, func(tx *models.Transaction) error {
+		count++
+		return writer.Write(tx)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if file != nil {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			return fmt.Errorf("failed to write output file: %w", closeErr)
+		}
+	}
+	if err != nil {
+		return dbErrorf("failed to export transactions: %w", err)
+	}
+
+	if *encrypt {
+		data, err := vault.Encrypt(sealed.Bytes(), passphrase)
+		if err != nil {
+			return err
+		}
+		if err := vault.WriteFile(*output, data, 0600); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+
+	// Keep stdout clean for the exported data
+	if *output != "" {
+		h.infof("✅ Exported %d transactions to %s\n", count, *output)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"flag"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// filterFlags holds the transaction filter flags shared by list, search and export
+type filterFlags struct {
+	txType   *string
+	category *string
+	account  *string
+	from     *string
+	to       *string
+
+	cfg *config.Config
+}
+
+// addFilterFlags registers the shared transaction filter flags on a flag set
+func addFilterFlags(fs *flag.FlagSet, cfg *config.Config) *filterFlags {
+	return &filterFlags{
+		txType:   fs.String("type", "all", "Filter by type: all, income, or expense"),
+		category: fs.String("category", "", "Filter by category"),
+		account:  fs.String("account", "", "Filter by account"),
+		from:     fs.String("from", "", "Only transactions on or after this date ("+cfg.InputDateFormat+")"),
+		cfg:      cfg,
+		to:       fs.String("to", "", "Only transactions on or before this date ("+cfg.InputDateFormat+")"),
+	}
+}
+
+// toFilter validates the flag values and converts them to a repository filter
+func (f *filterFlags) toFilter() (repository.TransactionFilter, error) {
+	filter := repository.TransactionFilter{
+		Type:     *f.txType,
+		Category: *f.category,
+		Account:  *f.account,
+	}
+
+	if filter.Type != "all" && filter.Type != "income" && filter.Type != "expense" {
+		return filter, validationErrorf("-type must be 'all', 'income' or 'expense'")
+	}
+
+	var err error
+	if *f.from != "" {
+		filter.From, err = f.cfg.ParseDate(*f.from)
+		if err != nil {
+			return filter, validationErrorf("invalid -from date. Use %s format", f.cfg.InputDateFormat)
+		}
+	}
+	if *f.to != "" {
+		filter.To, err = f.cfg.ParseDate(*f.to)
+		if err != nil {
+			return filter, validationErrorf("invalid -to date. Use %s format", f.cfg.InputDateFormat)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// GoalCommand handles the 'goal' subcommands for savings goals
+type GoalCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *GoalCommand) handleAdd(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	by := fs.String("by", "", "Target date to reach the goal (default: no deadline)")
+	account := fs.String("account", "", "Count the net transactions of this account as contributions")
+	category := fs.String("category", "", "Count the expenses in this category as contributions")
+	start := fs.String("start", "", "Date linked transactions count from (default: today)")
+	saved := fs.Float64("saved", 0, "Amount already saved, recorded as a first contribution")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("goal add needs <name> <target>")
+	}
+	goal := &models.Goal{Name: positional[0], Account: *account, Category: *category}
+	if goal.Target, err = strconv.ParseFloat(positional[1], 64); err != nil || goal.Target <= 0 {
+		return validationErrorf("invalid target '%s': must be a positive number", positional[1])
+	}
+	if *account != "" && *category != "" {
+		return validationErrorf("a goal links to an account or a category, not both")
+	}
+	if *saved < 0 {
+		return validationErrorf("-saved cannot be negative")
+	}
+
+	now := time.Now()
+	goal.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if *start != "" {
+		if goal.StartDate, err = h.Config.ParseDate(*start); err != nil {
+			return validationErrorf("invalid start date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+	if *by != "" {
+		if goal.TargetDate, err = h.Config.ParseDate(*by); err != nil {
+			return validationErrorf("invalid target date. Use %s format", h.Config.InputDateFormat)
+		}
+		if !goal.TargetDate.After(goal.StartDate) {
+			return validationErrorf("target date must be after the start date (%s)", h.Config.FormatDate(goal.StartDate))
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	existing, err := h.goalSvc.Get(goal.Name)
+	if err != nil {
+		return dbErrorf("failed to retrieve goal: %w", err)
+	}
+	if existing != nil {
+		return validationErrorf("goal '%s' already exists", goal.Name)
+	}
+	if err := h.goalSvc.Add(goal, *saved); err != nil {
+		return dbErrorf("failed to add goal: %w", err)
+	}
+	return c.reportGoal(goal, "✅ Goal '%s' added")
+}
+
+func (c *GoalCommand) handleContribute(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	date := fs.String("date", "", "Date of the contribution (default: today)")
+	note := fs.String("note", "", "Note stored with the contribution")
+	withdraw := fs.Bool("withdraw", false, "Take the amount out of the goal instead")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("goal contribute needs <name> <amount>")
+	}
+	amount, err := strconv.ParseFloat(positional[1], 64)
+	if err != nil || amount <= 0 {
+		return validationErrorf("invalid amount '%s': must be a positive number", positional[1])
+	}
+	if *withdraw {
+		amount = -amount
+	}
+	when := time.Now()
+	if *date != "" {
+		if when, err = h.Config.ParseDate(*date); err != nil {
+			return validationErrorf("invalid date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	goal, err := c.getGoal(positional[0])
+	if err != nil {
+		return err
+	}
+	if _, err := h.goalSvc.Contribute(goal, amount, when, *note); err != nil {
+		return dbErrorf("failed to contribute: %w", err)
+	}
+
+	message := fmt.Sprintf("✅ Put %s towards '%%s'", h.money(amount))
+	if *withdraw {
+		message = fmt.Sprintf("✅ Took %s out of '%%s'", h.money(-amount))
+	}
+	return c.reportGoal(goal, message)
+}
+
+// reportGoal shows the progress of a goal after a change, or its record
+func (c *GoalCommand) reportGoal(goal *models.Goal, message string) error {
+	h := c.Handler
+	progress, err := h.goalSvc.ProgressOf(goal, time.Now())
+	if err != nil {
+		return dbErrorf("failed to calculate progress: %w", err)
+	}
+	if h.IsMachineOutput() {
+		return h.WriteRecord(goalRecord(progress))
+	}
+	h.printf(message+"\n", goal.Name)
+	c.printProgress(progress)
+	return nil
+}
+
+func (c *GoalCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	progress, err := h.goalSvc.Progress(time.Now())
+	if err != nil {
+		return dbErrorf("failed to load goals: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(progress))
+		for i := range progress {
+			records = append(records, goalRecord(&progress[i]))
+		}
+		return h.WriteRecords(goalColumns, records)
+	}
+
+	if len(progress) == 0 {
+		h.println("No goals yet. Add one with 'atad goal add <name> <target> -by <date>'.")
+		return nil
+	}
+	h.println("\n🎯 Savings Goals")
+	h.println("─────────────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-20s %12s %12s %6s  %-10s %12s  %-9s  %s\n", "Goal", "Saved", "Target", "", "By", "Monthly", "Status", "Linked to")
+	h.println("─────────────────────────────────────────────────────────────────────────────────────────────────")
+	for i := range progress {
+		p := &progress[i]
+		by, monthly := "-", "-"
+		if p.Goal.HasDeadline() {
+			by = h.Config.FormatDate(p.Goal.TargetDate)
+			if !p.Reached() {
+				monthly = h.money(p.RequiredMonthly())
+			}
+		}
+		link := p.Goal.Link()
+		if link == "" {
+			link = "-"
+		}
+		h.printf("%-20s %12s %12s %5.0f%%  %-10s %12s  %-9s  %s\n", TruncateString(p.Goal.Name, 20), h.money(p.Saved()),
+			h.money(p.Goal.Target), p.Percent(), by, monthly, p.Status(), link)
+	}
+	return nil
+}
+
+func (c *GoalCommand) handleStatus(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("goal status takes at most one goal <name>")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	now := time.Now()
+	var progress []service.GoalProgress
+	if len(positional) == 1 {
+		goal, err := c.getGoal(positional[0])
+		if err != nil {
+			return err
+		}
+		p, err := h.goalSvc.ProgressOf(goal, now)
+		if err != nil {
+			return dbErrorf("failed to calculate progress: %w", err)
+		}
+		progress = append(progress, *p)
+	} else if progress, err = h.goalSvc.Progress(now); err != nil {
+		return dbErrorf("failed to load goals: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(progress))
+		for i := range progress {
+			records = append(records, goalRecord(&progress[i]))
+		}
+		return h.WriteRecords(goalColumns, records)
+	}
+
+	if len(progress) == 0 {
+		h.println("No goals yet. Add one with 'atad goal add <name> <target> -by <date>'.")
+		return nil
+	}
+	for i := range progress {
+		h.printf("\n🎯 %s\n", progress[i].Goal.Name)
+		c.printProgress(&progress[i])
+	}
+	if len(positional) == 1 {
+		return c.printContributions(progress[0].Goal)
+	}
+	return nil
+}
+
+// printProgress shows how far a goal has come and what it needs
+func (c *GoalCommand) printProgress(p *service.GoalProgress) {
+	h := c.Handler
+	goal := p.Goal
+	h.printf("   %s %.0f%%\n", progressBar(p.Percent()), p.Percent())
+	h.printf("   Saved:     %s of %s", h.money(p.Saved()), h.money(goal.Target))
+	if p.Linked != 0 {
+		h.printf(" (%s by hand, %s from %s)", h.money(p.Manual), h.money(p.Linked), goal.Link())
+	}
+	h.println()
+	if link := goal.Link(); link != "" && p.Linked == 0 {
+		h.printf("   Linked to: %s since %s\n", link, h.Config.FormatDate(goal.StartDate))
+	}
+	if p.Reached() {
+		h.println("   Status:    🎉 reached")
+		return
+	}
+	h.printf("   Remaining: %s\n", h.money(p.Remaining()))
+	if !goal.HasDeadline() {
+		if date, ok := p.Projected(); ok {
+			h.printf("   At the pace so far it is reached around %s\n", h.Config.FormatDate(date))
+		}
+		return
+	}
+
+	h.printf("   Target:    %s\n", h.Config.FormatDate(goal.TargetDate))
+	switch p.Status() {
+	case service.GoalOverdue:
+		h.printf("   Status:    ⏰ overdue since %s; %s still to save\n", h.Config.FormatDate(goal.TargetDate), h.money(p.Remaining()))
+	case service.GoalOnTrack:
+		h.printf("   Status:    ✅ on track (%s expected by now)\n", h.money(p.Expected()))
+		h.printf("   Monthly:   %s to finish on time\n", h.money(p.RequiredMonthly()))
+	default:
+		h.printf("   Status:    ⚠️  behind by %s (%s expected by now)\n", h.money(p.Expected()-p.Saved()), h.money(p.Expected()))
+		h.printf("   Monthly:   %s to finish on time\n", h.money(p.RequiredMonthly()))
+		if date, ok := p.Projected(); ok {
+			h.printf("   At the pace so far it is reached around %s\n", h.Config.FormatDate(date))
+		}
+	}
+}
+
+// printContributions lists the contributions recorded by hand for a goal
+func (c *GoalCommand) printContributions(goal *models.Goal) error {
+	h := c.Handler
+	contributions, err := h.goalSvc.Contributions(goal)
+	if err != nil {
+		return dbErrorf("failed to load contributions: %w", err)
+	}
+	if len(contributions) == 0 {
+		return nil
+	}
+	h.println("\n   Contributions")
+	for _, contribution := range contributions {
+		h.printf("   %-10s %12s  %s\n", h.Config.FormatDate(contribution.Date), h.money(contribution.Amount), contribution.Note)
+	}
+	return nil
+}
+
+func (c *GoalCommand) handleDelete(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("goal delete needs a goal <name>")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	goal, err := c.getGoal(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := h.goalSvc.Delete(goal.Name); err != nil {
+		return dbErrorf("failed to delete goal: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"goal", goal.Name}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted goal '%s' and its contributions\n", goal.Name)
+	return nil
+}
+
+// getGoal finds a goal by name, failing when there is none
+func (c *GoalCommand) getGoal(name string) (*models.Goal, error) {
+	goal, err := c.Handler.goalSvc.Get(name)
+	if err != nil {
+		return nil, dbErrorf("failed to retrieve goal: %w", err)
+	}
+	if goal == nil {
+		return nil, notFoundErrorf("goal '%s' not found", name)
+	}
+	return goal, nil
+}
+
+// goalColumns are the machine-readable fields of a goal's progress
+var goalColumns = []string{"id", "name", "target", "target_date", "account", "category", "start_date",
+	"saved", "manual", "linked", "remaining", "percent", "expected", "required_monthly", "status"}
+
+func goalRecord(p *service.GoalProgress) Record {
+	var targetDate interface{}
+	if p.Goal.HasDeadline() {
+		targetDate = p.Goal.TargetDate
+	}
+	return Record{
+		{"id", p.Goal.ID},
+		{"name", p.Goal.Name},
+		{"target", p.Goal.Target},
+		{"target_date", targetDate},
+		{"account", p.Goal.Account},
+		{"category", p.Goal.Category},
+		{"start_date", p.Goal.StartDate},
+		{"saved", p.Saved()},
+		{"manual", p.Manual},
+		{"linked", p.Linked},
+		{"remaining", p.Remaining()},
+		{"percent", p.Percent()},
+		{"expected", p.Expected()},
+		{"required_monthly", p.RequiredMonthly()},
+		{"status", p.Status()},
+	}
+}
@@ -0,0 +1,608 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// LoanCommand handles the 'loan' subcommands for debts and their payments
+type LoanCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *LoanCommand) handleAdd(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	rate := fs.Float64("rate", 0, "Annual interest rate in percent")
+	term := fs.Int("term", 0, "Term in months; omit for revolving debt such as a credit card")
+	payment := fs.Float64("payment", 0, "Monthly payment (default: the installment that pays the loan off over -term)")
+	category := fs.String("category", "", "Category of the payment transactions (default: the loan name)")
+	start := fs.String("start", "", "Date the principal was owed on; installments fall monthly from it (default: today)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("loan add needs <name> <principal>")
+	}
+	loan := &models.Loan{Name: positional[0], Rate: *rate, TermMonths: *term, Payment: *payment, Category: *category}
+	if loan.Principal, err = strconv.ParseFloat(positional[1], 64); err != nil || loan.Principal <= 0 {
+		return validationErrorf("invalid principal '%s': must be a positive number", positional[1])
+	}
+	if *term == 0 && *payment == 0 {
+		return usageErrorf("loan add needs -term <months> or -payment <amount>")
+	}
+	if *rate < 0 || *rate > 100 {
+		return validationErrorf("-rate must be a percentage between 0 and 100")
+	}
+	if *term < 0 || *payment < 0 {
+		return validationErrorf("-term and -payment cannot be negative")
+	}
+	if loan.Category == "" {
+		loan.Category = loan.Name
+	}
+	if loan.Payment == 0 {
+		loan.Payment = models.MonthlyPayment(loan.Principal, loan.Rate, loan.TermMonths)
+	}
+	if interest := loan.Principal * loan.MonthlyRate(); loan.Payment <= interest {
+		return validationErrorf("a payment of %s does not cover the %s interest of the first month", h.money(loan.Payment), h.money(interest))
+	}
+
+	now := time.Now()
+	loan.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if *start != "" {
+		if loan.StartDate, err = h.Config.ParseDate(*start); err != nil {
+			return validationErrorf("invalid start date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	existing, err := h.loanSvc.Get(loan.Name)
+	if err != nil {
+		return dbErrorf("failed to retrieve loan: %w", err)
+	}
+	if existing != nil {
+		return validationErrorf("loan '%s' already exists", loan.Name)
+	}
+	if existing, err = h.loanSvc.GetByCategory(loan.Category); err != nil {
+		return dbErrorf("failed to retrieve loan: %w", err)
+	}
+	if existing != nil {
+		return validationErrorf("category '%s' already holds the payments of loan '%s'", loan.Category, existing.Name)
+	}
+	if err := h.loanSvc.Add(loan); err != nil {
+		return dbErrorf("failed to add loan: %w", err)
+	}
+
+	status, err := h.loanSvc.Status(loan, now)
+	if err != nil {
+		return dbErrorf("failed to calculate loan status: %w", err)
+	}
+	projection := status.Schedule(0)
+	if h.IsMachineOutput() {
+		return h.WriteRecord(loanRecord(status, projection))
+	}
+	h.printf("✅ Loan '%s' added\n", loan.Name)
+	c.printLoan(status, projection)
+	h.printf("   Payments are expenses in category '%s'; record one with 'atad loan pay %s'\n", loan.Category, loan.Name)
+	return nil
+}
+
+func (c *LoanCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	statuses, err := h.loanSvc.Statuses(time.Now())
+	if err != nil {
+		return dbErrorf("failed to load loans: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(loanColumns, loanRecords(statuses))
+	}
+	if len(statuses) == 0 {
+		h.println("No loans yet. Add one with 'atad loan add <name> <principal> -rate <percent> -term <months>'.")
+		return nil
+	}
+	h.println("\n💳 Loans")
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-20s %12s %12s %7s %11s %5s  %-10s  %s\n", "Loan", "Principal", "Owed", "Rate", "Payment", "Paid", "Payoff", "Category")
+	h.println("──────────────────────────────────────────────────────────────────────────────────────────")
+	for _, status := range statuses {
+		loan := status.Loan
+		h.printf("%-20s %12s %12s %6.2f%% %11s %4.0f%%  %-10s  %s\n", TruncateString(loan.Name, 20), h.money(loan.Principal),
+			h.money(status.Owed()), loan.Rate, h.money(loan.Payment), status.Percent(), c.payoffLabel(status, status.Schedule(0)), loan.Category)
+	}
+	return nil
+}
+
+func (c *LoanCommand) handlePay(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	date := fs.String("date", "", "Date of the payment (default: today)")
+	account := fs.String("account", "", "Account the payment is made from")
+	description := fs.String("desc", "", "Description of the payment transaction (default: '<name> payment')")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return usageErrorf("loan pay needs <name> [amount]")
+	}
+	when := time.Now()
+	if *date != "" {
+		if when, err = h.Config.ParseDate(*date); err != nil {
+			return validationErrorf("invalid date. Use %s format", h.Config.InputDateFormat)
+		}
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	loan, err := c.getLoan(positional[0])
+	if err != nil {
+		return err
+	}
+	amount := loan.Payment
+	if len(positional) == 2 {
+		if amount, err = strconv.ParseFloat(positional[1], 64); err != nil || amount <= 0 {
+			return validationErrorf("invalid amount '%s': must be a positive number", positional[1])
+		}
+	}
+
+	tx, err := h.loanSvc.Pay(loan, amount, when, *account, *description)
+	if err != nil {
+		return dbErrorf("failed to save payment: %w", err)
+	}
+	status, err := h.loanSvc.Status(loan, time.Now())
+	if err != nil {
+		return dbErrorf("failed to calculate loan status: %w", err)
+	}
+	payment, _ := status.Payment(tx.ID)
+
+	if h.IsMachineOutput() {
+		if err := h.WriteRecord(Record{
+			{"transaction_id", tx.ID},
+			{"loan", loan.Name},
+			{"date", tx.Date},
+			{"amount", tx.Amount},
+			{"interest", payment.Interest},
+			{"principal", payment.Principal},
+			{"excess", payment.Excess},
+			{"balance", payment.Balance},
+			{"owed", status.Owed()},
+		}); err != nil {
+			return err
+		}
+		h.raiseAlerts(tx)
+		return nil
+	}
+
+	h.printf("✅ Paid %s towards '%s' on %s\n", h.money(amount), loan.Name, h.Config.FormatDate(tx.Date))
+	h.printf("   Interest:  %s\n", h.money(payment.Interest))
+	h.printf("   Principal: %s\n", h.money(payment.Principal))
+	if payment.Excess > 0 {
+		h.warnf("%s of the payment is more than '%s' owed; it is kept as a credit", h.money(payment.Excess), loan.Name)
+	}
+	if status.PaidOff() {
+		h.printf("   🎉 '%s' is paid off\n", loan.Name)
+	} else {
+		h.printf("   Owed:      %s\n", h.money(status.Owed()))
+	}
+	h.raiseAlerts(tx)
+	return nil
+}
+
+func (c *LoanCommand) handleStatus(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("loan status needs a loan <name>")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	loan, err := c.getLoan(positional[0])
+	if err != nil {
+		return err
+	}
+	status, err := h.loanSvc.Status(loan, time.Now())
+	if err != nil {
+		return dbErrorf("failed to calculate loan status: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(status.Payments))
+		for _, p := range status.Payments {
+			records = append(records, Record{
+				{"transaction_id", p.Transaction.ID},
+				{"date", p.Transaction.Date},
+				{"amount", p.Transaction.Amount},
+				{"interest", p.Interest},
+				{"principal", p.Principal},
+				{"excess", p.Excess},
+				{"balance", p.Balance},
+			})
+		}
+		return h.WriteRecords([]string{"transaction_id", "date", "amount", "interest", "principal", "excess", "balance"}, records)
+	}
+
+	h.printf("\n💳 %s\n", loan.Name)
+	c.printLoan(status, status.Schedule(0))
+	if len(status.Payments) == 0 {
+		h.printf("\n   No payments yet. Record one with 'atad loan pay %s'.\n", loan.Name)
+		return nil
+	}
+	h.println("\n   Payments")
+	h.printf("   %-10s %12s %12s %12s %12s\n", "Date", "Amount", "Interest", "Principal", "Balance")
+	for _, p := range status.Payments {
+		h.printf("   %-10s %12s %12s %12s %12s\n", h.Config.FormatDate(p.Transaction.Date), h.money(p.Transaction.Amount),
+			h.money(p.Interest), h.money(p.Principal), h.money(p.Balance))
+	}
+	return nil
+}
+
+func (c *LoanCommand) handleSchedule(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	extra := fs.Float64("extra", 0, "Pay this much more every month (what-if)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("loan schedule needs a loan <name>")
+	}
+	if *extra < 0 {
+		return validationErrorf("-extra cannot be negative")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	loan, err := c.getLoan(positional[0])
+	if err != nil {
+		return err
+	}
+	status, err := h.loanSvc.Status(loan, time.Now())
+	if err != nil {
+		return dbErrorf("failed to calculate loan status: %w", err)
+	}
+	projection := status.Schedule(*extra)
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(projection.Installments))
+		for _, i := range projection.Installments {
+			records = append(records, Record{
+				{"number", i.Number},
+				{"date", i.Date},
+				{"payment", i.Payment},
+				{"interest", i.Interest},
+				{"principal", i.Principal},
+				{"balance", i.Balance},
+			})
+		}
+		return h.WriteRecords([]string{"number", "date", "payment", "interest", "principal", "balance"}, records)
+	}
+
+	h.printf("\n📅 Amortization schedule for %s\n", loan.Name)
+	if status.PaidOff() {
+		h.println("Nothing is owed any more.")
+		return nil
+	}
+	if !projection.PaidOff {
+		h.printf("A payment of %s a month never pays off the %s owed.\n", h.money(loan.Payment+*extra), h.money(status.Owed()))
+		return nil
+	}
+	h.println("──────────────────────────────────────────────────────────────────")
+	h.printf("%4s  %-10s %12s %12s %12s %12s\n", "#", "Date", "Payment", "Interest", "Principal", "Balance")
+	h.println("──────────────────────────────────────────────────────────────────")
+	for _, i := range projection.Installments {
+		h.printf("%4d  %-10s %12s %12s %12s %12s\n", i.Number, h.Config.FormatDate(i.Date), h.money(i.Payment),
+			h.money(i.Interest), h.money(i.Principal), h.money(i.Balance))
+	}
+	h.println("──────────────────────────────────────────────────────────────────")
+	h.printf("Paid off on %s after %s more, with %s interest\n", h.Config.FormatDate(projection.PayoffDate()),
+		plural(len(projection.Installments), "payment"), h.money(projection.Interest()))
+
+	if *extra > 0 {
+		base := status.Schedule(0)
+		if !base.PaidOff {
+			h.printf("\nPaying %s more a month is what pays the loan off at all.\n", h.money(*extra))
+			return nil
+		}
+		h.printf("\nPaying %s more a month pays it off on %s instead of %s: %s sooner and %s less interest.\n",
+			h.money(*extra), h.Config.FormatDate(projection.PayoffDate()), h.Config.FormatDate(base.PayoffDate()),
+			plural(len(base.Installments)-len(projection.Installments), "month"), h.money(base.Interest()-projection.Interest()))
+	}
+	return nil
+}
+
+func (c *LoanCommand) handleCompare(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	extra := fs.Float64("extra", 0, "Pay this much more every month on top of the loan payments")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *extra < 0 {
+		return validationErrorf("-extra cannot be negative")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	statuses, err := h.loanSvc.Statuses(time.Now())
+	if err != nil {
+		return dbErrorf("failed to load loans: %w", err)
+	}
+	results := service.ComparePayoff(statuses, *extra)
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(strategyColumns, strategyRecords(results, time.Now()))
+	}
+	c.printStrategies(statuses, results, *extra)
+	return nil
+}
+
+func (c *LoanCommand) handleDelete(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("loan delete needs a loan <name>")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	loan, err := c.getLoan(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := h.loanSvc.Delete(loan.Name); err != nil {
+		return dbErrorf("failed to delete loan: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"loan", loan.Name}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted loan '%s'; its payments stay as transactions in '%s'\n", loan.Name, loan.Category)
+	return nil
+}
+
+// printLoan shows what is owed on a loan and when it is paid off
+func (c *LoanCommand) printLoan(status *service.LoanStatus, projection *service.Projection) {
+	h := c.Handler
+	loan := status.Loan
+	h.printf("   %s %.0f%% of the principal paid off\n", progressBar(status.Percent()), status.Percent())
+	h.printf("   Owed:      %s of %s", h.money(status.Owed()), h.money(loan.Principal))
+	if status.Accrued > 0 {
+		h.printf(" (%s interest due)", h.money(status.Accrued))
+	}
+	h.println()
+	terms := fmt.Sprintf("%.2f%% a year, %s a month from %s", loan.Rate, h.money(loan.Payment), h.Config.FormatDate(loan.StartDate))
+	if loan.HasTerm() {
+		terms += " over " + plural(loan.TermMonths, "month")
+	}
+	h.printf("   Terms:     %s\n", terms)
+	if len(status.Payments) > 0 {
+		h.printf("   Paid:      %s principal and %s interest in %s\n", h.money(status.PrincipalPaid()),
+			h.money(status.InterestPaid()), plural(len(status.Payments), "payment"))
+	}
+	if status.Credit > 0 {
+		h.printf("   Credit:    %s paid beyond what was owed\n", h.money(status.Credit))
+	}
+	if status.PaidOff() {
+		h.println("   Status:    🎉 paid off")
+		return
+	}
+	if !projection.PaidOff {
+		h.printf("   Payoff:    ⚠️  never at %s a month; the payment does not outgrow the interest\n", h.money(loan.Payment))
+		return
+	}
+	h.printf("   Payoff:    %s, with %s more interest%s\n", h.Config.FormatDate(projection.PayoffDate()),
+		h.money(projection.Interest()), c.scheduleNote(status, projection))
+}
+
+// scheduleNote compares the projected payoff of a loan with a term to the
+// date of its last scheduled installment
+func (c *LoanCommand) scheduleNote(status *service.LoanStatus, projection *service.Projection) string {
+	loan := status.Loan
+	if !loan.HasTerm() || len(projection.Installments) == 0 {
+		return ""
+	}
+	months := loan.TermMonths - projection.Installments[len(projection.Installments)-1].Number
+	switch {
+	case months > 0:
+		return fmt.Sprintf(" (%s ahead of schedule)", plural(months, "month"))
+	case months < 0:
+		return fmt.Sprintf(" (%s behind schedule)", plural(-months, "month"))
+	}
+	return " (on schedule)"
+}
+
+// payoffLabel is the projected payoff date of a loan, or why there is none
+func (c *LoanCommand) payoffLabel(status *service.LoanStatus, projection *service.Projection) string {
+	switch {
+	case status.PaidOff():
+		return "paid off"
+	case !projection.PaidOff:
+		return "never"
+	}
+	return c.Handler.Config.FormatDate(projection.PayoffDate())
+}
+
+// printStrategies compares paying off the open debts with each strategy
+func (c *LoanCommand) printStrategies(statuses []*service.LoanStatus, results []service.StrategyResult, extra float64) {
+	h := c.Handler
+	now := time.Now()
+	owed, payments, open := 0.0, 0.0, 0
+	for _, status := range statuses {
+		if !status.PaidOff() {
+			owed += status.Owed()
+			payments += status.Loan.Payment
+			open++
+		}
+	}
+	if open == 0 {
+		h.println("No debts to pay off.")
+		return
+	}
+
+	budget := h.money(payments)
+	if extra > 0 {
+		budget += fmt.Sprintf(" + %s extra", h.money(extra))
+	}
+	h.printf("\n💳 Paying off %s across %s with %s a month\n", h.money(owed), plural(open, "debt"), budget)
+	h.println("─────────────────────────────────────────────────────────────────────────────────")
+	h.printf("%-10s %-10s %7s %12s  %s\n", "Strategy", "Debt-free", "Months", "Interest", "Order")
+	h.println("─────────────────────────────────────────────────────────────────────────────────")
+	for _, r := range results {
+		if !r.PaidOff {
+			h.printf("%-10s %-10s %7s %12s  %s\n", r.Strategy, "never", "-", "-", "-")
+			continue
+		}
+		h.printf("%-10s %-10s %7d %12s  %s\n", r.Strategy, monthLabel(now, r.Months), r.Months, h.money(r.Interest), payoffOrder(r))
+	}
+	h.println("─────────────────────────────────────────────────────────────────────────────────")
+
+	minimum, avalanche, snowball := results[0], results[1], results[2]
+	if !avalanche.PaidOff || !snowball.PaidOff {
+		return
+	}
+	if minimum.PaidOff && minimum.Interest > avalanche.Interest {
+		freed := "freed-up payments"
+		if extra > 0 {
+			freed = "the extra and freed-up payments"
+		}
+		h.printf("Putting %s to work saves up to %s interest and ends the debt %s sooner.\n",
+			freed, h.money(minimum.Interest-avalanche.Interest), plural(minimum.Months-avalanche.Months, "month"))
+	}
+	if saved := snowball.Interest - avalanche.Interest; saved >= 0.01 {
+		h.printf("The avalanche costs %s less interest than the snowball.\n", h.money(saved))
+	} else {
+		h.println("The avalanche and the snowball cost the same interest here.")
+	}
+	if first := avalanche.Payoffs[0].Months - snowball.Payoffs[0].Months; first > 0 {
+		h.printf("The snowball clears its first debt (%s) %s earlier, in %s.\n", snowball.Payoffs[0].Loan.Name, plural(first, "month"),
+			monthLabel(now, snowball.Payoffs[0].Months))
+	}
+}
+
+// payoffOrder lists the debts of a strategy in the order they are paid off
+func payoffOrder(r service.StrategyResult) string {
+	names := make([]string, 0, len(r.Payoffs))
+	for _, p := range r.Payoffs {
+		names = append(names, p.Loan.Name)
+	}
+	return strings.Join(names, " → ")
+}
+
+// monthLabel names the month months after now, e.g. "Mar 2031"
+func monthLabel(now time.Time, months int) string {
+	return time.Date(now.Year(), now.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC).Format("Jan 2006")
+}
+
+// getLoan finds a loan by name, failing when there is none
+func (c *LoanCommand) getLoan(name string) (*models.Loan, error) {
+	loan, err := c.Handler.loanSvc.Get(name)
+	if err != nil {
+		return nil, dbErrorf("failed to retrieve loan: %w", err)
+	}
+	if loan == nil {
+		return nil, notFoundErrorf("loan '%s' not found", name)
+	}
+	return loan, nil
+}
+
+// loanColumns are the machine-readable fields of a loan's status
+var loanColumns = []string{"id", "name", "principal", "rate", "term_months", "payment", "category", "start_date",
+	"owed", "accrued", "principal_paid", "interest_paid", "percent_paid", "payoff_date", "interest_left", "paid_off"}
+
+func loanRecord(status *service.LoanStatus, projection *service.Projection) Record {
+	loan := status.Loan
+	var payoff, interestLeft interface{}
+	if projection.PaidOff && !status.PaidOff() {
+		payoff, interestLeft = projection.PayoffDate(), projection.Interest()
+	}
+	return Record{
+		{"id", loan.ID},
+		{"name", loan.Name},
+		{"principal", loan.Principal},
+		{"rate", loan.Rate},
+		{"term_months", loan.TermMonths},
+		{"payment", loan.Payment},
+		{"category", loan.Category},
+		{"start_date", loan.StartDate},
+		{"owed", status.Owed()},
+		{"accrued", status.Accrued},
+		{"principal_paid", status.PrincipalPaid()},
+		{"interest_paid", status.InterestPaid()},
+		{"percent_paid", status.Percent()},
+		{"payoff_date", payoff},
+		{"interest_left", interestLeft},
+		{"paid_off", status.PaidOff()},
+	}
+}
+
+func loanRecords(statuses []*service.LoanStatus) []Record {
+	records := make([]Record, 0, len(statuses))
+	for _, status := range statuses {
+		records = append(records, loanRecord(status, status.Schedule(0)))
+	}
+	return records
+}
+
+// strategyColumns are the machine-readable fields of a payoff comparison
+var strategyColumns = []string{"strategy", "extra", "months", "debt_free", "interest", "order"}
+
+func strategyRecords(results []service.StrategyResult, now time.Time) []Record {
+	records := make([]Record, 0, len(results))
+	for _, r := range results {
+		var months, debtFree, interest interface{}
+		if r.PaidOff {
+			months, interest = r.Months, r.Interest
+			debtFree = time.Date(now.Year(), now.Month()+time.Month(r.Months), 1, 0, 0, 0, 0, time.UTC)
+		}
+		records = append(records, Record{
+			{"strategy", r.Strategy},
+			{"extra", r.Extra},
+			{"months", months},
+			{"debt_free", debtFree},
+			{"interest", interest},
+			{"order", payoffOrder(r)},
+		})
+	}
+	return records
+}
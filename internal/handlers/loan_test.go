@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlural(t *testing.T) {
+	for _, tt := range []struct {
+		count int
+		want  string
+	}{
+		{0, "0 payments"},
+		{1, "1 payment"},
+		{2, "2 payments"},
+	} {
+		if got := plural(tt.count, "payment"); got != tt.want {
+			t.Errorf("plural(%d) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoanStatusCountsOnePayment(t *testing.T) {
+	app := newTestApp(t)
+	app.mustRun("loan", "add", "Car", "1200", "-rate", "0", "-term", "12")
+	app.mustRun("loan", "pay", "Car")
+
+	stdout := app.mustRun("loan", "status", "Car")
+	if !strings.Contains(stdout, "in 1 payment\n") {
+		t.Errorf("stdout = %q, want the single payment counted", stdout)
+	}
+	if !strings.Contains(stdout, "over 12 months") {
+		t.Errorf("stdout = %q, want the term", stdout)
+	}
+}
+
+func TestLoanPayKeepsOverpaymentAsCredit(t *testing.T) {
+	app := newTestApp(t)
+	app.mustRun("loan", "add", "Car", "100", "-rate", "0", "-term", "2")
+
+	code, _, stderr := app.run("loan", "pay", "Car", "130")
+	if code != ExitOK {
+		t.Fatalf("exit code = %d (stderr %q)", code, stderr)
+	}
+	if !strings.Contains(stderr, "kept as a credit") {
+		t.Errorf("stderr = %q, want the overpayment reported", stderr)
+	}
+
+	stdout := app.mustRun("loan", "status", "Car")
+	if !strings.Contains(stdout, "Credit:") || !strings.Contains(stdout, "30.00") {
+		t.Errorf("stdout = %q, want the 30.00 credit", stdout)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Output formats selected with the global --output flag
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputTSV   = "tsv"
+)
+
+// OutputFormats lists the values accepted by --output
+var OutputFormats = []string{OutputTable, OutputJSON, OutputCSV, OutputTSV}
+
+// ValidOutputFormat reports whether format is accepted by --output
+func ValidOutputFormat(format string) bool {
+	for _, f := range OutputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Field is a named value in a machine-readable record. Field names are part of
+// the CLI's stable interface; do not rename them.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is an ordered list of fields, rendered as a JSON object or a CSV/TSV row
+type Record []Field
+
+// MarshalJSON keeps the field order stable
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(jsonValue(field.Value))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonValue formats dates as ISO 8601 days
+func jsonValue(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		if t.IsZero() {
+			return nil
+		}
+		return t.Format("2006-01-02")
+	}
+	return v
+}
+
+// textValue renders a field for CSV/TSV output without truncation
+func textValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format("2006-01-02")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// IsMachineOutput reports whether the handler prints machine-readable output
+func (h *CLIHandler) IsMachineOutput() bool {
+	return h.Output != "" && h.Output != OutputTable
+}
+
+// WriteRecords prints records in the selected machine-readable format. Columns
+// are always written, even when there are no records, so consumers see a stable schema.
+func (h *CLIHandler) WriteRecords(columns []string, records []Record) error {
+	return writeRecords(h.Stdout, h.Output, columns, records)
+}
+
+// WriteRecord prints a single record; in JSON mode it is an object instead of an array
+func (h *CLIHandler) WriteRecord(record Record) error {
+	if h.Output == OutputJSON {
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(h.Stdout, string(data))
+		return err
+	}
+	columns := make([]string, len(record))
+	for i, field := range record {
+		columns[i] = field.Name
+	}
+	return writeRecords(h.Stdout, h.Output, columns, []Record{record})
+}
+
+func writeRecords(w io.Writer, format string, columns []string, records []Record) error {
+	switch format {
+	case OutputJSON:
+		if records == nil {
+			records = []Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputCSV, OutputTSV:
+		writer := csv.NewWriter(w)
+		if format == OutputTSV {
+			writer.Comma = '\t'
+		}
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		for _, record := range records {
+			row := make([]string, len(record))
+			for i, field := range record {
+				row[i] = textValue(field.Value)
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unsupported output format '%s'", format)
+	}
+}
+
+// transactionColumns are the machine-readable fields of a transaction
+var transactionColumns = []string{"id", "date", "type", "description", "category", "account", "status", "amount"}
+
+// transactionRecord converts a transaction to a machine-readable record
+func transactionRecord(tx *models.Transaction) Record {
+	return Record{
+		{"id", tx.ID},
+		{"date", tx.Date},
+		{"type", tx.Type},
+		{"description", tx.Description},
+		{"category", tx.Category},
+		{"account", tx.Account},
+		{"status", tx.Status},
+		{"amount", tx.Amount},
+	}
+}
+
+// writeTransactions prints transactions in the selected machine-readable format
+func (h *CLIHandler) writeTransactions(transactions []*models.Transaction) error {
+	records := make([]Record, 0, len(transactions))
+	for _, tx := range transactions {
+		records = append(records, transactionRecord(tx))
+	}
+	return h.WriteRecords(transactionColumns, records)
+}
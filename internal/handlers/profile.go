@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// ProfileCommand handles the 'profile' subcommands. Each profile is a separate
+// database; the default profile is the database at DB_PATH or ~/.atad/atad.db.
+type ProfileCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *ProfileCommand) handleList(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	profiles, err := database.ListProfiles()
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+	active := h.ProfileName()
+
+	if h.IsMachineOutput() {
+		records := make([]Record, 0, len(profiles))
+		for _, name := range profiles {
+			path, _ := h.profilePath(name)
+			records = append(records, Record{{"name", name}, {"active", name == active}, {"path", path}})
+		}
+		return h.WriteRecords([]string{"name", "active", "path"}, records)
+	}
+
+	h.println("\n📒 Profiles")
+	h.println("─────────────────────────────────────────────────────────────")
+	for _, name := range profiles {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		path, _ := h.profilePath(name)
+		h.printf("%s %-20s %s\n", marker, name, path)
+	}
+	if active == "" {
+		h.printf("\n(--db %s is in use instead of a profile)\n", h.DBPath)
+	}
+	return nil
+}
+
+func (c *ProfileCommand) handleCreate(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("profile create needs a <name>")
+	}
+	name := positional[0]
+
+	if err := database.ValidateProfileName(name); err != nil {
+		return validationErrorf("%v", err)
+	}
+	if database.ProfileExists(name) {
+		return validationErrorf("profile '%s' already exists", name)
+	}
+	if err := database.CreateProfile(name); err != nil {
+		return dbErrorf("failed to create profile: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"name", name}, {"path", database.ProfilePath(name)}})
+	}
+	h.printf("✅ Created profile '%s' (%s)\n", name, database.ProfilePath(name))
+	h.infof("Switch to it with 'atad profile use %s' or pass --profile %s\n", name, name)
+	return nil
+}
+
+func (c *ProfileCommand) handleUse(args []string) error {
+	h := c.Handler
+	positional, err := h.parseArgs(h.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("profile use needs a <name>")
+	}
+	name := positional[0]
+
+	path, err := h.profilePath(name)
+	if err != nil {
+		return err
+	}
+	if name == database.DefaultProfile {
+		name = ""
+	}
+	if err := h.Config.Set("profile.active", name); err != nil {
+		return validationErrorf("%v", err)
+	}
+	if err := h.Config.Save(h.ConfigPath); err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = database.DefaultProfile
+	}
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"name", name}, {"path", path}})
+	}
+	h.printf("✅ Active profile is now '%s' (%s)\n", name, path)
+	return nil
+}
+
+func (c *ProfileCommand) handleDelete(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	force := fs.Bool("force", false, "Delete the profile even if it has transactions")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("profile delete needs a <name>")
+	}
+	name := positional[0]
+
+	if name == database.DefaultProfile {
+		return validationErrorf("the default profile cannot be deleted")
+	}
+	if _, err := h.profilePath(name); err != nil {
+		return err
+	}
+	if name == h.Config.ActiveProfile || name == h.ProfileName() {
+		return validationErrorf("profile '%s' is active; switch with 'atad profile use default' first", name)
+	}
+
+	if !*force {
+		count, err := profileTransactionCount(database.ProfilePath(name))
+		if err != nil {
+			return dbErrorf("failed to read profile '%s': %w", name, err)
+		}
+		if count > 0 {
+			return validationErrorf("profile '%s' has %d transactions; use -force to delete it", name, count)
+		}
+	}
+
+	if err := h.snapshotProfileDatabase(name, "delete"); err != nil {
+		return err
+	}
+	if err := database.DeleteProfile(name); err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"name", name}, {"deleted", true}})
+	}
+	h.printf("🗑️  Deleted profile '%s'\n", name)
+	return nil
+}
+
+// profileTotals summarises one profile in the consolidated report
+type profileTotals struct {
+	name         string
+	income       float64
+	expenses     float64
+	transactions int
+}
+
+// handleReport totals income and expenses of every profile. Databases are
+// opened read-only, so the report never changes a profile.
+func (c *ProfileCommand) handleReport(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	period := fs.String("period", h.Config.DefaultReportPeriod, "Time period: all, month, or year")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	now := time.Now()
+	startDate, endDate, err := periodRange(*period, now)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := database.ListProfiles()
+	if err != nil {
+		return dbErrorf("%w", err)
+	}
+
+	filter := repository.TransactionFilter{From: startDate, To: endDate}
+	var results []profileTotals
+	for _, name := range profiles {
+		path, err := h.profilePath(name)
+		if err != nil {
+			return err
+		}
+		totals, err := readProfileTotals(name, path, filter)
+		if err != nil {
+			return dbErrorf("failed to read profile '%s': %w", name, err)
+		}
+		results = append(results, totals)
+	}
+
+	if h.IsMachineOutput() {
+		columns := []string{"profile", "income", "expenses", "net", "transactions", "period_start", "period_end"}
+		records := make([]Record, 0, len(results))
+		for _, r := range results {
+			records = append(records, Record{
+				{"profile", r.name},
+				{"income", r.income},
+				{"expenses", r.expenses},
+				{"net", r.income - r.expenses},
+				{"transactions", r.transactions},
+				{"period_start", startDate},
+				{"period_end", endDate},
+			})
+		}
+		return h.WriteRecords(columns, records)
+	}
+
+	h.printf("\n📒 Consolidated Report - %s\n\n", periodName(*period, now))
+	h.printf("%-20s %14s %14s %14s %8s\n", "Profile", "Income", "Expenses", "Net", "Count")
+	h.println(strings.Repeat("─", 74))
+
+	var total profileTotals
+	for _, r := range results {
+		h.printf("%-20s %14s %14s %14s %8d\n", r.name, h.money(r.income), h.money(r.expenses), h.money(r.income-r.expenses), r.transactions)
+		total.income += r.income
+		total.expenses += r.expenses
+		total.transactions += r.transactions
+	}
+	h.println(strings.Repeat("─", 74))
+	h.printf("%-20s %14s %14s %14s %8d\n", "Total", h.money(total.income), h.money(total.expenses), h.money(total.income-total.expenses), total.transactions)
+	return nil
+}
+
+// readProfileTotals sums the transactions of a profile database. A profile
+// whose database has not been created yet has no transactions.
+func readProfileTotals(name, path string, filter repository.TransactionFilter) (profileTotals, error) {
+	totals := profileTotals{name: name}
+	if !fileExists(path) {
+		return totals, nil
+	}
+
+	db, err := database.OpenReadOnly(path)
+	if err != nil {
+		return totals, err
+	}
+	defer db.Close()
+
+	err = repository.NewTransactionRepository(db.DB).Iterate(filter, func(tx *models.Transaction) error {
+		totals.transactions++
+		if tx.Type == "income" {
+			totals.income += tx.Amount
+		} else {
+			totals.expenses += tx.Amount
+		}
+		return nil
+	})
+	return totals, err
+}
+
+// profileTransactionCount counts the transactions stored in a profile database
+func profileTransactionCount(path string) (int, error) {
+	db, err := database.OpenReadOnly(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	return repository.NewTransactionRepository(db.DB).Count()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// ReconcileCommand handles the 'reconcile' subcommand
+type ReconcileCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *ReconcileCommand) Run(args []string) error {
+	h := c.Handler
+	reconcileCmd := h.newFlagSet()
+	account := reconcileCmd.String("account", "", "Account to reconcile (required)")
+	statementEnd := reconcileCmd.String("statement-end", "", "Statement end date in "+h.Config.InputDateFormat+" format (required)")
+	balance := reconcileCmd.Float64("balance", 0, "Closing balance shown on the statement (required)")
+	opening := reconcileCmd.Float64("opening", 0, "Balance of the account before its first transaction here (remembered for later statements)")
+	clearIDs := reconcileCmd.String("clear", "", "Comma-separated transaction IDs to mark as cleared")
+	unclearIDs := reconcileCmd.String("unclear", "", "Comma-separated transaction IDs to mark as uncleared")
+	interactive := reconcileCmd.Bool("i", false, "Tick off transactions interactively")
+	finish := reconcileCmd.Bool("finish", false, "Lock the cleared transactions if they match the statement")
+
+	positional, err := h.parseArgs(reconcileCmd, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	set := map[string]bool{}
+	reconcileCmd.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *account == "" || *statementEnd == "" || !set["balance"] {
+		return usageErrorf("-account, -statement-end and -balance are required")
+	}
+
+	endDate, err := h.Config.ParseDate(*statementEnd)
+	if err != nil {
+		return validationErrorf("invalid statement end date. Use %s format", h.Config.InputDateFormat)
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+
+	if set["opening"] {
+		if err := h.reconcileSvc.SetOpeningBalance(*account, *opening); err != nil {
+			return dbErrorf("%w", err)
+		}
+	}
+	if err := c.setStatuses(*account, *clearIDs, models.StatusCleared); err != nil {
+		return err
+	}
+	if err := c.setStatuses(*account, *unclearIDs, models.StatusUncleared); err != nil {
+		return err
+	}
+
+	status, err := h.reconcileSvc.Status(*account, endDate, *balance)
+	if err != nil {
+		return dbErrorf("failed to load transactions: %w", err)
+	}
+
+	if *interactive {
+		return c.runInteractive(status)
+	}
+
+	c.printStatus(status)
+	if *finish {
+		return c.finish(status)
+	}
+	return nil
+}
+
+// setStatuses applies a status to a comma-separated list of transaction IDs,
+// all of which must belong to the account being reconciled
+func (c *ReconcileCommand) setStatuses(account, ids string, status string) error {
+	if ids == "" {
+		return nil
+	}
+	h := c.Handler
+
+	// Check every ID before changing any
+	var txIDs []int64
+	for _, field := range strings.Split(ids, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return validationErrorf("invalid transaction ID '%s'", field)
+		}
+		tx, err := h.txRepo.GetByID(id)
+		if err != nil {
+			return dbErrorf("transaction %d: %w", id, err)
+		}
+		if tx == nil {
+			return notFoundErrorf("transaction %d not found", id)
+		}
+		if tx.Account != account {
+			return validationErrorf("transaction %d belongs to account '%s', not '%s'", id, tx.Account, account)
+		}
+		txIDs = append(txIDs, id)
+	}
+
+	for _, id := range txIDs {
+		if err := h.txRepo.SetStatus(id, status); err != nil {
+			return dbErrorf("transaction %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// runInteractive lets the user toggle transactions from stdin until they finish or quit
+func (c *ReconcileCommand) runInteractive(status *service.ReconcileStatus) error {
+	h := c.Handler
+	reader := bufio.NewReader(h.Stdin)
+	for {
+		c.printStatus(status)
+		h.printf("\nToggle IDs (e.g. 3,5), 'a' clear all, 'f' finish, 'q' quit: ")
+
+		line, err := reader.ReadString('\n')
+		input := strings.TrimSpace(line)
+		if err != nil && input == "" {
+			return nil
+		}
+
+		switch input {
+		case "q", "":
+			return nil
+		case "f":
+			return c.finish(status)
+		case "a":
+			for _, tx := range status.Transactions {
+				if tx.Status != models.StatusCleared {
+					if err := h.reconcileSvc.Toggle(tx); err != nil {
+						fmt.Fprintf(h.Stderr, "Error: %v\n", err)
+					}
+				}
+			}
+		default:
+			for _, field := range strings.Split(input, ",") {
+				id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+				if err != nil {
+					fmt.Fprintf(h.Stderr, "Invalid transaction ID '%s'\n", field)
+					continue
+				}
+				tx := findTransaction(status.Transactions, id)
+				if tx == nil {
+					fmt.Fprintf(h.Stderr, "Transaction %d is not part of this reconciliation\n", id)
+					continue
+				}
+				if err := h.reconcileSvc.Toggle(tx); err != nil {
+					fmt.Fprintf(h.Stderr, "Error: %v\n", err)
+				}
+			}
+		}
+
+		refreshed, err := h.reconcileSvc.Status(status.Account, status.StatementDate, status.StatementBalance)
+		if err != nil {
+			return dbErrorf("failed to load transactions: %w", err)
+		}
+		status = refreshed
+	}
+}
+
+func (c *ReconcileCommand) finish(status *service.ReconcileStatus) error {
+	h := c.Handler
+	rec, err := h.reconcileSvc.Finish(status)
+	if err != nil {
+		return validationErrorf("cannot finish reconciliation: %w", err)
+	}
+	h.printf("\n🔒 Account '%s' reconciled to %s at %s. Cleared transactions are now locked.\n",
+		rec.Account, h.Config.FormatDate(rec.StatementDate), h.money(rec.StatementBalance))
+	return nil
+}
+
+func (c *ReconcileCommand) printStatus(status *service.ReconcileStatus) {
+	h := c.Handler
+	h.printf("\n🧾 Reconcile %s to %s\n", status.Account, h.Config.FormatDate(status.StatementDate))
+	h.println("─────────────────────────────────────────────────────────────────────────────")
+
+	if len(status.Transactions) == 0 {
+		h.println("No unreconciled transactions.")
+	} else {
+		h.printf("%-6s %-3s %-12s %-35s %12s\n", "ID", "", "Date", "Description", "Amount")
+		h.println("─────────────────────────────────────────────────────────────────────────────")
+		for _, tx := range status.Transactions {
+			mark := "[ ]"
+			if tx.Status == models.StatusCleared {
+				mark = "[x]"
+			}
+			h.printf("%-6d %-3s %-12s %-35s %12.2f\n",
+				tx.ID, mark, h.Config.FormatDate(tx.Date),
+				TruncateString(tx.Description, 35), tx.SignedAmount())
+		}
+	}
+
+	h.println("─────────────────────────────────────────────────────────────────────────────")
+	h.printf("Statement balance:  %s\n", h.money(status.StatementBalance))
+	if status.OpeningBalance != 0 {
+		h.printf("Opening balance:    %s\n", h.money(status.OpeningBalance))
+	}
+	h.printf("Cleared balance:    %s\n", h.money(status.ClearedBalance))
+	if status.Balanced() {
+		h.printf("Difference:         %s ✅\n", h.money(0))
+	} else {
+		h.printf("Difference:         %s ⚠️\n", h.money(status.Difference))
+	}
+}
+
+func findTransaction(transactions []*models.Transaction, id int64) *models.Transaction {
+	for _, tx := range transactions {
+		if tx.ID == id {
+			return tx
+		}
+	}
+	return nil
+}
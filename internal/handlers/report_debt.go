@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// handleDebt sums up every loan, when the debt is paid off on the current
+// payments and how the payoff strategies compare
+func (c *ReportCommand) handleDebt(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	extra := fs.Float64("extra", 0, "Compare the payoff strategies with this much more every month")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *extra < 0 {
+		return validationErrorf("-extra cannot be negative")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	now := time.Now()
+	statuses, err := h.loanSvc.Statuses(now)
+	if err != nil {
+		return dbErrorf("failed to load loans: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(loanColumns, loanRecords(statuses))
+	}
+
+	h.printf("\n💳 Debt Report - %s\n", h.Config.FormatDate(now))
+	if len(statuses) == 0 {
+		h.println("No loans yet. Add one with 'atad loan add <name> <principal> -rate <percent> -term <months>'.")
+		return nil
+	}
+	loans := &LoanCommand{Handler: h}
+	rule := "───────────────────────────────────────────────────────────────────────────────────────────────────────"
+	h.println(rule)
+	h.printf("%-18s %12s %7s %11s %-18s %14s %14s  %s\n", "Loan", "Owed", "Rate", "Payment", "Paid off", "Interest paid", "Interest left", "Payoff")
+	h.println(rule)
+	var owed, payments, interestPaid, interestLeft float64
+	for _, status := range statuses {
+		projection := status.Schedule(0)
+		left := "-"
+		if projection.PaidOff && !status.PaidOff() {
+			left = h.money(projection.Interest())
+			interestLeft += projection.Interest()
+		}
+		if !status.PaidOff() {
+			payments += status.Loan.Payment
+		}
+		owed += status.Owed()
+		interestPaid += status.InterestPaid()
+		h.printf("%-18s %12s %6.2f%% %11s %s %4.0f%% %14s %14s  %s\n", TruncateString(status.Loan.Name, 18), h.money(status.Owed()),
+			status.Loan.Rate, h.money(status.Loan.Payment), progressBar(status.Percent()), status.Percent(),
+			h.money(status.InterestPaid()), left, loans.payoffLabel(status, projection))
+	}
+	h.println(rule)
+	h.printf("%-18s %12s %7s %11s %-18s %14s %14s\n", "Total", h.money(owed), "", h.money(payments), "",
+		h.money(interestPaid), h.money(interestLeft))
+
+	results := service.ComparePayoff(statuses, *extra)
+	if minimum := results[0]; minimum.PaidOff && minimum.Months > 0 {
+		h.printf("\nDebt-free on the current payments in %s (%s).\n", monthLabel(now, minimum.Months), plural(minimum.Months, "month"))
+	}
+	loans.printStrategies(statuses, results, *extra)
+	return nil
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// varianceUnits names the periods of a variance report in sentences
+var varianceUnits = map[string]string{
+	models.PeriodWeekly:    "week",
+	models.PeriodMonthly:   "month",
+	models.PeriodQuarterly: "quarter",
+	models.PeriodYearly:    "year",
+}
+
+// handleBudgetVariance compares every budgeted category with its actual
+// spending, or income, over the last complete periods
+func (c *ReportCommand) handleBudgetVariance(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	count := fs.Int("periods", 6, "Number of complete periods to compare")
+	period := fs.String("period", models.PeriodMonthly, "Length of each period: weekly, monthly, quarterly or yearly")
+	budgetType := fs.String("type", models.BudgetExpense, "Compare expense budgets or income targets")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *count < 1 || *count > 60 {
+		return validationErrorf("-periods must be between 1 and 60")
+	}
+	if _, ok := varianceUnits[*period]; !ok {
+		return validationErrorf("-period must be 'weekly', 'monthly', 'quarterly' or 'yearly'")
+	}
+	if *budgetType != models.BudgetExpense && *budgetType != models.BudgetIncome {
+		return validationErrorf("-type must be 'expense' or 'income'")
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	report, err := h.summarySvc.Variance(time.Now(), *period, *budgetType, *count)
+	if err != nil {
+		return dbErrorf("failed to calculate budget variance: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(varianceColumns, varianceRecords(report))
+	}
+	c.printVariance(report, *count)
+	return nil
+}
+
+// varianceColumns are the machine-readable fields of a variance report, one
+// record per category and period with the category's summary repeated
+var varianceColumns = []string{"category", "type", "start_date", "end_date", "planned", "actual", "variance", "variance_pct",
+	"budgeted", "missed", "periods_missed", "periods_budgeted", "average_variance_pct", "trend"}
+
+func varianceRecords(report *service.VarianceReport) []Record {
+	var records []Record
+	for i := range report.Categories {
+		line := &report.Categories[i]
+		for _, p := range line.Periods {
+			records = append(records, Record{
+				{"category", line.Category},
+				{"type", line.Type},
+				{"start_date", p.Start},
+				{"end_date", p.End},
+				{"planned", roundTo(p.Planned, 2)},
+				{"actual", roundTo(p.Actual, 2)},
+				{"variance", roundTo(p.Variance(), 2)},
+				{"variance_pct", roundTo(p.VariancePercent(), 2)},
+				{"budgeted", p.Budgeted},
+				{"missed", line.Missed(p)},
+				{"periods_missed", line.MissedCount()},
+				{"periods_budgeted", line.BudgetedCount()},
+				{"average_variance_pct", roundTo(line.AveragePercent(), 2)},
+				{"trend", line.Trend()},
+			})
+		}
+	}
+	return records
+}
+
+// printVariance shows the variance of every period per category, the totals
+// and a chart of actual amounts as a share of the budget
+func (c *ReportCommand) printVariance(report *service.VarianceReport, count int) {
+	h := c.Handler
+	unit := varianceUnits[report.Period]
+	kind := "Budget"
+	if report.Type == models.BudgetIncome {
+		kind = "Income Target"
+	}
+	h.printf("\n📊 %s Variance - last %d %ss (%s)\n", kind, count, unit,
+		h.formatPeriod(report.Starts[0], report.Ends[len(report.Ends)-1]))
+	if len(report.Categories) == 0 {
+		h.printf("No budgets cover these %ss.\n", unit)
+		return
+	}
+
+	header := fmt.Sprintf("%-18s", "Category")
+	for _, start := range report.Starts {
+		header += fmt.Sprintf(" %7s", periodLabel(report.Period, start))
+	}
+	header += fmt.Sprintf(" %12s %12s %7s %6s  %s", "Planned", "Actual", "Avg", "Missed", "Trend")
+	rule := strings.Repeat("─", len([]rune(header)))
+	h.println(rule)
+	h.println(header)
+	h.println(rule)
+	for i := range report.Categories {
+		line := &report.Categories[i]
+		row := fmt.Sprintf("%-18s", TruncateString(line.Category, 18))
+		for _, p := range line.Periods {
+			cell := "-"
+			if p.Budgeted && p.Planned > 0 {
+				cell = fmt.Sprintf("%+.0f%%", p.VariancePercent())
+			}
+			row += fmt.Sprintf(" %7s", cell)
+		}
+		planned, actual := line.Totals()
+		row += fmt.Sprintf(" %12s %12s %+6.0f%% %6s  %s", h.money(planned), h.money(actual), line.AveragePercent(),
+			fmt.Sprintf("%d/%d", line.MissedCount(), line.BudgetedCount()), trendLabel(line.Trend()))
+		h.println(row)
+	}
+	h.println(rule)
+
+	h.println()
+	for i := range report.Categories {
+		line := &report.Categories[i]
+		if line.MissedCount() > 0 {
+			h.println(varianceSentence(line, unit, count))
+		}
+	}
+
+	DrawVarianceBarChart(h.Stdout, report.Categories)
+}
+
+// varianceSentence sums up a category, e.g. "Restaurants: over budget 4 of
+// the last 6 months, average +18%"
+func varianceSentence(line *service.CategoryVariance, unit string, count int) string {
+	missed := "over budget"
+	if line.Type == models.BudgetIncome {
+		missed = "below target"
+	}
+	of := fmt.Sprintf("of the last %d %ss", count, unit)
+	if budgeted := line.BudgetedCount(); budgeted < count {
+		of = fmt.Sprintf("of %d budgeted %ss", budgeted, unit)
+	}
+	return fmt.Sprintf("%s: %s %d %s, average %+.0f%%", line.Category, missed, line.MissedCount(), of, line.AveragePercent())
+}
+
+// periodLabel is a short column heading for a report period
+func periodLabel(period string, start time.Time) string {
+	switch period {
+	case models.PeriodWeekly:
+		return start.Format("02 Jan")
+	case models.PeriodQuarterly:
+		return fmt.Sprintf("Q%d %02d", (start.Month()-1)/3+1, start.Year()%100)
+	case models.PeriodYearly:
+		return start.Format("2006")
+	}
+	return start.Format("Jan 06")
+}
+
+// trendLabel adds an arrow to a variance trend
+func trendLabel(trend string) string {
+	switch trend {
+	case service.TrendRising:
+		return "↑ rising"
+	case service.TrendFalling:
+		return "↓ falling"
+	case service.TrendSteady:
+		return "→ steady"
+	}
+	return "-"
+}
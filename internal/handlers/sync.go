@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/finances"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// PlanCommand handles the 'plan' subcommand
+type PlanCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *PlanCommand) Run(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	path := fs.String("f", "", "Finances file describing the budgets and rules ('-' for stdin)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	plan, err := h.syncPlan(*path)
+	if err != nil {
+		return err
+	}
+	if h.IsMachineOutput() {
+		return h.WriteRecords(syncChangeColumns, syncChangeRecords(plan))
+	}
+	h.printSyncPlan(plan)
+	if len(plan.Changes) > 0 {
+		h.printf("\nPlan: %s. Run 'atad apply -f %s' to make them.\n", planCounts(plan), *path)
+	}
+	return nil
+}
+
+// ApplyCommand handles the 'apply' subcommand
+type ApplyCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *ApplyCommand) Run(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	path := fs.String("f", "", "Finances file describing the budgets and rules ('-' for stdin)")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	if *path == "-" && !*yes {
+		return usageErrorf("apply reads confirmation from stdin; use -yes with '-f -'")
+	}
+
+	plan, err := h.syncPlan(*path)
+	if err != nil {
+		return err
+	}
+	if len(plan.Changes) == 0 {
+		if h.IsMachineOutput() {
+			return h.WriteRecords(syncChangeColumns, nil)
+		}
+		h.println("No changes. The budgets and rules match the file.")
+		return nil
+	}
+
+	if !h.IsMachineOutput() {
+		h.printSyncPlan(plan)
+	}
+	if !*yes {
+		fmt.Fprintf(h.Stderr, "\nPlan: %s.\n", planCounts(plan))
+		fmt.Fprint(h.Stderr, "Continue? [y/N] ")
+		answer, _ := bufio.NewReader(h.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Fprintln(h.Stderr, "Apply cancelled")
+			return nil
+		}
+	}
+
+	if err := h.snapshot("apply"); err != nil {
+		return err
+	}
+	if err := h.syncSvc.Apply(plan); err != nil {
+		return dbErrorf("failed to apply changes: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecords(syncChangeColumns, syncChangeRecords(plan))
+	}
+	h.printf("\n✅ Applied %s\n", planCounts(plan))
+	return nil
+}
+
+// DumpCommand handles the 'dump' subcommand
+type DumpCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *DumpCommand) Run(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	output := fs.String("o", "", "Output file (default: stdout)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return err
+	}
+	file, err := h.syncSvc.Dump()
+	if err != nil {
+		return dbErrorf("failed to read budgets and rules: %w", err)
+	}
+
+	out := h.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := file.Write(out); err != nil {
+		return fmt.Errorf("failed to write finances file: %w", err)
+	}
+
+	if *output != "" {
+		h.infof("✅ Dumped %d budgets and %d rules to %s\n", len(file.Budgets), len(file.Rules), *output)
+	}
+	return nil
+}
+
+// syncPlan reads a finances file and compares it with the database
+func (h *CLIHandler) syncPlan(path string) (*service.SyncPlan, error) {
+	if path == "" {
+		return nil, usageErrorf("a finances file is required (-f <file>)")
+	}
+
+	var file *finances.File
+	var err error
+	name := path
+	if path == "-" {
+		name = "stdin"
+		file, err = finances.Parse(h.Stdin)
+	} else {
+		file, err = finances.ReadFile(path)
+	}
+	if os.IsNotExist(err) {
+		return nil, notFoundErrorf("file '%s' not found", path)
+	}
+	if err != nil {
+		return nil, validationErrorf("%s: %v", name, err)
+	}
+
+	if err := h.InitDatabase(); err != nil {
+		return nil, err
+	}
+	plan, err := h.syncSvc.Plan(file, time.Now())
+	if err != nil {
+		return nil, dbErrorf("failed to plan changes: %w", err)
+	}
+	return plan, nil
+}
+
+// printSyncPlan shows each change: '+' creates, '~' updates and '-' deletes
+func (h *CLIHandler) printSyncPlan(plan *service.SyncPlan) {
+	if len(plan.Changes) == 0 {
+		h.println("No changes. The budgets and rules match the file.")
+		return
+	}
+	for _, change := range plan.Changes {
+		symbol := map[string]string{
+			service.ActionCreate: "+",
+			service.ActionUpdate: "~",
+			service.ActionDelete: "-",
+		}[change.Action]
+		h.printf("%s %s\n", symbol, h.changeTitle(change))
+		for _, field := range change.Fields {
+			h.printf("    %s: %s → %s\n", field.Field, field.Old, field.New)
+		}
+	}
+}
+
+// changeTitle names the budget or rule a change applies to
+func (h *CLIHandler) changeTitle(change service.Change) string {
+	if budget := change.Budget; budget != nil {
+		title := fmt.Sprintf("budget %s", budget.Category)
+		if budget.IsIncome() {
+			title += " (income)"
+		}
+		if budget.IsRecurring() {
+			title += fmt.Sprintf(" %s %s", h.money(budget.Amount), strings.ToLower(budget.PeriodName()))
+		} else {
+			title += fmt.Sprintf(" %s for %s", h.money(budget.Amount), h.formatPeriod(budget.StartDate, budget.EndDate))
+		}
+		return title
+	}
+	rule := change.Rule
+	return fmt.Sprintf("rule %s → %s (priority %d)", rule.Pattern, rule.Category, rule.Priority)
+}
+
+// planCounts sums up a plan as "X to create, Y to update, Z to delete"
+func planCounts(plan *service.SyncPlan) string {
+	return fmt.Sprintf("%d to create, %d to update, %d to delete",
+		plan.Count(service.ActionCreate), plan.Count(service.ActionUpdate), plan.Count(service.ActionDelete))
+}
+
+// syncChangeColumns are the machine-readable fields of a plan
+var syncChangeColumns = []string{"action", "kind", "id", "name", "changes"}
+
+func syncChangeRecords(plan *service.SyncPlan) []Record {
+	records := make([]Record, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		var id interface{}
+		kind, name := "rule", ""
+		if change.Budget != nil {
+			kind, name = "budget", change.Budget.Category
+			if change.Budget.ID != 0 {
+				id = change.Budget.ID
+			}
+		} else {
+			name = change.Rule.Pattern
+			if change.Rule.ID != 0 {
+				id = change.Rule.ID
+			}
+		}
+		fields := make([]string, 0, len(change.Fields))
+		for _, field := range change.Fields {
+			fields = append(fields, fmt.Sprintf("%s: %s -> %s", field.Field, field.Old, field.New))
+		}
+		records = append(records, Record{
+			{"action", change.Action},
+			{"kind", kind},
+			{"id", id},
+			{"name", name},
+			{"changes", strings.Join(fields, "; ")},
+		})
+	}
+	return records
+}
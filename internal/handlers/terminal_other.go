@@ -0,0 +1,17 @@
+//go:build !linux
+
+package handlers
+
+import "os"
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readPassword reads a line from the terminal. Echo is only turned off on
+// Linux; elsewhere prefer ATAD_PASSPHRASE.
+func readPassword(f *os.File) (string, error) {
+	return readLine(f)
+}
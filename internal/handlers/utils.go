@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"sort"
 
 	"github.com/NimbleMarkets/ntcharts/barchart"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
 )
 
 // TruncateString truncates a string to maxLen, adding "..." if needed
@@ -16,6 +21,21 @@ func TruncateString(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// plural formats a count with its noun, adding an "s" unless the count is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// roundTo rounds a figure for machine-readable output to the given number of
+// decimals, so it does not carry floating point noise
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
+
 // CategoryColor holds category name and its assigned color
 type CategoryColor struct {
 	Category string
@@ -27,7 +47,7 @@ type CategoryColor struct {
 var categoryColors = make(map[string]CategoryColor)
 
 // DrawCategoryBarChart renders a bar chart for category spending using ntcharts
-func DrawCategoryBarChart(byCategory map[string]float64, total float64) {
+func DrawCategoryBarChart(w io.Writer, byCategory map[string]float64, total float64) {
 	if len(byCategory) == 0 {
 		return
 	}
@@ -122,8 +142,8 @@ func DrawCategoryBarChart(byCategory map[string]float64, total float64) {
 	bc.Draw()
 
 	// Render the chart
-	fmt.Println("\nCategory Breakdown Chart:")
-	fmt.Println(bc.View())
+	fmt.Fprintln(w, "\nCategory Breakdown Chart:")
+	fmt.Fprintln(w, bc.View())
 }
 
 // GetCategoryColor returns the color style for a category
@@ -134,3 +154,60 @@ func GetCategoryColor(category string) lipgloss.Style {
 	// Default style if color not found
 	return lipgloss.NewStyle()
 }
+
+// DrawVarianceBarChart renders each category's actual amount as a share of its
+// budget over the report periods using ntcharts. Bars over 100% are red for
+// expense budgets and green for income targets.
+func DrawVarianceBarChart(w io.Writer, lines []service.CategoryVariance) {
+	if len(lines) == 0 {
+		return
+	}
+
+	overStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Background(lipgloss.Color("9"))    // bright red
+	underStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Background(lipgloss.Color("10")) // bright green
+
+	barData := make([]barchart.BarData, 0, len(lines))
+	maxValue := 100.0
+	for i := range lines {
+		line := &lines[i]
+		planned, actual := line.Totals()
+		if planned <= 0 {
+			continue
+		}
+		percent := actual / planned * 100
+		maxValue = max(maxValue, percent)
+
+		style := underStyle
+		if (percent > 100) != (line.Type == models.BudgetIncome) {
+			style = overStyle
+		}
+		label := line.Category
+		if len(label) > 15 {
+			label = label[:12] + "..."
+		}
+		label = fmt.Sprintf("%s %.0f%%", label, percent)
+		barData = append(barData, barchart.BarData{
+			Label:  label,
+			Values: []barchart.BarValue{{Name: label, Value: percent, Style: style}},
+		})
+	}
+	if len(barData) == 0 {
+		return
+	}
+
+	chartHeight := min(len(barData)*2+4, 30)
+	axisStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("7"))   // white
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14")) // cyan
+
+	// The data goes in first so the horizontal bars leave room for the labels
+	bc := barchart.New(80, chartHeight,
+		barchart.WithDataSet(barData),
+		barchart.WithHorizontalBars(),
+		barchart.WithMaxValue(maxValue*1.1), // Add 10% padding
+		barchart.WithStyles(axisStyle, labelStyle),
+	)
+	bc.Draw()
+
+	fmt.Fprintln(w, "\nActual as % of Budget:")
+	fmt.Fprintln(w, bc.View())
+}
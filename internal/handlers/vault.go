@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/vault"
+)
+
+// Environment variables that supply passphrases to scripts instead of a prompt
+const (
+	PassphraseEnv    = "ATAD_PASSPHRASE"
+	NewPassphraseEnv = "ATAD_NEW_PASSPHRASE" // New passphrase for 'vault passwd'
+)
+
+// VaultCommand handles the 'vault' subcommands, which turn at-rest
+// encryption of the active database on and off
+type VaultCommand struct {
+	Handler *CLIHandler
+}
+
+func (c *VaultCommand) handleStatus(args []string) error {
+	h := c.Handler
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	path, err := h.DatabasePath()
+	if err != nil {
+		return err
+	}
+	encrypted := database.IsVault(path)
+	_, lockErr := os.Stat(path + ".lock")
+	open := encrypted && lockErr == nil
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"path", path}, {"encrypted", encrypted}, {"open", open}})
+	}
+	if !encrypted {
+		h.printf("🔓 %s is not encrypted. Run 'atad vault enable' to encrypt it.\n", path)
+		return nil
+	}
+	h.printf("🔒 %s is encrypted\n", path)
+	if open {
+		h.printf("   It is currently open in another atad process (%s.lock)\n", path)
+	}
+	return nil
+}
+
+func (c *VaultCommand) handleEnable(args []string) error {
+	h := c.Handler
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	path, err := h.DatabasePath()
+	if err != nil {
+		return err
+	}
+	if database.IsVault(path) {
+		return validationErrorf("%s is already encrypted", path)
+	}
+
+	passphrase, err := h.readPassphrase(PassphraseEnv, "New passphrase: ", true)
+	if err != nil {
+		return err
+	}
+	if err := database.EnableVault(path, passphrase); err != nil {
+		return dbErrorf("failed to encrypt database: %w", err)
+	}
+
+	if snapshots, _ := database.ListSnapshots(h.backupDir(), h.snapshotProfile()); len(snapshots) > 0 {
+		h.warnf("%d earlier snapshots in %s are not encrypted; delete them if they must not be readable", len(snapshots), h.backupDir())
+	}
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"path", path}, {"encrypted", true}})
+	}
+	h.printf("🔒 Encrypted %s. Commands now ask for the passphrase or read $%s.\n", path, PassphraseEnv)
+	return nil
+}
+
+func (c *VaultCommand) handleDisable(args []string) error {
+	h := c.Handler
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	path, err := h.DatabasePath()
+	if err != nil {
+		return err
+	}
+	if !database.IsVault(path) {
+		return validationErrorf("%s is not encrypted", path)
+	}
+
+	passphrase, err := h.readPassphrase(PassphraseEnv, "Passphrase: ", false)
+	if err != nil {
+		return err
+	}
+	if err := database.DisableVault(path, passphrase); err != nil {
+		return dbErrorf("failed to decrypt database: %w", err)
+	}
+
+	if h.IsMachineOutput() {
+		return h.WriteRecord(Record{{"path", path}, {"encrypted", false}})
+	}
+	h.printf("🔓 Decrypted %s\n", path)
+	return nil
+}
+
+func (c *VaultCommand) handlePasswd(args []string) error {
+	h := c.Handler
+	if err := c.noArgs(args); err != nil {
+		return err
+	}
+	path, err := h.DatabasePath()
+	if err != nil {
+		return err
+	}
+	if !database.IsVault(path) {
+		return validationErrorf("%s is not encrypted", path)
+	}
+
+	oldPassphrase, err := h.readPassphrase(PassphraseEnv, "Current passphrase: ", false)
+	if err != nil {
+		return err
+	}
+	newPassphrase, err := h.readPassphrase(NewPassphraseEnv, "New passphrase: ", true)
+	if err != nil {
+		return err
+	}
+	if err := database.ChangeVaultPassphrase(path, oldPassphrase, newPassphrase); err != nil {
+		return dbErrorf("failed to change passphrase: %w", err)
+	}
+
+	h.infof("🔑 Passphrase of %s changed\n", path)
+	return nil
+}
+
+// handleDecrypt decrypts an encrypted backup or export to a file or stdout
+func (c *VaultCommand) handleDecrypt(args []string) error {
+	h := c.Handler
+	fs := h.newFlagSet()
+	output := fs.String("o", "", "Output file (defaults to stdout)")
+
+	positional, err := h.parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("vault decrypt needs exactly one <file>")
+	}
+	file := positional[0]
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return notFoundErrorf("file '%s' not found", file)
+		}
+		return err
+	}
+	if !vault.IsEncrypted(data) {
+		return validationErrorf("%s is not encrypted", file)
+	}
+
+	passphrase, err := h.readPassphrase(PassphraseEnv, "Passphrase: ", false)
+	if err != nil {
+		return err
+	}
+	plaintext, err := vault.Decrypt(data, passphrase)
+	if err != nil {
+		return validationErrorf("%v", err)
+	}
+
+	if *output == "" {
+		_, err = h.Stdout.Write(plaintext)
+		return err
+	}
+	if err := vault.WriteFile(*output, plaintext, 0600); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	h.infof("🔓 Decrypted %s to %s\n", file, *output)
+	return nil
+}
+
+func (c *VaultCommand) noArgs(args []string) error {
+	positional, err := c.Handler.parseArgs(c.Handler.newFlagSet(), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("unexpected argument '%s'", positional[0])
+	}
+	return nil
+}
+
+// databasePassphrase supplies the passphrase of an encrypted database to
+// database.NewDatabase. It is asked for once per run.
+func (h *CLIHandler) databasePassphrase(path string) (string, error) {
+	if h.passphrase == "" {
+		passphrase, err := h.readPassphrase(PassphraseEnv, fmt.Sprintf("Passphrase for %s: ", path), false)
+		if err != nil {
+			return "", err
+		}
+		h.passphrase = passphrase
+	}
+	return h.passphrase, nil
+}
+
+// readPassphrase takes a passphrase from the environment variable env or
+// prompts for it on the terminal. With confirm set, the prompt asks twice.
+func (h *CLIHandler) readPassphrase(env, prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	in, ok := h.Stdin.(*os.File)
+	if !ok || h.noPrompt || !isTerminal(in) {
+		return "", validationErrorf("a passphrase is required: set $%s or run atad in a terminal", env)
+	}
+
+	fmt.Fprint(h.Stderr, prompt)
+	passphrase, err := readPassword(in)
+	fmt.Fprintln(h.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return "", validationErrorf("a passphrase is required")
+	}
+
+	if confirm {
+		fmt.Fprint(h.Stderr, "Repeat passphrase: ")
+		again, err := readPassword(in)
+		fmt.Fprintln(h.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if again != passphrase {
+			return "", validationErrorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// readLine reads up to a newline one byte at a time, so nothing after the
+// line is consumed from the terminal
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
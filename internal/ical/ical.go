@@ -0,0 +1,89 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events, such as
+// the due dates of bills, for calendar apps to subscribe to.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Calendar is a feed of events
+type Calendar struct {
+	Name   string // Shown by calendar apps as the calendar's name
+	Events []Event
+}
+
+// Event is an all-day event
+type Event struct {
+	UID         string // Stable across exports so apps update events instead of duplicating them
+	Date        time.Time
+	Summary     string
+	Description string
+	Remind      int // Days before the event to show a reminder; 0 for none
+}
+
+// Write writes the calendar with CRLF line endings and long lines folded,
+// as the format requires. stamp is recorded as the time the events were
+// last written.
+func (c *Calendar) Write(w io.Writer, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//atad//bills//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", event.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		line("TRANSP", "TRANSPARENT")
+		if event.Remind > 0 {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escape(event.Summary))
+			line("TRIGGER", fmt.Sprintf("-P%dD", event.Remind))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// escape escapes the characters that are special in text values
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// writeFolded writes a content line, folding it into lines of at most 75
+// octets without splitting a UTF-8 character
+func writeFolded(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	w.WriteString(line + "\r\n")
+}
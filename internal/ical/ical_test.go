@@ -0,0 +1,110 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Rent", "Rent"},
+		{"Gas, water; power", `Gas\, water\; power`},
+		{`C:\bills`, `C:\\bills`},
+		{"line one\nline two\r\nthree", `line one\nline two\nthree`},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.text); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Rent"},
+		{"exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("0123456789", 20)},
+		{"long multibyte", "SUMMARY:" + strings.Repeat("€ü", 60)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			writeFolded(w, tt.line)
+			w.Flush()
+			out := buf.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, line := range lines {
+				if len(line) > 75 {
+					t.Errorf("line %d is %d octets", i, len(line))
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d %q does not start with a space", i, line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d %q splits a character", i, line)
+				}
+			}
+			if len(tt.line) <= 75 && len(lines) != 1 {
+				t.Errorf("a %d octet line was folded into %d", len(tt.line), len(lines))
+			}
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cal := &Calendar{
+		Name: "Bills, household",
+		Events: []Event{
+			{UID: "bill-1-20260131@atad", Date: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+				Summary: "Rent; flat 2, due", Description: "1,200.00 to the landlord", Remind: 3},
+			{UID: "bill-2-20261231@atad", Date: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), Summary: "Insurance"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := cal.Write(&buf, time.Date(2026, 10, 18, 9, 30, 0, 0, time.FixedZone("CEST", 2*3600))); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		`X-WR-CALNAME:Bills\, household` + "\r\n",
+		"DTSTAMP:20261018T073000Z\r\n",
+		// All-day events end the next day, across month and year ends
+		"DTSTART;VALUE=DATE:20260131\r\nDTEND;VALUE=DATE:20260201\r\n",
+		"DTSTART;VALUE=DATE:20261231\r\nDTEND;VALUE=DATE:20270101\r\n",
+		`SUMMARY:Rent\; flat 2\, due` + "\r\n",
+		`DESCRIPTION:1\,200.00 to the landlord` + "\r\n",
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\n" + `DESCRIPTION:Rent\; flat 2\, due` + "\r\nTRIGGER:-P3D\r\nEND:VALARM\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VALARM") != 1 {
+		t.Errorf("want a reminder only for the first event:\n%s", out)
+	}
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Error("output has bare LF line endings")
+	}
+}
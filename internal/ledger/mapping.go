@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Mapping translates between atad categories/accounts and plain-text accounting
+// account names. It is loaded from a mapping file such as:
+//
+//	# Commodity written after every amount
+//	currency = EUR
+//	# Asset account used for transactions without an account
+//	default = Assets:Bank:Checking
+//	account Savings = Assets:Bank:Savings
+//	expense Groceries = Expenses:Food:Groceries
+//	income Salary = Income:Job:Salary
+//
+// Anything not listed falls back to Assets:<Account>, Expenses:<Category> and Income:<Category>.
+type Mapping struct {
+	Currency       string
+	DefaultAccount string
+	Accounts       map[string]string // atad account -> journal account
+	Expenses       map[string]string // atad category -> journal account
+	Income         map[string]string // atad category -> journal account
+}
+
+// DefaultMapping returns the mapping used when no mapping file is given
+func DefaultMapping() *Mapping {
+	return &Mapping{
+		Currency:       "USD",
+		DefaultAccount: "Assets:Checking",
+		Accounts:       make(map[string]string),
+		Expenses:       make(map[string]string),
+		Income:         make(map[string]string),
+	}
+}
+
+// LoadMapping reads a mapping file on top of the defaults
+func LoadMapping(filename string) (*Mapping, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mapping file: %w", err)
+	}
+	defer file.Close()
+
+	m := DefaultMapping()
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("mapping line %d: expected 'key = value'", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("mapping line %d: missing value", lineNum)
+		}
+
+		kind, name, _ := strings.Cut(key, " ")
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(kind) {
+		case "currency":
+			m.Currency = value
+		case "default":
+			m.DefaultAccount = value
+		case "account":
+			m.Accounts[name] = value
+		case "expense":
+			m.Expenses[name] = value
+		case "income":
+			m.Income[name] = value
+		default:
+			return nil, fmt.Errorf("mapping line %d: unknown key '%s'", lineNum, kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	return m, nil
+}
+
+// AssetAccount returns the journal account for an atad account
+func (m *Mapping) AssetAccount(account string) string {
+	if account == "" {
+		return m.DefaultAccount
+	}
+	if mapped, ok := m.Accounts[account]; ok {
+		return mapped
+	}
+	return "Assets:" + accountComponent(account)
+}
+
+// CategoryAccount returns the journal account for a category of the given transaction type
+func (m *Mapping) CategoryAccount(txType, category string) string {
+	if txType == "income" {
+		if mapped, ok := m.Income[category]; ok {
+			return mapped
+		}
+		return "Income:" + accountComponent(category)
+	}
+	if mapped, ok := m.Expenses[category]; ok {
+		return mapped
+	}
+	return "Expenses:" + accountComponent(category)
+}
+
+// Category resolves a journal account back to a transaction type and category.
+// ok is false for accounts that are neither income nor expense accounts.
+func (m *Mapping) Category(journalAccount string) (txType, category string, ok bool) {
+	for cat, acc := range m.Expenses {
+		if acc == journalAccount {
+			return "expense", cat, true
+		}
+	}
+	for cat, acc := range m.Income {
+		if acc == journalAccount {
+			return "income", cat, true
+		}
+	}
+
+	root, rest, _ := strings.Cut(journalAccount, ":")
+	if rest == "" {
+		rest = "Uncategorized"
+	}
+	switch root {
+	case "Expenses":
+		return "expense", lastComponent(rest), true
+	case "Income":
+		return "income", lastComponent(rest), true
+	}
+	return "", "", false
+}
+
+// Account resolves a journal asset or liability account back to an atad account name
+func (m *Mapping) Account(journalAccount string) string {
+	if journalAccount == m.DefaultAccount {
+		return ""
+	}
+	for name, acc := range m.Accounts {
+		if acc == journalAccount {
+			return name
+		}
+	}
+	return lastComponent(journalAccount)
+}
+
+// accountComponent turns free text into a valid account name component:
+// letters, digits and dashes, starting with an upper-case letter
+func accountComponent(s string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upperNext {
+				r = unicode.ToUpper(r)
+				upperNext = false
+			}
+			b.WriteRune(r)
+		case r == '-':
+			b.WriteRune(r)
+		default:
+			upperNext = true
+		}
+	}
+	if b.Len() == 0 {
+		return "Uncategorized"
+	}
+	result := b.String()
+	if first := []rune(result)[0]; !unicode.IsLetter(first) {
+		result = "X" + result
+	}
+	return result
+}
+
+func lastComponent(account string) string {
+	if i := strings.LastIndex(account, ":"); i >= 0 {
+		return account[i+1:]
+	}
+	return account
+}
@@ -0,0 +1,119 @@
+package ledger
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Dialect selects the plain-text accounting syntax
+type Dialect int
+
+const (
+	Beancount Dialect = iota
+	Ledger            // Also read by hledger
+)
+
+// Writer emits balanced double-entry transactions, one per atad transaction
+type Writer struct {
+	w        io.Writer
+	dialect  Dialect
+	mapping  *Mapping
+	opened   map[string]time.Time // Beancount accounts and the first date they are used
+	started  bool
+	firstErr error
+}
+
+// NewWriter creates a journal writer; a nil mapping uses DefaultMapping
+func NewWriter(dialect Dialect, w io.Writer, mapping *Mapping) *Writer {
+	if mapping == nil {
+		mapping = DefaultMapping()
+	}
+	return &Writer{
+		w:       w,
+		dialect: dialect,
+		mapping: mapping,
+		opened:  make(map[string]time.Time),
+	}
+}
+
+// Write emits one transaction with a category posting and a balancing asset posting
+func (jw *Writer) Write(tx *models.Transaction) error {
+	if !jw.started {
+		jw.started = true
+		if jw.dialect == Beancount {
+			jw.printf("option \"operating_currency\" \"%s\"\n\n", jw.mapping.Currency)
+		}
+	}
+
+	category := jw.mapping.CategoryAccount(tx.Type, tx.Category)
+	asset := jw.mapping.AssetAccount(tx.Account)
+	jw.trackOpen(category, tx.Date)
+	jw.trackOpen(asset, tx.Date)
+
+	// Expenses are debited, income is credited; the asset side balances it
+	categoryAmount := tx.Amount
+	if tx.Type == "income" {
+		categoryAmount = -tx.Amount
+	}
+
+	flag := "*"
+	if tx.Status == "" || tx.Status == models.StatusUncleared {
+		flag = "!"
+	}
+
+	switch jw.dialect {
+	case Beancount:
+		jw.printf("%s %s \"%s\"\n", tx.Date.Format("2006-01-02"), flag, escapeQuoted(tx.Description))
+	case Ledger:
+		jw.printf("%s %s %s\n", tx.Date.Format("2006/01/02"), flag, singleLine(tx.Description))
+	}
+	jw.printf("  %-40s %12.2f %s\n", category, categoryAmount, jw.mapping.Currency)
+	jw.printf("  %-40s %12.2f %s\n\n", asset, -categoryAmount, jw.mapping.Currency)
+
+	return jw.firstErr
+}
+
+// Close writes the Beancount open directives for every account used
+func (jw *Writer) Close() error {
+	if jw.dialect != Beancount || len(jw.opened) == 0 {
+		return jw.firstErr
+	}
+
+	accounts := make([]string, 0, len(jw.opened))
+	for account := range jw.opened {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		jw.printf("%s open %s\n", jw.opened[account].Format("2006-01-02"), account)
+	}
+	return jw.firstErr
+}
+
+func (jw *Writer) trackOpen(account string, date time.Time) {
+	if first, ok := jw.opened[account]; !ok || date.Before(first) {
+		jw.opened[account] = date
+	}
+}
+
+func (jw *Writer) printf(format string, args ...interface{}) {
+	if jw.firstErr != nil {
+		return
+	}
+	_, jw.firstErr = fmt.Fprintf(jw.w, format, args...)
+}
+
+func escapeQuoted(s string) string {
+	s = strings.ReplaceAll(singleLine(s), `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert kinds
+const (
+	AlertBudgetThreshold  = "budget_threshold"  // A budget reached one of its thresholds
+	AlertLargeTransaction = "large_transaction" // A single expense reached alerts.large_transaction
+	AlertTest             = "test"              // Sent by 'atad alerts test'
+)
+
+// Alert is a notification raised by a transaction. Budget alerts fire once
+// per threshold and budget period.
+type Alert struct {
+	ID            int64     `json:"id"`
+	Kind          string    `json:"kind"`
+	Category      string    `json:"category"`
+	BudgetID      int64     `json:"budget_id,omitempty"`
+	PeriodStart   time.Time `json:"period_start,omitzero"`
+	PeriodEnd     time.Time `json:"period_end,omitzero"`
+	Threshold     float64   `json:"threshold,omitempty"`    // Percent of the budget reached
+	PercentUsed   float64   `json:"percent_used,omitempty"` // Percent of the budget actually used
+	Spent         float64   `json:"spent,omitempty"`
+	Available     float64   `json:"available,omitempty"`
+	TransactionID int64     `json:"transaction_id,omitempty"`
+	Amount        float64   `json:"amount"` // Amount of the transaction that raised the alert
+	Message       string    `json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ThresholdsNone is the threshold list that turns budget alerts off
+const ThresholdsNone = "none"
+
+// ParseThresholds parses a comma-separated list of percentages such as
+// "50,80,100" into ascending order. An empty string gives nil and
+// ThresholdsNone an empty, non-nil list.
+func ParseThresholds(value string) ([]float64, error) {
+	if strings.TrimSpace(value) == ThresholdsNone {
+		return []float64{}, nil
+	}
+	var thresholds []float64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSuffix(strings.TrimSpace(field), "%")
+		if field == "" {
+			continue
+		}
+		threshold, err := strconv.ParseFloat(field, 64)
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid threshold '%s': use positive percentages such as 50,80,100", field)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+// FormatThresholds writes thresholds in the form ParseThresholds reads
+func FormatThresholds(thresholds []float64) string {
+	if thresholds != nil && len(thresholds) == 0 {
+		return ThresholdsNone
+	}
+	fields := make([]string, len(thresholds))
+	for i, threshold := range thresholds {
+		fields[i] = strconv.FormatFloat(threshold, 'f', -1, 64)
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Bill is a payment due on a schedule. A bill is paid by an expense whose
+// description contains its pattern and whose amount is within the tolerance
+// of the expected amount, or by marking a due date paid by hand.
+type Bill struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Amount    float64   `json:"amount"`    // Expected amount
+	Period    string    `json:"period"`    // weekly, monthly, quarterly or yearly
+	DueDate   time.Time `json:"due_date"`  // First due date; later ones follow the period
+	Pattern   string    `json:"pattern"`   // Case-insensitive text in the payee description
+	Tolerance float64   `json:"tolerance"` // Percent the paid amount may differ from Amount
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsValidBillPeriod reports whether period is a valid bill schedule
+func IsValidBillPeriod(period string) bool {
+	switch period {
+	case PeriodWeekly, PeriodMonthly, PeriodQuarterly, PeriodYearly:
+		return true
+	}
+	return false
+}
+
+// DueAt returns the nth due date after the first one; n may be negative
+func (b *Bill) DueAt(n int) time.Time {
+	switch b.Period {
+	case PeriodWeekly:
+		return b.DueDate.AddDate(0, 0, 7*n)
+	case PeriodQuarterly:
+		return addMonths(b.DueDate, 3*n)
+	case PeriodYearly:
+		return addMonths(b.DueDate, 12*n)
+	}
+	return addMonths(b.DueDate, n)
+}
+
+// Matches reports whether a transaction pays the bill
+func (b *Bill) Matches(tx *Transaction) bool {
+	if tx.Type != "expense" || !strings.Contains(strings.ToLower(tx.Description), strings.ToLower(b.Pattern)) {
+		return false
+	}
+	return math.Abs(tx.Amount-b.Amount) <= b.Amount*b.Tolerance/100+0.005
+}
+
+// BillPayment marks a due date of a bill paid by hand
+type BillPayment struct {
+	ID        int64     `json:"id"`
+	BillID    int64     `json:"bill_id"`
+	DueDate   time.Time `json:"due_date"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueAt(t *testing.T) {
+	tests := []struct {
+		name string
+		bill Bill
+		n    int
+		want time.Time
+	}{
+		{"monthly from the 31st into February", Bill{Period: PeriodMonthly, DueDate: day(2026, 1, 31)}, 1, day(2026, 2, 28)},
+		{"monthly from the 31st back to 31 days", Bill{Period: PeriodMonthly, DueDate: day(2026, 1, 31)}, 2, day(2026, 3, 31)},
+		{"monthly from the 31st into a 30 day month", Bill{Period: PeriodMonthly, DueDate: day(2026, 1, 31)}, 3, day(2026, 4, 30)},
+		{"monthly before the first due date", Bill{Period: PeriodMonthly, DueDate: day(2026, 3, 31)}, -1, day(2026, 2, 28)},
+		{"monthly into a leap year February", Bill{Period: PeriodMonthly, DueDate: day(2027, 12, 30)}, 2, day(2028, 2, 29)},
+		{"weekly", Bill{Period: PeriodWeekly, DueDate: day(2026, 12, 28)}, 1, day(2027, 1, 4)},
+		{"quarterly from the 31st", Bill{Period: PeriodQuarterly, DueDate: day(2026, 8, 31)}, 1, day(2026, 11, 30)},
+		{"yearly from a leap day", Bill{Period: PeriodYearly, DueDate: day(2028, 2, 29)}, 1, day(2029, 2, 28)},
+		{"yearly back to a leap day", Bill{Period: PeriodYearly, DueDate: day(2028, 2, 29)}, 4, day(2032, 2, 29)},
+	}
+	for _, tt := range tests {
+		if got := tt.bill.DueAt(tt.n); !got.Equal(tt.want) {
+			t.Errorf("%s: DueAt(%d) = %s, want %s", tt.name, tt.n, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestBillMatches(t *testing.T) {
+	bill := Bill{Amount: 100, Pattern: "Power Co", Tolerance: 5}
+	tests := []struct {
+		name string
+		tx   Transaction
+		want bool
+	}{
+		{"exact", Transaction{Type: "expense", Description: "POWER CO direct debit", Amount: 100}, true},
+		{"at the tolerance", Transaction{Type: "expense", Description: "power co", Amount: 105}, true},
+		{"below the tolerance", Transaction{Type: "expense", Description: "power co", Amount: 95}, true},
+		{"beyond the tolerance", Transaction{Type: "expense", Description: "power co", Amount: 105.01}, false},
+		{"other payee", Transaction{Type: "expense", Description: "Water Co", Amount: 100}, false},
+		{"income", Transaction{Type: "income", Description: "Power Co refund", Amount: 100}, false},
+	}
+	for _, tt := range tests {
+		if got := bill.Matches(&tt.tx); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
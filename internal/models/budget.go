@@ -2,11 +2,241 @@ package models
 
 import "time"
 
+// Budget periods. A custom budget covers StartDate to EndDate once; the
+// recurring ones repeat the amount every period, counted from StartDate.
+const (
+	PeriodCustom    = "custom"
+	PeriodWeekly    = "weekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
+// BudgetPeriods lists the valid budget periods
+var BudgetPeriods = []string{PeriodCustom, PeriodWeekly, PeriodMonthly, PeriodQuarterly, PeriodYearly}
+
+// Budget types. An expense budget caps spending; an income budget is a target
+// to earn, compared against income transactions of its category.
+const (
+	BudgetExpense = "expense"
+	BudgetIncome  = "income"
+)
+
+// Rollover settings decide what a recurring budget carries into its next
+// period: nothing, unspent money only, unspent money and overspending, or
+// unspent money up to RolloverCap in total
+const (
+	RolloverNone    = "none"
+	RolloverSurplus = "surplus"
+	RolloverFull    = "full"
+	RolloverCapped  = "capped"
+)
+
+// Rollovers lists the valid rollover settings
+var Rollovers = []string{RolloverNone, RolloverSurplus, RolloverFull, RolloverCapped}
+
 type Budget struct {
 	ID        int64     `json:"id"`
 	Category  string    `json:"category"`
-	Amount    float64   `json:"amount"`
-	Period    string    `json:"period"`     // Always "custom"
-	StartDate time.Time `json:"start_date"` // Required
-	EndDate   time.Time `json:"end_date"`   // Required
+	Type      string    `json:"type"`       // BudgetExpense or BudgetIncome
+	Amount    float64   `json:"amount"`     // Amount per period
+	Period    string    `json:"period"`     // One of BudgetPeriods
+	StartDate time.Time `json:"start_date"` // Start of a custom budget, anchor of a recurring one
+	EndDate   time.Time `json:"end_date"`   // End of a custom budget; zero for recurring ones
+
+	Rollover    string  `json:"rollover"`     // One of Rollovers
+	RolloverCap float64 `json:"rollover_cap"` // Largest carried amount with RolloverCapped
+
+	AlertThresholds []float64 `json:"alert_thresholds"` // Percentages that raise alerts; nil uses alerts.thresholds
+}
+
+// IsValidBudgetPeriod reports whether period is one of BudgetPeriods
+func IsValidBudgetPeriod(period string) bool {
+	for _, p := range BudgetPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRollover reports whether rollover is one of Rollovers
+func IsValidRollover(rollover string) bool {
+	for _, r := range Rollovers {
+		if r == rollover {
+			return true
+		}
+	}
+	return false
+}
+
+// Carry returns the amount carried into the next period when remaining was
+// left over (or overspent, when negative) at the end of a period
+func (b *Budget) Carry(remaining float64) float64 {
+	switch b.Rollover {
+	case RolloverSurplus:
+		return max(remaining, 0)
+	case RolloverFull:
+		return remaining
+	case RolloverCapped:
+		return min(max(remaining, 0), b.RolloverCap)
+	}
+	return 0
+}
+
+// RolloverName describes what the budget carries over, e.g. "unspent up to
+// $50.00", formatting money with format
+func (b *Budget) RolloverName(format func(float64) string) string {
+	switch b.Rollover {
+	case RolloverSurplus:
+		return "unspent"
+	case RolloverFull:
+		return "unspent and overspent"
+	case RolloverCapped:
+		return "unspent up to " + format(b.RolloverCap)
+	}
+	return "none"
+}
+
+// RollsOver reports whether the budget carries amounts between periods
+func (b *Budget) RollsOver() bool {
+	return b.IsRecurring() && b.Rollover != "" && b.Rollover != RolloverNone
+}
+
+// IsIncome reports whether the budget is an income target
+func (b *Budget) IsIncome() bool {
+	return b.Type == BudgetIncome
+}
+
+// IsRecurring reports whether the budget repeats every period
+func (b *Budget) IsRecurring() bool {
+	return b.Period != PeriodCustom
+}
+
+// Overlaps reports whether two budgets of the same category and type would
+// both apply to some day. Two custom budgets overlap when their ranges share a day and
+// two recurring budgets always do, since both run indefinitely. A custom
+// budget does not overlap a recurring one: it overrides it for its range.
+func (b *Budget) Overlaps(other *Budget) bool {
+	if b.Category != other.Category || b.IsIncome() != other.IsIncome() || b.IsRecurring() != other.IsRecurring() {
+		return false
+	}
+	if b.IsRecurring() {
+		return true
+	}
+	return !startOfDay(b.StartDate).After(startOfDay(other.EndDate)) &&
+		!startOfDay(other.StartDate).After(startOfDay(b.EndDate))
+}
+
+// PeriodAt returns the first and last day of the budget period containing
+// date. ok is false when date falls outside the budget: before a recurring
+// budget's anchor or outside a custom budget's range.
+func (b *Budget) PeriodAt(date time.Time) (start, end time.Time, ok bool) {
+	// Compare calendar days in the location the budget dates were stored in
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, b.StartDate.Location())
+	if !b.IsRecurring() {
+		start, end = startOfDay(b.StartDate), startOfDay(b.EndDate)
+		return start, end, !day.Before(start) && !day.After(end)
+	}
+
+	anchor := startOfDay(b.StartDate)
+	if day.Before(anchor) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	// Estimate the period number from the elapsed time, then correct it
+	n := b.estimatePeriods(anchor, day)
+	for n > 0 && b.periodStart(anchor, n).After(day) {
+		n--
+	}
+	for !b.periodStart(anchor, n+1).After(day) {
+		n++
+	}
+	return b.periodStart(anchor, n), b.periodStart(anchor, n+1).AddDate(0, 0, -1), true
+}
+
+// PreviousPeriod returns the budget period before the one starting at start.
+// ok is false when start is the first period of the budget.
+func (b *Budget) PreviousPeriod(start time.Time) (time.Time, time.Time, bool) {
+	if !b.IsRecurring() {
+		return time.Time{}, time.Time{}, false
+	}
+	return b.PeriodAt(start.AddDate(0, 0, -1))
+}
+
+// PeriodName describes the period of a budget, e.g. "Monthly"
+func (b *Budget) PeriodName() string {
+	if b.Period == "" {
+		return ""
+	}
+	return string(b.Period[0]-'a'+'A') + b.Period[1:]
+}
+
+// periodStart returns the start of the nth period after the anchor. Months are
+// added to the anchor itself so that an anchor on the 31st falls back to the
+// last day of shorter months without drifting.
+func (b *Budget) periodStart(anchor time.Time, n int) time.Time {
+	switch b.Period {
+	case PeriodWeekly:
+		return anchor.AddDate(0, 0, 7*n)
+	case PeriodQuarterly:
+		return addMonths(anchor, 3*n)
+	case PeriodYearly:
+		return addMonths(anchor, 12*n)
+	}
+	return addMonths(anchor, n)
+}
+
+func (b *Budget) estimatePeriods(anchor, day time.Time) int {
+	months := (day.Year()-anchor.Year())*12 + int(day.Month()-anchor.Month())
+	switch b.Period {
+	case PeriodWeekly:
+		return int(day.Sub(anchor).Hours() / (24 * 7))
+	case PeriodQuarterly:
+		return months / 3
+	case PeriodYearly:
+		return months / 12
+	}
+	return months
+}
+
+// addMonths adds n months to t, clamping the day to the end of the month
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// CalendarDays counts the calendar days from a to b, ignoring the time of day
+// and the location of b
+func CalendarDays(a, b time.Time) int {
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// DefaultAnchor returns the natural start of the period containing date: the
+// Monday of the week, the first of the month, quarter or year. Like parsed
+// input dates, the result is a calendar day in UTC.
+func DefaultAnchor(period string, date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodQuarterly:
+		month := (day.Month()-1)/3*3 + 1
+		return time.Date(day.Year(), month, 1, 0, 0, 0, 0, day.Location())
+	case PeriodYearly:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, day.Location())
+	}
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
 }
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// DaysPerMonth is the average length of a month, for ranges that are not
+// whole calendar months
+const DaysPerMonth = 365.25 / 12
+
+// BudgetTemplate is a named set of category budgets that can be applied to
+// new date ranges
+type BudgetTemplate struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Items     []*TemplateItem `json:"items"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TemplateItem is one budget of a template, saved with the period its amount
+// was set for
+type TemplateItem struct {
+	Category        string    `json:"category"`
+	Type            string    `json:"type"`   // BudgetExpense or BudgetIncome
+	Amount          float64   `json:"amount"` // Amount per period
+	Period          string    `json:"period"` // One of BudgetPeriods
+	Days            int       `json:"days"`   // Length of a custom budget's range
+	AlertThresholds []float64 `json:"alert_thresholds"`
+}
+
+// NewTemplateItem captures a budget in a template
+func NewTemplateItem(budget *Budget) *TemplateItem {
+	item := &TemplateItem{
+		Category:        budget.Category,
+		Type:            budget.Type,
+		Amount:          budget.Amount,
+		Period:          budget.Period,
+		AlertThresholds: budget.AlertThresholds,
+	}
+	if !budget.IsRecurring() {
+		item.Days = CalendarDays(budget.StartDate, budget.EndDate) + 1
+	}
+	return item
+}
+
+// AmountFor scales the item to the range start to end. Recurring amounts are
+// multiplied by the number of periods in the range, counting whole calendar
+// months exactly and other ranges by their days; a custom amount is spread
+// over the days it was set for.
+func (i *TemplateItem) AmountFor(start, end time.Time) float64 {
+	days := float64(CalendarDays(start, end) + 1)
+	months := 0
+	switch i.Period {
+	case PeriodWeekly:
+		return i.Amount * days / 7
+	case PeriodMonthly:
+		months = 1
+	case PeriodQuarterly:
+		months = 3
+	case PeriodYearly:
+		months = 12
+	default:
+		if i.Days <= 0 {
+			return i.Amount
+		}
+		return i.Amount * days / float64(i.Days)
+	}
+	if whole := WholeMonths(start, end); whole > 0 {
+		return i.Amount * float64(whole) / float64(months)
+	}
+	return i.Amount * days / (DaysPerMonth * float64(months))
+}
+
+// PeriodLabel describes the period of the item's amount, e.g. "/month"
+func (i *TemplateItem) PeriodLabel() string {
+	switch i.Period {
+	case PeriodWeekly:
+		return "/week"
+	case PeriodMonthly:
+		return "/month"
+	case PeriodQuarterly:
+		return "/quarter"
+	case PeriodYearly:
+		return "/year"
+	}
+	if i.Days == 1 {
+		return "/day"
+	}
+	return "/" + strconv.Itoa(i.Days) + " days"
+}
+
+// WholeMonths counts the calendar months of a range running from the first
+// of a month to the last day of a month, and returns 0 for any other range
+func WholeMonths(start, end time.Time) int {
+	if start.Day() != 1 || end.AddDate(0, 0, 1).Day() != 1 || end.Before(start) {
+		return 0
+	}
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+}
+
+// PreviousRange returns the range of the same length just before start to
+// end: the same number of calendar months for whole months, otherwise the
+// same number of days
+func PreviousRange(start, end time.Time) (time.Time, time.Time) {
+	if months := WholeMonths(start, end); months > 0 {
+		return addMonths(start, -months), start.AddDate(0, 0, -1)
+	}
+	days := CalendarDays(start, end) + 1
+	return start.AddDate(0, 0, -days), start.AddDate(0, 0, -1)
+}
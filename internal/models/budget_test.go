@@ -0,0 +1,160 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		budget     Budget
+		date       time.Time
+		start, end time.Time
+		ok         bool
+	}{
+		{
+			name:   "monthly on the anchor",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 1)},
+			date:   day(2026, 3, 1),
+			start:  day(2026, 3, 1), end: day(2026, 3, 31), ok: true,
+		},
+		{
+			name:   "monthly on the last day",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 1)},
+			date:   time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC),
+			start:  day(2026, 2, 1), end: day(2026, 2, 28), ok: true,
+		},
+		{
+			name:   "monthly anchored on the 31st in February",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 31)},
+			date:   day(2026, 2, 28),
+			start:  day(2026, 2, 28), end: day(2026, 3, 30), ok: true,
+		},
+		{
+			name:   "monthly anchored on the 31st does not drift",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 31)},
+			date:   day(2026, 3, 31),
+			start:  day(2026, 3, 31), end: day(2026, 4, 29), ok: true,
+		},
+		{
+			name:   "monthly anchored on the 31st before February ends",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 31)},
+			date:   day(2026, 2, 27),
+			start:  day(2026, 1, 31), end: day(2026, 2, 27), ok: true,
+		},
+		{
+			name:   "weekly on the last day",
+			budget: Budget{Period: PeriodWeekly, StartDate: day(2026, 1, 5)},
+			date:   day(2026, 1, 11),
+			start:  day(2026, 1, 5), end: day(2026, 1, 11), ok: true,
+		},
+		{
+			name:   "weekly many weeks on",
+			budget: Budget{Period: PeriodWeekly, StartDate: day(2026, 1, 5)},
+			date:   day(2026, 10, 18),
+			start:  day(2026, 10, 12), end: day(2026, 10, 18), ok: true,
+		},
+		{
+			name:   "quarterly mid-month anchor",
+			budget: Budget{Period: PeriodQuarterly, StartDate: day(2026, 2, 15)},
+			date:   day(2026, 5, 14),
+			start:  day(2026, 2, 15), end: day(2026, 5, 14), ok: true,
+		},
+		{
+			name:   "quarterly next period",
+			budget: Budget{Period: PeriodQuarterly, StartDate: day(2026, 2, 15)},
+			date:   day(2026, 5, 15),
+			start:  day(2026, 5, 15), end: day(2026, 8, 14), ok: true,
+		},
+		{
+			name:   "yearly anchored on a leap day",
+			budget: Budget{Period: PeriodYearly, StartDate: day(2024, 2, 29)},
+			date:   day(2025, 2, 27),
+			start:  day(2024, 2, 29), end: day(2025, 2, 27), ok: true,
+		},
+		{
+			name:   "yearly anchored on a leap day in a common year",
+			budget: Budget{Period: PeriodYearly, StartDate: day(2024, 2, 29)},
+			date:   day(2025, 2, 28),
+			start:  day(2025, 2, 28), end: day(2026, 2, 27), ok: true,
+		},
+		{
+			name:   "before the anchor",
+			budget: Budget{Period: PeriodMonthly, StartDate: day(2026, 3, 1)},
+			date:   day(2026, 2, 28),
+		},
+		{
+			name:   "custom on its last day",
+			budget: Budget{Period: PeriodCustom, StartDate: day(2026, 3, 10), EndDate: day(2026, 3, 20)},
+			date:   time.Date(2026, 3, 20, 18, 0, 0, 0, time.UTC),
+			start:  day(2026, 3, 10), end: day(2026, 3, 20), ok: true,
+		},
+		{
+			name:   "custom after its range",
+			budget: Budget{Period: PeriodCustom, StartDate: day(2026, 3, 10), EndDate: day(2026, 3, 20)},
+			date:   day(2026, 3, 21),
+			start:  day(2026, 3, 10), end: day(2026, 3, 20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.budget.PeriodAt(tt.date)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("period = %s to %s, want %s to %s", start.Format(time.DateOnly), end.Format(time.DateOnly),
+					tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestPreviousPeriod(t *testing.T) {
+	budget := Budget{Period: PeriodMonthly, StartDate: day(2026, 1, 31)}
+
+	start, end, ok := budget.PreviousPeriod(day(2026, 3, 31))
+	if !ok || !start.Equal(day(2026, 2, 28)) || !end.Equal(day(2026, 3, 30)) {
+		t.Errorf("previous of 31 March = %v to %v, %v", start, end, ok)
+	}
+	if _, _, ok := budget.PreviousPeriod(day(2026, 1, 31)); ok {
+		t.Error("the first period has a previous period")
+	}
+
+	custom := Budget{Period: PeriodCustom, StartDate: day(2026, 3, 1), EndDate: day(2026, 3, 31)}
+	if _, _, ok := custom.PreviousPeriod(day(2026, 3, 1)); ok {
+		t.Error("a custom budget has a previous period")
+	}
+}
+
+func TestCarry(t *testing.T) {
+	tests := []struct {
+		rollover  string
+		remaining float64
+		want      float64
+	}{
+		{RolloverNone, 60, 0},
+		{RolloverNone, -40, 0},
+		{"", 60, 0},
+		{RolloverSurplus, 60, 60},
+		{RolloverSurplus, -40, 0},
+		{RolloverFull, 60, 60},
+		{RolloverFull, -40, -40},
+		{RolloverCapped, 30, 30},
+		{RolloverCapped, 60, 50},
+		{RolloverCapped, -40, 0},
+	}
+
+	for _, tt := range tests {
+		budget := Budget{Period: PeriodMonthly, Rollover: tt.rollover, RolloverCap: 50}
+		if got := budget.Carry(tt.remaining); got != tt.want {
+			t.Errorf("%q rollover of %.2f carries %.2f, want %.2f", tt.rollover, tt.remaining, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// EnvelopeAssignment moves money between the ready-to-assign pool and a
+// category envelope. A positive amount funds the envelope from the pool; a
+// negative one takes money out of it, as when moving to another envelope.
+type EnvelopeAssignment struct {
+	ID        int64     `json:"id"`
+	Date      time.Time `json:"date"`
+	Category  string    `json:"category"`
+	Amount    float64   `json:"amount"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Goal is an amount to save by a date. Contributions are recorded by hand,
+// and a goal linked to an account or category also counts the transactions
+// there from its start date.
+type Goal struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Target     float64   `json:"target"`
+	TargetDate time.Time `json:"target_date"` // Zero for a goal without a deadline
+	Account    string    `json:"account"`     // Linked account; its net transactions count as contributions
+	Category   string    `json:"category"`    // Linked category; its expenses count as contributions
+	StartDate  time.Time `json:"start_date"`  // Linked transactions count from this date
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// HasDeadline reports whether the goal has a target date
+func (g *Goal) HasDeadline() bool {
+	return !g.TargetDate.IsZero()
+}
+
+// Link describes what the goal is linked to, e.g. "account Savings"; empty
+// for a goal with manual contributions only
+func (g *Goal) Link() string {
+	switch {
+	case g.Account != "":
+		return "account " + g.Account
+	case g.Category != "":
+		return "category " + g.Category
+	}
+	return ""
+}
+
+// GoalContribution is money put towards a goal by hand; a negative amount
+// takes money out of it
+type GoalContribution struct {
+	ID        int64     `json:"id"`
+	GoalID    int64     `json:"goal_id"`
+	Date      time.Time `json:"date"`
+	Amount    float64   `json:"amount"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
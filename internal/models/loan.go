@@ -0,0 +1,52 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Loan is a debt paid off in monthly installments: a mortgage or car loan
+// with a term, or revolving debt such as a credit card with a fixed payment.
+// Expenses in the loan's category are its payments.
+type Loan struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Principal  float64   `json:"principal"`   // Balance owed on the start date
+	Rate       float64   `json:"rate"`        // Annual interest rate in percent
+	TermMonths int       `json:"term_months"` // 0 for revolving debt
+	Payment    float64   `json:"payment"`     // Scheduled monthly payment
+	Category   string    `json:"category"`    // Expenses in this category are payments
+	StartDate  time.Time `json:"start_date"`  // Interest accrues monthly from this date
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MonthlyRate is the interest charged per month as a fraction
+func (l *Loan) MonthlyRate() float64 {
+	return l.Rate / 100 / 12
+}
+
+// InstallmentDate is the date of the nth monthly installment, n months
+// after the start date
+func (l *Loan) InstallmentDate(n int) time.Time {
+	return addMonths(l.StartDate, n)
+}
+
+// HasTerm reports whether the loan is scheduled to end after a fixed number
+// of months
+func (l *Loan) HasTerm() bool {
+	return l.TermMonths > 0
+}
+
+// MonthlyPayment is the installment, rounded to cents, that pays off
+// principal at an annual rate in percent over the given months
+func MonthlyPayment(principal, rate float64, months int) float64 {
+	if months <= 0 {
+		return 0
+	}
+	r := rate / 100 / 12
+	if r == 0 {
+		return math.Round(principal/float64(months)*100) / 100
+	}
+	payment := principal * r / (1 - math.Pow(1+r, -float64(months)))
+	return math.Round(payment*100) / 100
+}
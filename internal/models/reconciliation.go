@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Reconciliation records a bank statement that was matched against the database
+type Reconciliation struct {
+	ID               int64     `json:"id"`
+	Account          string    `json:"account"`
+	StatementDate    time.Time `json:"statement_date"`
+	StatementBalance float64   `json:"statement_balance"`
+	ReconciledAt     time.Time `json:"reconciled_at"`
+}
@@ -2,12 +2,35 @@ package models
 
 import "time"
 
+// Transaction cleared statuses used for statement reconciliation
+const (
+	StatusUncleared  = "uncleared"
+	StatusCleared    = "cleared"
+	StatusReconciled = "reconciled"
+)
+
 type Transaction struct {
 	ID          int64     `json:"id"`
 	Date        time.Time `json:"date"`
 	Description string    `json:"description"`
 	Amount      float64   `json:"amount"`
 	Category    string    `json:"category"`
-	Type        string    `json:"type"` // "income" or "expense"
+	Type        string    `json:"type"`    // "income" or "expense"
+	Account     string    `json:"account"` // Bank account the transaction belongs to
+	Status      string    `json:"status"`  // "uncleared", "cleared" or "reconciled"
 	CreatedAt   time.Time `json:"created_at"`
 }
+
+// SignedAmount returns the amount as it affects an account balance
+// (positive for income, negative for expenses)
+func (t *Transaction) SignedAmount() float64 {
+	if t.Type == "expense" {
+		return -t.Amount
+	}
+	return t.Amount
+}
+
+// IsReconciled reports whether the transaction is locked by a finished reconciliation
+func (t *Transaction) IsReconciled() bool {
+	return t.Status == StatusReconciled
+}
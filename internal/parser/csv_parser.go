@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -15,6 +16,20 @@ import (
 // CSVParser handles parsing CSV bank statements
 type CSVParser struct {
 	dateFormats []string
+	balances    []float64 // Running balances of the last parsed file, parallel to its transactions
+	warnings    []string  // Problems found in the last parsed file
+}
+
+// BalanceCheck is the result of verifying the running balance column of a statement
+type BalanceCheck struct {
+	Checked        int      // Number of rows whose balance was verified
+	Mismatches     []string // Human-readable description of each row that did not add up
+	ClosingBalance float64  // Balance after the last transaction of the statement
+}
+
+// OK reports whether every running balance matched
+func (c *BalanceCheck) OK() bool {
+	return len(c.Mismatches) == 0
 }
 
 // NewCSVParser creates a new CSV parser with common date formats
@@ -28,6 +43,7 @@ func NewCSVParser() *CSVParser {
 			"Jan 02, 2006",
 			"02-Jan-2006",
 			"2006-01-02 15:04:05",
+			time.RFC3339,
 		},
 	}
 }
@@ -56,6 +72,8 @@ func (p *CSVParser) ParseFile(filename string) ([]*models.Transaction, error) {
 	}
 
 	var transactions []*models.Transaction
+	p.balances = nil
+	p.warnings = nil
 	lineNum := 1
 
 	for {
@@ -71,16 +89,78 @@ func (p *CSVParser) ParseFile(filename string) ([]*models.Transaction, error) {
 		tx, err := p.parseRecord(record, colMap)
 		if err != nil {
 			// Skip invalid records with warning
-			fmt.Printf("Warning: Skipping line %d: %v\n", lineNum, err)
+			p.warnings = append(p.warnings, fmt.Sprintf("Skipping line %d: %v", lineNum, err))
 			continue
 		}
 
 		transactions = append(transactions, tx)
+
+		if colMap["balance"] != -1 && colMap["balance"] < len(record) {
+			balance, err := p.parseBalance(record[colMap["balance"]])
+			if err != nil {
+				// A single unreadable balance makes the column unusable for verification
+				p.warnings = append(p.warnings, fmt.Sprintf("Ignoring balance column, line %d: %v", lineNum, err))
+				colMap["balance"] = -1
+				p.balances = nil
+			} else {
+				p.balances = append(p.balances, balance)
+			}
+		}
+	}
+
+	if colMap["balance"] == -1 {
+		p.balances = nil
 	}
 
 	return transactions, nil
 }
 
+// Warnings returns the problems found in the last parsed file, such as
+// skipped lines, for the caller to report
+func (p *CSVParser) Warnings() []string {
+	return p.warnings
+}
+
+// CheckBalances verifies the running balance column of the last parsed file
+// against its transactions. It returns nil when the file had no balance column.
+// Statements listed newest-first are detected automatically.
+func (p *CSVParser) CheckBalances(transactions []*models.Transaction) *BalanceCheck {
+	if len(p.balances) == 0 || len(p.balances) != len(transactions) {
+		return nil
+	}
+
+	ascending := p.balanceMismatches(transactions, false)
+	descending := p.balanceMismatches(transactions, true)
+
+	check := &BalanceCheck{
+		Checked:        len(transactions) - 1,
+		Mismatches:     ascending,
+		ClosingBalance: p.balances[len(p.balances)-1],
+	}
+	if len(descending) < len(ascending) {
+		check.Mismatches = descending
+		check.ClosingBalance = p.balances[0]
+	}
+	return check
+}
+
+// balanceMismatches compares each running balance with the previous one plus the row's amount
+func (p *CSVParser) balanceMismatches(transactions []*models.Transaction, newestFirst bool) []string {
+	var mismatches []string
+	for i := 1; i < len(transactions); i++ {
+		prev, cur := i-1, i
+		if newestFirst {
+			prev, cur = i, i-1
+		}
+		expected := p.balances[prev] + transactions[cur].SignedAmount()
+		if math.Abs(expected-p.balances[cur]) >= 0.005 {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s: expected balance %.2f, statement shows %.2f",
+				transactions[cur].Date.Format("2006-01-02"), transactions[cur].Description, expected, p.balances[cur]))
+		}
+	}
+	return mismatches
+}
+
 // detectColumns maps CSV headers to field indices
 func (p *CSVParser) detectColumns(headers []string) map[string]int {
 	colMap := map[string]int{
@@ -89,6 +169,9 @@ func (p *CSVParser) detectColumns(headers []string) map[string]int {
 		"amount":      -1,
 		"category":    -1,
 		"type":        -1,
+		"balance":     -1,
+		"account":     -1,
+		"status":      -1,
 	}
 
 	for i, header := range headers {
@@ -122,6 +205,19 @@ func (p *CSVParser) detectColumns(headers []string) map[string]int {
 		if headerLower == "type" || headerLower == "transaction type" {
 			colMap["type"] = i
 		}
+
+		// Account and cleared status columns (optional, written by 'atad export')
+		if headerLower == "account" {
+			colMap["account"] = i
+		}
+		if headerLower == "status" {
+			colMap["status"] = i
+		}
+
+		// Running or closing balance column (optional)
+		if strings.Contains(headerLower, "balance") {
+			colMap["balance"] = i
+		}
 	}
 
 	return colMap
@@ -168,12 +264,27 @@ func (p *CSVParser) parseRecord(record []string, colMap map[string]int) (*models
 		}
 	}
 
+	account := ""
+	if colMap["account"] != -1 && colMap["account"] < len(record) {
+		account = strings.TrimSpace(record[colMap["account"]])
+	}
+
+	status := models.StatusUncleared
+	if colMap["status"] != -1 && colMap["status"] < len(record) {
+		switch st := strings.ToLower(strings.TrimSpace(record[colMap["status"]])); st {
+		case models.StatusCleared, models.StatusReconciled:
+			status = st
+		}
+	}
+
 	return &models.Transaction{
 		Type:        txType,
 		Amount:      amount,
 		Category:    category,
 		Description: description,
 		Date:        date,
+		Account:     account,
+		Status:      status,
 	}, nil
 }
 
@@ -187,6 +298,20 @@ func (p *CSVParser) parseDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse date format")
 }
 
+// parseBalance reads a running balance, which may be negative for overdrawn accounts
+func (p *CSVParser) parseBalance(balanceStr string) (float64, error) {
+	amount, txType, err := p.parseAmount(balanceStr)
+	if err != nil {
+		return 0, err
+	}
+	cleaned := strings.TrimSpace(balanceStr)
+	if txType == "expense" && (strings.HasPrefix(cleaned, "-") || strings.HasPrefix(cleaned, "(") ||
+		strings.HasSuffix(cleaned, "DR") || strings.HasSuffix(cleaned, "Dr")) {
+		return -amount, nil
+	}
+	return amount, nil
+}
+
 // parseAmount extracts amount and determines transaction type
 func (p *CSVParser) parseAmount(amountStr string) (float64, string, error) {
 	// Remove currency symbols and spaces
@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/ledger"
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// JournalParser reads Beancount and Ledger/hledger journals into transactions
+type JournalParser struct {
+	mapping  *ledger.Mapping
+	warnings []string // Problems found in the last parsed file
+}
+
+// NewJournalParser creates a journal parser; a nil mapping uses ledger.DefaultMapping
+func NewJournalParser(mapping *ledger.Mapping) *JournalParser {
+	if mapping == nil {
+		mapping = ledger.DefaultMapping()
+	}
+	return &JournalParser{mapping: mapping}
+}
+
+// IsJournalFile reports whether a file name looks like a plain-text accounting journal
+func IsJournalFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".beancount", ".bean", ".ledger", ".journal", ".hledger", ".dat":
+		return true
+	}
+	return false
+}
+
+var (
+	// 2025-12-02 * "Payee" "Narration"  /  2025/12/02 * Payee  /  2025-12-02=2025-12-03 ! (123) Payee
+	journalHeader = regexp.MustCompile(`^(\d{4}[-/.]\d{2}[-/.]\d{2})(?:=\S+)?\s+(txn\s+)?([*!])?\s*(?:\([^)]*\)\s*)?(.*)$`)
+	quotedString  = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	postingAmount = regexp.MustCompile(`^([^\d\s.,+-]*)\s*([-+]?[\d,]*\.?\d+)\s*([A-Za-z][A-Za-z0-9_'.-]*)?`)
+)
+
+type journalPosting struct {
+	account   string
+	amount    float64
+	hasAmount bool
+}
+
+type journalEntry struct {
+	line        int
+	date        time.Time
+	flag        string
+	description string
+	postings    []journalPosting
+}
+
+// ParseFile parses a journal and returns one transaction per income or expense posting.
+// Entries that only move money between asset accounts are skipped with a warning.
+func (p *JournalParser) ParseFile(filename string) ([]*models.Transaction, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var transactions []*models.Transaction
+	var current *journalEntry
+	p.warnings = nil
+	warnf := func(line int, format string, args ...interface{}) {
+		p.warnings = append(p.warnings, fmt.Sprintf("Skipping entry at line %d: ", line)+fmt.Sprintf(format, args...))
+	}
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		txs, err := p.toTransactions(current)
+		if err != nil {
+			warnf(current.line, "%v", err)
+		} else {
+			transactions = append(transactions, txs...)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		raw := scanner.Text()
+		line := stripJournalComment(raw)
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		if !indented {
+			flush()
+			if match := journalHeader.FindStringSubmatch(line); match != nil && isTransactionHeader(match) {
+				date, err := time.Parse("2006-01-02", strings.NewReplacer("/", "-", ".", "-").Replace(match[1]))
+				if err != nil {
+					warnf(lineNum, "invalid date '%s'", match[1])
+					continue
+				}
+				current = &journalEntry{
+					line:        lineNum,
+					date:        date,
+					flag:        match[3],
+					description: journalDescription(match[4]),
+				}
+			}
+			// Other directives (open, option, price, account, ...) are ignored
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		posting, ok, err := parsePosting(line)
+		if err != nil {
+			warnf(current.line, "%v", err)
+			current = nil
+			continue
+		}
+		if ok {
+			current.postings = append(current.postings, posting)
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// Warnings returns the problems found in the last parsed file, such as
+// skipped entries, for the caller to report
+func (p *JournalParser) Warnings() []string {
+	return p.warnings
+}
+
+// toTransactions converts a balanced journal entry into atad transactions
+func (p *JournalParser) toTransactions(entry *journalEntry) ([]*models.Transaction, error) {
+	if len(entry.postings) < 2 {
+		return nil, fmt.Errorf("entry needs at least two postings")
+	}
+
+	// Infer the single posting allowed to omit its amount
+	missing := -1
+	sum := 0.0
+	for i, posting := range entry.postings {
+		if !posting.hasAmount {
+			if missing != -1 {
+				return nil, fmt.Errorf("more than one posting without an amount")
+			}
+			missing = i
+			continue
+		}
+		sum += posting.amount
+	}
+	if missing != -1 {
+		entry.postings[missing].amount = -sum
+		entry.postings[missing].hasAmount = true
+	}
+
+	// The asset side names the account; use the first non income/expense posting
+	account := ""
+	for _, posting := range entry.postings {
+		if _, _, ok := p.mapping.Category(posting.account); !ok {
+			account = p.mapping.Account(posting.account)
+			break
+		}
+	}
+
+	status := models.StatusUncleared
+	if entry.flag == "*" {
+		status = models.StatusCleared
+	}
+
+	var transactions []*models.Transaction
+	for _, posting := range entry.postings {
+		txType, category, ok := p.mapping.Category(posting.account)
+		if !ok || posting.amount == 0 {
+			continue
+		}
+
+		amount := posting.amount
+		if txType == "income" {
+			amount = -amount // Income postings are credits
+		}
+		if amount < 0 {
+			// Refunds and reversals flip the direction
+			amount = -amount
+			if txType == "income" {
+				txType = "expense"
+			} else {
+				txType = "income"
+			}
+		}
+
+		description := entry.description
+		if description == "" {
+			description = "Imported transaction"
+		}
+
+		transactions = append(transactions, &models.Transaction{
+			Date:        entry.date,
+			Description: description,
+			Amount:      amount,
+			Category:    category,
+			Type:        txType,
+			Account:     account,
+			Status:      status,
+		})
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("no income or expense postings (transfer between accounts)")
+	}
+	return transactions, nil
+}
+
+// isTransactionHeader tells transaction headers apart from dated directives like "open"
+func isTransactionHeader(match []string) bool {
+	if match[2] != "" || match[3] != "" {
+		return true
+	}
+	rest := strings.TrimSpace(match[4])
+	if strings.HasPrefix(rest, `"`) {
+		return true
+	}
+	switch strings.ToLower(strings.SplitN(rest, " ", 2)[0]) {
+	case "open", "close", "balance", "pad", "note", "document", "price", "event", "query", "custom", "commodity":
+		return false
+	}
+	// Ledger allows unflagged transactions with a bare payee
+	return true
+}
+
+// journalDescription extracts the payee/narration from a header
+func journalDescription(rest string) string {
+	rest = strings.TrimSpace(rest)
+	quoted := quotedString.FindAllStringSubmatch(rest, -1)
+	if len(quoted) == 0 {
+		// Ledger: the rest of the line is the payee; drop beancount-style tags/links
+		var words []string
+		for _, word := range strings.Fields(rest) {
+			if !strings.HasPrefix(word, "#") && !strings.HasPrefix(word, "^") {
+				words = append(words, word)
+			}
+		}
+		return strings.Join(words, " ")
+	}
+
+	var parts []string
+	for _, q := range quoted {
+		if text := strings.ReplaceAll(strings.ReplaceAll(q[1], `\"`, `"`), `\\`, `\`); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " - ")
+}
+
+// parsePosting reads an indented posting line. ok is false for metadata lines.
+func parsePosting(line string) (journalPosting, bool, error) {
+	line = strings.TrimSpace(line)
+
+	// Beancount posting flags
+	if strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "! ") {
+		line = strings.TrimSpace(line[2:])
+	}
+
+	// Account and amount are separated by two spaces or a tab
+	account, amountStr := line, ""
+	if i := strings.IndexAny(line, "\t"); i >= 0 {
+		account, amountStr = line[:i], line[i+1:]
+	}
+	if i := strings.Index(account, "  "); i >= 0 {
+		account, amountStr = line[:i], line[i+2:]
+	}
+	account = strings.TrimSpace(account)
+	amountStr = strings.TrimSpace(amountStr)
+
+	// Metadata ("key: value") and anything that is not an account name
+	if !strings.Contains(account, ":") || strings.HasSuffix(strings.Fields(account)[0], ":") {
+		return journalPosting{}, false, nil
+	}
+	// Ledger virtual postings
+	account = strings.Trim(account, "()[]")
+
+	if amountStr == "" {
+		return journalPosting{account: account}, true, nil
+	}
+
+	// Drop costs, prices and balance assertions
+	for _, sep := range []string{"@", "{", "="} {
+		if i := strings.Index(amountStr, sep); i >= 0 {
+			amountStr = strings.TrimSpace(amountStr[:i])
+		}
+	}
+
+	negative := false
+	if strings.HasPrefix(amountStr, "-") {
+		negative = true
+		amountStr = strings.TrimSpace(amountStr[1:])
+	}
+
+	match := postingAmount.FindStringSubmatch(amountStr)
+	if match == nil {
+		return journalPosting{}, false, fmt.Errorf("invalid amount '%s'", amountStr)
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(match[2], ",", ""), 64)
+	if err != nil {
+		return journalPosting{}, false, fmt.Errorf("invalid amount '%s'", amountStr)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return journalPosting{account: account, amount: amount, hasAmount: true}, true, nil
+}
+
+// stripJournalComment removes ';' comments and '#' comment lines
+func stripJournalComment(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "|") {
+		return ""
+	}
+	inQuotes := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
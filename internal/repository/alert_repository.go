@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+type AlertRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRepository(db *sql.DB) *AlertRepository {
+	return &AlertRepository{db: db}
+}
+
+// Create records an alert in the alert log
+func (r *AlertRepository) Create(alert *models.Alert) error {
+	query := `
+		INSERT INTO alerts (kind, category, budget_id, period_start, threshold, transaction_id, amount, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var budgetID, periodStart, transactionID interface{}
+	if alert.BudgetID != 0 {
+		budgetID = alert.BudgetID
+	}
+	if !alert.PeriodStart.IsZero() {
+		periodStart = alert.PeriodStart
+	}
+	if alert.TransactionID != 0 {
+		transactionID = alert.TransactionID
+	}
+	if alert.CreatedAt.IsZero() {
+		alert.CreatedAt = time.Now()
+	}
+
+	result, err := execWithRetry(r.db, query, alert.Kind, alert.Category, budgetID, periodStart, alert.Threshold,
+		transactionID, alert.Amount, alert.Message, alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record alert: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	alert.ID = id
+	return nil
+}
+
+// GetHighestThreshold returns the highest threshold already alerted for a
+// budget period, or 0 when none was
+func (r *AlertRepository) GetHighestThreshold(budgetID int64, periodStart time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(MAX(threshold), 0)
+		FROM alerts
+		WHERE kind = ? AND budget_id = ? AND period_start = ?
+	`
+
+	var threshold float64
+	err := r.db.QueryRow(query, models.AlertBudgetThreshold, budgetID, periodStart).Scan(&threshold)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read alert log: %w", err)
+	}
+	return threshold, nil
+}
+
+// GetRecent retrieves the latest alerts, newest first
+func (r *AlertRepository) GetRecent(limit int) ([]*models.Alert, error) {
+	query := `
+		SELECT id, kind, category, COALESCE(budget_id, 0), period_start, threshold,
+			COALESCE(transaction_id, 0), amount, message, created_at
+		FROM alerts
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*models.Alert
+	for rows.Next() {
+		alert := &models.Alert{}
+		var periodStart sql.NullTime
+		err := rows.Scan(&alert.ID, &alert.Kind, &alert.Category, &alert.BudgetID, &periodStart, &alert.Threshold,
+			&alert.TransactionID, &alert.Amount, &alert.Message, &alert.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		if periodStart.Valid {
+			alert.PeriodStart = periodStart.Time
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+const billColumns = `id, name, amount, period, due_date, pattern, tolerance, created_at`
+
+// scanBill reads a row selected with billColumns
+func scanBill(row rowScanner) (*models.Bill, error) {
+	bill := &models.Bill{}
+	err := row.Scan(&bill.ID, &bill.Name, &bill.Amount, &bill.Period, &bill.DueDate, &bill.Pattern, &bill.Tolerance, &bill.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+type BillRepository struct {
+	db *sql.DB
+}
+
+func NewBillRepository(db *sql.DB) *BillRepository {
+	return &BillRepository{db: db}
+}
+
+// Create adds a new bill
+func (r *BillRepository) Create(bill *models.Bill) error {
+	query := `
+		INSERT INTO bills (name, amount, period, due_date, pattern, tolerance, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	bill.CreatedAt = time.Now()
+	result, err := execWithRetry(r.db, query, bill.Name, bill.Amount, bill.Period, bill.DueDate, bill.Pattern, bill.Tolerance, bill.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bill: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	bill.ID = id
+	return nil
+}
+
+// Get retrieves a bill by name, or nil when there is none
+func (r *BillRepository) Get(name string) (*models.Bill, error) {
+	bill, err := scanBill(r.db.QueryRow(`SELECT `+billColumns+` FROM bills WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+	return bill, nil
+}
+
+// GetAll retrieves every bill ordered by name
+func (r *BillRepository) GetAll() ([]*models.Bill, error) {
+	rows, err := r.db.Query(`SELECT ` + billColumns + ` FROM bills ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bills: %w", err)
+	}
+	defer rows.Close()
+
+	var bills []*models.Bill
+	for rows.Next() {
+		bill, err := scanBill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
+		}
+		bills = append(bills, bill)
+	}
+
+	return bills, rows.Err()
+}
+
+// Delete removes a bill and the due dates marked paid by name
+func (r *BillRepository) Delete(name string) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin deleting bill: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		var id int64
+		err = dbTx.QueryRow(`SELECT id FROM bills WHERE name = ?`, name).Scan(&id)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("bill '%s' %w", name, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get bill: %w", err)
+		}
+		if _, err := dbTx.Exec(`DELETE FROM bill_payments WHERE bill_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete bill payments: %w", err)
+		}
+		if _, err := dbTx.Exec(`DELETE FROM bills WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete bill: %w", err)
+		}
+		return dbTx.Commit()
+	})
+}
+
+// MarkPaid records a due date of a bill as paid by hand
+func (r *BillRepository) MarkPaid(payment *models.BillPayment) error {
+	query := `
+		INSERT INTO bill_payments (bill_id, due_date, note, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(bill_id, due_date) DO UPDATE SET note = excluded.note
+	`
+
+	payment.CreatedAt = time.Now()
+	if _, err := execWithRetry(r.db, query, payment.BillID, payment.DueDate, payment.Note, payment.CreatedAt); err != nil {
+		return fmt.Errorf("failed to mark bill paid: %w", err)
+	}
+	return nil
+}
+
+// GetPayments returns the due dates marked paid by hand, per bill id
+func (r *BillRepository) GetPayments() (map[int64][]*models.BillPayment, error) {
+	rows, err := r.db.Query(`SELECT id, bill_id, due_date, note, created_at FROM bill_payments ORDER BY due_date`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bill payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := make(map[int64][]*models.BillPayment)
+	for rows.Next() {
+		p := &models.BillPayment{}
+		if err := rows.Scan(&p.ID, &p.BillID, &p.DueDate, &p.Note, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bill payment: %w", err)
+		}
+		payments[p.BillID] = append(payments[p.BillID], p)
+	}
+
+	return payments, rows.Err()
+}
+
+// GetCandidates returns the expenses from startDate through the whole of
+// endDate whose description contains pattern, ignoring case, oldest first
+func (r *BillRepository) GetCandidates(pattern string, startDate, endDate time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE type = 'expense' AND instr(LOWER(description), ?) > 0 AND date >= ? AND date < ?
+		ORDER BY date, id
+	`
+
+	rows, err := r.db.Query(query, strings.ToLower(pattern), startDate, dayAfter(endDate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bill transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
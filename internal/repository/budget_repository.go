@@ -3,10 +3,36 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/PeguB/atad-project/internal/models"
 )
 
+const budgetColumns = `id, category, type, amount, period, start_date, end_date, rollover, rollover_cap, alert_thresholds`
+
+// scanBudget reads a row selected with budgetColumns
+func scanBudget(row rowScanner) (*models.Budget, error) {
+	budget := &models.Budget{}
+	var startDate, endDate sql.NullTime
+	var thresholds string
+	err := row.Scan(&budget.ID, &budget.Category, &budget.Type, &budget.Amount, &budget.Period, &startDate, &endDate,
+		&budget.Rollover, &budget.RolloverCap, &thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if budget.AlertThresholds, err = models.ParseThresholds(thresholds); err != nil {
+		return nil, err
+	}
+	if startDate.Valid {
+		budget.StartDate = startDate.Time
+	}
+	if endDate.Valid {
+		budget.EndDate = endDate.Time
+	}
+	return budget, nil
+}
+
 type BudgetRepository struct {
 	db *sql.DB
 }
@@ -15,12 +41,21 @@ func NewBudgetRepository(db *sql.DB) *BudgetRepository {
 	return &BudgetRepository{db: db}
 }
 
-// Create adds a new budget
-func (r *BudgetRepository) Create(budget *models.Budget) error {
-	query := `
-		INSERT INTO budgets (category, amount, period, start_date, end_date)
-		VALUES (?, ?, ?, ?, ?)
-	`
+// insertBudget is the statement adding a budget, with the arguments of budgetArgs
+const insertBudget = `
+	INSERT INTO budgets (category, type, amount, period, start_date, end_date, rollover, rollover_cap, alert_thresholds)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// budgetArgs fills in the defaults of a new budget and returns the arguments
+// of insertBudget
+func budgetArgs(budget *models.Budget) []interface{} {
+	if budget.Type == "" {
+		budget.Type = models.BudgetExpense
+	}
+	if budget.Rollover == "" {
+		budget.Rollover = models.RolloverNone
+	}
 
 	var startDate, endDate interface{}
 	if !budget.StartDate.IsZero() {
@@ -29,8 +64,13 @@ func (r *BudgetRepository) Create(budget *models.Budget) error {
 	if !budget.EndDate.IsZero() {
 		endDate = budget.EndDate
 	}
+	return []interface{}{budget.Category, budget.Type, budget.Amount, budget.Period, startDate, endDate,
+		budget.Rollover, budget.RolloverCap, models.FormatThresholds(budget.AlertThresholds)}
+}
 
-	result, err := r.db.Exec(query, budget.Category, budget.Amount, budget.Period, startDate, endDate)
+// Create adds a new budget
+func (r *BudgetRepository) Create(budget *models.Budget) error {
+	result, err := execWithRetry(r.db, insertBudget, budgetArgs(budget)...)
 	if err != nil {
 		return fmt.Errorf("failed to create budget: %w", err)
 	}
@@ -44,114 +84,161 @@ func (r *BudgetRepository) Create(budget *models.Budget) error {
 	return nil
 }
 
-// GetByCategory retrieves a budget for a specific category
-func (r *BudgetRepository) GetByCategory(category string) (*models.Budget, error) {
+// CreateAll adds several budgets in a single database transaction, so either
+// all of them are created or none
+func (r *BudgetRepository) CreateAll(budgets []*models.Budget) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin creating budgets: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		ids := make([]int64, len(budgets))
+		for i, budget := range budgets {
+			result, err := dbTx.Exec(insertBudget, budgetArgs(budget)...)
+			if err != nil {
+				return fmt.Errorf("failed to create the '%s' budget: %w", budget.Category, err)
+			}
+			if ids[i], err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		}
+		if err := dbTx.Commit(); err != nil {
+			return err
+		}
+
+		for i, budget := range budgets {
+			budget.ID = ids[i]
+		}
+		return nil
+	})
+}
+
+// GetByID retrieves a budget by its id
+func (r *BudgetRepository) GetByID(id int64) (*models.Budget, error) {
 	query := `
-		SELECT id, category, amount, period, start_date, end_date
+		SELECT ` + budgetColumns + `
 		FROM budgets
-		WHERE category = ?
-		ORDER BY start_date DESC
-		LIMIT 1
+		WHERE id = ?
 	`
 
-	budget := &models.Budget{}
-	var startDate, endDate sql.NullTime
-	err := r.db.QueryRow(query, category).Scan(
-		&budget.ID,
-		&budget.Category,
-		&budget.Amount,
-		&budget.Period,
-		&startDate,
-		&endDate,
-	)
-
+	budget, err := scanBudget(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, nil // No budget set for this category
+		return nil, nil
 	}
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to get budget: %w", err)
 	}
 
-	if startDate.Valid {
-		budget.StartDate = startDate.Time
-	}
-	if endDate.Valid {
-		budget.EndDate = endDate.Time
-	}
-
 	return budget, nil
 }
 
-// GetByCategoryAndPeriod retrieves a budget for a specific category and period
-func (r *BudgetRepository) GetByCategoryAndPeriod(category, period string) (*models.Budget, error) {
+// GetAllByCategory retrieves every budget of a category, oldest first
+func (r *BudgetRepository) GetAllByCategory(category string) ([]*models.Budget, error) {
 	query := `
-		SELECT id, category, amount, period, start_date, end_date
+		SELECT ` + budgetColumns + `
 		FROM budgets
-		WHERE category = ? AND period = ?
+		WHERE category = ?
+		ORDER BY start_date, id
 	`
 
-	budget := &models.Budget{}
-	var startDate, endDate sql.NullTime
-	err := r.db.QueryRow(query, category, period).Scan(
-		&budget.ID,
-		&budget.Category,
-		&budget.Amount,
-		&budget.Period,
-		&startDate,
-		&endDate,
-	)
+	rows, err := r.db.Query(query, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budgets: %w", err)
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return nil, nil // No budget set for this category and period
+	var budgets []*models.Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
 	}
 
+	return budgets, rows.Err()
+}
+
+// GetOverlapping retrieves a budget of the same category that overlaps budget,
+// ignoring budget itself, or nil when there is none
+func (r *BudgetRepository) GetOverlapping(budget *models.Budget) (*models.Budget, error) {
+	budgets, err := r.GetAllByCategory(budget.Category)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get budget: %w", err)
+		return nil, err
+	}
+	for _, other := range budgets {
+		if other.ID != budget.ID && budget.Overlaps(other) {
+			return other, nil
+		}
 	}
+	return nil, nil
+}
 
-	if startDate.Valid {
-		budget.StartDate = startDate.Time
+// GetByCategoryAt retrieves the budget of a category and type that covers
+// date. A custom budget covering the date wins over a recurring one; among
+// recurring budgets the one with the latest anchor applies.
+func (r *BudgetRepository) GetByCategoryAt(category, budgetType string, date time.Time) (*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE category = ? AND type = ?
+		ORDER BY period = 'custom' DESC, start_date DESC
+	`
+
+	rows, err := r.db.Query(query, category, budgetType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budgets: %w", err)
 	}
-	if endDate.Valid {
-		budget.EndDate = endDate.Time
+	defer rows.Close()
+
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		if _, _, ok := budget.PeriodAt(date); ok {
+			return budget, nil
+		}
 	}
 
-	return budget, nil
+	return nil, rows.Err()
 }
 
-// GetByCategoryAndDateRange retrieves a budget for a specific category and custom date range
-func (r *BudgetRepository) GetByCategoryAndDateRange(category string, startDate, endDate interface{}) (*models.Budget, error) {
+// GetByCategoryAndPeriod retrieves a budget for a specific category, type and period
+func (r *BudgetRepository) GetByCategoryAndPeriod(category, budgetType, period string) (*models.Budget, error) {
 	query := `
-		SELECT id, category, amount, period, start_date, end_date
+		SELECT ` + budgetColumns + `
 		FROM budgets
-		WHERE category = ? AND period = 'custom' AND start_date = ? AND end_date = ?
+		WHERE category = ? AND type = ? AND period = ?
 	`
 
-	budget := &models.Budget{}
-	var sd, ed sql.NullTime
-	err := r.db.QueryRow(query, category, startDate, endDate).Scan(
-		&budget.ID,
-		&budget.Category,
-		&budget.Amount,
-		&budget.Period,
-		&sd,
-		&ed,
-	)
-
+	budget, err := scanBudget(r.db.QueryRow(query, category, budgetType, period))
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, nil // No budget set for this category and period
 	}
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to get budget: %w", err)
 	}
 
-	if sd.Valid {
-		budget.StartDate = sd.Time
+	return budget, nil
+}
+
+// GetByCategoryAndDateRange retrieves a budget for a specific category, type and custom date range
+func (r *BudgetRepository) GetByCategoryAndDateRange(category, budgetType string, startDate, endDate interface{}) (*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE category = ? AND type = ? AND period = 'custom' AND start_date = ? AND end_date = ?
+	`
+
+	budget, err := scanBudget(r.db.QueryRow(query, category, budgetType, startDate, endDate))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if ed.Valid {
-		budget.EndDate = ed.Time
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
 	}
 
 	return budget, nil
@@ -160,7 +247,7 @@ func (r *BudgetRepository) GetByCategoryAndDateRange(category string, startDate,
 // GetAll retrieves all budgets
 func (r *BudgetRepository) GetAll() ([]*models.Budget, error) {
 	query := `
-		SELECT id, category, amount, period, start_date, end_date
+		SELECT ` + budgetColumns + `
 		FROM budgets
 		ORDER BY category
 	`
@@ -173,33 +260,41 @@ func (r *BudgetRepository) GetAll() ([]*models.Budget, error) {
 
 	var budgets []*models.Budget
 	for rows.Next() {
-		budget := &models.Budget{}
-		var startDate, endDate sql.NullTime
-		err := rows.Scan(&budget.ID, &budget.Category, &budget.Amount, &budget.Period, &startDate, &endDate)
+		budget, err := scanBudget(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan budget: %w", err)
 		}
-		if startDate.Valid {
-			budget.StartDate = startDate.Time
-		}
-		if endDate.Valid {
-			budget.EndDate = endDate.Time
-		}
 		budgets = append(budgets, budget)
 	}
 
 	return budgets, rows.Err()
 }
 
-// Update modifies an existing budget
-func (r *BudgetRepository) Update(budget *models.Budget) error {
-	query := `
-		UPDATE budgets
-		SET amount = ?
-		WHERE category = ? AND period = ?
-	`
+// updateBudget is the statement changing a budget, with the arguments of
+// updateArgs
+const updateBudget = `
+	UPDATE budgets
+	SET category = ?, type = ?, amount = ?, period = ?, start_date = ?, end_date = ?, rollover = ?, rollover_cap = ?,
+		alert_thresholds = ?
+	WHERE id = ?
+`
+
+func updateArgs(budget *models.Budget) []interface{} {
+	if budget.Rollover == "" {
+		budget.Rollover = models.RolloverNone
+	}
 
-	result, err := r.db.Exec(query, budget.Amount, budget.Category, budget.Period)
+	var endDate interface{}
+	if !budget.EndDate.IsZero() {
+		endDate = budget.EndDate
+	}
+	return []interface{}{budget.Category, budget.Type, budget.Amount, budget.Period, budget.StartDate, endDate,
+		budget.Rollover, budget.RolloverCap, models.FormatThresholds(budget.AlertThresholds), budget.ID}
+}
+
+// Update modifies the budget with budget.ID
+func (r *BudgetRepository) Update(budget *models.Budget) error {
+	result, err := execWithRetry(r.db, updateBudget, updateArgs(budget)...)
 	if err != nil {
 		return fmt.Errorf("failed to update budget: %w", err)
 	}
@@ -210,16 +305,16 @@ func (r *BudgetRepository) Update(budget *models.Budget) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("budget not found")
+		return fmt.Errorf("budget %d %w", budget.ID, ErrNotFound)
 	}
 
 	return nil
 }
 
-// Delete removes a budget by category and period
-func (r *BudgetRepository) Delete(category, period string) error {
-	query := `DELETE FROM budgets WHERE category = ? AND period = ?`
-	result, err := r.db.Exec(query, category, period)
+// Delete removes a budget by id
+func (r *BudgetRepository) Delete(id int64) error {
+	query := `DELETE FROM budgets WHERE id = ?`
+	result, err := execWithRetry(r.db, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete budget: %w", err)
 	}
@@ -230,24 +325,25 @@ func (r *BudgetRepository) Delete(category, period string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("budget not found")
+		return fmt.Errorf("budget %d %w", id, ErrNotFound)
 	}
 
 	return nil
 }
 
-// GetSpending calculates total spending for a category in a given date range
-func (r *BudgetRepository) GetSpending(category string, startDate, endDate interface{}) (float64, error) {
+// GetSpending calculates total spending for a category from startDate through
+// the whole of endDate
+func (r *BudgetRepository) GetSpending(category string, startDate, endDate time.Time) (float64, error) {
 	query := `
 		SELECT COALESCE(SUM(amount), 0)
 		FROM transactions
 		WHERE category = ? 
 		AND type = 'expense'
-		AND date >= ? AND date <= ?
+		AND date >= ? AND date < ?
 	`
 
 	var total float64
-	err := r.db.QueryRow(query, category, startDate, endDate).Scan(&total)
+	err := r.db.QueryRow(query, category, startDate, dayAfter(endDate)).Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get spending: %w", err)
 	}
@@ -255,21 +351,91 @@ func (r *BudgetRepository) GetSpending(category string, startDate, endDate inter
 	return total, nil
 }
 
-// GetIncome calculates total income for a category in a given date range
-func (r *BudgetRepository) GetIncome(category string, startDate, endDate interface{}) (float64, error) {
+// SpendingRange is a category and an inclusive date range to total
+// transactions of one type over
+type SpendingRange struct {
+	Category string
+	Type     string // "expense" or "income"; empty means "expense"
+	Start    time.Time
+	End      time.Time
+}
+
+// maxRangesPerQuery keeps GetSpendingByRanges below SQLite's limit on bound
+// parameters
+const maxRangesPerQuery = 2000
+
+// GetSpendingByRanges totals the transactions of every range with one
+// aggregated query, returning the totals in the order of ranges
+func (r *BudgetRepository) GetSpendingByRanges(ranges []SpendingRange) ([]float64, error) {
+	totals := make([]float64, len(ranges))
+	for offset := 0; offset < len(ranges); offset += maxRangesPerQuery {
+		chunk := ranges[offset:min(offset+maxRangesPerQuery, len(ranges))]
+
+		values := make([]string, len(chunk))
+		args := make([]interface{}, 0, 5*len(chunk))
+		for i, rng := range chunk {
+			txType := rng.Type
+			if txType == "" {
+				txType = "expense"
+			}
+			values[i] = "(?, ?, ?, ?, ?)"
+			args = append(args, offset+i, rng.Category, txType, rng.Start, dayAfter(rng.End))
+		}
+		query := `
+			WITH ranges(idx, category, type, start_date, end_before) AS (VALUES ` + strings.Join(values, ", ") + `)
+			SELECT ranges.idx, COALESCE(SUM(t.amount), 0)
+			FROM ranges
+			LEFT JOIN transactions t
+				ON t.category = ranges.category
+				AND t.type = ranges.type
+				AND t.date >= ranges.start_date AND t.date < ranges.end_before
+			GROUP BY ranges.idx
+		`
+
+		rows, err := r.db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get spending: %w", err)
+		}
+		for rows.Next() {
+			var idx int
+			var total float64
+			if err := rows.Scan(&idx, &total); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan spending: %w", err)
+			}
+			totals[idx] = total
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get spending: %w", err)
+		}
+	}
+	return totals, nil
+}
+
+// GetIncome calculates total income for a category from startDate through the
+// whole of endDate
+func (r *BudgetRepository) GetIncome(category string, startDate, endDate time.Time) (float64, error) {
 	query := `
 		SELECT COALESCE(SUM(amount), 0)
 		FROM transactions
 		WHERE category = ? 
 		AND type = 'income'
-		AND date >= ? AND date <= ?
+		AND date >= ? AND date < ?
 	`
 
 	var total float64
-	err := r.db.QueryRow(query, category, startDate, endDate).Scan(&total)
+	err := r.db.QueryRow(query, category, startDate, dayAfter(endDate)).Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get income: %w", err)
 	}
 
 	return total, nil
 }
+
+// dayAfter returns the start of the day after t, the exclusive upper bound
+// for a range that includes all of t's day
+func dayAfter(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// insertRule and updateRule write a categorization rule
+const (
+	insertRule = `INSERT INTO category_rules (category, pattern, description, priority) VALUES (?, ?, ?, ?)`
+	updateRule = `UPDATE category_rules SET category = ?, pattern = ?, description = ?, priority = ? WHERE id = ?`
+)
+
+type CategoryRuleRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRuleRepository(db *sql.DB) *CategoryRuleRepository {
+	return &CategoryRuleRepository{db: db}
+}
+
+// Create adds a new categorization rule
+func (r *CategoryRuleRepository) Create(rule *models.CategoryRule) error {
+	result, err := execWithRetry(r.db, insertRule, rule.Category, rule.Pattern, rule.Description, rule.Priority)
+	if err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	rule.ID = id
+	return nil
+}
+
+// GetAll retrieves every categorization rule, highest priority first
+func (r *CategoryRuleRepository) GetAll() ([]models.CategoryRule, error) {
+	query := `
+		SELECT id, category, pattern, description, priority
+		FROM category_rules
+		ORDER BY priority DESC, id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.CategoryRule
+	for rows.Next() {
+		var rule models.CategoryRule
+		if err := rows.Scan(&rule.ID, &rule.Category, &rule.Pattern, &rule.Description, &rule.Priority); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// Changeset lists budget and categorization rule writes that belong together
+type Changeset struct {
+	CreateBudgets []*models.Budget
+	UpdateBudgets []*models.Budget
+	DeleteBudgets []int64
+	CreateRules   []*models.CategoryRule
+	UpdateRules   []*models.CategoryRule
+	DeleteRules   []int64
+}
+
+// Len counts the writes of a changeset
+func (c *Changeset) Len() int {
+	return len(c.CreateBudgets) + len(c.UpdateBudgets) + len(c.DeleteBudgets) +
+		len(c.CreateRules) + len(c.UpdateRules) + len(c.DeleteRules)
+}
+
+type ChangesetRepository struct {
+	db *sql.DB
+}
+
+func NewChangesetRepository(db *sql.DB) *ChangesetRepository {
+	return &ChangesetRepository{db: db}
+}
+
+// Apply writes a changeset in a single database transaction. Deletes run
+// first and creates last, so a unique key given up by one record can be
+// taken by another.
+func (r *ChangesetRepository) Apply(c *Changeset) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin changes: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		for _, id := range c.DeleteBudgets {
+			if _, err := dbTx.Exec(`DELETE FROM budgets WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete budget %d: %w", id, err)
+			}
+		}
+		for _, id := range c.DeleteRules {
+			if _, err := dbTx.Exec(`DELETE FROM category_rules WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete rule %d: %w", id, err)
+			}
+		}
+		for _, budget := range c.UpdateBudgets {
+			if _, err := dbTx.Exec(updateBudget, updateArgs(budget)...); err != nil {
+				return fmt.Errorf("failed to update the '%s' budget: %w", budget.Category, err)
+			}
+		}
+		for _, rule := range c.UpdateRules {
+			if _, err := dbTx.Exec(updateRule, rule.Category, rule.Pattern, rule.Description, rule.Priority, rule.ID); err != nil {
+				return fmt.Errorf("failed to update rule %d: %w", rule.ID, err)
+			}
+		}
+
+		budgetIDs := make([]int64, len(c.CreateBudgets))
+		for i, budget := range c.CreateBudgets {
+			result, err := dbTx.Exec(insertBudget, budgetArgs(budget)...)
+			if err != nil {
+				return fmt.Errorf("failed to create the '%s' budget: %w", budget.Category, err)
+			}
+			if budgetIDs[i], err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		}
+		ruleIDs := make([]int64, len(c.CreateRules))
+		for i, rule := range c.CreateRules {
+			result, err := dbTx.Exec(insertRule, rule.Category, rule.Pattern, rule.Description, rule.Priority)
+			if err != nil {
+				return fmt.Errorf("failed to create the '%s' rule: %w", rule.Category, err)
+			}
+			if ruleIDs[i], err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		}
+		if err := dbTx.Commit(); err != nil {
+			return err
+		}
+
+		for i, budget := range c.CreateBudgets {
+			budget.ID = budgetIDs[i]
+		}
+		for i, rule := range c.CreateRules {
+			rule.ID = ruleIDs[i]
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+// envelopeStartKey is the settings key holding the date envelope budgeting began
+const envelopeStartKey = "envelope.start"
+
+type EnvelopeRepository struct {
+	db *sql.DB
+}
+
+func NewEnvelopeRepository(db *sql.DB) *EnvelopeRepository {
+	return &EnvelopeRepository{db: db}
+}
+
+// Assign records an assignment to an envelope
+func (r *EnvelopeRepository) Assign(a *models.EnvelopeAssignment) error {
+	query := `
+		INSERT INTO envelope_assignments (date, category, amount, note, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	a.CreatedAt = time.Now()
+	result, err := execWithRetry(r.db, query, a.Date, a.Category, a.Amount, a.Note, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to assign to envelope: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	a.ID = id
+	return nil
+}
+
+// Move takes amount out of one envelope and puts it into another, as a pair
+// of assignments written in a single database transaction
+func (r *EnvelopeRepository) Move(from, to string, amount float64, date time.Time, note string) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin move: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		query := `
+			INSERT INTO envelope_assignments (date, category, amount, note, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`
+		now := time.Now()
+		if _, err := dbTx.Exec(query, date, from, -amount, note, now); err != nil {
+			return fmt.Errorf("failed to move from envelope: %w", err)
+		}
+		if _, err := dbTx.Exec(query, date, to, amount, note, now); err != nil {
+			return fmt.Errorf("failed to move to envelope: %w", err)
+		}
+
+		return dbTx.Commit()
+	})
+}
+
+// GetAssigned returns the total assigned to each envelope
+func (r *EnvelopeRepository) GetAssigned() (map[string]float64, error) {
+	rows, err := r.db.Query(`SELECT category, SUM(amount) FROM envelope_assignments GROUP BY category`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query envelope assignments: %w", err)
+	}
+	defer rows.Close()
+
+	assigned := make(map[string]float64)
+	for rows.Next() {
+		var category string
+		var amount float64
+		if err := rows.Scan(&category, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan envelope assignment: %w", err)
+		}
+		assigned[category] = amount
+	}
+
+	return assigned, rows.Err()
+}
+
+// GetStart returns the date envelope budgeting began; ok is false before the
+// first assignment
+func (r *EnvelopeRepository) GetStart() (start time.Time, ok bool, err error) {
+	var value string
+	err = r.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, envelopeStartKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get envelope start: %w", err)
+	}
+	start, err = time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid envelope start '%s': %w", value, err)
+	}
+	return start, true, nil
+}
+
+// SetStart sets the date from which income and spending count toward envelopes
+func (r *EnvelopeRepository) SetStart(start time.Time) error {
+	query := `INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`
+	if _, err := execWithRetry(r.db, query, envelopeStartKey, start.Format(time.DateOnly)); err != nil {
+		return fmt.Errorf("failed to set envelope start: %w", err)
+	}
+	return nil
+}
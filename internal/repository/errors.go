@@ -0,0 +1,9 @@
+package repository
+
+import "errors"
+
+// ErrNotFound is wrapped by errors about records that do not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrTransactionLocked is returned when modifying a transaction that has been reconciled
+var ErrTransactionLocked = errors.New("transaction is reconciled and locked against edits")
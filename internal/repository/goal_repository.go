@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+const goalColumns = `id, name, target, target_date, account, category, start_date, created_at`
+
+// scanGoal reads a row selected with goalColumns
+func scanGoal(row rowScanner) (*models.Goal, error) {
+	goal := &models.Goal{}
+	var targetDate sql.NullTime
+	err := row.Scan(&goal.ID, &goal.Name, &goal.Target, &targetDate, &goal.Account, &goal.Category, &goal.StartDate, &goal.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if targetDate.Valid {
+		goal.TargetDate = targetDate.Time
+	}
+	return goal, nil
+}
+
+type GoalRepository struct {
+	db *sql.DB
+}
+
+func NewGoalRepository(db *sql.DB) *GoalRepository {
+	return &GoalRepository{db: db}
+}
+
+// Create adds a new goal
+func (r *GoalRepository) Create(goal *models.Goal) error {
+	query := `
+		INSERT INTO goals (name, target, target_date, account, category, start_date, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var targetDate interface{}
+	if goal.HasDeadline() {
+		targetDate = goal.TargetDate
+	}
+	goal.CreatedAt = time.Now()
+	result, err := execWithRetry(r.db, query, goal.Name, goal.Target, targetDate, goal.Account, goal.Category, goal.StartDate, goal.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	goal.ID = id
+	return nil
+}
+
+// Get retrieves a goal by name, or nil when there is none
+func (r *GoalRepository) Get(name string) (*models.Goal, error) {
+	goal, err := scanGoal(r.db.QueryRow(`SELECT `+goalColumns+` FROM goals WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+	return goal, nil
+}
+
+// GetAll retrieves every goal, those due soonest first and goals without a
+// target date last
+func (r *GoalRepository) GetAll() ([]*models.Goal, error) {
+	rows, err := r.db.Query(`SELECT ` + goalColumns + ` FROM goals ORDER BY target_date IS NULL, target_date, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []*models.Goal
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, rows.Err()
+}
+
+// Delete removes a goal and its contributions by name
+func (r *GoalRepository) Delete(name string) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin deleting goal: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		var id int64
+		err = dbTx.QueryRow(`SELECT id FROM goals WHERE name = ?`, name).Scan(&id)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("goal '%s' %w", name, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get goal: %w", err)
+		}
+		if _, err := dbTx.Exec(`DELETE FROM goal_contributions WHERE goal_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete goal contributions: %w", err)
+		}
+		if _, err := dbTx.Exec(`DELETE FROM goals WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete goal: %w", err)
+		}
+		return dbTx.Commit()
+	})
+}
+
+// AddContribution records money put towards a goal, or taken out of it
+func (r *GoalRepository) AddContribution(c *models.GoalContribution) error {
+	query := `
+		INSERT INTO goal_contributions (goal_id, date, amount, note, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	c.CreatedAt = time.Now()
+	result, err := execWithRetry(r.db, query, c.GoalID, c.Date, c.Amount, c.Note, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add contribution: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	c.ID = id
+	return nil
+}
+
+// GetContributions returns the contributions recorded for a goal, oldest first
+func (r *GoalRepository) GetContributions(goalID int64) ([]*models.GoalContribution, error) {
+	query := `
+		SELECT id, goal_id, date, amount, note, created_at
+		FROM goal_contributions
+		WHERE goal_id = ?
+		ORDER BY date, id
+	`
+
+	rows, err := r.db.Query(query, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contributions: %w", err)
+	}
+	defer rows.Close()
+
+	var contributions []*models.GoalContribution
+	for rows.Next() {
+		c := &models.GoalContribution{}
+		if err := rows.Scan(&c.ID, &c.GoalID, &c.Date, &c.Amount, &c.Note, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan contribution: %w", err)
+		}
+		contributions = append(contributions, c)
+	}
+
+	return contributions, rows.Err()
+}
+
+// GetSaved totals what every goal has saved up to the whole of endDate, per
+// goal id: the contributions recorded by hand and the linked transactions
+// since the goal's start. Income into a linked account and expenses in a
+// linked category add to a goal; the opposite transactions take from it.
+func (r *GoalRepository) GetSaved(endDate time.Time) (manual, linked map[int64]float64, err error) {
+	manual = make(map[int64]float64)
+	linked = make(map[int64]float64)
+
+	query := `
+		SELECT goal_id, SUM(amount)
+		FROM goal_contributions
+		WHERE date < ?
+		GROUP BY goal_id
+	`
+	if err := r.sumByGoal(manual, query, dayAfter(endDate)); err != nil {
+		return nil, nil, fmt.Errorf("failed to sum contributions: %w", err)
+	}
+
+	query = `
+		SELECT g.id, SUM(CASE
+			WHEN g.account != '' THEN CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END
+			ELSE CASE WHEN t.type = 'expense' THEN t.amount ELSE -t.amount END
+		END)
+		FROM goals g
+		JOIN transactions t
+			ON ((g.account != '' AND t.account = g.account) OR (g.account = '' AND g.category != '' AND t.category = g.category))
+			AND t.date >= g.start_date AND t.date < ?
+		GROUP BY g.id
+	`
+	if err := r.sumByGoal(linked, query, dayAfter(endDate)); err != nil {
+		return nil, nil, fmt.Errorf("failed to sum linked transactions: %w", err)
+	}
+	return manual, linked, nil
+}
+
+// sumByGoal reads (goal id, total) rows into totals
+func (r *GoalRepository) sumByGoal(totals map[int64]float64, query string, args ...interface{}) error {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var total float64
+		if err := rows.Scan(&id, &total); err != nil {
+			return err
+		}
+		totals[id] = total
+	}
+	return rows.Err()
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+const loanColumns = `id, name, principal, rate, term_months, payment, category, start_date, created_at`
+
+// scanLoan reads a row selected with loanColumns
+func scanLoan(row rowScanner) (*models.Loan, error) {
+	loan := &models.Loan{}
+	err := row.Scan(&loan.ID, &loan.Name, &loan.Principal, &loan.Rate, &loan.TermMonths, &loan.Payment,
+		&loan.Category, &loan.StartDate, &loan.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+type LoanRepository struct {
+	db *sql.DB
+}
+
+func NewLoanRepository(db *sql.DB) *LoanRepository {
+	return &LoanRepository{db: db}
+}
+
+// Create adds a new loan
+func (r *LoanRepository) Create(loan *models.Loan) error {
+	query := `
+		INSERT INTO loans (name, principal, rate, term_months, payment, category, start_date, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	loan.CreatedAt = time.Now()
+	result, err := execWithRetry(r.db, query, loan.Name, loan.Principal, loan.Rate, loan.TermMonths, loan.Payment,
+		loan.Category, loan.StartDate, loan.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create loan: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	loan.ID = id
+	return nil
+}
+
+// Get retrieves a loan by name, or nil when there is none
+func (r *LoanRepository) Get(name string) (*models.Loan, error) {
+	loan, err := scanLoan(r.db.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+	return loan, nil
+}
+
+// GetByCategory retrieves the loan paid in a category, or nil when there is none
+func (r *LoanRepository) GetByCategory(category string) (*models.Loan, error) {
+	loan, err := scanLoan(r.db.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE category = ?`, category))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan: %w", err)
+	}
+	return loan, nil
+}
+
+// GetAll retrieves every loan ordered by name
+func (r *LoanRepository) GetAll() ([]*models.Loan, error) {
+	rows, err := r.db.Query(`SELECT ` + loanColumns + ` FROM loans ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []*models.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+// Delete removes a loan by name; its payment transactions are kept
+func (r *LoanRepository) Delete(name string) error {
+	result, err := execWithRetry(r.db, `DELETE FROM loans WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete loan: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("loan '%s' %w", name, ErrNotFound)
+	}
+	return nil
+}
+
+// GetPayments returns the payments of a loan up to the whole of endDate,
+// oldest first: the expenses in its category from its start date
+func (r *LoanRepository) GetPayments(loan *models.Loan, endDate time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE type = 'expense' AND category = ? AND date >= ? AND date < ?
+		ORDER BY date, id
+	`
+
+	rows, err := r.db.Query(query, loan.Category, loan.StartDate, dayAfter(endDate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loan payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan payment: %w", err)
+		}
+		payments = append(payments, tx)
+	}
+
+	return payments, rows.Err()
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// Finish locks every cleared transaction of the account up to the statement
+// date and records the statement, all in a single database transaction
+func (r *ReconciliationRepository) Finish(rec *models.Reconciliation) error {
+	return withRetry(func() error {
+		return r.finish(rec)
+	})
+}
+
+func (r *ReconciliationRepository) finish(rec *models.Reconciliation) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin reconciliation: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	_, err = dbTx.Exec(`
+		UPDATE transactions
+		SET status = ?
+		WHERE account = ? AND status = ? AND date <= ?
+	`, models.StatusReconciled, rec.Account, models.StatusCleared, rec.StatementDate)
+	if err != nil {
+		return fmt.Errorf("failed to lock cleared transactions: %w", err)
+	}
+
+	rec.ReconciledAt = time.Now()
+	result, err := dbTx.Exec(`
+		INSERT INTO reconciliations (account, statement_date, statement_balance, reconciled_at)
+		VALUES (?, ?, ?, ?)
+	`, rec.Account, rec.StatementDate, rec.StatementBalance, rec.ReconciledAt)
+	if err != nil {
+		return fmt.Errorf("failed to record reconciliation: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	rec.ID = id
+
+	return dbTx.Commit()
+}
+
+// GetLatest retrieves the most recent reconciliation of an account
+func (r *ReconciliationRepository) GetLatest(account string) (*models.Reconciliation, error) {
+	query := `
+		SELECT id, account, statement_date, statement_balance, reconciled_at
+		FROM reconciliations
+		WHERE account = ?
+		ORDER BY statement_date DESC, id DESC
+		LIMIT 1
+	`
+
+	rec := &models.Reconciliation{}
+	err := r.db.QueryRow(query, account).Scan(&rec.ID, &rec.Account, &rec.StatementDate, &rec.StatementBalance, &rec.ReconciledAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Account never reconciled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation: %w", err)
+	}
+
+	return rec, nil
+}
+
+// GetOpeningBalance returns the balance an account had before its first
+// transaction, or 0 when none was set
+func (r *ReconciliationRepository) GetOpeningBalance(account string) (float64, error) {
+	var balance float64
+	err := r.db.QueryRow(`SELECT balance FROM opening_balances WHERE account = ?`, account).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+	return balance, nil
+}
+
+// SetOpeningBalance stores the balance an account had before its first transaction
+func (r *ReconciliationRepository) SetOpeningBalance(account string, balance float64) error {
+	query := `
+		INSERT INTO opening_balances (account, balance, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(account) DO UPDATE SET balance = excluded.balance, updated_at = excluded.updated_at
+	`
+
+	if _, err := execWithRetry(r.db, query, account, balance, time.Now()); err != nil {
+		return fmt.Errorf("failed to set opening balance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Writes that still find the database locked after SQLite's busy timeout,
+// e.g. while another process imports a large file, are retried with
+// exponential backoff before giving up
+const (
+	retryAttempts = 5
+	retryDelay    = 100 * time.Millisecond
+)
+
+// isBusy reports whether err means another connection holds the database lock
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// withRetry runs fn until it succeeds, fails with an error other than
+// SQLITE_BUSY or runs out of attempts
+func withRetry(fn func() error) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isBusy(err) || attempt == retryAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// execWithRetry runs a single write statement with withRetry
+func execWithRetry(db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := withRetry(func() error {
+		var err error
+		result, err = db.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
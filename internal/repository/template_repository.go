@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+type TemplateRepository struct {
+	db *sql.DB
+}
+
+func NewTemplateRepository(db *sql.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// Save stores a template with its items, replacing any template of the same
+// name, in a single database transaction
+func (r *TemplateRepository) Save(template *models.BudgetTemplate) error {
+	if template.CreatedAt.IsZero() {
+		template.CreatedAt = time.Now()
+	}
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin saving template: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		if err := deleteTemplate(dbTx, template.Name); err != nil {
+			return err
+		}
+		result, err := dbTx.Exec(`INSERT INTO budget_templates (name, created_at) VALUES (?, ?)`, template.Name, template.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save template: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		query := `
+			INSERT INTO budget_template_items (template_id, category, type, amount, period, days, alert_thresholds)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`
+		for _, item := range template.Items {
+			_, err := dbTx.Exec(query, id, item.Category, item.Type, item.Amount, item.Period, item.Days,
+				models.FormatThresholds(item.AlertThresholds))
+			if err != nil {
+				return fmt.Errorf("failed to save template item: %w", err)
+			}
+		}
+		if err := dbTx.Commit(); err != nil {
+			return err
+		}
+
+		template.ID = id
+		return nil
+	})
+}
+
+// Get retrieves a template with its items by name, or nil when there is none
+func (r *TemplateRepository) Get(name string) (*models.BudgetTemplate, error) {
+	templates, err := r.query(`WHERE t.name = ?`, name)
+	if err != nil || len(templates) == 0 {
+		return nil, err
+	}
+	return templates[0], nil
+}
+
+// GetAll retrieves every template with its items, ordered by name
+func (r *TemplateRepository) GetAll() ([]*models.BudgetTemplate, error) {
+	return r.query("")
+}
+
+// query reads the templates matching where, with their items ordered by type
+// and category
+func (r *TemplateRepository) query(where string, args ...interface{}) ([]*models.BudgetTemplate, error) {
+	query := `
+		SELECT t.id, t.name, t.created_at, i.category, i.type, i.amount, i.period, i.days, i.alert_thresholds
+		FROM budget_templates t
+		LEFT JOIN budget_template_items i ON i.template_id = t.id
+		` + where + `
+		ORDER BY t.name, i.type, i.category
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*models.BudgetTemplate
+	for rows.Next() {
+		var id int64
+		var name string
+		var createdAt time.Time
+		var category, itemType, period, thresholds sql.NullString
+		var amount sql.NullFloat64
+		var days sql.NullInt64
+		if err := rows.Scan(&id, &name, &createdAt, &category, &itemType, &amount, &period, &days, &thresholds); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+
+		if len(templates) == 0 || templates[len(templates)-1].ID != id {
+			templates = append(templates, &models.BudgetTemplate{ID: id, Name: name, CreatedAt: createdAt})
+		}
+		if !category.Valid {
+			continue // A template without items
+		}
+		item := &models.TemplateItem{
+			Category: category.String,
+			Type:     itemType.String,
+			Amount:   amount.Float64,
+			Period:   period.String,
+			Days:     int(days.Int64),
+		}
+		if item.AlertThresholds, err = models.ParseThresholds(thresholds.String); err != nil {
+			return nil, err
+		}
+		template := templates[len(templates)-1]
+		template.Items = append(template.Items, item)
+	}
+
+	return templates, rows.Err()
+}
+
+// Delete removes a template and its items by name
+func (r *TemplateRepository) Delete(name string) error {
+	return withRetry(func() error {
+		dbTx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin deleting template: %w", err)
+		}
+		defer dbTx.Rollback()
+
+		var id int64
+		err = dbTx.QueryRow(`SELECT id FROM budget_templates WHERE name = ?`, name).Scan(&id)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("template '%s' %w", name, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get template: %w", err)
+		}
+		if err := deleteTemplate(dbTx, name); err != nil {
+			return err
+		}
+		return dbTx.Commit()
+	})
+}
+
+// deleteTemplate removes the template called name and its items, if any
+func deleteTemplate(dbTx *sql.Tx, name string) error {
+	_, err := dbTx.Exec(`DELETE FROM budget_template_items WHERE template_id IN (SELECT id FROM budget_templates WHERE name = ?)`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete template items: %w", err)
+	}
+	if _, err := dbTx.Exec(`DELETE FROM budget_templates WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
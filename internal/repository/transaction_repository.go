@@ -3,11 +3,14 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/PeguB/atad-project/internal/models"
 )
 
+const transactionColumns = `id, date, description, amount, category, type, account, status, created_at`
+
 type TransactionRepository struct {
 	db *sql.DB
 }
@@ -16,19 +19,40 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransaction reads a row selected with transactionColumns
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	tx := &models.Transaction{}
+	err := row.Scan(&tx.ID, &tx.Date, &tx.Description, &tx.Amount, &tx.Category, &tx.Type, &tx.Account, &tx.Status, &tx.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 // Create adds a new transaction
 func (r *TransactionRepository) Create(tx *models.Transaction) error {
 	query := `
-		INSERT INTO transactions (date, description, amount, category, type, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO transactions (date, description, amount, category, type, account, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	if tx.Status == "" {
+		tx.Status = models.StatusUncleared
+	}
+
+	result, err := execWithRetry(r.db, query,
 		tx.Date,
 		tx.Description,
 		tx.Amount,
 		tx.Category,
 		tx.Type,
+		tx.Account,
+		tx.Status,
 		time.Now(),
 	)
 	if err != nil {
@@ -44,10 +68,24 @@ func (r *TransactionRepository) Create(tx *models.Transaction) error {
 	return nil
 }
 
+// GetByID retrieves a single transaction
+func (r *TransactionRepository) GetByID(id int64) (*models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = ?`
+
+	tx, err := scanTransaction(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return tx, nil
+}
+
 // GetAll retrieves all transactions
 func (r *TransactionRepository) GetAll() ([]*models.Transaction, error) {
 	query := `
-		SELECT id, date, description, amount, category, type, created_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		ORDER BY date DESC
 	`
@@ -60,8 +98,7 @@ func (r *TransactionRepository) GetAll() ([]*models.Transaction, error) {
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		tx := &models.Transaction{}
-		err := rows.Scan(&tx.ID, &tx.Date, &tx.Description, &tx.Amount, &tx.Category, &tx.Type, &tx.CreatedAt)
+		tx, err := scanTransaction(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
@@ -74,7 +111,7 @@ func (r *TransactionRepository) GetAll() ([]*models.Transaction, error) {
 // GetByType retrieves transactions by type (income or expense)
 func (r *TransactionRepository) GetByType(txType string) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, date, description, amount, category, type, created_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE type = ?
 		ORDER BY date DESC
@@ -88,8 +125,7 @@ func (r *TransactionRepository) GetByType(txType string) ([]*models.Transaction,
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		tx := &models.Transaction{}
-		err := rows.Scan(&tx.ID, &tx.Date, &tx.Description, &tx.Amount, &tx.Category, &tx.Type, &tx.CreatedAt)
+		tx, err := scanTransaction(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
@@ -99,10 +135,285 @@ func (r *TransactionRepository) GetByType(txType string) ([]*models.Transaction,
 	return transactions, rows.Err()
 }
 
-// Delete removes a transaction
+// TransactionFilter narrows the transactions returned by Find and Iterate.
+// Zero values mean "no restriction".
+type TransactionFilter struct {
+	Type     string    // "income" or "expense"; "" or "all" for both
+	Category string    // Exact category name
+	Account  string    // Exact account name
+	Query    string    // Case-insensitive text matched against description and category
+	From     time.Time // Inclusive start date
+	To       time.Time // Inclusive end date (the whole day is included)
+}
+
+// where builds the SQL WHERE clause and arguments for the filter
+func (f TransactionFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if f.Type != "" && f.Type != "all" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, f.Type)
+	}
+	if f.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Account != "" {
+		conditions = append(conditions, "account = ?")
+		args = append(args, f.Account)
+	}
+	if f.Query != "" {
+		query := strings.ToLower(f.Query)
+		conditions = append(conditions, "(instr(LOWER(description), ?) > 0 OR instr(LOWER(category), ?) > 0)")
+		args = append(args, query, query)
+	}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, time.Date(f.From.Year(), f.From.Month(), f.From.Day(), 0, 0, 0, 0, f.From.Location()))
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "date < ?")
+		args = append(args, time.Date(f.To.Year(), f.To.Month(), f.To.Day()+1, 0, 0, 0, 0, f.To.Location()))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Find retrieves the transactions matching a filter, newest first
+func (r *TransactionRepository) Find(filter TransactionFilter) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	err := r.Iterate(filter, func(tx *models.Transaction) error {
+		transactions = append(transactions, tx)
+		return nil
+	})
+	return transactions, err
+}
+
+// Iterate streams the transactions matching a filter to fn, newest first,
+// without loading them all into memory. Iteration stops at the first error fn returns.
+func (r *TransactionRepository) Iterate(filter TransactionFilter, fn func(*models.Transaction) error) error {
+	where, args := filter.where()
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		` + where + `
+		ORDER BY date DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetUnreconciled retrieves the transactions of an account up to endDate that
+// have not yet been locked by a reconciliation, oldest first
+func (r *TransactionRepository) GetUnreconciled(account string, endDate time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE account = ? AND status != ? AND date <= ?
+		ORDER BY date ASC, id ASC
+	`
+
+	rows, err := r.db.Query(query, account, models.StatusReconciled, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unreconciled transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
+
+// GetClearedBalance sums the cleared and reconciled transactions of an account up to endDate
+func (r *TransactionRepository) GetClearedBalance(account string, endDate time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE -amount END), 0)
+		FROM transactions
+		WHERE account = ? AND status IN (?, ?) AND date <= ?
+	`
+
+	var balance float64
+	err := r.db.QueryRow(query, account, models.StatusCleared, models.StatusReconciled, endDate).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cleared balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// GetAccounts returns the distinct account names in use
+func (r *TransactionRepository) GetAccounts() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT account FROM transactions WHERE account != '' ORDER BY account`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []string
+	for rows.Next() {
+		var account string
+		if err := rows.Scan(&account); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// Count returns the number of stored transactions
+func (r *TransactionRepository) Count() (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM transactions`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count, nil
+}
+
+// SumByType totals the transactions of a type ("income" or "expense") from
+// startDate through the whole of endDate
+func (r *TransactionRepository) SumByType(txType string, startDate, endDate time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE type = ? AND date >= ? AND date < ?
+	`
+
+	var total float64
+	if err := r.db.QueryRow(query, txType, startDate, dayAfter(endDate)).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum %s transactions: %w", txType, err)
+	}
+	return total, nil
+}
+
+// SumByCategory totals the transactions of a type ("income" or "expense") per
+// category from startDate through the whole of endDate
+func (r *TransactionRepository) SumByCategory(txType string, startDate, endDate time.Time) (map[string]float64, error) {
+	query := `
+		SELECT category, SUM(amount)
+		FROM transactions
+		WHERE type = ? AND date >= ? AND date < ?
+		GROUP BY category
+	`
+
+	rows, err := r.db.Query(query, txType, startDate, dayAfter(endDate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum %s transactions: %w", txType, err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]float64)
+	for rows.Next() {
+		var category string
+		var total float64
+		if err := rows.Scan(&category, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan totals: %w", err)
+		}
+		totals[category] = total
+	}
+	return totals, rows.Err()
+}
+
+// GetCategories returns the distinct transaction categories in use
+func (r *TransactionRepository) GetCategories() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT category FROM transactions WHERE category != '' ORDER BY category`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// SetStatus changes the cleared status of a transaction; reconciled rows are locked
+func (r *TransactionRepository) SetStatus(id int64, status string) error {
+	if status != models.StatusUncleared && status != models.StatusCleared {
+		return fmt.Errorf("invalid status '%s'", status)
+	}
+
+	query := `UPDATE transactions SET status = ? WHERE id = ? AND status != ?`
+	result, err := execWithRetry(r.db, query, status, id, models.StatusReconciled)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return r.notFoundOrLocked(id)
+	}
+
+	return nil
+}
+
+// Update modifies an existing transaction; reconciled rows are locked
+func (r *TransactionRepository) Update(tx *models.Transaction) error {
+	query := `
+		UPDATE transactions
+		SET date = ?, description = ?, amount = ?, category = ?, type = ?, account = ?
+		WHERE id = ? AND status != ?
+	`
+
+	result, err := execWithRetry(r.db, query, tx.Date, tx.Description, tx.Amount, tx.Category, tx.Type, tx.Account, tx.ID, models.StatusReconciled)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return r.notFoundOrLocked(tx.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a transaction; reconciled rows are locked
 func (r *TransactionRepository) Delete(id int64) error {
-	query := `DELETE FROM transactions WHERE id = ?`
-	result, err := r.db.Exec(query, id)
+	query := `DELETE FROM transactions WHERE id = ? AND status != ?`
+	result, err := execWithRetry(r.db, query, id, models.StatusReconciled)
 	if err != nil {
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
@@ -113,12 +424,24 @@ func (r *TransactionRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("transaction not found")
+		return r.notFoundOrLocked(id)
 	}
 
 	return nil
 }
 
+// notFoundOrLocked explains why a write to a transaction affected no rows
+func (r *TransactionRepository) notFoundOrLocked(id int64) error {
+	tx, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if tx != nil && tx.IsReconciled() {
+		return ErrTransactionLocked
+	}
+	return fmt.Errorf("transaction %w", ErrNotFound)
+}
+
 // IsDuplicate checks if a transaction already exists with same date, amount, and description
 func (r *TransactionRepository) IsDuplicate(tx *models.Transaction) (bool, error) {
 	query := `
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// Time limits for the external sinks, so a hanging hook cannot block 'add'
+const (
+	commandTimeout = 10 * time.Second
+	webhookTimeout = 5 * time.Second
+)
+
+// AlertSink delivers alerts somewhere outside the alert log
+type AlertSink interface {
+	Name() string
+	Send(alert *models.Alert) error
+}
+
+// TerminalSink prints alerts
+type TerminalSink struct {
+	W io.Writer
+}
+
+func (s *TerminalSink) Name() string { return "terminal" }
+
+func (s *TerminalSink) Send(alert *models.Alert) error {
+	_, err := fmt.Fprintf(s.W, "🔔 %s\n", alert.Message)
+	return err
+}
+
+// CommandSink runs a shell command for every alert. The alert is passed as
+// JSON on stdin and its main fields as ATAD_ALERT_* environment variables.
+type CommandSink struct {
+	Command string
+}
+
+func (s *CommandSink) Name() string { return "command" }
+
+func (s *CommandSink) Send(alert *models.Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"ATAD_ALERT_KIND="+alert.Kind,
+		"ATAD_ALERT_CATEGORY="+alert.Category,
+		"ATAD_ALERT_MESSAGE="+alert.Message,
+		"ATAD_ALERT_AMOUNT="+strconv.FormatFloat(alert.Amount, 'f', 2, 64),
+		"ATAD_ALERT_THRESHOLD="+strconv.FormatFloat(alert.Threshold, 'f', -1, 64),
+		"ATAD_ALERT_PERCENT_USED="+strconv.FormatFloat(alert.PercentUsed, 'f', 1, 64),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s", commandTimeout)
+		}
+		if len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+		}
+		return err
+	}
+	return nil
+}
+
+// WebhookSink posts every alert as JSON to a URL
+type WebhookSink struct {
+	URL string
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Send(alert *models.Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return nil
+}
+
+// AlertRules are the defaults budgets alert with
+type AlertRules struct {
+	Thresholds       []float64 // Percents of a budget, used by budgets without their own
+	LargeTransaction float64   // Expenses of at least this amount raise an alert; 0 for off
+}
+
+// AlertService raises alerts for new transactions. Every alert is recorded in
+// the alert log, which also keeps each budget threshold from firing twice in
+// the same period, and is then passed to the sinks.
+type AlertService struct {
+	budgetRepo  *repository.BudgetRepository
+	budgetSvc   *BudgetService
+	alertRepo   *repository.AlertRepository
+	rules       AlertRules
+	formatMoney func(float64) string
+	sinks       []AlertSink
+}
+
+func NewAlertService(budgetRepo *repository.BudgetRepository, budgetSvc *BudgetService, alertRepo *repository.AlertRepository,
+	rules AlertRules, formatMoney func(float64) string) *AlertService {
+	return &AlertService{
+		budgetRepo:  budgetRepo,
+		budgetSvc:   budgetSvc,
+		alertRepo:   alertRepo,
+		rules:       rules,
+		formatMoney: formatMoney,
+	}
+}
+
+// AddSink delivers later alerts to sink as well
+func (s *AlertService) AddSink(sink AlertSink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// Check raises the alerts for a newly saved transaction: a large transaction
+// alert, and a budget alert for the highest threshold of its budget period
+// that has now been reached for the first time. The alerts raised are
+// delivered and returned even when the budget check or a sink fails; the
+// error then joins every failure.
+func (s *AlertService) Check(tx *models.Transaction) ([]*models.Alert, error) {
+	if tx.Type != "expense" {
+		return nil, nil
+	}
+
+	var alerts []*models.Alert
+	if s.rules.LargeTransaction > 0 && tx.Amount >= s.rules.LargeTransaction {
+		alerts = append(alerts, &models.Alert{
+			Kind:          models.AlertLargeTransaction,
+			Category:      tx.Category,
+			TransactionID: tx.ID,
+			Amount:        tx.Amount,
+			Message:       fmt.Sprintf("Large expense: %s for '%s' in %s", s.formatMoney(tx.Amount), tx.Description, tx.Category),
+		})
+	}
+
+	// A failing budget check still lets the large transaction alert through
+	var errs []error
+	alert, err := s.budgetAlert(tx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("budget alert failed: %w", err))
+	} else if alert != nil {
+		alerts = append(alerts, alert)
+	}
+
+	for _, alert := range alerts {
+		if err := s.Send(alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return alerts, errors.Join(errs...)
+}
+
+// budgetAlert returns the alert for the budget period of tx, or nil when no
+// new threshold was reached
+func (s *AlertService) budgetAlert(tx *models.Transaction) (*models.Alert, error) {
+	budget, err := s.budgetRepo.GetByCategoryAt(tx.Category, models.BudgetExpense, tx.Date)
+	if err != nil || budget == nil {
+		return nil, err
+	}
+	thresholds := budget.AlertThresholds
+	if thresholds == nil {
+		thresholds = s.rules.Thresholds
+	}
+	if len(thresholds) == 0 {
+		return nil, nil
+	}
+
+	status, err := s.budgetSvc.Status(budget, tx.Date)
+	if err != nil {
+		return nil, err
+	}
+	reached := 0.0
+	for _, threshold := range thresholds {
+		if status.PercentUsed() >= threshold {
+			reached = threshold
+		}
+	}
+	if reached == 0 {
+		return nil, nil
+	}
+	alerted, err := s.alertRepo.GetHighestThreshold(budget.ID, status.Start)
+	if err != nil {
+		return nil, err
+	}
+	if reached <= alerted {
+		return nil, nil
+	}
+
+	verb := "reached"
+	if reached >= 100 && status.Over() {
+		verb = "exceeded"
+	}
+	return &models.Alert{
+		Kind:          models.AlertBudgetThreshold,
+		Category:      budget.Category,
+		BudgetID:      budget.ID,
+		PeriodStart:   status.Start,
+		PeriodEnd:     status.End,
+		Threshold:     reached,
+		PercentUsed:   status.PercentUsed(),
+		Spent:         status.Spent,
+		Available:     status.Available,
+		TransactionID: tx.ID,
+		Amount:        tx.Amount,
+		Message: fmt.Sprintf("%s budget %s %.0f%%: %s of %s spent", budget.Category, verb, reached,
+			s.formatMoney(status.Spent), s.formatMoney(status.Available)),
+	}, nil
+}
+
+// Send records an alert in the alert log and passes it to every sink. Failing
+// sinks do not stop the others.
+func (s *AlertService) Send(alert *models.Alert) error {
+	if err := s.alertRepo.Create(alert); err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Send(alert); err != nil {
+			errs = append(errs, fmt.Errorf("%s alert failed: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Recent returns the latest alerts of the alert log, newest first
+func (s *AlertService) Recent(limit int) ([]*models.Alert, error) {
+	return s.alertRepo.GetRecent(limit)
+}
+
+// SinkNames lists where alerts are delivered besides the alert log
+func (s *AlertService) SinkNames() string {
+	names := []string{"log"}
+	for _, sink := range s.sinks {
+		names = append(names, sink.Name())
+	}
+	return strings.Join(names, ", ")
+}
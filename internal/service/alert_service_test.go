@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+func testAlert() *models.Alert {
+	return &models.Alert{
+		Kind:        models.AlertBudgetThreshold,
+		Category:    "Food",
+		Threshold:   80,
+		PercentUsed: 85.5,
+		Amount:      42.5,
+		Message:     "Food budget reached 80%",
+	}
+}
+
+func TestWebhookSinkPostsAlert(t *testing.T) {
+	var got models.Alert
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("body is not an alert: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(testAlert()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if got.Category != "Food" || got.Threshold != 80 || got.Message != "Food budget reached 80%" {
+		t.Errorf("posted alert = %+v", got)
+	}
+}
+
+func TestWebhookSinkReportsFailedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	err := sink.Send(testAlert())
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Send error = %v, want the 500 response", err)
+	}
+}
+
+func TestCommandSinkPassesAlert(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "alert.txt")
+	sink := &CommandSink{Command: fmt.Sprintf(
+		`{ echo "$ATAD_ALERT_KIND|$ATAD_ALERT_CATEGORY|$ATAD_ALERT_AMOUNT|$ATAD_ALERT_THRESHOLD|$ATAD_ALERT_PERCENT_USED"; cat; } > %q`, out)}
+	if err := sink.Send(testAlert()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, payload, _ := strings.Cut(string(data), "\n")
+	if want := "budget_threshold|Food|42.50|80|85.5"; env != want {
+		t.Errorf("environment = %q, want %q", env, want)
+	}
+	var got models.Alert
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("stdin %q is not an alert: %v", payload, err)
+	}
+	if got.Message != "Food budget reached 80%" {
+		t.Errorf("stdin alert = %+v", got)
+	}
+}
+
+func TestCommandSinkReportsFailure(t *testing.T) {
+	sink := &CommandSink{Command: "echo broken >&2; exit 3"}
+	err := sink.Send(testAlert())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Send error = %v, want the command's output", err)
+	}
+}
+
+// recordingSink keeps the alerts it is sent
+type recordingSink struct {
+	alerts []*models.Alert
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(alert *models.Alert) error {
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func TestCheckDeliversAlertsWhenBudgetCheckFails(t *testing.T) {
+	db, err := database.NewDatabaseAt(filepath.Join(t.TempDir(), "atad.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	budgetRepo := repository.NewBudgetRepository(db.DB)
+	svc := NewAlertService(budgetRepo, NewBudgetService(budgetRepo), repository.NewAlertRepository(db.DB),
+		AlertRules{Thresholds: []float64{80, 100}, LargeTransaction: 100}, func(amount float64) string {
+			return fmt.Sprintf("$%.2f", amount)
+		})
+	sink := &recordingSink{}
+	svc.AddSink(sink)
+
+	// Without the budgets table the budget check fails
+	if _, err := db.DB.Exec(`DROP TABLE budgets`); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := &models.Transaction{ID: 1, Type: "expense", Description: "Laptop", Category: "Tech", Amount: 900, Date: time.Now()}
+	alerts, err := svc.Check(tx)
+	if err == nil || !strings.Contains(err.Error(), "budget alert failed") {
+		t.Errorf("Check error = %v, want the budget check failure", err)
+	}
+	if len(alerts) != 1 || alerts[0].Kind != models.AlertLargeTransaction {
+		t.Fatalf("alerts = %v, want the large transaction alert", alerts)
+	}
+	if len(sink.alerts) != 1 {
+		t.Errorf("sink got %d alerts, want 1", len(sink.alerts))
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// Bill due date statuses
+const (
+	BillPaid     = "paid"
+	BillOverdue  = "overdue"
+	BillDueToday = "due today"
+	BillUpcoming = "upcoming"
+)
+
+// BillDue is one due date of a bill and what paid it
+type BillDue struct {
+	Bill        *models.Bill
+	Date        time.Time
+	Transaction *models.Transaction // The matching expense, if any
+	Manual      *models.BillPayment // Set when the due date was marked paid by hand
+}
+
+// Paid reports whether the due date has been paid
+func (d *BillDue) Paid() bool {
+	return d.Transaction != nil || d.Manual != nil
+}
+
+// DaysUntil counts the days from today to the due date; negative once it
+// has passed
+func (d *BillDue) DaysUntil(today time.Time) int {
+	return models.CalendarDays(today, d.Date)
+}
+
+// Status says whether the due date is paid, overdue, due today or upcoming
+func (d *BillDue) Status(today time.Time) string {
+	switch days := d.DaysUntil(today); {
+	case d.Paid():
+		return BillPaid
+	case days < 0:
+		return BillOverdue
+	case days == 0:
+		return BillDueToday
+	}
+	return BillUpcoming
+}
+
+type BillService struct {
+	billRepo *repository.BillRepository
+}
+
+func NewBillService(billRepo *repository.BillRepository) *BillService {
+	return &BillService{billRepo: billRepo}
+}
+
+// Add creates a bill
+func (s *BillService) Add(bill *models.Bill) error {
+	return s.billRepo.Create(bill)
+}
+
+// Get returns a bill by name, or nil when there is none
+func (s *BillService) Get(name string) (*models.Bill, error) {
+	return s.billRepo.Get(name)
+}
+
+// All returns every bill ordered by name
+func (s *BillService) All() ([]*models.Bill, error) {
+	return s.billRepo.GetAll()
+}
+
+// Delete removes a bill; the transactions that paid it are kept
+func (s *BillService) Delete(name string) error {
+	return s.billRepo.Delete(name)
+}
+
+// MarkPaid records a due date of a bill as paid without a matching expense
+func (s *BillService) MarkPaid(bill *models.Bill, due time.Time, note string) error {
+	return s.billRepo.MarkPaid(&models.BillPayment{BillID: bill.ID, DueDate: due, Note: note})
+}
+
+// Dues returns the due dates of a bill from the first through the whole of
+// through, each with what paid it
+func (s *BillService) Dues(bill *models.Bill, through time.Time) ([]BillDue, error) {
+	payments, err := s.billRepo.GetPayments()
+	if err != nil {
+		return nil, err
+	}
+	return s.dues(bill, payments[bill.ID], through)
+}
+
+// Schedule returns the due dates of every bill from from through the whole
+// of through, by date. A zero from starts at each bill's first due date.
+func (s *BillService) Schedule(from, through time.Time) ([]BillDue, error) {
+	bills, err := s.billRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.billRepo.GetPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	var schedule []BillDue
+	for _, bill := range bills {
+		dues, err := s.dues(bill, payments[bill.ID], through)
+		if err != nil {
+			return nil, err
+		}
+		for _, due := range dues {
+			if from.IsZero() || models.CalendarDays(from, due.Date) >= 0 {
+				schedule = append(schedule, due)
+			}
+		}
+	}
+	sort.SliceStable(schedule, func(i, j int) bool {
+		if !schedule[i].Date.Equal(schedule[j].Date) {
+			return schedule[i].Date.Before(schedule[j].Date)
+		}
+		return schedule[i].Bill.Name < schedule[j].Bill.Name
+	})
+	return schedule, nil
+}
+
+// Overdue returns the unpaid due dates before today, oldest first
+func (s *BillService) Overdue(today time.Time) ([]BillDue, error) {
+	schedule, err := s.Schedule(time.Time{}, today.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	var overdue []BillDue
+	for _, due := range schedule {
+		if !due.Paid() {
+			overdue = append(overdue, due)
+		}
+	}
+	return overdue, nil
+}
+
+// dues lists the due dates of a bill through the whole of through and
+// matches them with payments. A due date marked paid by hand needs no
+// expense. Each matching expense, oldest first, pays the nearer of the due
+// dates before and after it, or the other one when that is already paid, so
+// a payment made early or late still counts and never pays two due dates.
+// The due date after through takes part in the matching, so an early payment
+// for it is not taken for a late one, and is left out of the result.
+func (s *BillService) dues(bill *models.Bill, marked []*models.BillPayment, through time.Time) ([]BillDue, error) {
+	var dues []BillDue
+	for n := 0; n == 0 || models.CalendarDays(bill.DueAt(n-1), through) >= 0; n++ {
+		dues = append(dues, BillDue{Bill: bill, Date: bill.DueAt(n)})
+	}
+
+	manual := make(map[string]*models.BillPayment, len(marked))
+	for _, payment := range marked {
+		manual[payment.DueDate.Format("2006-01-02")] = payment
+	}
+	for i := range dues {
+		dues[i].Manual = manual[dues[i].Date.Format("2006-01-02")]
+	}
+
+	candidates, err := s.billRepo.GetCandidates(bill.Pattern, bill.DueAt(-1), bill.DueAt(len(dues)))
+	if err != nil {
+		return nil, err
+	}
+	next := 0 // The first due date after the transaction
+	for _, tx := range candidates {
+		if !bill.Matches(tx) || models.CalendarDays(bill.DueAt(-1), tx.Date) <= 0 {
+			continue
+		}
+		for models.CalendarDays(bill.DueAt(next), tx.Date) >= 0 {
+			next++
+		}
+		before, after := next-1, next
+		if models.CalendarDays(bill.DueAt(before), tx.Date) > models.CalendarDays(tx.Date, bill.DueAt(after)) {
+			before, after = after, before
+		}
+		for _, n := range []int{before, after} {
+			if n >= 0 && n < len(dues) && !dues[n].Paid() {
+				dues[n].Transaction = tx
+				break
+			}
+		}
+	}
+	return dues[:len(dues)-1], nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+func TestBillDues(t *testing.T) {
+	db, err := database.NewDatabaseAt(filepath.Join(t.TempDir(), "atad.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	svc := NewBillService(repository.NewBillRepository(db.DB))
+	rent := &models.Bill{Name: "Rent", Amount: 1000, Period: models.PeriodMonthly, DueDate: date(2026, 1, 31), Pattern: "landlord", Tolerance: 5}
+	fibre := &models.Bill{Name: "Fibre", Amount: 40, Period: models.PeriodMonthly, DueDate: date(2026, 3, 31), Pattern: "fibre"}
+	for _, bill := range []*models.Bill{rent, fibre} {
+		if err := svc.Add(bill); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	txRepo := repository.NewTransactionRepository(db.DB)
+	for _, tx := range []*models.Transaction{
+		{Type: "expense", Description: "LANDLORD January", Amount: 1000, Date: date(2026, 1, 30)},
+		{Type: "expense", Description: "Landlord February", Amount: 990, Date: date(2026, 2, 27)},
+		// Nearer 28 February, which is paid already, so it pays 31 March early
+		{Type: "expense", Description: "landlord", Amount: 1000, Date: date(2026, 3, 3)},
+		{Type: "expense", Description: "landlord with arrears", Amount: 1200, Date: date(2026, 4, 30)},
+		{Type: "income", Description: "landlord deposit back", Amount: 1000, Date: date(2026, 4, 30)},
+		// Early for 31 May, after the dues asked for; not taken for 30 April
+		{Type: "expense", Description: "landlord June", Amount: 1000, Date: date(2026, 5, 29)},
+		{Type: "expense", Description: "Fibre broadband", Amount: 40, Date: date(2026, 4, 2)},
+	} {
+		tx.Category = "Bills"
+		if err := txRepo.Create(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dues, err := svc.Dues(rent, date(2026, 5, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		date time.Time
+		paid time.Time // Date of the paying transaction; zero when unpaid
+	}{
+		{date(2026, 1, 31), date(2026, 1, 30)},
+		{date(2026, 2, 28), date(2026, 2, 27)},
+		{date(2026, 3, 31), date(2026, 3, 3)},
+		{date(2026, 4, 30), time.Time{}},
+	}
+	if len(dues) != len(want) {
+		t.Fatalf("got %d due dates, want %d", len(dues), len(want))
+	}
+	for i, w := range want {
+		due := dues[i]
+		if !due.Date.Equal(w.date) {
+			t.Errorf("due %d on %s, want %s", i, due.Date.Format(time.DateOnly), w.date.Format(time.DateOnly))
+		}
+		switch {
+		case w.paid.IsZero() && due.Paid():
+			t.Errorf("%s paid by %+v, want unpaid", w.date.Format(time.DateOnly), due.Transaction)
+		case !w.paid.IsZero() && (due.Transaction == nil || !due.Transaction.Date.Equal(w.paid)):
+			t.Errorf("%s paid by %+v, want the payment of %s", w.date.Format(time.DateOnly), due.Transaction, w.paid.Format(time.DateOnly))
+		}
+	}
+
+	last := dues[3]
+	for _, tt := range []struct {
+		today time.Time
+		want  string
+	}{
+		{date(2026, 4, 29), BillUpcoming},
+		{date(2026, 4, 30), BillDueToday},
+		{date(2026, 5, 1), BillOverdue},
+	} {
+		if got := last.Status(tt.today); got != tt.want {
+			t.Errorf("status on %s = %q, want %q", tt.today.Format(time.DateOnly), got, tt.want)
+		}
+	}
+
+	schedule, err := svc.Schedule(date(2026, 3, 1), date(2026, 4, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, due := range schedule {
+		names = append(names, due.Bill.Name+" "+due.Date.Format(time.DateOnly))
+	}
+	wantNames := "Fibre 2026-03-31, Rent 2026-03-31, Fibre 2026-04-30, Rent 2026-04-30"
+	if got := strings.Join(names, ", "); got != wantNames {
+		t.Errorf("schedule = %s, want %s", got, wantNames)
+	}
+
+	overdue, err := svc.Overdue(date(2026, 5, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overdue) != 2 || overdue[0].Bill.Name != "Fibre" || overdue[1].Bill.Name != "Rent" {
+		t.Fatalf("overdue = %+v, want Fibre and Rent on 30 April", overdue)
+	}
+
+	if err := svc.MarkPaid(rent, date(2026, 4, 30), "paid in cash"); err != nil {
+		t.Fatal(err)
+	}
+	overdue, err = svc.Overdue(date(2026, 5, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overdue) != 1 || overdue[0].Bill.Name != "Fibre" {
+		t.Errorf("overdue after marking Rent paid = %+v, want only Fibre", overdue)
+	}
+}
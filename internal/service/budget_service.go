@@ -0,0 +1,221 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// BudgetPeriodStatus is the state of one period of a budget
+type BudgetPeriodStatus struct {
+	Start     time.Time
+	End       time.Time
+	Amount    float64 // Budget amount per period
+	Carried   float64 // Brought forward from earlier periods by the rollover setting
+	Available float64 // Amount + Carried
+	Spent     float64 // Expenses, or the income received for an income target
+}
+
+// Remaining is what is left of the available amount; negative when overspent
+func (s *BudgetPeriodStatus) Remaining() float64 {
+	return s.Available - s.Spent
+}
+
+// Shortfall is what an income target still lacks; 0 once it is met
+func (s *BudgetPeriodStatus) Shortfall() float64 {
+	return max(s.Remaining(), 0)
+}
+
+// PercentUsed is the share of the available amount spent
+func (s *BudgetPeriodStatus) PercentUsed() float64 {
+	if s.Available <= 0 {
+		if s.Spent > 0 {
+			return 100
+		}
+		return 0
+	}
+	return s.Spent / s.Available * 100
+}
+
+// Over reports whether more than the available amount was spent
+func (s *BudgetPeriodStatus) Over() bool {
+	return s.Spent > s.Available
+}
+
+// DaysLeft counts the days from date through the end of the period,
+// including date itself; 0 once the period is over
+func (s *BudgetPeriodStatus) DaysLeft(date time.Time) int {
+	return max(models.CalendarDays(date, s.End)+1, 0)
+}
+
+// DaysElapsed counts the days of the period up to and including date
+func (s *BudgetPeriodStatus) DaysElapsed(date time.Time) int {
+	total := models.CalendarDays(s.Start, s.End) + 1
+	return min(max(models.CalendarDays(s.Start, date)+1, 0), total)
+}
+
+// BurnRate is the average spending per elapsed day of the period
+func (s *BudgetPeriodStatus) BurnRate(date time.Time) float64 {
+	days := s.DaysElapsed(date)
+	if days == 0 {
+		return 0
+	}
+	return s.Spent / float64(days)
+}
+
+type BudgetService struct {
+	budgetRepo *repository.BudgetRepository
+}
+
+func NewBudgetService(budgetRepo *repository.BudgetRepository) *BudgetService {
+	return &BudgetService{budgetRepo: budgetRepo}
+}
+
+// Status returns the period of a budget containing date, including the amount
+// carried over from earlier periods
+func (s *BudgetService) Status(budget *models.Budget, date time.Time) (*BudgetPeriodStatus, error) {
+	history, err := s.History(budget, date, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &history[0], nil
+}
+
+// History returns up to n periods of a budget, newest first, ending with the
+// period containing date. Carried amounts accumulate from the budget's first
+// period, so every earlier period's spending is read.
+func (s *BudgetService) History(budget *models.Budget, date time.Time, n int) ([]BudgetPeriodStatus, error) {
+	periods, ok := budgetPeriods(budget, date, n)
+	if !ok {
+		return nil, fmt.Errorf("the budget for '%s' does not cover %s", budget.Category, date.Format("2006-01-02"))
+	}
+	spent, err := s.budgetRepo.GetSpendingByRanges(periods)
+	if err != nil {
+		return nil, err
+	}
+	return periodStatuses(budget, periods, spent, n), nil
+}
+
+// BudgetStatus is the current period of one active budget
+type BudgetStatus struct {
+	Budget *models.Budget
+	BudgetPeriodStatus
+}
+
+// StatusAll evaluates every budget active at date with a single spending
+// query. Per category and type, a custom budget covering date overrides the
+// recurring one, as in BudgetRepository.GetByCategoryAt. The result lists
+// expense budgets before income targets, each ordered by category.
+func (s *BudgetService) StatusAll(date time.Time) ([]BudgetStatus, error) {
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	active := activeBudgets(budgets, date)
+
+	// Gather the periods of all budgets so spending is read in one pass
+	var ranges []repository.SpendingRange
+	offsets := make([]int, len(active))
+	counts := make([]int, len(active))
+	for i, budget := range active {
+		periods, _ := budgetPeriods(budget, date, 1)
+		offsets[i], counts[i] = len(ranges), len(periods)
+		ranges = append(ranges, periods...)
+	}
+	spent, err := s.budgetRepo.GetSpendingByRanges(ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]BudgetStatus, len(active))
+	for i, budget := range active {
+		periods := ranges[offsets[i] : offsets[i]+counts[i]]
+		current := periodStatuses(budget, periods, spent[offsets[i]:offsets[i]+counts[i]], 1)[0]
+		statuses[i] = BudgetStatus{Budget: budget, BudgetPeriodStatus: current}
+	}
+	return statuses, nil
+}
+
+// activeBudgets picks the budget that applies at date per category and type,
+// expense budgets first, each ordered by category
+func activeBudgets(budgets []*models.Budget, date time.Time) []*models.Budget {
+	// Budgets are keyed by type, then category, which sorts expense first
+	active := map[string]*models.Budget{}
+	var keys []string
+	for _, budget := range budgets {
+		if _, _, ok := budget.PeriodAt(date); !ok {
+			continue
+		}
+		key := budget.Type + "\x00" + budget.Category
+		current, seen := active[key]
+		if !seen {
+			keys = append(keys, key)
+		}
+		if !seen || overrides(budget, current) {
+			active[key] = budget
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]*models.Budget, len(keys))
+	for i, key := range keys {
+		result[i] = active[key]
+	}
+	return result
+}
+
+// overrides reports whether budget takes precedence over current for the same
+// category and date: custom budgets first, then the latest anchor
+func overrides(budget, current *models.Budget) bool {
+	if budget.IsRecurring() != current.IsRecurring() {
+		return !budget.IsRecurring()
+	}
+	return budget.StartDate.After(current.StartDate)
+}
+
+// budgetPeriods lists the periods of a budget, newest first, from the one
+// containing date back to the first period when carried amounts matter, and
+// otherwise back n periods. ok is false when the budget does not cover date.
+func budgetPeriods(budget *models.Budget, date time.Time, n int) ([]repository.SpendingRange, bool) {
+	start, end, ok := budget.PeriodAt(date)
+	if !ok {
+		return nil, false
+	}
+
+	periods := []repository.SpendingRange{{Category: budget.Category, Type: budget.Type, Start: start, End: end}}
+	for budget.RollsOver() || len(periods) < n {
+		prevStart, prevEnd, ok := budget.PreviousPeriod(periods[len(periods)-1].Start)
+		if !ok {
+			break
+		}
+		periods = append(periods, repository.SpendingRange{Category: budget.Category, Type: budget.Type, Start: prevStart, End: prevEnd})
+	}
+	return periods, true
+}
+
+// periodStatuses works out the carried and available amounts of periods
+// (newest first) from the oldest forwards and returns the newest n
+func periodStatuses(budget *models.Budget, periods []repository.SpendingRange, spent []float64, n int) []BudgetPeriodStatus {
+	statuses := make([]BudgetPeriodStatus, len(periods))
+	carried := 0.0
+	for i := len(periods) - 1; i >= 0; i-- {
+		status := BudgetPeriodStatus{
+			Start:     periods[i].Start,
+			End:       periods[i].End,
+			Amount:    budget.Amount,
+			Carried:   carried,
+			Available: budget.Amount + carried,
+			Spent:     spent[i],
+		}
+		statuses[i] = status
+		carried = budget.Carry(status.Remaining())
+	}
+
+	if len(statuses) > n {
+		statuses = statuses[:n]
+	}
+	return statuses
+}
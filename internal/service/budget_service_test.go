@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBudgetPeriods(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   models.Budget
+		date     time.Time
+		n        int
+		starts   []time.Time // Newest first
+		uncovers bool
+	}{
+		{
+			name:   "without rollover only the requested periods",
+			budget: models.Budget{Period: models.PeriodMonthly, StartDate: date(2026, 1, 1)},
+			date:   date(2026, 4, 15),
+			n:      2,
+			starts: []time.Time{date(2026, 4, 1), date(2026, 3, 1)},
+		},
+		{
+			name:   "rollover goes back to the anchor",
+			budget: models.Budget{Period: models.PeriodMonthly, StartDate: date(2026, 1, 31), Rollover: models.RolloverSurplus},
+			date:   date(2026, 4, 15),
+			n:      1,
+			starts: []time.Time{date(2026, 3, 31), date(2026, 2, 28), date(2026, 1, 31)},
+		},
+		{
+			name:   "first day of a period",
+			budget: models.Budget{Period: models.PeriodWeekly, StartDate: date(2026, 1, 5), Rollover: models.RolloverFull},
+			date:   date(2026, 1, 12),
+			n:      1,
+			starts: []time.Time{date(2026, 1, 12), date(2026, 1, 5)},
+		},
+		{
+			name:     "before the anchor",
+			budget:   models.Budget{Period: models.PeriodMonthly, StartDate: date(2026, 5, 1)},
+			date:     date(2026, 4, 15),
+			n:        1,
+			uncovers: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods, ok := budgetPeriods(&tt.budget, tt.date, tt.n)
+			if ok == tt.uncovers {
+				t.Fatalf("ok = %v", ok)
+			}
+			if len(periods) != len(tt.starts) {
+				t.Fatalf("got %d periods, want %d: %+v", len(periods), len(tt.starts), periods)
+			}
+			for i, start := range tt.starts {
+				if !periods[i].Start.Equal(start) {
+					t.Errorf("period %d starts %s, want %s", i, periods[i].Start.Format(time.DateOnly), start.Format(time.DateOnly))
+				}
+				if i > 0 && !periods[i].End.Equal(periods[i-1].Start.AddDate(0, 0, -1)) {
+					t.Errorf("period %d ends %s, the day before period %d", i, periods[i].End.Format(time.DateOnly), i-1)
+				}
+			}
+		})
+	}
+}
+
+func TestPeriodStatusesRollover(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollover string
+		spent    []float64 // January to March
+		carried  []float64 // Carried into January to March
+	}{
+		{"none", models.RolloverNone, []float64{40, 200, 20}, []float64{0, 0, 0}},
+		{"surplus", models.RolloverSurplus, []float64{40, 200, 20}, []float64{0, 60, 0}},
+		{"full carries overspending", models.RolloverFull, []float64{40, 200, 20}, []float64{0, 60, -40}},
+		{"full carries surplus", models.RolloverFull, []float64{40, 70, 20}, []float64{0, 60, 90}},
+		{"capped", models.RolloverCapped, []float64{40, 200, 20}, []float64{0, 50, 0}},
+		{"capped on the total carried", models.RolloverCapped, []float64{40, 70, 20}, []float64{0, 50, 50}},
+		{"capped below the cap", models.RolloverCapped, []float64{80, 90, 20}, []float64{0, 20, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &models.Budget{Category: "Food", Amount: 100, Period: models.PeriodMonthly,
+				StartDate: date(2026, 1, 1), Rollover: tt.rollover, RolloverCap: 50}
+			periods, ok := budgetPeriods(budget, date(2026, 3, 15), 3)
+			if !ok || len(periods) != 3 {
+				t.Fatalf("periods = %+v, %v", periods, ok)
+			}
+			// Periods and statuses run newest first
+			spent := []float64{tt.spent[2], tt.spent[1], tt.spent[0]}
+
+			statuses := periodStatuses(budget, periods, spent, 3)
+			for i, want := range tt.carried {
+				s := statuses[len(statuses)-1-i]
+				if s.Carried != want || s.Available != 100+want {
+					t.Errorf("%s: carried %.2f, available %.2f; want %.2f carried", s.Start.Month(), s.Carried, s.Available, want)
+				}
+			}
+
+			if latest := periodStatuses(budget, periods, spent, 1); len(latest) != 1 || latest[0].Carried != tt.carried[2] {
+				t.Errorf("latest period only = %+v, want %.2f carried", latest, tt.carried[2])
+			}
+		})
+	}
+}
@@ -6,22 +6,33 @@ import (
 	"strings"
 
 	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
 )
 
 type CategoryService struct {
-	rules []models.CategoryRule
+	ruleRepo *repository.CategoryRuleRepository
+	rules    []models.CategoryRule
 }
 
-func NewCategoryService() *CategoryService {
+// NewCategoryService loads the stored categorization rules. Without a rule
+// store the built-in rules are used.
+func NewCategoryService(ruleRepo *repository.CategoryRuleRepository) (*CategoryService, error) {
 	rules := models.DefaultCategoryRules()
+	if ruleRepo != nil {
+		var err error
+		if rules, err = ruleRepo.GetAll(); err != nil {
+			return nil, err
+		}
+	}
 	// Sort by priority (highest first)
-	sort.Slice(rules, func(i, j int) bool {
+	sort.SliceStable(rules, func(i, j int) bool {
 		return rules[i].Priority > rules[j].Priority
 	})
 
 	return &CategoryService{
-		rules: rules,
-	}
+		ruleRepo: ruleRepo,
+		rules:    rules,
+	}, nil
 }
 
 // CategorizeTransaction attempts to categorize a transaction based on its description
@@ -44,7 +55,8 @@ func (s *CategoryService) CategorizeTransaction(description string) string {
 	return "Uncategorized"
 }
 
-// AddCustomRule adds a new categorization rule
+// AddCustomRule adds a new categorization rule, storing it when there is a
+// rule store
 func (s *CategoryService) AddCustomRule(category, pattern, description string, priority int) error {
 	// Validate the regex pattern
 	_, err := regexp.Compile(pattern)
@@ -58,11 +70,16 @@ func (s *CategoryService) AddCustomRule(category, pattern, description string, p
 		Description: description,
 		Priority:    priority,
 	}
+	if s.ruleRepo != nil {
+		if err := s.ruleRepo.Create(&rule); err != nil {
+			return err
+		}
+	}
 
 	s.rules = append(s.rules, rule)
 
 	// Re-sort by priority
-	sort.Slice(s.rules, func(i, j int) bool {
+	sort.SliceStable(s.rules, func(i, j int) bool {
 		return s.rules[i].Priority > s.rules[j].Priority
 	})
 
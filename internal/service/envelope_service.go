@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// Envelope is the money set aside for one category
+type Envelope struct {
+	Category string
+	Assigned float64 // Net amount assigned from the pool and other envelopes
+	Spent    float64 // Expenses in the category since envelope budgeting began
+}
+
+// Available is what is left to spend; negative when overspent
+func (e *Envelope) Available() float64 {
+	return e.Assigned - e.Spent
+}
+
+// EnvelopeStatus is the state of zero-based budgeting: income flows into the
+// ready-to-assign pool and from there into envelopes
+type EnvelopeStatus struct {
+	Start     time.Time // Income and spending count from this date
+	Started   bool      // False until the first assignment
+	Income    float64
+	Assigned  float64
+	Envelopes []Envelope // Sorted by category
+}
+
+// ReadyToAssign is the income not yet given to an envelope; the goal is zero
+func (s *EnvelopeStatus) ReadyToAssign() float64 {
+	return s.Income - s.Assigned
+}
+
+// Envelope returns the envelope of a category, or an empty one
+func (s *EnvelopeStatus) Envelope(category string) Envelope {
+	for _, e := range s.Envelopes {
+		if e.Category == category {
+			return e
+		}
+	}
+	return Envelope{Category: category}
+}
+
+type EnvelopeService struct {
+	txRepo       *repository.TransactionRepository
+	budgetRepo   *repository.BudgetRepository
+	envelopeRepo *repository.EnvelopeRepository
+}
+
+func NewEnvelopeService(txRepo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, envelopeRepo *repository.EnvelopeRepository) *EnvelopeService {
+	return &EnvelopeService{
+		txRepo:       txRepo,
+		budgetRepo:   budgetRepo,
+		envelopeRepo: envelopeRepo,
+	}
+}
+
+// Status computes the pool and every envelope as of now. Categories with
+// spending but no assignments are included as overspent envelopes.
+func (s *EnvelopeService) Status(now time.Time) (*EnvelopeStatus, error) {
+	start, started, err := s.envelopeRepo.GetStart()
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		start = defaultEnvelopeStart(now)
+	}
+	status := &EnvelopeStatus{Start: start, Started: started}
+
+	if status.Income, err = s.txRepo.SumByType("income", start, now); err != nil {
+		return nil, err
+	}
+
+	assigned, err := s.envelopeRepo.GetAssigned()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.txRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	for category := range assigned {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for i, category := range categories {
+		if i > 0 && categories[i-1] == category {
+			continue
+		}
+		spent, err := s.budgetRepo.GetSpending(category, start, now)
+		if err != nil {
+			return nil, err
+		}
+		if assigned[category] == 0 && spent == 0 {
+			continue
+		}
+		status.Assigned += assigned[category]
+		status.Envelopes = append(status.Envelopes, Envelope{Category: category, Assigned: assigned[category], Spent: spent})
+	}
+
+	return status, nil
+}
+
+// Assign funds an envelope from the ready-to-assign pool. The first
+// assignment starts envelope budgeting at the beginning of the current month
+// unless Start was called before.
+func (s *EnvelopeService) Assign(category string, amount float64, date time.Time, note string) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if err := s.ensureStarted(date); err != nil {
+		return err
+	}
+	return s.envelopeRepo.Assign(&models.EnvelopeAssignment{
+		Date:     date,
+		Category: category,
+		Amount:   amount,
+		Note:     note,
+	})
+}
+
+// Move shifts money between two envelopes
+func (s *EnvelopeService) Move(from, to string, amount float64, date time.Time, note string) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if from == to {
+		return fmt.Errorf("cannot move money within envelope '%s'", from)
+	}
+	if err := s.ensureStarted(date); err != nil {
+		return err
+	}
+	return s.envelopeRepo.Move(from, to, amount, date, note)
+}
+
+// Start sets the date from which income and spending count toward envelopes
+func (s *EnvelopeService) Start(date time.Time) error {
+	return s.envelopeRepo.SetStart(date)
+}
+
+func (s *EnvelopeService) ensureStarted(date time.Time) error {
+	_, started, err := s.envelopeRepo.GetStart()
+	if err != nil || started {
+		return err
+	}
+	return s.envelopeRepo.SetStart(defaultEnvelopeStart(date))
+}
+
+// defaultEnvelopeStart is the first day of the month containing date
+func defaultEnvelopeStart(date time.Time) time.Time {
+	return models.DefaultAnchor(models.PeriodMonthly, date)
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// forecastLookback is how far back spending patterns are read
+const forecastLookback = 182 * 24 * time.Hour
+
+// minRecurringOccurrences is how often a payment must repeat at a regular
+// interval before it is expected again
+const minRecurringOccurrences = 3
+
+// ExpectedTransaction is a recurring payment expected later in the period
+type ExpectedTransaction struct {
+	Description string
+	Date        time.Time
+	Amount      float64
+}
+
+// BudgetForecast projects the spending of a budget to the end of its current
+// period. For an income target it projects the income instead: OverrunDate is
+// the day the target is expected to be met and SafePerDay what still has to
+// come in per day, besides the recurring income, to meet it.
+type BudgetForecast struct {
+	BudgetPeriodStatus
+	Date        time.Time             // Day the forecast was made for
+	Projected   float64               // Expected spending by the end of the period
+	Upcoming    []ExpectedTransaction // Recurring payments still to come
+	OverrunDate time.Time             // First day spending is expected to exceed the budget; zero if never
+	SafePerDay  float64               // What can be spent per remaining day, today included, to stay within budget
+}
+
+// ProjectedOver reports whether spending is expected to exceed the budget by
+// the end of the period
+func (f *BudgetForecast) ProjectedOver() bool {
+	return !f.OverrunDate.IsZero()
+}
+
+// ProjectedShortfall is what an income target is expected to lack at the end
+// of the period; 0 when it is expected to be met
+func (f *BudgetForecast) ProjectedShortfall() float64 {
+	return max(f.Available-f.Projected, 0)
+}
+
+type ForecastService struct {
+	txRepo    *repository.TransactionRepository
+	budgetSvc *BudgetService
+}
+
+func NewForecastService(txRepo *repository.TransactionRepository, budgetSvc *BudgetService) *ForecastService {
+	return &ForecastService{
+		txRepo:    txRepo,
+		budgetSvc: budgetSvc,
+	}
+}
+
+// Forecast projects the spending, or for an income target the income, of
+// budget from date to the end of the period containing date. Each remaining day is expected to cost a blend of this
+// period's burn rate and the category's average for that weekday over the
+// last six months; the burn rate counts for more as the period goes on.
+// Payments that recur weekly or monthly are left out of both averages and
+// added on the days they are next due.
+func (s *ForecastService) Forecast(budget *models.Budget, date time.Time) (*BudgetForecast, error) {
+	status, err := s.budgetSvc.Status(budget, date)
+	if err != nil {
+		return nil, err
+	}
+	day := civilDay(date)
+
+	txType := models.BudgetExpense
+	if budget.IsIncome() {
+		txType = models.BudgetIncome
+	}
+	history, err := s.txRepo.Find(repository.TransactionFilter{
+		Type:     txType,
+		Category: budget.Category,
+		From:     day.Add(-forecastLookback),
+		To:       day,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &BudgetForecast{BudgetPeriodStatus: *status, Date: day}
+	end := civilDay(status.End)
+	recurring := findRecurring(history)
+	f.Upcoming = upcoming(recurring, day, end)
+
+	// Everything not recurring is variable spending
+	var variable []*models.Transaction
+	periodVariable := 0.0
+	for _, tx := range history {
+		if recurring[descriptionKey(tx.Description)] != nil {
+			continue
+		}
+		variable = append(variable, tx)
+		if !civilDay(tx.Date).Before(civilDay(status.Start)) {
+			periodVariable += tx.Amount
+		}
+	}
+	elapsed := status.DaysElapsed(date)
+	burn := 0.0
+	if elapsed > 0 {
+		burn = periodVariable / float64(elapsed)
+	}
+	weekdays, hasPattern := weekdayAverages(variable, day)
+	weight := 1.0
+	if total := models.CalendarDays(status.Start, status.End) + 1; hasPattern && total > 0 {
+		weight = float64(elapsed) / float64(total)
+	}
+
+	due := map[time.Time]float64{}
+	for _, e := range f.Upcoming {
+		due[e.Date] += e.Amount
+	}
+
+	f.Projected = status.Spent
+	if status.Over() {
+		f.OverrunDate = day
+	}
+	for d := day.AddDate(0, 0, 1); !d.After(end); d = d.AddDate(0, 0, 1) {
+		expected := weight*burn + due[d]
+		if hasPattern {
+			expected += (1 - weight) * weekdays[d.Weekday()]
+		}
+		f.Projected += expected
+		if f.OverrunDate.IsZero() && f.Projected > status.Available {
+			f.OverrunDate = d
+		}
+	}
+
+	committed := 0.0
+	for _, e := range f.Upcoming {
+		committed += e.Amount
+	}
+	if days := status.DaysLeft(date); days > 0 {
+		f.SafePerDay = max((status.Remaining()-committed)/float64(days), 0)
+	}
+	return f, nil
+}
+
+// recurringSeries is a payment that repeats at a regular interval
+type recurringSeries struct {
+	description string
+	last        time.Time
+	amount      float64 // Amount of the latest payment
+	monthly     bool    // Monthly when true, otherwise weekly
+}
+
+// next returns the due date after t
+func (r *recurringSeries) next(t time.Time) time.Time {
+	if r.monthly {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 7)
+}
+
+// findRecurring groups expenses by description and keeps the groups paid at
+// least minRecurringOccurrences times roughly weekly or monthly, keyed by
+// descriptionKey
+func findRecurring(transactions []*models.Transaction) map[string]*recurringSeries {
+	groups := map[string][]*models.Transaction{}
+	for _, tx := range transactions {
+		key := descriptionKey(tx.Description)
+		groups[key] = append(groups[key], tx)
+	}
+
+	recurring := map[string]*recurringSeries{}
+	for key, txs := range groups {
+		if len(txs) < minRecurringOccurrences {
+			continue
+		}
+		sort.Slice(txs, func(i, j int) bool { return txs[i].Date.Before(txs[j].Date) })
+
+		weekly, monthly := true, true
+		for i := 1; i < len(txs); i++ {
+			gap := models.CalendarDays(txs[i-1].Date, txs[i].Date)
+			weekly = weekly && gap >= 6 && gap <= 8
+			monthly = monthly && gap >= 27 && gap <= 33
+		}
+		if !weekly && !monthly {
+			continue
+		}
+		last := txs[len(txs)-1]
+		recurring[key] = &recurringSeries{
+			description: last.Description,
+			last:        civilDay(last.Date),
+			amount:      last.Amount,
+			monthly:     monthly,
+		}
+	}
+	return recurring
+}
+
+// upcoming lists the recurring payments due after day through end, sorted by
+// date
+func upcoming(recurring map[string]*recurringSeries, day, end time.Time) []ExpectedTransaction {
+	var expected []ExpectedTransaction
+	for _, r := range recurring {
+		// A payment more than a week overdue has probably stopped
+		if r.next(r.last).Before(day.AddDate(0, 0, -7)) {
+			continue
+		}
+		for due := r.next(r.last); !due.After(end); due = r.next(due) {
+			if due.After(day) {
+				expected = append(expected, ExpectedTransaction{Description: r.description, Date: due, Amount: r.amount})
+			}
+		}
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if !expected[i].Date.Equal(expected[j].Date) {
+			return expected[i].Date.Before(expected[j].Date)
+		}
+		return expected[i].Description < expected[j].Description
+	})
+	return expected
+}
+
+// weekdayAverages returns the average spending on each weekday from the first
+// transaction up to and including day. ok is false without any history.
+func weekdayAverages(transactions []*models.Transaction, day time.Time) (averages [7]float64, ok bool) {
+	if len(transactions) == 0 {
+		return averages, false
+	}
+	first := day
+	var totals [7]float64
+	for _, tx := range transactions {
+		d := civilDay(tx.Date)
+		if d.Before(first) {
+			first = d
+		}
+		totals[d.Weekday()] += tx.Amount
+	}
+
+	var counts [7]int
+	for d := first; !d.After(day); d = d.AddDate(0, 0, 1) {
+		counts[d.Weekday()]++
+	}
+	for i := range averages {
+		if counts[i] > 0 {
+			averages[i] = totals[i] / float64(counts[i])
+		}
+	}
+	return averages, true
+}
+
+// descriptionKey matches descriptions of the same payee
+func descriptionKey(description string) string {
+	return strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
+
+// civilDay returns the calendar day of t at midnight UTC, like parsed input dates
+func civilDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
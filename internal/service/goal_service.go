@@ -0,0 +1,196 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// Goal statuses
+const (
+	GoalReached = "reached"
+	GoalOnTrack = "on track"
+	GoalBehind  = "behind"
+	GoalOverdue = "overdue" // The target date passed before the goal was reached
+	GoalOpen    = "no date" // Without a target date there is no pace to keep
+)
+
+// GoalProgress is how far a goal has come by Date
+type GoalProgress struct {
+	Goal   *models.Goal
+	Date   time.Time
+	Manual float64 // Contributions recorded by hand
+	Linked float64 // Net transactions of the linked account or category
+}
+
+// Saved is everything put towards the goal
+func (p *GoalProgress) Saved() float64 {
+	return p.Manual + p.Linked
+}
+
+// Remaining is what is still to be saved; 0 once the goal is reached
+func (p *GoalProgress) Remaining() float64 {
+	return max(p.Goal.Target-p.Saved(), 0)
+}
+
+// Percent is the share of the target saved
+func (p *GoalProgress) Percent() float64 {
+	if p.Goal.Target <= 0 {
+		return 100
+	}
+	return p.Saved() / p.Goal.Target * 100
+}
+
+// Reached reports whether the target has been saved
+func (p *GoalProgress) Reached() bool {
+	return roundCents(p.Saved()) >= p.Goal.Target
+}
+
+// MonthsLeft is the time until the target date in average months; negative
+// once it has passed
+func (p *GoalProgress) MonthsLeft() float64 {
+	return float64(models.CalendarDays(p.Date, p.Goal.TargetDate)) / models.DaysPerMonth
+}
+
+// RequiredMonthly is what has to be saved each month to reach the target on
+// time. Within the last month, or past the date, all of the rest is due.
+func (p *GoalProgress) RequiredMonthly() float64 {
+	if !p.Goal.HasDeadline() || p.Reached() {
+		return 0
+	}
+	if months := p.MonthsLeft(); months > 1 {
+		return roundCents(p.Remaining() / months)
+	}
+	return roundCents(p.Remaining())
+}
+
+// Expected is what a steady pace from the start date would have saved by now
+func (p *GoalProgress) Expected() float64 {
+	if !p.Goal.HasDeadline() {
+		return 0
+	}
+	total := models.CalendarDays(p.Goal.StartDate, p.Goal.TargetDate)
+	if total <= 0 {
+		return p.Goal.Target
+	}
+	elapsed := min(max(models.CalendarDays(p.Goal.StartDate, p.Date), 0), total)
+	return roundCents(p.Goal.Target * float64(elapsed) / float64(total))
+}
+
+// Status says whether the goal keeps up with a steady pace to its target date
+func (p *GoalProgress) Status() string {
+	switch {
+	case p.Reached():
+		return GoalReached
+	case !p.Goal.HasDeadline():
+		return GoalOpen
+	case models.CalendarDays(p.Date, p.Goal.TargetDate) < 0:
+		return GoalOverdue
+	case roundCents(p.Saved()) >= p.Expected():
+		return GoalOnTrack
+	}
+	return GoalBehind
+}
+
+// Projected is when the goal is reached if saving goes on at the average
+// monthly pace since the start date; ok is false without savings to go by
+func (p *GoalProgress) Projected() (date time.Time, ok bool) {
+	if p.Reached() {
+		return p.Date, true
+	}
+	months := float64(models.CalendarDays(p.Goal.StartDate, p.Date)) / models.DaysPerMonth
+	if months <= 0 || p.Saved() <= 0 {
+		return time.Time{}, false
+	}
+	rate := p.Saved() / months
+	days := math.Ceil(p.Remaining() / rate * models.DaysPerMonth)
+	if days > 100*365 {
+		return time.Time{}, false
+	}
+	return p.Date.AddDate(0, 0, int(days)), true
+}
+
+type GoalService struct {
+	goalRepo *repository.GoalRepository
+}
+
+func NewGoalService(goalRepo *repository.GoalRepository) *GoalService {
+	return &GoalService{goalRepo: goalRepo}
+}
+
+// Add creates a goal. An amount already saved is recorded as a first
+// contribution on the start date.
+func (s *GoalService) Add(goal *models.Goal, saved float64) error {
+	if err := s.goalRepo.Create(goal); err != nil {
+		return err
+	}
+	if saved == 0 {
+		return nil
+	}
+	return s.goalRepo.AddContribution(&models.GoalContribution{
+		GoalID: goal.ID,
+		Date:   goal.StartDate,
+		Amount: saved,
+		Note:   "saved before the goal was added",
+	})
+}
+
+// Get returns a goal by name, or nil when there is none
+func (s *GoalService) Get(name string) (*models.Goal, error) {
+	return s.goalRepo.Get(name)
+}
+
+// All returns every goal, those due soonest first
+func (s *GoalService) All() ([]*models.Goal, error) {
+	return s.goalRepo.GetAll()
+}
+
+// Delete removes a goal and its contributions
+func (s *GoalService) Delete(name string) error {
+	return s.goalRepo.Delete(name)
+}
+
+// Contribute records money put towards a goal, or taken out of it when
+// amount is negative
+func (s *GoalService) Contribute(goal *models.Goal, amount float64, date time.Time, note string) (*models.GoalContribution, error) {
+	c := &models.GoalContribution{GoalID: goal.ID, Date: date, Amount: amount, Note: note}
+	if err := s.goalRepo.AddContribution(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Contributions lists the contributions recorded for a goal, oldest first
+func (s *GoalService) Contributions(goal *models.Goal) ([]*models.GoalContribution, error) {
+	return s.goalRepo.GetContributions(goal.ID)
+}
+
+// Progress evaluates every goal at now, with two aggregated queries for the
+// amounts saved
+func (s *GoalService) Progress(now time.Time) ([]GoalProgress, error) {
+	goals, err := s.goalRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	manual, linked, err := s.goalRepo.GetSaved(now)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]GoalProgress, len(goals))
+	for i, goal := range goals {
+		progress[i] = GoalProgress{Goal: goal, Date: now, Manual: manual[goal.ID], Linked: linked[goal.ID]}
+	}
+	return progress, nil
+}
+
+// ProgressOf evaluates one goal at now
+func (s *GoalService) ProgressOf(goal *models.Goal, now time.Time) (*GoalProgress, error) {
+	manual, linked, err := s.goalRepo.GetSaved(now)
+	if err != nil {
+		return nil, err
+	}
+	return &GoalProgress{Goal: goal, Date: now, Manual: manual[goal.ID], Linked: linked[goal.ID]}, nil
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// maxInstallments bounds schedules of debts whose payment barely covers the
+// interest: 100 years of monthly payments
+const maxInstallments = 1200
+
+// LoanPayment is a payment transaction split into the interest it covered
+// and the principal it paid off
+type LoanPayment struct {
+	Transaction *models.Transaction
+	Interest    float64
+	Principal   float64
+	Excess      float64 // Paid beyond what was owed
+	Balance     float64 // Principal owed after the payment
+}
+
+// LoanStatus is the state of a loan at Date after its payments. Interest is
+// charged on the balance at every monthly installment date, and a payment
+// covers the interest charged so far before it pays off principal.
+type LoanStatus struct {
+	Loan     *models.Loan
+	Date     time.Time
+	Payments []LoanPayment
+	Balance  float64 // Principal still owed
+	Accrued  float64 // Interest charged and not paid yet
+	Credit   float64 // Paid beyond what was owed
+	Months   int     // Installment dates passed by Date
+}
+
+// Owed is what it takes to pay the loan off at Date
+func (s *LoanStatus) Owed() float64 {
+	return roundCents(s.Balance + s.Accrued)
+}
+
+// PaidOff reports whether nothing is owed any more
+func (s *LoanStatus) PaidOff() bool {
+	return s.Owed() <= 0
+}
+
+// InterestPaid totals the interest covered by the payments
+func (s *LoanStatus) InterestPaid() float64 {
+	total := 0.0
+	for _, p := range s.Payments {
+		total += p.Interest
+	}
+	return roundCents(total)
+}
+
+// PrincipalPaid is how much of the principal has been paid off
+func (s *LoanStatus) PrincipalPaid() float64 {
+	return roundCents(s.Loan.Principal - s.Balance)
+}
+
+// Percent is the share of the principal paid off
+func (s *LoanStatus) Percent() float64 {
+	if s.Loan.Principal <= 0 {
+		return 100
+	}
+	return s.PrincipalPaid() / s.Loan.Principal * 100
+}
+
+// Payment finds the split of a payment transaction
+func (s *LoanStatus) Payment(txID int64) (LoanPayment, bool) {
+	for _, p := range s.Payments {
+		if p.Transaction.ID == txID {
+			return p, true
+		}
+	}
+	return LoanPayment{}, false
+}
+
+// Installment is one scheduled monthly payment
+type Installment struct {
+	Number    int // Counted from the loan's start date
+	Date      time.Time
+	Payment   float64
+	Interest  float64
+	Principal float64
+	Balance   float64 // Principal owed after the installment
+}
+
+// Projection is the schedule that pays off a loan from its status with the
+// loan's payment plus Extra every month
+type Projection struct {
+	Extra        float64
+	Installments []Installment
+	PaidOff      bool // False when the payment does not outgrow the interest
+}
+
+// PayoffDate is the date of the last installment; the zero time when
+// nothing is left to pay
+func (p *Projection) PayoffDate() time.Time {
+	if len(p.Installments) == 0 {
+		return time.Time{}
+	}
+	return p.Installments[len(p.Installments)-1].Date
+}
+
+// Interest totals the interest of the installments
+func (p *Projection) Interest() float64 {
+	total := 0.0
+	for _, i := range p.Installments {
+		total += i.Interest
+	}
+	return roundCents(total)
+}
+
+// Schedule projects the installments that pay off the loan, paying extra on
+// top of its payment every month. Interest left unpaid so far is covered
+// first, and the last installment of the term settles what rounding left.
+func (s *LoanStatus) Schedule(extra float64) *Projection {
+	loan := s.Loan
+	projection := &Projection{Extra: extra, PaidOff: true}
+	balance, accrued := s.Balance, s.Accrued
+	payment := loan.Payment + extra
+	rate := loan.MonthlyRate()
+
+	for n := s.Months + 1; balance > 0 || accrued > 0; n++ {
+		charge := roundCents(balance * rate)
+		interest := roundCents(accrued + charge)
+		owed := roundCents(balance + interest)
+		if payment <= charge && payment < owed || n-s.Months > maxInstallments {
+			projection.PaidOff = false
+			break
+		}
+		paid := min(payment, owed)
+		if n == loan.TermMonths && owed-paid < 1 {
+			paid = owed
+		}
+		covered := min(paid, interest)
+		accrued = roundCents(interest - covered)
+		balance = roundCents(balance - (paid - covered))
+		projection.Installments = append(projection.Installments, Installment{
+			Number:    n,
+			Date:      loan.InstallmentDate(n),
+			Payment:   roundCents(paid),
+			Interest:  covered,
+			Principal: roundCents(paid - covered),
+			Balance:   balance,
+		})
+	}
+	return projection
+}
+
+// Payoff strategies for several debts
+const (
+	StrategyMinimum   = "minimum"   // Every debt is paid on its own, money freed by a paid-off debt is not reused
+	StrategyAvalanche = "avalanche" // Extra money goes to the highest rate first
+	StrategySnowball  = "snowball"  // Extra money goes to the smallest balance first
+)
+
+// DebtPayoff is when a strategy pays off one debt
+type DebtPayoff struct {
+	Loan     *models.Loan
+	Months   int
+	Interest float64
+}
+
+// StrategyResult is the outcome of paying off every debt with a strategy
+type StrategyResult struct {
+	Strategy string
+	Extra    float64      // Paid every month on top of the loan payments
+	Months   int          // Until the last debt is paid off
+	Interest float64      // Paid across all debts
+	Payoffs  []DebtPayoff // In the order the debts are paid off
+	PaidOff  bool         // False when a debt would run beyond maxInstallments months
+}
+
+// ComparePayoff simulates paying off the open debts month by month with
+// each strategy. The avalanche and snowball put the loan payments plus extra
+// towards the debts: every debt gets its own payment first, and what is left,
+// including the payments of debts already paid off, goes to the debt the
+// strategy picks.
+func ComparePayoff(statuses []*LoanStatus, extra float64) []StrategyResult {
+	strategies := []string{StrategyMinimum, StrategyAvalanche, StrategySnowball}
+	results := make([]StrategyResult, 0, len(strategies))
+	for _, strategy := range strategies {
+		results = append(results, simulatePayoff(statuses, strategy, extra))
+	}
+	return results
+}
+
+// simulatedDebt is a debt being paid off by simulatePayoff
+type simulatedDebt struct {
+	loan     *models.Loan
+	balance  float64
+	accrued  float64 // Interest charged and not paid yet
+	interest float64 // Interest paid
+}
+
+// pay puts up to amount towards the debt, interest first, and returns what
+// was used
+func (d *simulatedDebt) pay(amount float64) float64 {
+	amount = min(max(amount, 0), roundCents(d.balance+d.accrued))
+	covered := min(amount, d.accrued)
+	d.accrued = roundCents(d.accrued - covered)
+	d.interest += covered
+	d.balance = roundCents(d.balance - (amount - covered))
+	return amount
+}
+
+func simulatePayoff(statuses []*LoanStatus, strategy string, extra float64) StrategyResult {
+	result := StrategyResult{Strategy: strategy, PaidOff: true}
+	var open []*simulatedDebt
+	budget := 0.0
+	for _, s := range statuses {
+		if s.PaidOff() {
+			continue
+		}
+		open = append(open, &simulatedDebt{loan: s.Loan, balance: s.Balance, accrued: s.Accrued})
+		budget += s.Loan.Payment
+	}
+	if strategy != StrategyMinimum {
+		result.Extra = extra
+		budget += extra
+	}
+
+	for month := 1; len(open) > 0; month++ {
+		if month > maxInstallments {
+			result.PaidOff = false
+			break
+		}
+		available := budget
+		for _, d := range open {
+			d.accrued = roundCents(d.accrued + d.balance*d.loan.MonthlyRate())
+			available -= d.pay(d.loan.Payment)
+		}
+		if strategy != StrategyMinimum {
+			sort.SliceStable(open, func(i, j int) bool {
+				a, b := open[i], open[j]
+				if strategy == StrategySnowball && a.balance != b.balance {
+					return a.balance < b.balance
+				}
+				if a.loan.Rate != b.loan.Rate {
+					return a.loan.Rate > b.loan.Rate
+				}
+				return a.balance < b.balance
+			})
+			for _, d := range open {
+				available -= d.pay(available)
+			}
+		}
+
+		remaining := open[:0]
+		for _, d := range open {
+			if d.balance > 0 || d.accrued > 0 {
+				remaining = append(remaining, d)
+				continue
+			}
+			result.Payoffs = append(result.Payoffs, DebtPayoff{Loan: d.loan, Months: month, Interest: roundCents(d.interest)})
+			result.Interest += d.interest
+			result.Months = month
+		}
+		open = remaining
+	}
+	for _, d := range open {
+		result.Interest += d.interest
+	}
+	result.Interest = roundCents(result.Interest)
+	return result
+}
+
+type LoanService struct {
+	loanRepo *repository.LoanRepository
+	txRepo   *repository.TransactionRepository
+}
+
+func NewLoanService(loanRepo *repository.LoanRepository, txRepo *repository.TransactionRepository) *LoanService {
+	return &LoanService{loanRepo: loanRepo, txRepo: txRepo}
+}
+
+// Add creates a loan
+func (s *LoanService) Add(loan *models.Loan) error {
+	return s.loanRepo.Create(loan)
+}
+
+// Get returns a loan by name, or nil when there is none
+func (s *LoanService) Get(name string) (*models.Loan, error) {
+	return s.loanRepo.Get(name)
+}
+
+// GetByCategory returns the loan paid in a category, or nil when there is none
+func (s *LoanService) GetByCategory(category string) (*models.Loan, error) {
+	return s.loanRepo.GetByCategory(category)
+}
+
+// All returns every loan ordered by name
+func (s *LoanService) All() ([]*models.Loan, error) {
+	return s.loanRepo.GetAll()
+}
+
+// Delete removes a loan; its payment transactions are kept
+func (s *LoanService) Delete(name string) error {
+	return s.loanRepo.Delete(name)
+}
+
+// Pay records a payment as an expense transaction in the loan's category
+func (s *LoanService) Pay(loan *models.Loan, amount float64, date time.Time, account, description string) (*models.Transaction, error) {
+	if description == "" {
+		description = fmt.Sprintf("%s payment", loan.Name)
+	}
+	tx := &models.Transaction{
+		Date:        date,
+		Description: description,
+		Amount:      amount,
+		Category:    loan.Category,
+		Type:        "expense",
+		Account:     account,
+	}
+	if err := s.txRepo.Create(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Status splits the payments of a loan up to now into interest and
+// principal and returns what is still owed
+func (s *LoanService) Status(loan *models.Loan, now time.Time) (*LoanStatus, error) {
+	payments, err := s.loanRepo.GetPayments(loan, now)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &LoanStatus{Loan: loan, Date: now, Balance: loan.Principal}
+	rate := loan.MonthlyRate()
+	// accrue charges the interest of every installment date up to date
+	accrue := func(date time.Time) {
+		for models.CalendarDays(loan.InstallmentDate(status.Months+1), date) >= 0 {
+			status.Accrued = roundCents(status.Accrued + status.Balance*rate)
+			status.Months++
+		}
+	}
+	for _, tx := range payments {
+		accrue(tx.Date)
+		interest := min(tx.Amount, status.Accrued)
+		principal := min(roundCents(tx.Amount-interest), status.Balance)
+		excess := roundCents(tx.Amount - interest - principal)
+		status.Accrued = roundCents(status.Accrued - interest)
+		status.Balance = roundCents(status.Balance - principal)
+		status.Credit = roundCents(status.Credit + excess)
+		status.Payments = append(status.Payments, LoanPayment{
+			Transaction: tx,
+			Interest:    interest,
+			Principal:   principal,
+			Excess:      excess,
+			Balance:     status.Balance,
+		})
+	}
+	accrue(now)
+	return status, nil
+}
+
+// Statuses returns the status of every loan at now
+func (s *LoanService) Statuses(now time.Time) ([]*LoanStatus, error) {
+	loans, err := s.loanRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*LoanStatus, 0, len(loans))
+	for _, loan := range loans {
+		status, err := s.Status(loan, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
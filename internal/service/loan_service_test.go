@@ -0,0 +1,212 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+var loanStart = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		loan     models.Loan
+		status   LoanStatus
+		extra    float64
+		want     []Installment // Date is checked through Number
+		paidOff  bool
+		interest float64
+	}{
+		{
+			name:   "three month amortization",
+			loan:   models.Loan{Principal: 1000, Rate: 12, TermMonths: 3, Payment: 340.02},
+			status: LoanStatus{Balance: 1000},
+			want: []Installment{
+				{Number: 1, Payment: 340.02, Interest: 10, Principal: 330.02, Balance: 669.98},
+				{Number: 2, Payment: 340.02, Interest: 6.70, Principal: 333.32, Balance: 336.66},
+				// The last installment of the term settles the rounding
+				{Number: 3, Payment: 340.03, Interest: 3.37, Principal: 336.66, Balance: 0},
+			},
+			paidOff:  true,
+			interest: 20.07,
+		},
+		{
+			name:   "interest free",
+			loan:   models.Loan{Principal: 300, TermMonths: 3, Payment: 100},
+			status: LoanStatus{Balance: 300},
+			want: []Installment{
+				{Number: 1, Payment: 100, Principal: 100, Balance: 200},
+				{Number: 2, Payment: 100, Principal: 100, Balance: 100},
+				{Number: 3, Payment: 100, Principal: 100, Balance: 0},
+			},
+			paidOff: true,
+		},
+		{
+			name:   "extra payment",
+			loan:   models.Loan{Principal: 1000, Rate: 12, TermMonths: 3, Payment: 340.02},
+			status: LoanStatus{Balance: 1000},
+			extra:  200,
+			want: []Installment{
+				{Number: 1, Payment: 540.02, Interest: 10, Principal: 530.02, Balance: 469.98},
+				{Number: 2, Payment: 474.68, Interest: 4.70, Principal: 469.98, Balance: 0},
+			},
+			paidOff:  true,
+			interest: 14.70,
+		},
+		{
+			name:   "part paid with interest due",
+			loan:   models.Loan{Principal: 1000, Rate: 12, Payment: 400},
+			status: LoanStatus{Balance: 600, Accrued: 5, Months: 2},
+			want: []Installment{
+				{Number: 3, Payment: 400, Interest: 11, Principal: 389, Balance: 211},
+				{Number: 4, Payment: 213.11, Interest: 2.11, Principal: 211, Balance: 0},
+			},
+			paidOff:  true,
+			interest: 13.11,
+		},
+		{
+			name:    "payment below the interest",
+			loan:    models.Loan{Principal: 1000, Rate: 12, Payment: 5},
+			status:  LoanStatus{Balance: 1000},
+			paidOff: false,
+		},
+		{
+			name:    "nothing owed",
+			loan:    models.Loan{Principal: 1000, Rate: 12, Payment: 100},
+			status:  LoanStatus{Months: 12},
+			paidOff: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := tt.loan
+			loan.StartDate = loanStart
+			status := tt.status
+			status.Loan = &loan
+
+			projection := status.Schedule(tt.extra)
+			if projection.PaidOff != tt.paidOff {
+				t.Errorf("PaidOff = %v, want %v", projection.PaidOff, tt.paidOff)
+			}
+			if len(projection.Installments) != len(tt.want) {
+				t.Fatalf("installments = %+v, want %+v", projection.Installments, tt.want)
+			}
+			for i, want := range tt.want {
+				want.Date = loan.InstallmentDate(want.Number)
+				if got := projection.Installments[i]; got != want {
+					t.Errorf("installment %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if got := projection.Interest(); got != tt.interest {
+				t.Errorf("Interest() = %.2f, want %.2f", got, tt.interest)
+			}
+		})
+	}
+}
+
+func TestComparePayoff(t *testing.T) {
+	card := &models.Loan{Name: "Card", Principal: 1000, Rate: 24, Payment: 100, StartDate: loanStart}
+	car := &models.Loan{Name: "Car", Principal: 300, Rate: 6, Payment: 50, StartDate: loanStart}
+	paid := &models.Loan{Name: "Paid", Principal: 500, Rate: 5, Payment: 50, StartDate: loanStart}
+	statuses := []*LoanStatus{
+		{Loan: card, Balance: 1000},
+		{Loan: car, Balance: 300},
+		{Loan: paid},
+	}
+
+	results := ComparePayoff(statuses, 100)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	minimum, avalanche, snowball := results[0], results[1], results[2]
+
+	tests := []struct {
+		result StrategyResult
+		name   string
+		extra  float64
+		order  []string
+	}{
+		{minimum, StrategyMinimum, 0, []string{"Car", "Card"}},
+		{avalanche, StrategyAvalanche, 100, []string{"Card", "Car"}},
+		{snowball, StrategySnowball, 100, []string{"Car", "Card"}},
+	}
+	for _, tt := range tests {
+		r := tt.result
+		if r.Strategy != tt.name || r.Extra != tt.extra || !r.PaidOff {
+			t.Errorf("%s: result = %+v", tt.name, r)
+			continue
+		}
+		if len(r.Payoffs) != len(tt.order) {
+			t.Errorf("%s: payoffs = %+v, want %v", tt.name, r.Payoffs, tt.order)
+			continue
+		}
+		for i, name := range tt.order {
+			if r.Payoffs[i].Loan.Name != name {
+				t.Errorf("%s: payoff %d is %s, want %s", tt.name, i, r.Payoffs[i].Loan.Name, name)
+			}
+		}
+		if r.Months != r.Payoffs[len(r.Payoffs)-1].Months {
+			t.Errorf("%s: Months = %d, want the last payoff's %d", tt.name, r.Months, r.Payoffs[len(r.Payoffs)-1].Months)
+		}
+	}
+
+	// The snowball clears the small debt first; the avalanche costs less
+	if avalanche.Payoffs[0].Months != 6 || snowball.Payoffs[0].Months != 3 {
+		t.Errorf("first payoffs after %d (avalanche) and %d (snowball) months, want 6 and 3",
+			avalanche.Payoffs[0].Months, snowball.Payoffs[0].Months)
+	}
+	if !(avalanche.Interest < snowball.Interest && snowball.Interest < minimum.Interest) {
+		t.Errorf("interest avalanche %.2f, snowball %.2f, minimum %.2f; want increasing",
+			avalanche.Interest, snowball.Interest, minimum.Interest)
+	}
+	if avalanche.Months > minimum.Months || snowball.Months > minimum.Months {
+		t.Errorf("months avalanche %d, snowball %d, minimum %d; extra money should not take longer",
+			avalanche.Months, snowball.Months, minimum.Months)
+	}
+}
+
+func TestComparePayoffNeverPaidOff(t *testing.T) {
+	loan := &models.Loan{Name: "Card", Principal: 1000, Rate: 24, Payment: 10, StartDate: loanStart}
+	for _, r := range ComparePayoff([]*LoanStatus{{Loan: loan, Balance: 1000}}, 0) {
+		if r.PaidOff {
+			t.Errorf("%s: paid off with a payment below the interest", r.Strategy)
+		}
+	}
+}
+
+func TestLoanStatusKeepsOverpaymentAsCredit(t *testing.T) {
+	db, err := database.NewDatabaseAt(filepath.Join(t.TempDir(), "atad.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	loanRepo := repository.NewLoanRepository(db.DB)
+	svc := NewLoanService(loanRepo, repository.NewTransactionRepository(db.DB))
+	loan := &models.Loan{Name: "Car", Principal: 100, TermMonths: 2, Payment: 50, Category: "Car", StartDate: loanStart}
+	if err := svc.Add(loan); err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []float64{80, 50} {
+		if _, err := svc.Pay(loan, amount, loanStart.AddDate(0, 1, 0), "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := svc.Status(loan, loanStart.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.PaidOff() || status.Credit != 30 {
+		t.Errorf("owed %.2f, credit %.2f; want paid off with a 30.00 credit", status.Owed(), status.Credit)
+	}
+	if len(status.Payments) != 2 || status.Payments[1].Principal != 20 || status.Payments[1].Excess != 30 {
+		t.Errorf("payments = %+v, want the second split 20 principal, 30 excess", status.Payments)
+	}
+}
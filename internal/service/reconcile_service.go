@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// ReconcileStatus compares the database against a bank statement
+type ReconcileStatus struct {
+	Account          string
+	StatementDate    time.Time
+	StatementBalance float64
+	OpeningBalance   float64 // Balance before the account's first transaction
+	ClearedBalance   float64 // OpeningBalance plus the cleared and reconciled transactions
+	Difference       float64 // StatementBalance - ClearedBalance
+	Transactions     []*models.Transaction
+}
+
+// Balanced reports whether the cleared transactions match the statement to the cent
+func (s *ReconcileStatus) Balanced() bool {
+	return math.Abs(s.Difference) < 0.005
+}
+
+type ReconcileService struct {
+	txRepo  *repository.TransactionRepository
+	recRepo *repository.ReconciliationRepository
+}
+
+func NewReconcileService(txRepo *repository.TransactionRepository, recRepo *repository.ReconciliationRepository) *ReconcileService {
+	return &ReconcileService{
+		txRepo:  txRepo,
+		recRepo: recRepo,
+	}
+}
+
+// Status loads the unreconciled transactions of an account and the difference
+// between its cleared balance, counted from its opening balance, and the
+// statement balance
+func (s *ReconcileService) Status(account string, statementDate time.Time, statementBalance float64) (*ReconcileStatus, error) {
+	// Include the whole statement day
+	endOfDay := time.Date(statementDate.Year(), statementDate.Month(), statementDate.Day(),
+		23, 59, 59, int(time.Second-time.Nanosecond), statementDate.Location())
+
+	transactions, err := s.txRepo.GetUnreconciled(account, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := s.recRepo.GetOpeningBalance(account)
+	if err != nil {
+		return nil, err
+	}
+	cleared, err := s.txRepo.GetClearedBalance(account, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+	cleared += opening
+
+	return &ReconcileStatus{
+		Account:          account,
+		StatementDate:    endOfDay,
+		StatementBalance: statementBalance,
+		OpeningBalance:   opening,
+		ClearedBalance:   cleared,
+		Difference:       statementBalance - cleared,
+		Transactions:     transactions,
+	}, nil
+}
+
+// SetOpeningBalance records the balance an account had before its first
+// transaction in the database, which every cleared balance starts from
+func (s *ReconcileService) SetOpeningBalance(account string, balance float64) error {
+	return s.recRepo.SetOpeningBalance(account, balance)
+}
+
+// Toggle flips a transaction between cleared and uncleared
+func (s *ReconcileService) Toggle(tx *models.Transaction) error {
+	status := models.StatusCleared
+	if tx.Status == models.StatusCleared {
+		status = models.StatusUncleared
+	}
+	if err := s.txRepo.SetStatus(tx.ID, status); err != nil {
+		return err
+	}
+	tx.Status = status
+	return nil
+}
+
+// Finish locks the cleared transactions once they match the statement balance
+func (s *ReconcileService) Finish(status *ReconcileStatus) (*models.Reconciliation, error) {
+	if !status.Balanced() {
+		return nil, fmt.Errorf("cleared balance %.2f does not match statement balance %.2f (difference %.2f)",
+			status.ClearedBalance, status.StatementBalance, status.Difference)
+	}
+
+	rec := &models.Reconciliation{
+		Account:          status.Account,
+		StatementDate:    status.StatementDate,
+		StatementBalance: status.StatementBalance,
+	}
+	if err := s.recRepo.Finish(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
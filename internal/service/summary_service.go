@@ -0,0 +1,359 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// PlanLine compares what was planned for a category with what happened
+type PlanLine struct {
+	Category string
+	Planned  float64 // Budgeted or targeted amount; 0 without a budget
+	Actual   float64
+	Budgeted bool // Whether a budget or income target covers the category
+}
+
+// Variance is the actual amount minus the planned one
+func (l PlanLine) Variance() float64 {
+	return l.Actual - l.Planned
+}
+
+// MonthlySummary sets the income targets and expense budgets of a month
+// against the actual income and spending
+type MonthlySummary struct {
+	Start    time.Time
+	End      time.Time
+	Income   []PlanLine
+	Expenses []PlanLine
+}
+
+// Totals adds up the planned and actual amounts of lines
+func Totals(lines []PlanLine) (planned, actual float64) {
+	for _, l := range lines {
+		planned += l.Planned
+		actual += l.Actual
+	}
+	return planned, actual
+}
+
+type SummaryService struct {
+	txRepo     *repository.TransactionRepository
+	budgetRepo *repository.BudgetRepository
+}
+
+func NewSummaryService(txRepo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository) *SummaryService {
+	return &SummaryService{
+		txRepo:     txRepo,
+		budgetRepo: budgetRepo,
+	}
+}
+
+// Monthly summarizes the calendar month containing date. A budget contributes
+// its amount spread evenly over the days of each period, so a weekly budget
+// plans about 4.3 weeks' worth and a custom budget only its days in the
+// month. Categories with activity but no budget are listed with nothing
+// planned.
+func (s *SummaryService) Monthly(date time.Time) (*MonthlySummary, error) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	summary := &MonthlySummary{Start: start, End: end}
+	for _, txType := range []string{models.BudgetIncome, models.BudgetExpense} {
+		actual, err := s.txRepo.SumByCategory(txType, start, end)
+		if err != nil {
+			return nil, err
+		}
+		lines := planLines(budgets, txType, start, end, actual)
+		if txType == models.BudgetIncome {
+			summary.Income = lines
+		} else {
+			summary.Expenses = lines
+		}
+	}
+	return summary, nil
+}
+
+// planLines builds the lines of one budget type, ordered by category
+func planLines(budgets []*models.Budget, budgetType string, start, end time.Time, actual map[string]float64) []PlanLine {
+	byCategory := map[string][]*models.Budget{}
+	for _, budget := range budgets {
+		if budget.Type == budgetType {
+			byCategory[budget.Category] = append(byCategory[budget.Category], budget)
+		}
+	}
+
+	lines := map[string]*PlanLine{}
+	for category, candidates := range byCategory {
+		if planned, covered := plannedAmount(candidates, start, end); covered {
+			lines[category] = &PlanLine{Category: category, Planned: planned, Budgeted: true}
+		}
+	}
+	for category, amount := range actual {
+		if lines[category] == nil {
+			lines[category] = &PlanLine{Category: category}
+		}
+		lines[category].Actual = amount
+	}
+
+	result := make([]PlanLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, *line)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Category < result[j].Category })
+	return result
+}
+
+// plannedAmount spreads the budgets of one category and type evenly over the
+// days of their periods and adds up the days from start through end. covered
+// is false when no budget applies on any of those days.
+func plannedAmount(budgets []*models.Budget, start, end time.Time) (planned float64, covered bool) {
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		budget, periodStart, periodEnd := budgetOn(budgets, day)
+		if budget == nil {
+			continue
+		}
+		planned += budget.Amount / float64(models.CalendarDays(periodStart, periodEnd)+1)
+		covered = true
+	}
+	return planned, covered
+}
+
+// budgetOn picks the budget that applies on day from the budgets of one
+// category and type, as BudgetRepository.GetByCategoryAt does, with the
+// period containing day
+func budgetOn(budgets []*models.Budget, day time.Time) (budget *models.Budget, start, end time.Time) {
+	for _, candidate := range budgets {
+		s, e, ok := candidate.PeriodAt(day)
+		if ok && (budget == nil || overrides(candidate, budget)) {
+			budget, start, end = candidate, s, e
+		}
+	}
+	return budget, start, end
+}
+
+// VariancePeriod is the planned and actual amount of a category in one
+// report period
+type VariancePeriod struct {
+	Start    time.Time
+	End      time.Time
+	Planned  float64
+	Actual   float64
+	Budgeted bool // Whether a budget covers any day of the period
+}
+
+// Variance is the actual amount minus the planned one
+func (p VariancePeriod) Variance() float64 {
+	return p.Actual - p.Planned
+}
+
+// VariancePercent is the variance as a share of the planned amount
+func (p VariancePeriod) VariancePercent() float64 {
+	if p.Planned <= 0 {
+		return 0
+	}
+	return p.Variance() / p.Planned * 100
+}
+
+// CategoryVariance follows one budgeted category over the report periods
+type CategoryVariance struct {
+	Category string
+	Type     string
+	Periods  []VariancePeriod // Oldest first
+}
+
+// Missed reports whether a period went the wrong way: spending over the
+// budget, or income under the target
+func (c *CategoryVariance) Missed(p VariancePeriod) bool {
+	if !p.Budgeted {
+		return false
+	}
+	if c.Type == models.BudgetIncome {
+		return p.Actual < p.Planned
+	}
+	return p.Actual > p.Planned
+}
+
+// MissedCount counts the periods that went the wrong way
+func (c *CategoryVariance) MissedCount() int {
+	n := 0
+	for _, p := range c.Periods {
+		if c.Missed(p) {
+			n++
+		}
+	}
+	return n
+}
+
+// BudgetedCount counts the periods a budget covers
+func (c *CategoryVariance) BudgetedCount() int {
+	n := 0
+	for _, p := range c.Periods {
+		if p.Budgeted {
+			n++
+		}
+	}
+	return n
+}
+
+// Totals adds up the planned and actual amounts of the budgeted periods
+func (c *CategoryVariance) Totals() (planned, actual float64) {
+	for _, p := range c.Periods {
+		if p.Budgeted {
+			planned += p.Planned
+			actual += p.Actual
+		}
+	}
+	return planned, actual
+}
+
+// AveragePercent is the mean variance percentage of the budgeted periods
+func (c *CategoryVariance) AveragePercent() float64 {
+	sum, n := 0.0, 0
+	for _, p := range c.Periods {
+		if p.Budgeted && p.Planned > 0 {
+			sum += p.VariancePercent()
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// Variance trends, from the line fitted through the variance percentages
+const (
+	TrendRising  = "rising"
+	TrendFalling = "falling"
+	TrendSteady  = "steady"
+)
+
+// trendThreshold is how far, in percent of the budget, the fitted variance
+// must move from the first budgeted period to the last for a category to be
+// rising or falling rather than steady. It applies to the whole report, so a
+// slow but steady drift over many periods still counts as a trend.
+const trendThreshold = 3.0
+
+// Trend fits a line through the variance percentages of the budgeted periods
+// and reports its direction; empty with fewer than three periods
+func (c *CategoryVariance) Trend() string {
+	var xs, ys []float64
+	for i, p := range c.Periods {
+		if p.Budgeted && p.Planned > 0 {
+			xs = append(xs, float64(i))
+			ys = append(ys, p.VariancePercent())
+		}
+	}
+	if len(xs) < 3 {
+		return ""
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - meanX) * (ys[i] - meanY)
+		den += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	switch change := num / den * (xs[len(xs)-1] - xs[0]); {
+	case change > trendThreshold:
+		return TrendRising
+	case change < -trendThreshold:
+		return TrendFalling
+	}
+	return TrendSteady
+}
+
+// VarianceReport compares budgets with actual amounts over consecutive
+// periods of the same length
+type VarianceReport struct {
+	Period     string // models.PeriodWeekly, PeriodMonthly, PeriodQuarterly or PeriodYearly
+	Type       string // models.BudgetExpense or BudgetIncome
+	Starts     []time.Time
+	Ends       []time.Time
+	Categories []CategoryVariance
+}
+
+// Variance reports the n complete periods of a kind before the one
+// containing date, for every category a budget of budgetType covers in any of
+// them. Planned amounts are spread over days as in Monthly, without rollover;
+// actual amounts of all categories and periods come from one query.
+func (s *SummaryService) Variance(date time.Time, period, budgetType string, n int) (*VarianceReport, error) {
+	report := &VarianceReport{Period: period, Type: budgetType}
+	end := models.DefaultAnchor(period, date)
+	for i := 0; i < n; i++ {
+		start := nextPeriod(end, period, -1)
+		report.Starts = append([]time.Time{start}, report.Starts...)
+		report.Ends = append([]time.Time{end.AddDate(0, 0, -1)}, report.Ends...)
+		end = start
+	}
+
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byCategory := map[string][]*models.Budget{}
+	for _, budget := range budgets {
+		if budget.Type == budgetType {
+			byCategory[budget.Category] = append(byCategory[budget.Category], budget)
+		}
+	}
+
+	var ranges []repository.SpendingRange
+	for category, candidates := range byCategory {
+		line := CategoryVariance{Category: category, Type: budgetType}
+		for i := range report.Starts {
+			planned, covered := plannedAmount(candidates, report.Starts[i], report.Ends[i])
+			line.Periods = append(line.Periods, VariancePeriod{
+				Start:    report.Starts[i],
+				End:      report.Ends[i],
+				Planned:  roundCents(planned),
+				Budgeted: covered,
+			})
+		}
+		if line.BudgetedCount() == 0 {
+			continue
+		}
+		for _, p := range line.Periods {
+			ranges = append(ranges, repository.SpendingRange{Category: category, Type: budgetType, Start: p.Start, End: p.End})
+		}
+		report.Categories = append(report.Categories, line)
+	}
+
+	actual, err := s.budgetRepo.GetSpendingByRanges(ranges)
+	if err != nil {
+		return nil, err
+	}
+	for i := range report.Categories {
+		for j := range report.Categories[i].Periods {
+			report.Categories[i].Periods[j].Actual = actual[i*n+j]
+		}
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Category < report.Categories[j].Category })
+	return report, nil
+}
+
+// nextPeriod moves a period start by count periods of a kind
+func nextPeriod(start time.Time, period string, count int) time.Time {
+	switch period {
+	case models.PeriodWeekly:
+		return start.AddDate(0, 0, 7*count)
+	case models.PeriodQuarterly:
+		return start.AddDate(0, 3*count, 0)
+	case models.PeriodYearly:
+		return start.AddDate(count, 0, 0)
+	}
+	return start.AddDate(0, count, 0)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/finances"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// What a change does to a budget or rule
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// FieldChange is one field of an updated budget or rule, with the values
+// written as in finances files
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Change is one difference between a finances file and the database. Budget
+// or Rule holds the record after the change, or the deleted one.
+type Change struct {
+	Action string
+	Budget *models.Budget
+	Rule   *models.CategoryRule
+	Fields []FieldChange // Changed fields of an update
+}
+
+// SyncPlan lists the changes that make the database match a finances file
+type SyncPlan struct {
+	Changes   []Change
+	changeset repository.Changeset
+}
+
+// Count returns how many changes of an action the plan has
+func (p *SyncPlan) Count(action string) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+type SyncService struct {
+	budgetRepo    *repository.BudgetRepository
+	ruleRepo      *repository.CategoryRuleRepository
+	changesetRepo *repository.ChangesetRepository
+}
+
+func NewSyncService(budgetRepo *repository.BudgetRepository, ruleRepo *repository.CategoryRuleRepository, changesetRepo *repository.ChangesetRepository) *SyncService {
+	return &SyncService{
+		budgetRepo:    budgetRepo,
+		ruleRepo:      ruleRepo,
+		changesetRepo: changesetRepo,
+	}
+}
+
+// Dump returns the budgets and rules in the database as a finances file
+func (s *SyncService) Dump() (*finances.File, error) {
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(budgets, func(i, j int) bool {
+		a, b := budgets[i], budgets[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.IsRecurring() != b.IsRecurring() {
+			return a.IsRecurring()
+		}
+		return a.StartDate.Before(b.StartDate)
+	})
+
+	rules, err := s.ruleRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	file := &finances.File{Budgets: budgets}
+	for i := range rules {
+		file.Rules = append(file.Rules, &rules[i])
+	}
+	return file, nil
+}
+
+// Plan compares a finances file with the database. Budgets match by category,
+// type and period, and custom budgets also by their dates; rules match by
+// pattern. Whatever the file does not list is deleted. A recurring budget
+// without a start keeps the anchor it has, or starts with the period
+// containing now.
+func (s *SyncService) Plan(file *finances.File, now time.Time) (*SyncPlan, error) {
+	plan := &SyncPlan{}
+
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]*models.Budget{}
+	for _, budget := range budgets {
+		existing[budgetKey(budget)] = budget
+	}
+	matched := map[int64]bool{}
+	for _, want := range file.Budgets {
+		budget := *want
+		current := existing[budgetKey(&budget)]
+		if budget.IsRecurring() && budget.StartDate.IsZero() {
+			budget.StartDate = models.DefaultAnchor(budget.Period, now)
+			if current != nil {
+				budget.StartDate = current.StartDate
+			}
+		}
+
+		if current == nil {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Budget: &budget})
+			plan.changeset.CreateBudgets = append(plan.changeset.CreateBudgets, &budget)
+			continue
+		}
+		budget.ID = current.ID
+		matched[current.ID] = true
+		if fields := budgetFieldChanges(current, &budget); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, Change{Action: ActionUpdate, Budget: &budget, Fields: fields})
+			plan.changeset.UpdateBudgets = append(plan.changeset.UpdateBudgets, &budget)
+		}
+	}
+	for _, budget := range budgets {
+		if !matched[budget.ID] {
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, Budget: budget})
+			plan.changeset.DeleteBudgets = append(plan.changeset.DeleteBudgets, budget.ID)
+		}
+	}
+
+	rules, err := s.ruleRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	existingRules := map[string]*models.CategoryRule{}
+	for i := range rules {
+		existingRules[rules[i].Pattern] = &rules[i]
+	}
+	matched = map[int64]bool{}
+	for _, want := range file.Rules {
+		rule := *want
+		current := existingRules[rule.Pattern]
+
+		if current == nil {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Rule: &rule})
+			plan.changeset.CreateRules = append(plan.changeset.CreateRules, &rule)
+			continue
+		}
+		rule.ID = current.ID
+		matched[current.ID] = true
+		if fields := ruleFieldChanges(current, &rule); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, Change{Action: ActionUpdate, Rule: &rule, Fields: fields})
+			plan.changeset.UpdateRules = append(plan.changeset.UpdateRules, &rule)
+		}
+	}
+	for i := range rules {
+		if rule := &rules[i]; !matched[rule.ID] {
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, Rule: rule})
+			plan.changeset.DeleteRules = append(plan.changeset.DeleteRules, rule.ID)
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply makes the changes of a plan in a single database transaction
+func (s *SyncService) Apply(plan *SyncPlan) error {
+	if plan.changeset.Len() == 0 {
+		return nil
+	}
+	return s.changesetRepo.Apply(&plan.changeset)
+}
+
+// budgetKey identifies a budget between a finances file and the database
+func budgetKey(budget *models.Budget) string {
+	key := budget.Category + "\x00" + budget.Type + "\x00" + budget.Period
+	if !budget.IsRecurring() {
+		key += "\x00" + budget.StartDate.Format(finances.DateLayout) + "\x00" + budget.EndDate.Format(finances.DateLayout)
+	}
+	return key
+}
+
+// budgetFieldChanges lists the fields of a budget that differ
+func budgetFieldChanges(old, want *models.Budget) []FieldChange {
+	var fields []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			fields = append(fields, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("amount", finances.FormatNumber(old.Amount), finances.FormatNumber(want.Amount))
+	add("start", old.StartDate.Format(finances.DateLayout), want.StartDate.Format(finances.DateLayout))
+	add("rollover", old.Rollover, want.Rollover)
+	add("cap", finances.FormatNumber(old.RolloverCap), finances.FormatNumber(want.RolloverCap))
+	add("alerts", finances.FormatAlerts(old.AlertThresholds), finances.FormatAlerts(want.AlertThresholds))
+	return fields
+}
+
+// ruleFieldChanges lists the fields of a rule that differ
+func ruleFieldChanges(old, want *models.CategoryRule) []FieldChange {
+	var fields []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			fields = append(fields, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("category", old.Category, want.Category)
+	add("description", old.Description, want.Description)
+	add("priority", strconv.Itoa(old.Priority), strconv.Itoa(want.Priority))
+	return fields
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PeguB/atad-project/internal/database"
+	"github.com/PeguB/atad-project/internal/finances"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+func newTestSyncService(t *testing.T) (*SyncService, *repository.BudgetRepository, *repository.CategoryRuleRepository) {
+	t.Helper()
+	db, err := database.NewDatabaseAt(filepath.Join(t.TempDir(), "atad.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// Start without the default categorization rules
+	if _, err := db.DB.Exec(`DELETE FROM category_rules`); err != nil {
+		t.Fatal(err)
+	}
+
+	budgetRepo := repository.NewBudgetRepository(db.DB)
+	ruleRepo := repository.NewCategoryRuleRepository(db.DB)
+	return NewSyncService(budgetRepo, ruleRepo, repository.NewChangesetRepository(db.DB)), budgetRepo, ruleRepo
+}
+
+func parseFinances(t *testing.T, text string) *finances.File {
+	t.Helper()
+	file, err := finances.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// summary lists the changes of a plan as "action subject field..." lines
+func summary(plan *SyncPlan) []string {
+	var lines []string
+	for _, c := range plan.Changes {
+		line := c.Action + " "
+		if c.Budget != nil {
+			line += "budget " + c.Budget.Category + " " + c.Budget.Period
+		} else {
+			line += "rule " + c.Rule.Pattern
+		}
+		for _, f := range c.Fields {
+			line += " " + f.Field + ":" + f.Old + "->" + f.New
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestSyncPlan(t *testing.T) {
+	svc, budgetRepo, ruleRepo := newTestSyncService(t)
+	for _, budget := range []*models.Budget{
+		{Category: "Food", Type: models.BudgetExpense, Amount: 400, Period: models.PeriodMonthly, StartDate: date(2026, 1, 15), Rollover: models.RolloverNone},
+		{Category: "Fun", Type: models.BudgetExpense, Amount: 50, Period: models.PeriodWeekly, StartDate: date(2026, 1, 5), Rollover: models.RolloverNone},
+		{Category: "Trip", Type: models.BudgetExpense, Amount: 900, Period: models.PeriodCustom, StartDate: date(2026, 8, 1), EndDate: date(2026, 8, 10), Rollover: models.RolloverNone},
+	} {
+		if err := budgetRepo.Create(budget); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rule := range []*models.CategoryRule{
+		{Category: "Food", Pattern: "(?i)grocer", Priority: 5},
+		{Category: "Fun", Pattern: "cinema"},
+	} {
+		if err := ruleRepo.Create(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file := parseFinances(t, `
+budgets:
+  - category: Food
+    amount: 450
+    period: monthly
+  - category: Trip
+    amount: 900
+    start: 2026-08-01
+    end: 2026-08-12
+  - category: Rent
+    amount: 1200
+    period: monthly
+rules:
+  - category: Food
+    pattern: '(?i)grocer'
+    priority: 10
+  - category: Cafe
+    pattern: 'café; bar, #1'
+`)
+	plan, err := svc.Plan(file, date(2026, 10, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		// A recurring budget without a start keeps its anchor
+		"update budget Food monthly amount:400->450",
+		// A custom budget with other dates is a different budget
+		"create budget Trip custom",
+		// A new recurring budget starts with the current period
+		"create budget Rent monthly",
+		"delete budget Fun weekly",
+		"delete budget Trip custom",
+		"update rule (?i)grocer priority:5->10",
+		"create rule café; bar, #1",
+		"delete rule cinema",
+	}
+	if got := summary(plan); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("plan:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, c := range plan.Changes {
+		if c.Budget != nil && c.Budget.Category == "Rent" && !c.Budget.StartDate.Equal(date(2026, 10, 1)) {
+			t.Errorf("new Rent budget starts %v, want 1 October", c.Budget.StartDate)
+		}
+		if c.Budget != nil && c.Budget.Category == "Food" && !c.Budget.StartDate.Equal(date(2026, 1, 15)) {
+			t.Errorf("Food budget anchor moved to %v", c.Budget.StartDate)
+		}
+	}
+	if plan.Count(ActionCreate) != 3 || plan.Count(ActionUpdate) != 2 || plan.Count(ActionDelete) != 3 {
+		t.Errorf("counts = %d created, %d updated, %d deleted", plan.Count(ActionCreate), plan.Count(ActionUpdate), plan.Count(ActionDelete))
+	}
+
+	if err := svc.Apply(plan); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	again, err := svc.Plan(file, date(2026, 10, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Changes) != 0 {
+		t.Errorf("plan after apply = %v, want no changes", summary(again))
+	}
+}
+
+func TestSyncDumpRoundTrip(t *testing.T) {
+	svc, _, _ := newTestSyncService(t)
+	file := parseFinances(t, `
+budgets:
+  - category: Groceries
+    amount: 500
+    period: monthly
+    start: 2026-01-01
+    rollover: capped
+    cap: 200
+    alerts: [50, 80]
+  - category: Groceries
+    amount: 800
+    start: 2026-12-01
+    end: 2026-12-31
+rules:
+  - category: Groceries
+    pattern: "it's \"fresh\""
+`)
+	plan, err := svc.Plan(file, date(2026, 10, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Apply(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	dumped, err := svc.Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := dumped.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = svc.Plan(parseFinances(t, buf.String()), date(2026, 10, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("plan of the dumped file = %v, want no changes\n%s", summary(plan), buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/repository"
+)
+
+// Where the base amount of a planned budget comes from
+const (
+	SourceTemplate = "template" // The template amount scaled to the range
+	SourceActual   = "actual"   // Last period's spending or income received
+)
+
+// ApplyOptions decide the amounts a template is applied with
+type ApplyOptions struct {
+	Start      time.Time
+	End        time.Time
+	Scale      float64 // Percent added to every amount; negative to cut
+	FromActual bool    // Seed amounts from the spending of the range before Start
+}
+
+// PlannedBudget is one budget a template would create
+type PlannedBudget struct {
+	Item     *models.TemplateItem
+	Budget   *models.Budget // The custom budget to create
+	Base     float64        // Amount before scaling
+	Source   string         // SourceTemplate or SourceActual
+	Conflict *models.Budget // An existing budget on the same days, if any
+}
+
+// Status is "new", "exists" when a budget with the same dates is already set
+// (the budgets table allows one per category, type and dates) or "overlaps"
+// when another custom budget covers some of the days
+func (p *PlannedBudget) Status() string {
+	switch {
+	case p.Conflict == nil:
+		return "new"
+	case p.Conflict.StartDate.Equal(p.Budget.StartDate) && p.Conflict.EndDate.Equal(p.Budget.EndDate):
+		return "exists"
+	}
+	return "overlaps"
+}
+
+type TemplateService struct {
+	templateRepo *repository.TemplateRepository
+	budgetRepo   *repository.BudgetRepository
+	txRepo       *repository.TransactionRepository
+}
+
+func NewTemplateService(templateRepo *repository.TemplateRepository, budgetRepo *repository.BudgetRepository, txRepo *repository.TransactionRepository) *TemplateService {
+	return &TemplateService{
+		templateRepo: templateRepo,
+		budgetRepo:   budgetRepo,
+		txRepo:       txRepo,
+	}
+}
+
+// Capture builds a template from the budgets and income targets active at
+// date, one per category and type as in BudgetService.StatusAll
+func (s *TemplateService) Capture(name string, date time.Time) (*models.BudgetTemplate, error) {
+	budgets, err := s.budgetRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	template := &models.BudgetTemplate{Name: name}
+	for _, budget := range activeBudgets(budgets, date) {
+		template.Items = append(template.Items, models.NewTemplateItem(budget))
+	}
+	return template, nil
+}
+
+// Save stores a template, replacing one of the same name
+func (s *TemplateService) Save(template *models.BudgetTemplate) error {
+	return s.templateRepo.Save(template)
+}
+
+// Get returns a template by name, or nil when there is none
+func (s *TemplateService) Get(name string) (*models.BudgetTemplate, error) {
+	return s.templateRepo.Get(name)
+}
+
+// All returns every template, ordered by name
+func (s *TemplateService) All() ([]*models.BudgetTemplate, error) {
+	return s.templateRepo.GetAll()
+}
+
+// Delete removes a template by name
+func (s *TemplateService) Delete(name string) error {
+	return s.templateRepo.Delete(name)
+}
+
+// Plan works out the custom budgets a template would create for a range and
+// which of them clash with budgets already set. With FromActual, each
+// category starts from what it spent (or received) in the range of the same
+// length before; categories without any keep the template amount. Amounts
+// are rounded to cents.
+func (s *TemplateService) Plan(template *models.BudgetTemplate, opts ApplyOptions) ([]*PlannedBudget, error) {
+	actual := map[string]map[string]float64{}
+	if opts.FromActual {
+		prevStart, prevEnd := models.PreviousRange(opts.Start, opts.End)
+		for _, txType := range []string{models.BudgetExpense, models.BudgetIncome} {
+			totals, err := s.txRepo.SumByCategory(txType, prevStart, prevEnd)
+			if err != nil {
+				return nil, err
+			}
+			actual[txType] = totals
+		}
+	}
+
+	plan := make([]*PlannedBudget, 0, len(template.Items))
+	for _, item := range template.Items {
+		planned := &PlannedBudget{Item: item, Base: roundCents(item.AmountFor(opts.Start, opts.End)), Source: SourceTemplate}
+		if amount := actual[item.Type][item.Category]; amount > 0 {
+			planned.Base, planned.Source = roundCents(amount), SourceActual
+		}
+
+		budget := &models.Budget{
+			Category:  item.Category,
+			Type:      item.Type,
+			Amount:    roundCents(planned.Base * (100 + opts.Scale) / 100),
+			Period:    models.PeriodCustom,
+			StartDate: opts.Start,
+			EndDate:   opts.End,
+		}
+		if item.Type != models.BudgetIncome {
+			budget.AlertThresholds = item.AlertThresholds
+		}
+		planned.Budget = budget
+
+		conflict, err := s.budgetRepo.GetOverlapping(budget)
+		if err != nil {
+			return nil, err
+		}
+		planned.Conflict = conflict
+		plan = append(plan, planned)
+	}
+	return plan, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Apply creates the budgets of a plan that have no conflict, all in one
+// database transaction, and returns them
+func (s *TemplateService) Apply(plan []*PlannedBudget) ([]*models.Budget, error) {
+	var budgets []*models.Budget
+	for _, planned := range plan {
+		if planned.Conflict == nil {
+			budgets = append(budgets, planned.Budget)
+		}
+	}
+	if err := s.budgetRepo.CreateAll(budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
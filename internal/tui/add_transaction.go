@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/models"
 	"github.com/PeguB/atad-project/internal/repository"
 	"github.com/PeguB/atad-project/internal/service"
@@ -18,22 +19,26 @@ type AddTransactionScreen struct {
 	repo            *repository.TransactionRepository
 	budgetRepo      *repository.BudgetRepository
 	categoryService *service.CategoryService
+	alertSvc        *service.AlertService
 	step            int
 	txType          string
 	description     string
 	amount          string
 	category        string
-	date            string // Date in DD/MM/YYYY format
+	date            string // Date in the configured input format
 	suggestedCat    string
 	err             string
 	success         string
+	cfg             *config.Config
 }
 
-func NewAddTransactionScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, categoryService *service.CategoryService) *AddTransactionScreen {
+func NewAddTransactionScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, categoryService *service.CategoryService, alertSvc *service.AlertService, cfg *config.Config) *AddTransactionScreen {
 	return &AddTransactionScreen{
+		cfg:             cfg,
 		repo:            repo,
 		budgetRepo:      budgetRepo,
 		categoryService: categoryService,
+		alertSvc:        alertSvc,
 		step:            0,
 	}
 }
@@ -89,22 +94,22 @@ func (s *AddTransactionScreen) Update(msg tea.Msg) (*AddTransactionScreen, tea.C
 			case "enter":
 				if s.date == "" {
 					// Use today's date if nothing entered
-					s.date = time.Now().Format("02/01/2006")
+					s.date = s.cfg.FormatInputDate(time.Now())
 				}
-				if _, err := time.Parse("02/01/2006", s.date); err == nil {
+				if _, err := s.cfg.ParseDate(s.date); err == nil {
 					// Auto-categorize based on description
 					s.suggestedCat = s.categoryService.CategorizeTransaction(s.description)
 					s.step = 4
 					s.err = ""
 				} else {
-					s.err = "Invalid date format (use DD/MM/YYYY)"
+					s.err = "Invalid date format (use " + s.cfg.InputDateFormat + ")"
 				}
 			case "backspace":
 				if len(s.date) > 0 {
 					s.date = s.date[:len(s.date)-1]
 				}
 			default:
-				if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == "/") {
+				if isDateKey(msg.String()) {
 					if len(s.date) < 10 {
 						s.date += msg.String()
 					}
@@ -143,7 +148,7 @@ func (s *AddTransactionScreen) saveTransaction() {
 	amount, _ := strconv.ParseFloat(s.amount, 64)
 
 	// Parse the date
-	txDate, err := time.Parse("02/01/2006", s.date)
+	txDate, err := s.cfg.ParseDate(s.date)
 	if err != nil {
 		s.err = fmt.Sprintf("Invalid date: %v", err)
 		s.step = 5
@@ -167,6 +172,7 @@ func (s *AddTransactionScreen) saveTransaction() {
 
 	// Update budget for both income and expense
 	s.updateBudget(amount)
+	s.raiseAlerts(tx)
 
 	s.step = 5
 }
@@ -178,67 +184,49 @@ func (s *AddTransactionScreen) updateBudget(amount float64) {
 		return
 	}
 
-	// Get budget for this category
-	budget, err := s.budgetRepo.GetByCategory(s.category)
+	// Parse the transaction date
+	txDate, err := s.cfg.ParseDate(s.date)
 	if err != nil {
-		// Error fetching budget - show to user for debugging
-		s.success += fmt.Sprintf("\n⚠️ Error fetching budget: %v", err)
-		return
-	}
-
-	if budget == nil {
-		// No budget set for this category
-		s.success += fmt.Sprintf("\n💡 No budget set for category '%s'", s.category)
+		s.success += fmt.Sprintf("\n⚠️ Error parsing date: %v", err)
 		return
 	}
 
-	// Parse the transaction date
-	txDate, err := time.Parse("02/01/2006", s.date)
+	// Get the budget covering the transaction date
+	budget, err := s.budgetRepo.GetByCategoryAt(s.category, s.txType, txDate)
 	if err != nil {
-		s.success += fmt.Sprintf("\n⚠️ Error parsing date: %v", err)
+		// Error fetching budget - show to user for debugging
+		s.success += fmt.Sprintf("\n⚠️ Error fetching budget: %v", err)
 		return
 	}
 
-	// Get current spending/income for this category in the budget period
-	var startDate, endDate interface{}
-
-	// Check if transaction falls within budget period
-	if !budget.StartDate.IsZero() && !budget.EndDate.IsZero() {
-		if txDate.Before(budget.StartDate) || txDate.After(budget.EndDate) {
-			// Transaction is outside budget period
-			s.success += fmt.Sprintf("\n💡 Transaction date (%s) outside budget period (%s to %s)",
-				txDate.Format("02/01/2006"),
-				budget.StartDate.Format("02/01/2006"),
-				budget.EndDate.Format("02/01/2006"))
-			return
+	if budget == nil {
+		// No budget set for this category, or none covering the date
+		kind := "budget"
+		if s.txType == "income" {
+			kind = "income target"
 		}
-		startDate = budget.StartDate
-		endDate = budget.EndDate
-	} else {
-		// If no date range specified, use transaction date's month
-		startOfMonth := time.Date(txDate.Year(), txDate.Month(), 1, 0, 0, 0, 0, txDate.Location())
-		endOfMonth := startOfMonth.AddDate(0, 1, -1)
-		startDate = startOfMonth
-		endDate = endOfMonth
+		s.success += fmt.Sprintf("\n💡 No %s for category '%s' on %s", kind, s.category, s.cfg.FormatDate(txDate))
+		return
 	}
 
+	// Spending/income is counted over the budget period containing the transaction
+	startDate, endDate, _ := budget.PeriodAt(txDate)
+
 	if s.txType == "expense" {
-		spending, err := s.budgetRepo.GetSpending(s.category, startDate, endDate)
+		status, err := service.NewBudgetService(s.budgetRepo).Status(budget, txDate)
 		if err != nil {
 			// Error getting spending - show to user
 			s.success += fmt.Sprintf("\n⚠️ Error calculating spending: %v", err)
 			return
 		}
 
-		// Show budget status
-		percentUsed := (spending / budget.Amount) * 100
-		if spending > budget.Amount {
-			s.success += fmt.Sprintf("\n⚠️  Over budget! Spent: $%.2f / $%.2f (%.0f%%)", spending, budget.Amount, percentUsed)
-		} else if percentUsed >= 80 {
-			s.success += fmt.Sprintf("\n⚠️  Budget warning: $%.2f / $%.2f (%.0f%%)", spending, budget.Amount, percentUsed)
-		} else {
-			s.success += fmt.Sprintf("\n💰 Budget: $%.2f / $%.2f (%.0f%%)", spending, budget.Amount, percentUsed)
+		// Show budget status against the budget plus any carried amount;
+		// crossing a threshold is reported by the alert rules
+		icon := "💰"
+		if status.Over() {
+			icon = "🚨"
 		}
+		s.success += fmt.Sprintf("\n%s Budget: %s / %s (%.0f%%)", icon, s.cfg.FormatMoney(status.Spent), s.cfg.FormatMoney(status.Available), status.PercentUsed())
 	} else if s.txType == "income" {
 		income, err := s.budgetRepo.GetIncome(s.category, startDate, endDate)
 		if err != nil {
@@ -250,13 +238,28 @@ func (s *AddTransactionScreen) updateBudget(amount float64) {
 		percentAchieved := (income / budget.Amount) * 100
 		// Show income tracking
 		if income < budget.Amount {
-			s.success += fmt.Sprintf("\n📊 Income: $%.2f / $%.2f target (%.0f%%)", income, budget.Amount, percentAchieved)
+			s.success += fmt.Sprintf("\n📊 Income: %s / %s target (%.0f%%)", s.cfg.FormatMoney(income), s.cfg.FormatMoney(budget.Amount), percentAchieved)
 		} else {
-			s.success += fmt.Sprintf("\n✅ Income goal met! $%.2f / $%.2f (%.0f%%)", income, budget.Amount, percentAchieved)
+			s.success += fmt.Sprintf("\n✅ Income goal met! %s / %s (%.0f%%)", s.cfg.FormatMoney(income), s.cfg.FormatMoney(budget.Amount), percentAchieved)
 		}
 	}
 }
 
+// raiseAlerts runs the alert rules for the saved transaction and shows the
+// alerts it raised
+func (s *AddTransactionScreen) raiseAlerts(tx *models.Transaction) {
+	if s.alertSvc == nil {
+		return
+	}
+	alerts, err := s.alertSvc.Check(tx)
+	for _, alert := range alerts {
+		s.success += "\n🔔 " + alert.Message
+	}
+	if err != nil {
+		s.success += fmt.Sprintf("\n⚠️ %v", err)
+	}
+}
+
 func (s *AddTransactionScreen) View() string {
 	var b strings.Builder
 
@@ -276,7 +279,7 @@ func (s *AddTransactionScreen) View() string {
 		caser := cases.Title(language.English)
 		b.WriteString(fmt.Sprintf("Type: %s\n", caser.String(s.txType)))
 		b.WriteString(fmt.Sprintf("Description: %s\n\n", s.description))
-		b.WriteString("Amount: $" + s.amount + "▊\n")
+		b.WriteString("Amount (" + s.cfg.Symbol + "): " + s.amount + "▊\n")
 		if s.err != "" {
 			b.WriteString("\n❌ " + s.err + "\n")
 		}
@@ -285,12 +288,12 @@ func (s *AddTransactionScreen) View() string {
 		caser := cases.Title(language.English)
 		b.WriteString(fmt.Sprintf("Type: %s\n", caser.String(s.txType)))
 		b.WriteString(fmt.Sprintf("Description: %s\n", s.description))
-		b.WriteString(fmt.Sprintf("Amount: $%s\n\n", s.amount))
+		b.WriteString(fmt.Sprintf("Amount: %s\n\n", formatTypedAmount(s.cfg, s.amount)))
 		if s.date == "" {
-			b.WriteString("Date (DD/MM/YYYY): ▊\n")
+			b.WriteString("Date (" + s.cfg.InputDateFormat + "): ▊\n")
 			b.WriteString("\n(Press Enter for today's date)\n")
 		} else {
-			b.WriteString("Date (DD/MM/YYYY): " + s.date + "▊\n")
+			b.WriteString("Date (" + s.cfg.InputDateFormat + "): " + s.date + "▊\n")
 			if s.err != "" {
 				b.WriteString("\n❌ " + s.err + "\n")
 			}
@@ -300,7 +303,7 @@ func (s *AddTransactionScreen) View() string {
 		caser := cases.Title(language.English)
 		b.WriteString(fmt.Sprintf("Type: %s\n", caser.String(s.txType)))
 		b.WriteString(fmt.Sprintf("Description: %s\n", s.description))
-		b.WriteString(fmt.Sprintf("Amount: $%s\n", s.amount))
+		b.WriteString(fmt.Sprintf("Amount: %s\n", formatTypedAmount(s.cfg, s.amount)))
 		b.WriteString(fmt.Sprintf("Date: %s\n\n", s.date))
 
 		if s.category == "" && s.suggestedCat != "" {
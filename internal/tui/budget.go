@@ -6,31 +6,43 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/models"
 	"github.com/PeguB/atad-project/internal/repository"
+	"github.com/PeguB/atad-project/internal/service"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type BudgetScreen struct {
-	budgetRepo *repository.BudgetRepository
-	mode       string // "list", "add", "view"
-	budgets    []*models.Budget
+	budgetRepo  *repository.BudgetRepository
+	budgetSvc   *service.BudgetService
+	forecastSvc *service.ForecastService
+	mode        string // "list", "add", "status"
+	budgets     []*models.Budget
+	statuses    []service.BudgetStatus // Active budgets, for the status view
+	forecasts   map[int64]*service.BudgetForecast
 
 	// Add budget fields
 	step      int
 	category  string
 	amount    string
-	startDate string // Date in DD/MM/YYYY format
-	endDate   string // Date in DD/MM/YYYY format
+	period    int    // Index into models.BudgetPeriods
+	startDate string // Date in the configured input format; the anchor of a recurring budget
+	endDate   string // Date in the configured input format
 
 	err     string
 	success string
+	cfg     *config.Config
 }
 
-func NewBudgetScreen(budgetRepo *repository.BudgetRepository) *BudgetScreen {
+func NewBudgetScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, cfg *config.Config) *BudgetScreen {
+	budgetSvc := service.NewBudgetService(budgetRepo)
 	return &BudgetScreen{
-		budgetRepo: budgetRepo,
-		mode:       "list",
+		cfg:         cfg,
+		budgetRepo:  budgetRepo,
+		budgetSvc:   budgetSvc,
+		forecastSvc: service.NewForecastService(repo, budgetSvc),
+		mode:        "list",
 	}
 }
 
@@ -52,6 +64,15 @@ func (s *BudgetScreen) Update(msg tea.Msg) (*BudgetScreen, tea.Cmd) {
 				s.mode = "add"
 				s.step = 0
 				s.resetAddFields()
+			case "s":
+				s.showStatus()
+			}
+		} else if s.mode == "status" {
+			switch msg.String() {
+			case "s", "l":
+				s.mode = "list"
+			case "r":
+				s.showStatus()
 			}
 		} else if s.mode == "add" {
 			return s.handleAddBudget(msg)
@@ -95,18 +116,35 @@ func (s *BudgetScreen) handleAddBudget(msg tea.KeyMsg) (*BudgetScreen, tea.Cmd)
 				s.amount += msg.String()
 			}
 		}
-	case 2: // Enter start date
+	case 2: // Choose period
 		switch msg.String() {
+		case "left", "h":
+			s.period = (s.period + len(models.BudgetPeriods) - 1) % len(models.BudgetPeriods)
+		case "right", "l", "tab":
+			s.period = (s.period + 1) % len(models.BudgetPeriods)
 		case "enter":
-			if s.startDate != "" {
-				if _, err := time.Parse("02/01/2006", s.startDate); err == nil {
-					s.step = 3
+			s.step = 3
+			s.err = ""
+		}
+	case 3: // Enter start date, or the anchor of a recurring budget
+		switch msg.String() {
+		case "enter":
+			if s.startDate == "" && s.recurring() {
+				// Anchor defaults to the start of the current period
+				s.err = ""
+				s.saveBudget()
+			} else if s.startDate != "" {
+				if _, err := s.cfg.ParseDate(s.startDate); err != nil {
+					s.err = "Invalid date (e.g., Sept has 30 days, not 31)"
+				} else if s.recurring() {
 					s.err = ""
+					s.saveBudget()
 				} else {
-					s.err = "Invalid date (e.g., Sept has 30 days, not 31)"
+					s.step = 4
+					s.err = ""
 				}
 			} else {
-				s.err = "Date required (use DD/MM/YYYY)"
+				s.err = "Date required (use " + s.cfg.InputDateFormat + ")"
 			}
 		case "backspace":
 			if len(s.startDate) > 0 {
@@ -114,25 +152,25 @@ func (s *BudgetScreen) handleAddBudget(msg tea.KeyMsg) (*BudgetScreen, tea.Cmd)
 				s.err = ""
 			}
 		default:
-			if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == "/") {
+			if isDateKey(msg.String()) {
 				if len(s.startDate) < 10 {
 					s.startDate += msg.String()
 					s.err = ""
 				}
 			}
 		}
-	case 3: // Enter end date
+	case 4: // Enter end date
 		switch msg.String() {
 		case "enter":
 			if s.endDate != "" {
-				if _, err := time.Parse("02/01/2006", s.endDate); err == nil {
+				if _, err := s.cfg.ParseDate(s.endDate); err == nil {
 					s.err = ""
 					s.saveBudget()
 				} else {
 					s.err = "Invalid date (e.g., Sept has 30 days, not 31)"
 				}
 			} else {
-				s.err = "Date required (use DD/MM/YYYY)"
+				s.err = "Date required (use " + s.cfg.InputDateFormat + ")"
 			}
 		case "backspace":
 			if len(s.endDate) > 0 {
@@ -140,7 +178,7 @@ func (s *BudgetScreen) handleAddBudget(msg tea.KeyMsg) (*BudgetScreen, tea.Cmd)
 				s.err = ""
 			}
 		default:
-			if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == "/") {
+			if isDateKey(msg.String()) {
 				if len(s.endDate) < 10 {
 					s.endDate += msg.String()
 					s.err = ""
@@ -151,58 +189,158 @@ func (s *BudgetScreen) handleAddBudget(msg tea.KeyMsg) (*BudgetScreen, tea.Cmd)
 	return s, nil
 }
 
+// showStatus switches to the progress bars of all active budgets
+func (s *BudgetScreen) showStatus() {
+	statuses, err := s.budgetSvc.StatusAll(time.Now())
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to load budget status: %v", err)
+		return
+	}
+	s.statuses = statuses
+	s.forecasts = map[int64]*service.BudgetForecast{}
+	for _, status := range statuses {
+		if forecast, err := s.forecastSvc.Forecast(status.Budget, time.Now()); err == nil {
+			s.forecasts[status.Budget.ID] = forecast
+		}
+	}
+	s.err = ""
+	s.mode = "status"
+}
+
+// recurring reports whether the budget being added repeats every period
+func (s *BudgetScreen) recurring() bool {
+	return models.BudgetPeriods[s.period] != models.PeriodCustom
+}
+
 func (s *BudgetScreen) saveBudget() {
 	amount, _ := strconv.ParseFloat(s.amount, 64)
 
-	startDate, err := time.Parse("02/01/2006", s.startDate)
+	if s.recurring() {
+		s.saveRecurringBudget(amount)
+		return
+	}
+
+	startDate, err := s.cfg.ParseDate(s.startDate)
 	if err != nil {
 		s.err = fmt.Sprintf("Invalid start date: %v", err)
-		s.step = 2 // Go back to start date step
+		s.step = 3 // Go back to start date step
 		return
 	}
-	endDate, err := time.Parse("02/01/2006", s.endDate)
+	endDate, err := s.cfg.ParseDate(s.endDate)
 	if err != nil {
 		s.err = fmt.Sprintf("Invalid end date: %v", err)
-		s.step = 3 // Stay on end date step
+		s.step = 4 // Stay on end date step
+		return
+	}
+	if endDate.Before(startDate) {
+		s.err = "End date is before start date"
+		s.step = 4
 		return
 	}
 
 	budget := &models.Budget{
 		Category:  s.category,
 		Amount:    amount,
-		Period:    "custom",
+		Period:    models.PeriodCustom,
 		StartDate: startDate,
 		EndDate:   endDate,
 	}
 
-	// Check if budget already exists for this category and date range
-	existing, err := s.budgetRepo.GetByCategoryAndDateRange(s.category, startDate, endDate)
+	// The same category and dates again updates the amount of that budget
+	existing, err := s.budgetRepo.GetByCategoryAndDateRange(s.category, models.BudgetExpense, startDate, endDate)
 	if err != nil {
 		s.err = fmt.Sprintf("Failed to check existing budget: %v", err)
 		s.mode = "list"
 		s.resetAddFields()
 		return
 	}
-
 	if existing != nil {
-		// Update existing budget
+		budget.ID = existing.ID
+	}
+
+	if s.rejectOverlap(budget) {
+		s.step = 3
+		return
+	}
+	if budget.ID != 0 {
 		err = s.budgetRepo.Update(budget)
-		if err != nil {
-			s.err = fmt.Sprintf("Failed to update: %v", err)
-		} else {
-			s.success = fmt.Sprintf("✅ Budget updated successfully! $%.2f for %s (%s to %s)", amount, s.category, s.startDate, s.endDate)
-			s.Init() // Reload budgets
-		}
 	} else {
-		// Create new budget
 		err = s.budgetRepo.Create(budget)
-		if err != nil {
-			s.err = fmt.Sprintf("Failed to save: %v", err)
+	}
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to save: %v", err)
+	} else if existing != nil {
+		s.success = fmt.Sprintf("✅ Budget updated successfully! %s for %s (%s to %s)", s.cfg.FormatMoney(amount), s.category, s.startDate, s.endDate)
+	} else {
+		s.success = fmt.Sprintf("✅ Budget created successfully! %s for %s (%s to %s)", s.cfg.FormatMoney(amount), s.category, s.startDate, s.endDate)
+	}
+	s.Init()
+	s.mode = "list"
+	s.resetAddFields()
+}
+
+// rejectOverlap shows an error and returns true when budget would apply on
+// the same days as another budget of its category
+func (s *BudgetScreen) rejectOverlap(budget *models.Budget) bool {
+	other, err := s.budgetRepo.GetOverlapping(budget)
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to check existing budgets: %v", err)
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	if other.IsRecurring() {
+		s.err = fmt.Sprintf("%s already has a %s budget (id %d)", other.Category, other.Period, other.ID)
+	} else {
+		s.err = fmt.Sprintf("Overlaps the %s budget %s (id %d)", other.Category, formatBudgetPeriod(s.cfg, other, time.Now()), other.ID)
+	}
+	return true
+}
+
+// saveRecurringBudget replaces the category's budget of the same period, as
+// 'atad budget set -period' does, keeping its anchor unless one was typed
+func (s *BudgetScreen) saveRecurringBudget(amount float64) {
+	period := models.BudgetPeriods[s.period]
+	anchor := models.DefaultAnchor(period, time.Now())
+	if s.startDate != "" {
+		var err error
+		if anchor, err = s.cfg.ParseDate(s.startDate); err != nil {
+			s.err = fmt.Sprintf("Invalid anchor date: %v", err)
+			return
+		}
+	}
+
+	budget := &models.Budget{
+		Category:  s.category,
+		Amount:    amount,
+		Period:    period,
+		StartDate: anchor,
+	}
+
+	existing, err := s.budgetRepo.GetByCategoryAndPeriod(s.category, models.BudgetExpense, period)
+	if err == nil && existing != nil {
+		budget.ID = existing.ID
+		if s.startDate == "" {
+			budget.StartDate = existing.StartDate
+		}
+		budget.Rollover, budget.RolloverCap = existing.Rollover, existing.RolloverCap
+	}
+	if err == nil && s.rejectOverlap(budget) {
+		return
+	}
+	if err == nil {
+		if budget.ID != 0 {
+			err = s.budgetRepo.Update(budget)
 		} else {
-			s.success = fmt.Sprintf("✅ Budget created successfully! $%.2f for %s (%s to %s)", amount, s.category, s.startDate, s.endDate)
-			s.Init() // Reload budgets
+			err = s.budgetRepo.Create(budget)
 		}
 	}
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to save: %v", err)
+	} else {
+		s.success = fmt.Sprintf("✅ %s budget saved! %s for %s from %s", budget.PeriodName(), s.cfg.FormatMoney(amount), s.category, s.cfg.FormatDate(budget.StartDate))
+	}
 	s.Init()
 	s.mode = "list"
 	s.resetAddFields()
@@ -212,6 +350,7 @@ func (s *BudgetScreen) resetAddFields() {
 	s.step = 0
 	s.category = ""
 	s.amount = ""
+	s.period = 0
 	s.startDate = ""
 	s.endDate = ""
 	s.err = ""
@@ -226,33 +365,41 @@ func (s *BudgetScreen) View() string {
 		if len(s.budgets) == 0 {
 			b.WriteString("No budgets set yet.\n\n")
 		} else {
-			b.WriteString(fmt.Sprintf("%-20s %-15s %-12s %s\n", "Category", "Period", "Budget", "Status"))
-			b.WriteString(strings.Repeat("-", 75) + "\n")
+			b.WriteString(fmt.Sprintf("%-20s %-33s %-12s %s\n", "Category", "Period", "Budget", "Status"))
+			b.WriteString(strings.Repeat("-", 93) + "\n")
 
+			now := time.Now()
 			for _, budget := range s.budgets {
-				// Get current spending
-				spending, err := s.budgetRepo.GetSpending(budget.Category, budget.StartDate, budget.EndDate)
-				percentage := 0.0
-				if budget.Amount > 0 {
-					percentage = (spending / budget.Amount) * 100
-				}
-
-				status := "✅"
-				if percentage > 100 {
-					status = "🚨"
-				} else if percentage > 80 {
-					status = "⚠️"
-				}
-
+				// Get spending in the current period, against the budget
+				// plus anything carried over from earlier periods
 				spendingInfo := ""
-				if err == nil {
-					spendingInfo = fmt.Sprintf(" %s $%.2f/%.2f (%.0f%%)", status, spending, budget.Amount, percentage)
+				if period, err := budgetStatus(s.budgetSvc, budget, now); err == nil && period != nil && budget.IsIncome() {
+					status := "🎯"
+					if period.Shortfall() == 0 {
+						status = "✅"
+					}
+					spendingInfo = fmt.Sprintf(" %s %s/%s received (%.0f%%)", status, s.cfg.FormatMoney(period.Spent), s.cfg.FormatMoney(period.Available), period.PercentUsed())
+				} else if err == nil && period != nil {
+					status := "✅"
+					if period.Over() {
+						status = "🚨"
+					} else if s.cfg.IsWarning(period.PercentUsed()) {
+						status = "⚠️"
+					}
+					spendingInfo = fmt.Sprintf(" %s %s/%s (%.0f%%)", status, s.cfg.FormatMoney(period.Spent), s.cfg.FormatMoney(period.Available), period.PercentUsed())
+					if period.Carried != 0 {
+						spendingInfo += fmt.Sprintf(" incl. %s carried", s.cfg.FormatMoney(period.Carried))
+					}
 				}
 
-				periodStr := fmt.Sprintf("%s-%s", budget.StartDate.Format("02/01/06"), budget.EndDate.Format("02/01/06"))
+				periodStr := formatBudgetPeriod(s.cfg, budget, now)
+				category := budget.Category
+				if budget.IsIncome() {
+					category += " (income)"
+				}
 
-				b.WriteString(fmt.Sprintf("%-20s %-15s $%-11.2f%s\n",
-					budget.Category, periodStr, budget.Amount, spendingInfo))
+				b.WriteString(fmt.Sprintf("%-20s %-33s %-12s%s\n",
+					category, periodStr, s.cfg.FormatMoney(budget.Amount), spendingInfo))
 			}
 			b.WriteString("\n")
 		}
@@ -262,8 +409,14 @@ func (s *BudgetScreen) View() string {
 			s.success = ""
 		}
 
-		b.WriteString("\nPress 'n' to add new budget | ESC to return\n")
+		if s.err != "" {
+			b.WriteString("❌ " + s.err + "\n")
+		}
+
+		b.WriteString("\nPress 'n' to add new budget | 's' for status bars | ESC to return\n")
 
+	} else if s.mode == "status" {
+		s.viewStatus(&b)
 	} else if s.mode == "add" {
 		b.WriteString("➕ Add Budget\n\n")
 
@@ -273,27 +426,40 @@ func (s *BudgetScreen) View() string {
 			b.WriteString("\n(Press Enter to continue)\n")
 		case 1:
 			b.WriteString(fmt.Sprintf("Category: %s\n\n", s.category))
-			b.WriteString("Budget Amount: $" + s.amount + "▊\n")
+			b.WriteString("Budget Amount (" + s.cfg.Symbol + "): " + s.amount + "▊\n")
 			if s.err != "" {
 				b.WriteString("\n❌ " + s.err + "\n")
 			}
 			b.WriteString("\n(Press Enter to continue)\n")
 		case 2:
 			b.WriteString(fmt.Sprintf("Category: %s\n", s.category))
-			b.WriteString(fmt.Sprintf("Amount: $%s\n\n", s.amount))
-			b.WriteString("Start Date (DD/MM/YYYY): " + s.startDate + "▊\n")
-			if s.err != "" {
-				b.WriteString("\n❌ " + s.err + "\n")
+			b.WriteString(fmt.Sprintf("Amount: %s\n\n", formatTypedAmount(s.cfg, s.amount)))
+			b.WriteString("Period: ")
+			for i, period := range models.BudgetPeriods {
+				if i == s.period {
+					b.WriteString("[" + period + "] ")
+				} else {
+					b.WriteString(" " + period + "  ")
+				}
 			}
-			b.WriteString("\n(Press Enter to continue)\n")
+			b.WriteString("\n\n(←/→ to choose, Enter to continue)\n")
 		case 3:
 			b.WriteString(fmt.Sprintf("Category: %s\n", s.category))
-			b.WriteString(fmt.Sprintf("Amount: $%s\n", s.amount))
-			b.WriteString(fmt.Sprintf("Start Date: %s\n\n", s.startDate))
-			b.WriteString("End Date (DD/MM/YYYY): " + s.endDate + "▊\n")
-			if s.err != "" {
-				b.WriteString("\n❌ " + s.err + "\n")
+			b.WriteString(fmt.Sprintf("Amount: %s\n", formatTypedAmount(s.cfg, s.amount)))
+			b.WriteString(fmt.Sprintf("Period: %s\n\n", models.BudgetPeriods[s.period]))
+			if s.recurring() {
+				anchor := models.DefaultAnchor(models.BudgetPeriods[s.period], time.Now())
+				b.WriteString("First Day (" + s.cfg.InputDateFormat + "): " + s.startDate + "▊\n")
+				b.WriteString(fmt.Sprintf("\n(Press Enter to save; leave empty to start on %s)\n", s.cfg.FormatDate(anchor)))
+			} else {
+				b.WriteString("Start Date (" + s.cfg.InputDateFormat + "): " + s.startDate + "▊\n")
+				b.WriteString("\n(Press Enter to continue)\n")
 			}
+		case 4:
+			b.WriteString(fmt.Sprintf("Category: %s\n", s.category))
+			b.WriteString(fmt.Sprintf("Amount: %s\n", formatTypedAmount(s.cfg, s.amount)))
+			b.WriteString(fmt.Sprintf("Start Date: %s\n\n", s.startDate))
+			b.WriteString("End Date (" + s.cfg.InputDateFormat + "): " + s.endDate + "▊\n")
 			b.WriteString("\n(Press Enter to save)\n")
 		}
 
@@ -305,6 +471,74 @@ func (s *BudgetScreen) View() string {
 	return b.String()
 }
 
+// viewStatus draws a progress bar per active budget with its burn rate and
+// forecast
+func (s *BudgetScreen) viewStatus(b *strings.Builder) {
+	now := time.Now()
+	b.WriteString(fmt.Sprintf("📊 Budget Status - %s\n\n", s.cfg.FormatDate(now)))
+
+	if len(s.statuses) == 0 {
+		b.WriteString("No active budgets.\n")
+	}
+	over := 0
+	for _, status := range s.statuses {
+		if status.Budget.IsIncome() {
+			s.viewIncomeStatus(b, status, now)
+			continue
+		}
+		icon := "✅"
+		if status.Over() {
+			icon = "🚨"
+			over++
+		} else if s.cfg.IsWarning(status.PercentUsed()) {
+			icon = "⚠️"
+		}
+		b.WriteString(fmt.Sprintf("%s %-20s %s %3.0f%%  %s of %s\n", icon, status.Budget.Category,
+			ProgressBar(status.PercentUsed(), 30), status.PercentUsed(),
+			s.cfg.FormatMoney(status.Spent), s.cfg.FormatMoney(status.Available)))
+		b.WriteString(fmt.Sprintf("   %s | %d days left | %s/day | %s left\n",
+			formatBudgetPeriod(s.cfg, status.Budget, now), status.DaysLeft(now),
+			s.cfg.FormatMoney(status.BurnRate(now)), s.cfg.FormatMoney(status.Remaining())))
+		if forecast := s.forecasts[status.Budget.ID]; forecast != nil {
+			line := fmt.Sprintf("   📈 Projected %s", s.cfg.FormatMoney(forecast.Projected))
+			if forecast.ProjectedOver() && !forecast.Over() {
+				line += " | runs out " + s.cfg.FormatDate(forecast.OverrunDate)
+			}
+			line += fmt.Sprintf(" | safe to spend %s/day", s.cfg.FormatMoney(forecast.SafePerDay))
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if over > 0 {
+		b.WriteString(fmt.Sprintf("🚨 %d budgets over\n", over))
+	}
+
+	b.WriteString("\nPress 's' for the budget list | 'r' to refresh | ESC to return\n")
+}
+
+// viewIncomeStatus draws the progress of an income target toward its amount
+func (s *BudgetScreen) viewIncomeStatus(b *strings.Builder, status service.BudgetStatus, now time.Time) {
+	icon := "🎯"
+	if status.Shortfall() == 0 {
+		icon = "✅"
+	}
+	b.WriteString(fmt.Sprintf("%s %-20s %s %3.0f%%  %s of %s received\n", icon, status.Budget.Category+" (income)",
+		ProgressBar(status.PercentUsed(), 30), status.PercentUsed(),
+		s.cfg.FormatMoney(status.Spent), s.cfg.FormatMoney(status.Available)))
+	b.WriteString(fmt.Sprintf("   %s | %d days left | %s to go\n",
+		formatBudgetPeriod(s.cfg, status.Budget, now), status.DaysLeft(now), s.cfg.FormatMoney(status.Shortfall())))
+	if forecast := s.forecasts[status.Budget.ID]; forecast != nil && status.Shortfall() > 0 {
+		line := fmt.Sprintf("   📈 Projected %s", s.cfg.FormatMoney(forecast.Projected))
+		if forecast.ProjectedShortfall() > 0 {
+			line += fmt.Sprintf(" | short by %s | %s/day more needed", s.cfg.FormatMoney(forecast.ProjectedShortfall()), s.cfg.FormatMoney(forecast.SafePerDay))
+		} else if forecast.ProjectedOver() {
+			line += " | target reached " + s.cfg.FormatDate(forecast.OverrunDate)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+}
+
 func (s *BudgetScreen) Reset() {
 	s.mode = "list"
 	s.resetAddFields()
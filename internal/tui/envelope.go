@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type EnvelopeScreen struct {
+	envelopeSvc *service.EnvelopeService
+	mode        string // "list", "category", "target", "amount"
+	action      string // "assign" or "move"
+	status      *service.EnvelopeStatus
+	cursor      int
+
+	// Assign and move fields
+	category string // Envelope to assign to, or to move from
+	target   int    // Index into status.Envelopes to move to
+	amount   string
+
+	err     string
+	success string
+	cfg     *config.Config
+}
+
+func NewEnvelopeScreen(envelopeSvc *service.EnvelopeService, cfg *config.Config) *EnvelopeScreen {
+	return &EnvelopeScreen{
+		cfg:         cfg,
+		envelopeSvc: envelopeSvc,
+		mode:        "list",
+	}
+}
+
+func (s *EnvelopeScreen) Init() error {
+	status, err := s.envelopeSvc.Status(time.Now())
+	if err != nil {
+		return err
+	}
+	s.status = status
+	if s.cursor >= len(status.Envelopes) {
+		s.cursor = max(len(status.Envelopes)-1, 0)
+	}
+	return nil
+}
+
+// Refresh reloads the envelopes unless an assign or move is being entered
+func (s *EnvelopeScreen) Refresh() {
+	if s.mode != "list" {
+		return
+	}
+	if err := s.Init(); err != nil {
+		s.err = fmt.Sprintf("Failed to load envelopes: %v", err)
+	}
+}
+
+func (s *EnvelopeScreen) Update(msg tea.Msg) (*EnvelopeScreen, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+	switch s.mode {
+	case "list":
+		s.handleList(key)
+	case "category":
+		s.handleCategory(key)
+	case "target":
+		s.handleTarget(key)
+	case "amount":
+		s.handleAmount(key)
+	}
+	return s, nil
+}
+
+func (s *EnvelopeScreen) handleList(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.status.Envelopes)-1 {
+			s.cursor++
+		}
+	case "a":
+		s.start("assign")
+		if e, ok := s.selected(); ok {
+			s.category = e.Category
+			s.mode = "amount"
+		}
+	case "n":
+		s.start("assign")
+	case "m":
+		e, ok := s.selected()
+		if !ok || len(s.status.Envelopes) < 2 {
+			s.err = "Moving needs at least two envelopes"
+			return
+		}
+		s.start("move")
+		s.category = e.Category
+		s.target = (s.cursor + 1) % len(s.status.Envelopes)
+		s.mode = "target"
+	}
+}
+
+// handleCategory reads the name of a new envelope to assign to
+func (s *EnvelopeScreen) handleCategory(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "enter":
+		if s.category != "" {
+			s.mode = "amount"
+		}
+	case "backspace":
+		if len(s.category) > 0 {
+			s.category = s.category[:len(s.category)-1]
+		}
+	default:
+		if len(msg.String()) == 1 {
+			s.category += msg.String()
+		}
+	}
+}
+
+// handleTarget picks the envelope money is moved to
+func (s *EnvelopeScreen) handleTarget(msg tea.KeyMsg) {
+	n := len(s.status.Envelopes)
+	switch msg.String() {
+	case "up", "k":
+		s.target = (s.target + n - 1) % n
+		if s.status.Envelopes[s.target].Category == s.category {
+			s.target = (s.target + n - 1) % n
+		}
+	case "down", "j":
+		s.target = (s.target + 1) % n
+		if s.status.Envelopes[s.target].Category == s.category {
+			s.target = (s.target + 1) % n
+		}
+	case "enter":
+		s.mode = "amount"
+	}
+}
+
+func (s *EnvelopeScreen) handleAmount(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "enter":
+		amount, err := strconv.ParseFloat(s.amount, 64)
+		if err != nil || amount <= 0 {
+			s.err = "Invalid amount"
+			return
+		}
+		s.save(amount)
+	case "backspace":
+		if len(s.amount) > 0 {
+			s.amount = s.amount[:len(s.amount)-1]
+		}
+	default:
+		if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == ".") {
+			s.amount += msg.String()
+		}
+	}
+}
+
+func (s *EnvelopeScreen) save(amount float64) {
+	now := time.Now()
+	var err error
+	if s.action == "move" {
+		to := s.status.Envelopes[s.target].Category
+		err = s.envelopeSvc.Move(s.category, to, amount, now, "")
+		s.success = fmt.Sprintf("✅ Moved %s from %s to %s", s.cfg.FormatMoney(amount), s.category, to)
+	} else {
+		err = s.envelopeSvc.Assign(s.category, amount, now, "")
+		s.success = fmt.Sprintf("✅ Assigned %s to %s", s.cfg.FormatMoney(amount), s.category)
+	}
+	if err != nil {
+		s.success = ""
+		s.err = fmt.Sprintf("Failed to save: %v", err)
+		return
+	}
+
+	category := s.category
+	s.mode = "list"
+	s.err = ""
+	if err := s.Init(); err != nil {
+		s.err = fmt.Sprintf("Failed to load envelopes: %v", err)
+		return
+	}
+	for i, e := range s.status.Envelopes {
+		if e.Category == category {
+			s.cursor = i
+		}
+	}
+}
+
+// start begins an assign or move from the list
+func (s *EnvelopeScreen) start(action string) {
+	s.action = action
+	s.mode = "category"
+	s.category = ""
+	s.amount = ""
+	s.err = ""
+	s.success = ""
+}
+
+func (s *EnvelopeScreen) selected() (service.Envelope, bool) {
+	if s.status == nil || s.cursor >= len(s.status.Envelopes) {
+		return service.Envelope{}, false
+	}
+	return s.status.Envelopes[s.cursor], true
+}
+
+func (s *EnvelopeScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString("✉️  Envelopes\n\n")
+	if s.status == nil {
+		b.WriteString("Loading...\n")
+		return b.String()
+	}
+
+	ready := s.status.ReadyToAssign()
+	b.WriteString(fmt.Sprintf("Since %s | Income %s | Assigned %s\n",
+		s.cfg.FormatDate(s.status.Start), s.cfg.FormatMoney(s.status.Income), s.cfg.FormatMoney(s.status.Assigned)))
+	switch {
+	case ready > 0.005:
+		b.WriteString(fmt.Sprintf("Ready to assign: %s\n\n", s.cfg.FormatMoney(ready)))
+	case ready < -0.005:
+		b.WriteString(fmt.Sprintf("Ready to assign: %s ⚠️  more assigned than earned\n\n", s.cfg.FormatMoney(ready)))
+	default:
+		b.WriteString(fmt.Sprintf("Ready to assign: %s ✅\n\n", s.cfg.FormatMoney(0)))
+	}
+
+	switch s.mode {
+	case "list":
+		s.viewEnvelopes(&b, s.cursor)
+		b.WriteString("\n↑/↓ move | a = assign | n = new envelope | m = move money | ESC to return\n")
+	case "category":
+		b.WriteString("Envelope (category): " + s.category + "▊\n")
+		b.WriteString("\n(Press Enter to continue)\n")
+	case "target":
+		b.WriteString(fmt.Sprintf("Move from %s to:\n\n", s.category))
+		s.viewEnvelopes(&b, s.target)
+		b.WriteString("\n↑/↓ choose | Enter = continue | ESC to return\n")
+	case "amount":
+		if s.action == "move" {
+			b.WriteString(fmt.Sprintf("Move from %s to %s\n\n", s.category, s.status.Envelopes[s.target].Category))
+		} else {
+			b.WriteString(fmt.Sprintf("Assign to %s\n\n", s.category))
+		}
+		b.WriteString("Amount (" + s.cfg.Symbol + "): " + s.amount + "▊\n")
+		b.WriteString("\n(Press Enter to save)\n")
+	}
+
+	if s.success != "" {
+		b.WriteString("\n" + s.success + "\n")
+	}
+	if s.err != "" {
+		b.WriteString("\n❌ " + s.err + "\n")
+	}
+
+	return b.String()
+}
+
+// viewEnvelopes lists the envelopes with the one at cursor highlighted
+func (s *EnvelopeScreen) viewEnvelopes(b *strings.Builder, cursor int) {
+	if len(s.status.Envelopes) == 0 {
+		b.WriteString("No envelopes yet. Press 'n' to fund one.\n")
+		return
+	}
+	b.WriteString(fmt.Sprintf("  %-20s %12s %12s %12s\n", "Envelope", "Assigned", "Spent", "Available"))
+	b.WriteString("  ─────────────────────────────────────────────────────────\n")
+	for i, e := range s.status.Envelopes {
+		marker := " "
+		if i == cursor {
+			marker = ">"
+		}
+		warn := ""
+		if e.Available() < 0 {
+			warn = " 🚨"
+		}
+		b.WriteString(fmt.Sprintf("%s %-20s %12s %12s %12s%s\n", marker, e.Category,
+			s.cfg.FormatMoney(e.Assigned), s.cfg.FormatMoney(e.Spent), s.cfg.FormatMoney(e.Available()), warn))
+	}
+}
+
+func (s *EnvelopeScreen) Reset() {
+	s.mode = "list"
+	s.action = ""
+	s.category = ""
+	s.amount = ""
+	s.cursor = 0
+	s.target = 0
+	s.err = ""
+	s.success = ""
+}
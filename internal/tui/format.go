@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+)
+
+// isDateKey reports whether a key press can be part of a typed date
+func isDateKey(key string) bool {
+	return len(key) == 1 && (key[0] >= '0' && key[0] <= '9' || strings.ContainsAny(key, "/-."))
+}
+
+// formatTypedAmount shows an amount being entered with the configured currency symbol
+func formatTypedAmount(cfg *config.Config, amount string) string {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return amount
+	}
+	return cfg.FormatMoney(value)
+}
+
+// budgetPeriod returns the dates spending is counted over for a budget at now:
+// the range of a custom budget or the current period of a recurring one. ok is
+// false for a recurring budget whose anchor is still in the future.
+func budgetPeriod(budget *models.Budget, now time.Time) (start, end time.Time, ok bool) {
+	if !budget.IsRecurring() {
+		return budget.StartDate, budget.EndDate, true
+	}
+	return budget.PeriodAt(now)
+}
+
+// formatBudgetPeriod describes the dates of a budget at now
+func formatBudgetPeriod(cfg *config.Config, budget *models.Budget, now time.Time) string {
+	start, end, ok := budgetPeriod(budget, now)
+	if !ok {
+		return fmt.Sprintf("%s from %s", budget.PeriodName(), cfg.FormatDate(budget.StartDate))
+	}
+	period := fmt.Sprintf("%s - %s", cfg.FormatDate(start), cfg.FormatDate(end))
+	if budget.IsRecurring() {
+		period = budget.PeriodName() + " " + period
+	}
+	return period
+}
+
+// budgetStatus returns the state of a budget at now, including carried
+// amounts. A custom budget that does not cover now reports its whole range; a
+// recurring budget that has not started yet has no status.
+func budgetStatus(svc *service.BudgetService, budget *models.Budget, now time.Time) (*service.BudgetPeriodStatus, error) {
+	if _, _, ok := budget.PeriodAt(now); !ok {
+		if budget.IsRecurring() {
+			return nil, nil
+		}
+		now = budget.StartDate
+	}
+	return svc.Status(budget, now)
+}
+
+// ProgressBar draws percent as a bar of width cells, full from 100%. The CLI
+// draws its bars with it too.
+func ProgressBar(percent float64, width int) string {
+	filled := min(max(int(percent/100*float64(width)), 0), width)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
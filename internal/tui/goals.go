@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type GoalsScreen struct {
+	goalSvc  *service.GoalService
+	mode     string // "list", "amount"
+	withdraw bool   // The amount being entered is taken out of the goal
+	progress []service.GoalProgress
+	cursor   int
+	amount   string
+	loaded   bool
+
+	err     string
+	success string
+	cfg     *config.Config
+}
+
+func NewGoalsScreen(goalSvc *service.GoalService, cfg *config.Config) *GoalsScreen {
+	return &GoalsScreen{
+		cfg:     cfg,
+		goalSvc: goalSvc,
+		mode:    "list",
+	}
+}
+
+func (s *GoalsScreen) Init() error {
+	progress, err := s.goalSvc.Progress(time.Now())
+	if err != nil {
+		return err
+	}
+	s.progress = progress
+	s.loaded = true
+	if s.cursor >= len(progress) {
+		s.cursor = max(len(progress)-1, 0)
+	}
+	return nil
+}
+
+// Refresh reloads the goals unless a contribution is being entered
+func (s *GoalsScreen) Refresh() {
+	if s.mode != "list" {
+		return
+	}
+	if err := s.Init(); err != nil {
+		s.err = fmt.Sprintf("Failed to load goals: %v", err)
+	}
+}
+
+func (s *GoalsScreen) Update(msg tea.Msg) (*GoalsScreen, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+	switch s.mode {
+	case "list":
+		s.handleList(key)
+	case "amount":
+		s.handleAmount(key)
+	}
+	return s, nil
+}
+
+func (s *GoalsScreen) handleList(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.progress)-1 {
+			s.cursor++
+		}
+	case "c", "w":
+		if len(s.progress) == 0 {
+			return
+		}
+		s.withdraw = msg.String() == "w"
+		s.mode = "amount"
+		s.amount = ""
+		s.err = ""
+		s.success = ""
+	}
+}
+
+func (s *GoalsScreen) handleAmount(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "enter":
+		amount, err := strconv.ParseFloat(s.amount, 64)
+		if err != nil || amount <= 0 {
+			s.err = "Invalid amount"
+			return
+		}
+		s.save(amount)
+	case "backspace":
+		if len(s.amount) > 0 {
+			s.amount = s.amount[:len(s.amount)-1]
+		}
+	default:
+		if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == ".") {
+			s.amount += msg.String()
+		}
+	}
+}
+
+func (s *GoalsScreen) save(amount float64) {
+	goal := s.progress[s.cursor].Goal
+	s.success = fmt.Sprintf("✅ Put %s towards %s", s.cfg.FormatMoney(amount), goal.Name)
+	if s.withdraw {
+		s.success = fmt.Sprintf("✅ Took %s out of %s", s.cfg.FormatMoney(amount), goal.Name)
+		amount = -amount
+	}
+	if _, err := s.goalSvc.Contribute(goal, amount, time.Now(), ""); err != nil {
+		s.success = ""
+		s.err = fmt.Sprintf("Failed to save: %v", err)
+		return
+	}
+
+	s.mode = "list"
+	s.err = ""
+	if err := s.Init(); err != nil {
+		s.err = fmt.Sprintf("Failed to load goals: %v", err)
+	}
+}
+
+func (s *GoalsScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString("🎯 Savings Goals\n\n")
+	if !s.loaded {
+		b.WriteString("Loading...\n")
+		return b.String()
+	}
+
+	switch s.mode {
+	case "list":
+		s.viewGoals(&b)
+		b.WriteString("\n↑/↓ move | c = contribute | w = withdraw | ESC to return\n")
+	case "amount":
+		p := &s.progress[s.cursor]
+		if s.withdraw {
+			b.WriteString(fmt.Sprintf("Take out of %s\n\n", p.Goal.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("Put towards %s (%s to go)\n\n", p.Goal.Name, s.cfg.FormatMoney(p.Remaining())))
+		}
+		b.WriteString("Amount (" + s.cfg.Symbol + "): " + s.amount + "▊\n")
+		b.WriteString("\n(Press Enter to save)\n")
+	}
+
+	if s.success != "" {
+		b.WriteString("\n" + s.success + "\n")
+	}
+	if s.err != "" {
+		b.WriteString("\n❌ " + s.err + "\n")
+	}
+
+	return b.String()
+}
+
+// viewGoals lists the goals with their progress, the selected one highlighted
+// and followed by its details
+func (s *GoalsScreen) viewGoals(b *strings.Builder) {
+	if len(s.progress) == 0 {
+		b.WriteString("No goals yet. Add one with 'atad goal add <name> <target>'.\n")
+		return
+	}
+	for i := range s.progress {
+		p := &s.progress[i]
+		marker := " "
+		if i == s.cursor {
+			marker = ">"
+		}
+		status := p.Status()
+		switch status {
+		case service.GoalReached:
+			status = "✅ " + status
+		case service.GoalBehind, service.GoalOverdue:
+			status = "⚠️  " + status
+		}
+		b.WriteString(fmt.Sprintf("%s %-20s %s %3.0f%% %12s / %-12s %s\n", marker, p.Goal.Name,
+			ProgressBar(p.Percent(), 20), p.Percent(), s.cfg.FormatMoney(p.Saved()), s.cfg.FormatMoney(p.Goal.Target), status))
+	}
+
+	p := &s.progress[s.cursor]
+	b.WriteString("\n")
+	if p.Goal.HasDeadline() {
+		b.WriteString(fmt.Sprintf("Target date: %s | Expected by now: %s\n",
+			s.cfg.FormatDate(p.Goal.TargetDate), s.cfg.FormatMoney(p.Expected())))
+		if monthly := p.RequiredMonthly(); monthly > 0 {
+			b.WriteString(fmt.Sprintf("Needs %s a month to finish on time\n", s.cfg.FormatMoney(monthly)))
+		}
+	}
+	if link := p.Goal.Link(); link != "" {
+		b.WriteString(fmt.Sprintf("Linked to %s: %s since %s\n", link, s.cfg.FormatMoney(p.Linked), s.cfg.FormatDate(p.Goal.StartDate)))
+	}
+	if date, ok := p.Projected(); ok && !p.Reached() {
+		b.WriteString(fmt.Sprintf("At the pace so far it is reached around %s\n", s.cfg.FormatDate(date)))
+	}
+}
+
+func (s *GoalsScreen) Reset() {
+	s.mode = "list"
+	s.withdraw = false
+	s.amount = ""
+	s.cursor = 0
+	s.err = ""
+	s.success = ""
+}
@@ -5,31 +5,52 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/repository"
+	"github.com/PeguB/atad-project/internal/service"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type IncomeReportScreen struct {
 	repo         *repository.TransactionRepository
+	budgetSvc    *service.BudgetService
+	forecastSvc  *service.ForecastService
+	summarySvc   *service.SummaryService
 	totalIncome  float64
 	byCategory   map[string]float64
+	targets      []incomeTarget     // Current period of every income target
+	plan         []service.PlanLine // Planned vs actual income of this month
 	startDate    string
 	endDate      string
 	step         int // 0: select period, 1: custom dates, 2: show results
 	err          string
 	periodChoice int // 0: all time, 1: this month, 2: this year, 3: custom
+	cfg          *config.Config
 }
 
-func NewIncomeReportScreen(repo *repository.TransactionRepository) *IncomeReportScreen {
+// incomeTarget is an income budget with the projection of its current period
+type incomeTarget struct {
+	category string
+	forecast *service.BudgetForecast
+}
+
+func NewIncomeReportScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, cfg *config.Config) *IncomeReportScreen {
+	budgetSvc := service.NewBudgetService(budgetRepo)
 	return &IncomeReportScreen{
-		repo: repo,
-		step: 0,
+		cfg:         cfg,
+		repo:        repo,
+		budgetSvc:   budgetSvc,
+		forecastSvc: service.NewForecastService(repo, budgetSvc),
+		summarySvc:  service.NewSummaryService(repo, budgetRepo),
+		step:        0,
 	}
 }
 
 func (s *IncomeReportScreen) Init() {
 	s.totalIncome = 0
 	s.byCategory = make(map[string]float64)
+	s.targets = nil
+	s.plan = nil
 	s.startDate = ""
 	s.endDate = ""
 	s.step = 0
@@ -50,16 +71,16 @@ func (s *IncomeReportScreen) Update(msg tea.Msg) (*IncomeReportScreen, tea.Cmd)
 			case "2": // This month
 				s.periodChoice = 1
 				now := time.Now()
-				s.startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("02/01/2006")
+				s.startDate = s.cfg.FormatInputDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
 				endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
-				s.endDate = endOfMonth.Format("02/01/2006")
+				s.endDate = s.cfg.FormatInputDate(endOfMonth)
 				s.calculateIncome()
 				s.step = 2
 			case "3": // This year
 				s.periodChoice = 2
 				now := time.Now()
-				s.startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()).Format("02/01/2006")
-				s.endDate = time.Date(now.Year(), 12, 31, 0, 0, 0, 0, now.Location()).Format("02/01/2006")
+				s.startDate = s.cfg.FormatInputDate(time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()))
+				s.endDate = s.cfg.FormatInputDate(time.Date(now.Year(), 12, 31, 0, 0, 0, 0, now.Location()))
 				s.calculateIncome()
 				s.step = 2
 			case "4": // Custom
@@ -71,9 +92,9 @@ func (s *IncomeReportScreen) Update(msg tea.Msg) (*IncomeReportScreen, tea.Cmd)
 			case "enter":
 				// For now, default to this month if not implemented
 				now := time.Now()
-				s.startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("02/01/2006")
+				s.startDate = s.cfg.FormatInputDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
 				endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
-				s.endDate = endOfMonth.Format("02/01/2006")
+				s.endDate = s.cfg.FormatInputDate(endOfMonth)
 				s.calculateIncome()
 				s.step = 2
 			}
@@ -100,12 +121,12 @@ func (s *IncomeReportScreen) calculateIncome() {
 	var startTime, endTime time.Time
 	var useFilter bool
 	if s.startDate != "" && s.endDate != "" {
-		startTime, err = time.Parse("02/01/2006", s.startDate)
+		startTime, err = s.cfg.ParseDate(s.startDate)
 		if err != nil {
 			s.err = fmt.Sprintf("Invalid start date: %v", err)
 			return
 		}
-		endTime, err = time.Parse("02/01/2006", s.endDate)
+		endTime, err = s.cfg.ParseDate(s.endDate)
 		if err != nil {
 			s.err = fmt.Sprintf("Invalid end date: %v", err)
 			return
@@ -129,6 +150,41 @@ func (s *IncomeReportScreen) calculateIncome() {
 		s.totalIncome += tx.Amount
 		s.byCategory[tx.Category] += tx.Amount
 	}
+
+	s.loadTargets()
+}
+
+// loadTargets projects the current period of every income target and, for
+// this month's report, compares the planned income with the actual one
+func (s *IncomeReportScreen) loadTargets() {
+	now := time.Now()
+	statuses, err := s.budgetSvc.StatusAll(now)
+	if err != nil {
+		s.err = fmt.Sprintf("Error loading income targets: %v", err)
+		return
+	}
+	s.targets = nil
+	for _, status := range statuses {
+		if !status.Budget.IsIncome() {
+			continue
+		}
+		forecast, err := s.forecastSvc.Forecast(status.Budget, now)
+		if err != nil {
+			s.err = fmt.Sprintf("Error projecting income: %v", err)
+			return
+		}
+		s.targets = append(s.targets, incomeTarget{category: status.Budget.Category, forecast: forecast})
+	}
+
+	s.plan = nil
+	if s.periodChoice == 1 {
+		summary, err := s.summarySvc.Monthly(now)
+		if err != nil {
+			s.err = fmt.Sprintf("Error loading planned income: %v", err)
+			return
+		}
+		s.plan = summary.Income
+	}
 }
 
 func (s *IncomeReportScreen) View() string {
@@ -172,7 +228,7 @@ func (s *IncomeReportScreen) View() string {
 			}
 
 			// Show total
-			b.WriteString(fmt.Sprintf("💰 Total Income: $%.2f\n\n", s.totalIncome))
+			b.WriteString(fmt.Sprintf("💰 Total Income: %s\n\n", s.cfg.FormatMoney(s.totalIncome)))
 
 			// Show breakdown by category
 			if len(s.byCategory) > 0 {
@@ -181,11 +237,13 @@ func (s *IncomeReportScreen) View() string {
 
 				for category, amount := range s.byCategory {
 					percentage := (amount / s.totalIncome) * 100
-					b.WriteString(fmt.Sprintf("  %-20s $%8.2f  (%.1f%%)\n", category, amount, percentage))
+					b.WriteString(fmt.Sprintf("  %-20s %9s  (%.1f%%)\n", category, s.cfg.FormatMoney(amount), percentage))
 				}
 			} else {
 				b.WriteString("No income transactions found for this period.\n")
 			}
+
+			s.viewTargets(&b)
 		}
 
 		b.WriteString("\nPress ESC to return to menu\n")
@@ -194,6 +252,46 @@ func (s *IncomeReportScreen) View() string {
 	return b.String()
 }
 
+// viewTargets shows the progress of the income targets and this month's
+// planned vs actual income
+func (s *IncomeReportScreen) viewTargets(b *strings.Builder) {
+	if len(s.targets) > 0 {
+		b.WriteString("\n🎯 Income Targets (current period):\n")
+		b.WriteString("─────────────────────────────────────\n")
+		for _, t := range s.targets {
+			f := t.forecast
+			icon := "⚠️"
+			switch {
+			case f.Shortfall() == 0:
+				icon = "✅"
+			case f.ProjectedShortfall() == 0:
+				icon = "📈"
+			}
+			b.WriteString(fmt.Sprintf("%s %-20s %s %3.0f%%  %s of %s\n", icon, t.category,
+				ProgressBar(f.PercentUsed(), 20), f.PercentUsed(), s.cfg.FormatMoney(f.Spent), s.cfg.FormatMoney(f.Available)))
+			line := fmt.Sprintf("   %s to go | projected %s by %s", s.cfg.FormatMoney(f.Shortfall()),
+				s.cfg.FormatMoney(f.Projected), s.cfg.FormatDate(f.End))
+			if f.ProjectedShortfall() > 0 {
+				line += fmt.Sprintf(" | short by %s", s.cfg.FormatMoney(f.ProjectedShortfall()))
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(s.plan) > 0 {
+		b.WriteString("\n📋 Planned vs Actual (this month):\n")
+		b.WriteString("─────────────────────────────────────────────────────\n")
+		b.WriteString(fmt.Sprintf("  %-20s %12s %12s %12s\n", "Category", "Planned", "Actual", "Variance"))
+		for _, line := range s.plan {
+			b.WriteString(fmt.Sprintf("  %-20s %12s %12s %12s\n", line.Category, s.cfg.FormatMoney(line.Planned),
+				s.cfg.FormatMoney(line.Actual), s.cfg.FormatMoney(line.Variance())))
+		}
+		planned, actual := service.Totals(s.plan)
+		b.WriteString(fmt.Sprintf("  %-20s %12s %12s %12s\n", "Total", s.cfg.FormatMoney(planned),
+			s.cfg.FormatMoney(actual), s.cfg.FormatMoney(actual-planned)))
+	}
+}
+
 func (s *IncomeReportScreen) Reset() {
 	s.Init()
 }
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/database"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProfileScreen lists the profiles and lets the user pick one to switch to
+type ProfileScreen struct {
+	profiles []string
+	active   string
+	cursor   int
+	chosen   string
+	err      string
+}
+
+func NewProfileScreen(active string) *ProfileScreen {
+	return &ProfileScreen{active: active}
+}
+
+func (s *ProfileScreen) Init() {
+	s.chosen = ""
+	s.err = ""
+	profiles, err := database.ListProfiles()
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to list profiles: %v", err)
+		return
+	}
+	s.profiles = profiles
+	for i, name := range profiles {
+		if name == s.active {
+			s.cursor = i
+		}
+	}
+}
+
+func (s *ProfileScreen) Update(msg tea.Msg) (*ProfileScreen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if s.cursor > 0 {
+				s.cursor--
+			}
+		case "down", "j":
+			if s.cursor < len(s.profiles)-1 {
+				s.cursor++
+			}
+		case "enter", " ":
+			if s.cursor < len(s.profiles) {
+				s.chosen = s.profiles[s.cursor]
+			}
+		}
+	}
+	return s, nil
+}
+
+// Chosen returns the profile selected with Enter, or "" if none was selected yet
+func (s *ProfileScreen) Chosen() string {
+	return s.chosen
+}
+
+func (s *ProfileScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString("📒 Switch Profile\n\n")
+
+	for i, name := range s.profiles {
+		cursor := " "
+		if i == s.cursor {
+			cursor = ">"
+		}
+		marker := ""
+		if name == s.active {
+			marker = " (active)"
+		}
+		b.WriteString(fmt.Sprintf("%s %s%s\n", cursor, name, marker))
+	}
+
+	b.WriteString("\nCreate profiles with 'atad profile create <name>'.\n")
+	b.WriteString("\n↑/↓ move | Enter = switch | ESC to return\n")
+
+	if s.err != "" {
+		b.WriteString("\n❌ " + s.err + "\n")
+	}
+
+	return b.String()
+}
+
+func (s *ProfileScreen) Reset() {
+	s.chosen = ""
+	s.err = ""
+}
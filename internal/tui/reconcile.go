@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PeguB/atad-project/internal/config"
+	"github.com/PeguB/atad-project/internal/models"
+	"github.com/PeguB/atad-project/internal/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type ReconcileScreen struct {
+	reconcileSvc *service.ReconcileService
+	step         int // 0: account, 1: statement date, 2: balance, 3: tick off, 4: done
+	account      string
+	statementEnd string // Date in the configured input format
+	balance      string
+	status       *service.ReconcileStatus
+	cursor       int
+	err          string
+	success      string
+	cfg          *config.Config
+}
+
+func NewReconcileScreen(reconcileSvc *service.ReconcileService, cfg *config.Config) *ReconcileScreen {
+	return &ReconcileScreen{
+		cfg:          cfg,
+		reconcileSvc: reconcileSvc,
+		step:         0,
+	}
+}
+
+func (s *ReconcileScreen) Update(msg tea.Msg) (*ReconcileScreen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch s.step {
+		case 0: // Enter account
+			switch msg.String() {
+			case "enter":
+				if s.account != "" {
+					s.step = 1
+				}
+			case "backspace":
+				if len(s.account) > 0 {
+					s.account = s.account[:len(s.account)-1]
+				}
+			default:
+				if len(msg.String()) == 1 {
+					s.account += msg.String()
+				}
+			}
+		case 1: // Enter statement end date
+			switch msg.String() {
+			case "enter":
+				if _, err := s.cfg.ParseDate(s.statementEnd); err == nil {
+					s.step = 2
+					s.err = ""
+				} else {
+					s.err = "Invalid date format (use " + s.cfg.InputDateFormat + ")"
+				}
+			case "backspace":
+				if len(s.statementEnd) > 0 {
+					s.statementEnd = s.statementEnd[:len(s.statementEnd)-1]
+				}
+			default:
+				if isDateKey(msg.String()) {
+					if len(s.statementEnd) < 10 {
+						s.statementEnd += msg.String()
+					}
+				}
+			}
+		case 2: // Enter statement balance
+			switch msg.String() {
+			case "enter":
+				if _, err := strconv.ParseFloat(s.balance, 64); err == nil && s.balance != "" {
+					s.err = ""
+					s.loadStatus()
+				} else {
+					s.err = "Invalid amount"
+				}
+			case "backspace":
+				if len(s.balance) > 0 {
+					s.balance = s.balance[:len(s.balance)-1]
+				}
+			default:
+				if len(msg.String()) == 1 && (msg.String()[0] >= '0' && msg.String()[0] <= '9' || msg.String() == "." || msg.String() == "-") {
+					s.balance += msg.String()
+				}
+			}
+		case 3: // Tick off transactions
+			switch msg.String() {
+			case "up", "k":
+				if s.cursor > 0 {
+					s.cursor--
+				}
+			case "down", "j":
+				if s.cursor < len(s.status.Transactions)-1 {
+					s.cursor++
+				}
+			case " ", "enter":
+				if s.cursor < len(s.status.Transactions) {
+					if err := s.reconcileSvc.Toggle(s.status.Transactions[s.cursor]); err != nil {
+						s.err = err.Error()
+					} else {
+						s.err = ""
+					}
+					s.loadStatus()
+				}
+			case "f":
+				rec, err := s.reconcileSvc.Finish(s.status)
+				if err != nil {
+					s.err = err.Error()
+				} else {
+					s.success = fmt.Sprintf("🔒 Account '%s' reconciled to %s at %s. Cleared transactions are now locked.",
+						rec.Account, s.cfg.FormatDate(rec.StatementDate), s.cfg.FormatMoney(rec.StatementBalance))
+					s.err = ""
+					s.step = 4
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *ReconcileScreen) loadStatus() {
+	endDate, _ := s.cfg.ParseDate(s.statementEnd)
+	balance, _ := strconv.ParseFloat(s.balance, 64)
+
+	status, err := s.reconcileSvc.Status(s.account, endDate, balance)
+	if err != nil {
+		s.err = fmt.Sprintf("Failed to load transactions: %v", err)
+		return
+	}
+	s.status = status
+	if s.cursor >= len(status.Transactions) {
+		s.cursor = 0
+	}
+	s.step = 3
+}
+
+func (s *ReconcileScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString("🧾 Reconcile Account\n\n")
+
+	switch s.step {
+	case 0:
+		b.WriteString("Account: " + s.account + "▊\n")
+		b.WriteString("\n(Press Enter to continue)\n")
+	case 1:
+		b.WriteString(fmt.Sprintf("Account: %s\n\n", s.account))
+		b.WriteString("Statement end date (" + s.cfg.InputDateFormat + "): " + s.statementEnd + "▊\n")
+	case 2:
+		b.WriteString(fmt.Sprintf("Account: %s\n", s.account))
+		b.WriteString(fmt.Sprintf("Statement end date: %s\n\n", s.statementEnd))
+		b.WriteString("Statement balance (" + s.cfg.Symbol + "): " + s.balance + "▊\n")
+	case 3:
+		b.WriteString(fmt.Sprintf("Account: %s | Statement: %s | Balance: %s\n\n",
+			s.account, s.statementEnd, s.cfg.FormatMoney(s.status.StatementBalance)))
+
+		if len(s.status.Transactions) == 0 {
+			b.WriteString("No unreconciled transactions.\n")
+		} else {
+			b.WriteString("      Date       Amount      Description\n")
+			b.WriteString("  ─── ────────── ─────────── ────────────────────────────────\n")
+			for i, tx := range s.status.Transactions {
+				cursor := " "
+				if i == s.cursor {
+					cursor = ">"
+				}
+				mark := "[ ]"
+				if tx.Status == models.StatusCleared {
+					mark = "[x]"
+				}
+				desc := tx.Description
+				if len(desc) > 32 {
+					desc = desc[:29] + "..."
+				}
+				b.WriteString(fmt.Sprintf("%s %s %s %11.2f %s\n",
+					cursor, mark, s.cfg.FormatDate(tx.Date), tx.SignedAmount(), desc))
+			}
+		}
+
+		b.WriteString("  ──────────────────────────────────────────────────────────────\n")
+		if s.status.OpeningBalance != 0 {
+			b.WriteString(fmt.Sprintf("  Opening balance: %s\n", s.cfg.FormatMoney(s.status.OpeningBalance)))
+		}
+		b.WriteString(fmt.Sprintf("  Cleared balance: %s\n", s.cfg.FormatMoney(s.status.ClearedBalance)))
+		if s.status.Balanced() {
+			b.WriteString(fmt.Sprintf("  Difference:      %s ✅\n", s.cfg.FormatMoney(0)))
+		} else {
+			b.WriteString(fmt.Sprintf("  Difference:      %s ⚠️\n", s.cfg.FormatMoney(s.status.Difference)))
+		}
+
+		b.WriteString("\n↑/↓ move | Space = toggle cleared | f = finish and lock | ESC to return\n")
+	case 4:
+		b.WriteString(s.success + "\n\n")
+		b.WriteString("Press ESC to return to menu\n")
+	}
+
+	if s.err != "" {
+		b.WriteString("\n❌ " + s.err + "\n")
+	}
+
+	return b.String()
+}
+
+func (s *ReconcileScreen) Reset() {
+	s.step = 0
+	s.account = ""
+	s.statementEnd = ""
+	s.balance = ""
+	s.status = nil
+	s.cursor = 0
+	s.err = ""
+	s.success = ""
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UnlockScreen asks for the passphrase of an encrypted database
+type UnlockScreen struct {
+	path       string
+	passphrase string
+	submitted  bool
+	err        string
+}
+
+func NewUnlockScreen(path string) *UnlockScreen {
+	return &UnlockScreen{path: path}
+}
+
+func (s *UnlockScreen) Update(msg tea.Msg) (*UnlockScreen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if s.passphrase != "" {
+				s.submitted = true
+			}
+		case "backspace":
+			if len(s.passphrase) > 0 {
+				s.passphrase = s.passphrase[:len(s.passphrase)-1]
+			}
+		default:
+			if len(msg.Runes) > 0 {
+				s.passphrase += string(msg.Runes)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Passphrase returns the passphrase once the user pressed Enter
+func (s *UnlockScreen) Passphrase() (string, bool) {
+	return s.passphrase, s.submitted
+}
+
+// Fail clears the input and shows why unlocking failed
+func (s *UnlockScreen) Fail(msg string) {
+	s.passphrase = ""
+	s.submitted = false
+	s.err = msg
+}
+
+func (s *UnlockScreen) View() string {
+	var b strings.Builder
+
+	b.WriteString("🔒 Unlock Encrypted Database\n\n")
+	b.WriteString(s.path + "\n\n")
+	b.WriteString("Passphrase: " + strings.Repeat("•", len([]rune(s.passphrase))) + "▊\n")
+	b.WriteString("\nEnter = unlock | ESC to return\n")
+
+	if s.err != "" {
+		b.WriteString("\n❌ " + s.err + "\n")
+	}
+
+	return b.String()
+}
+
+func (s *UnlockScreen) Reset() {
+	s.passphrase = ""
+	s.submitted = false
+	s.err = ""
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/PeguB/atad-project/internal/config"
 	"github.com/PeguB/atad-project/internal/models"
 	"github.com/PeguB/atad-project/internal/repository"
+	"github.com/PeguB/atad-project/internal/service"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -35,15 +38,17 @@ type ViewTransactionsScreen struct {
 	showBudgets  bool // true when 'b' is pressed to show budgets
 	err          error
 	total        float64
+	cfg          *config.Config
 }
 
-func NewViewTransactionsScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository) *ViewTransactionsScreen {
+func NewViewTransactionsScreen(repo *repository.TransactionRepository, budgetRepo *repository.BudgetRepository, cfg *config.Config) *ViewTransactionsScreen {
 	return &ViewTransactionsScreen{
+		cfg:        cfg,
 		repo:       repo,
 		budgetRepo: budgetRepo,
 		cursor:     0,
 		page:       0,
-		pageSize:   15,
+		pageSize:   cfg.PageSize,
 		sortBy:     sortByDate,
 		sortDesc:   true,
 		filterType: "all",
@@ -55,6 +60,13 @@ func (s *ViewTransactionsScreen) Init() {
 	s.loadBudgets()
 }
 
+// Refresh reloads the transactions and budgets, keeping filters, sorting and
+// the cursor, e.g. after another process changed the database
+func (s *ViewTransactionsScreen) Refresh() {
+	s.loadTransactions()
+	s.loadBudgets()
+}
+
 func (s *ViewTransactionsScreen) loadTransactions() {
 	var err error
 
@@ -234,8 +246,7 @@ func (s *ViewTransactionsScreen) Update(msg tea.Msg) (*ViewTransactionsScreen, t
 		case "b": // Toggle budgets view
 			s.showBudgets = !s.showBudgets
 		case "r": // Refresh
-			s.loadTransactions()
-			s.loadBudgets()
+			s.Refresh()
 		case "delete", "backspace":
 			if len(s.transactions) > 0 && s.cursor < len(s.transactions) {
 				tx := s.transactions[s.cursor]
@@ -271,25 +282,28 @@ func (s *ViewTransactionsScreen) View() string {
 		} else {
 			b.WriteString("  Category             Budget      Spent       Remaining   Period\n")
 			b.WriteString("  ──────────────────── ─────────── ─────────── ─────────── ──────────────────\n")
+			now := time.Now()
 			for _, budget := range s.budgets {
 				cat := budget.Category
 				if len(cat) > 20 {
 					cat = cat[:17] + "..."
 				}
 
-				// Get spending for this budget
-				spent, _ := s.budgetRepo.GetSpending(budget.Category, budget.StartDate, budget.EndDate)
-				remaining := budget.Amount - spent
+				// Get spending for this budget in its current period; the
+				// budget includes amounts carried over from earlier periods
+				available, spent := budget.Amount, 0.0
+				if period, err := budgetStatus(service.NewBudgetService(s.budgetRepo), budget, now); err == nil && period != nil {
+					available, spent = period.Available, period.Spent
+				}
+				remaining := available - spent
 
 				// Format amounts
-				budgetStr := fmt.Sprintf("$%.2f", budget.Amount)
-				spentStr := fmt.Sprintf("$%.2f", spent)
-				remainingStr := fmt.Sprintf("$%.2f", remaining)
+				budgetStr := s.cfg.FormatMoney(available)
+				spentStr := s.cfg.FormatMoney(spent)
+				remainingStr := s.cfg.FormatMoney(remaining)
 
 				// Format period
-				periodStr := fmt.Sprintf("%s - %s",
-					budget.StartDate.Format("02/01/2006"),
-					budget.EndDate.Format("02/01/2006"))
+				periodStr := formatBudgetPeriod(s.cfg, budget, now)
 
 				b.WriteString(fmt.Sprintf("  %-20s %-11s %-11s %-11s %s\n",
 					cat, budgetStr, spentStr, remainingStr, periodStr))
@@ -346,7 +360,7 @@ func (s *ViewTransactionsScreen) View() string {
 		}
 
 		// Format amount with sign
-		amountStr := fmt.Sprintf("$%.2f", tx.Amount)
+		amountStr := s.cfg.FormatMoney(tx.Amount)
 		if tx.Type == "income" {
 			amountStr = "+" + amountStr
 		} else {
@@ -375,7 +389,7 @@ func (s *ViewTransactionsScreen) View() string {
 
 		b.WriteString(fmt.Sprintf("%s %s %-8s %-11s %-20s %s\n",
 			cursor,
-			tx.Date.Format("2006-01-02"),
+			s.cfg.FormatDate(tx.Date),
 			typeStr,
 			amountStr,
 			cat,
@@ -384,7 +398,7 @@ func (s *ViewTransactionsScreen) View() string {
 
 	// Summary line
 	b.WriteString("  ────────────────────────────────────────────────────────────────────────────\n")
-	totalStr := fmt.Sprintf("$%.2f", s.total)
+	totalStr := s.cfg.FormatMoney(s.total)
 	if s.total >= 0 {
 		totalStr = "+" + totalStr
 	}
@@ -0,0 +1,196 @@
+// Package vault implements the passphrase-protected file format used for
+// encrypted databases, backups and exports.
+//
+// An encrypted file is
+//
+//	"ATADVLT1" | iterations (uint32, big endian) | salt (16 bytes) | nonce (12 bytes) | ciphertext
+//
+// The key is derived from the passphrase with PBKDF2-SHA256 and the data is
+// sealed with AES-256-GCM, using the header as additional data so that it
+// cannot be altered either.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Magic starts every encrypted file
+const Magic = "ATADVLT1"
+
+// Iterations is the PBKDF2 work factor for new keys
+const Iterations = 600000
+
+// MaxIterations bounds the work factor accepted from a file header, so that a
+// crafted file cannot make opening it run for hours
+const MaxIterations = 10 * Iterations
+
+const (
+	saltSize   = 16
+	nonceSize  = 12
+	keySize    = 32
+	headerSize = len(Magic) + 4 + saltSize + nonceSize
+)
+
+var (
+	// ErrWrongPassphrase is returned when data cannot be decrypted, either
+	// because the passphrase is wrong or because the data was modified
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted file")
+
+	// ErrNoPassphrase is returned when an empty passphrase is used
+	ErrNoPassphrase = errors.New("a passphrase is required")
+)
+
+// Key is a key derived from a passphrase. Reusing it to re-encrypt data avoids
+// running the key derivation again; every Seal uses a fresh nonce.
+type Key struct {
+	salt       []byte
+	iterations int
+	key        []byte
+}
+
+// NewKey derives a key from a passphrase with a random salt
+func NewKey(passphrase string) (*Key, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating salt: %w", err)
+	}
+	return deriveKey(passphrase, salt, Iterations)
+}
+
+func deriveKey(passphrase string, salt []byte, iterations int) (*Key, error) {
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+	return &Key{salt: salt, iterations: iterations, key: key}, nil
+}
+
+// Seal encrypts plaintext into the vault format
+func (k *Key) Seal(plaintext []byte) ([]byte, error) {
+	header := make([]byte, headerSize)
+	copy(header, Magic)
+	binary.BigEndian.PutUint32(header[len(Magic):], uint32(k.iterations))
+	copy(header[len(Magic)+4:], k.salt)
+	nonce := header[len(Magic)+4+saltSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	gcm, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+func (k *Key) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Open decrypts data in the vault format and returns the plaintext together
+// with the key, which can seal the data again without another derivation
+func Open(data []byte, passphrase string) ([]byte, *Key, error) {
+	if !IsEncrypted(data) || len(data) < headerSize {
+		return nil, nil, fmt.Errorf("not an encrypted atad file")
+	}
+	header := data[:headerSize]
+	iterations := int(binary.BigEndian.Uint32(header[len(Magic):]))
+	if iterations < Iterations || iterations > MaxIterations {
+		return nil, nil, fmt.Errorf("unsupported key derivation work factor %d", iterations)
+	}
+	salt := bytes.Clone(header[len(Magic)+4 : len(Magic)+4+saltSize])
+	nonce := header[len(Magic)+4+saltSize:]
+
+	key, err := deriveKey(passphrase, salt, iterations)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := key.aead()
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize:], header)
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+	return plaintext, key, nil
+}
+
+// Encrypt seals plaintext with a new key derived from passphrase
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	key, err := NewKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.Seal(plaintext)
+}
+
+// Decrypt opens data sealed with passphrase
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	plaintext, _, err := Open(data, passphrase)
+	return plaintext, err
+}
+
+// IsEncrypted reports whether data starts with the vault header
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// IsEncryptedFile reports whether the file at path is in the vault format
+func IsEncryptedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return IsEncrypted(magic)
+}
+
+// WriteFile writes data to path atomically: a temporary file in the same
+// directory is renamed over the target
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".atad-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.Write(data)
+	if syncErr := tmp.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,66 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plaintext := []byte("SQLite format 3\x00 and some rows")
+	sealed, err := Encrypt(plaintext, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatal("sealed data has no vault header")
+	}
+
+	got, key, err := Open(sealed, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("plaintext = %q, want %q", got, plaintext)
+	}
+
+	// The returned key seals again without a new derivation
+	resealed, err := key.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decrypt(resealed, "correct horse"); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt of resealed data = %q, %v", got, err)
+	}
+
+	if _, err := Decrypt(sealed, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+	if _, err := Encrypt(plaintext, ""); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("empty passphrase: err = %v, want ErrNoPassphrase", err)
+	}
+}
+
+func TestOpenRejectsWorkFactor(t *testing.T) {
+	sealed, err := Encrypt([]byte("data"), "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iterations := range []uint32{0, 1, Iterations - 1, MaxIterations + 1, 1<<32 - 1} {
+		tampered := bytes.Clone(sealed)
+		binary.BigEndian.PutUint32(tampered[len(Magic):], iterations)
+		_, _, err := Open(tampered, "pass")
+		if err == nil || !strings.Contains(err.Error(), "work factor") {
+			t.Errorf("iterations %d: err = %v, want the work factor rejected", iterations, err)
+		}
+	}
+}
+
+func TestOpenRejectsShortData(t *testing.T) {
+	if _, err := Decrypt([]byte(Magic+"short"), "pass"); err == nil {
+		t.Error("Decrypt accepted a truncated header")
+	}
+}